@@ -0,0 +1,49 @@
+package congo
+
+// A chain is a parent latch to count down when a latch completes.
+type chain struct {
+	parent *CountDownLatch
+	weight uint
+}
+
+// Chain is equivalent to ChainWeighted with a weight of 1.
+func (latch *CountDownLatch) Chain(parent *CountDownLatch) {
+	latch.ChainWeighted(parent, 1)
+}
+
+// ChainWeighted arranges for the parent latch to be counted down by the given weight when the current round of count down
+// of this latch completes, which makes hierarchical fan-in trees of latches composable without glue goroutines.
+// If the count down is already complete, the parent is counted down immediately.
+//
+// The parent is counted down by the goroutine that completes this latch. Errors from counting down the parent, e.g. because it is already complete, are ignored.
+// Canceling this latch does not count down the parent.
+func (latch *CountDownLatch) ChainWeighted(parent *CountDownLatch, weight uint) {
+	latch.m.Lock()
+	defer latch.unlock()
+	select {
+	case <-latch.countDownCompleteCh:
+		if latch.cause == nil {
+			latch.completedChains = append(latch.completedChains, chain{parent: parent, weight: weight})
+		}
+	default:
+		latch.chains = append(latch.chains, chain{parent: parent, weight: weight})
+	}
+}
+
+// completeChains hands the parents chained to the current round of count down over to unlock, which counts them down.
+// This call must be guarded using the latch mutex.
+func (latch *CountDownLatch) completeChains() {
+	latch.completedChains = append(latch.completedChains, latch.chains...)
+	latch.chains = nil
+}
+
+// unlock releases the latch mutex, then counts down the parents chained to a round completed while it was locked,
+// so that the latch mutex is never held while locking a parent.
+func (latch *CountDownLatch) unlock() {
+	chains := latch.completedChains
+	latch.completedChains = nil
+	latch.m.Unlock()
+	for _, c := range chains {
+		c.parent.WeightedCountDown(c.weight)
+	}
+}
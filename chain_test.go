@@ -0,0 +1,79 @@
+package congo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCountDownLatch_chain(t *testing.T) {
+	// a two level fan-in tree: four leaves feed two branches, which feed the root
+	root := NewCountDownLatch(2)
+	var leaves []*CountDownLatch
+	for i := 0; i < 2; i++ {
+		branch := NewCountDownLatch(2)
+		branch.Chain(root)
+		for j := 0; j < 2; j++ {
+			leaf := NewCountDownLatch(3)
+			leaf.Chain(branch)
+			leaves = append(leaves, leaf)
+		}
+	}
+
+	for i, leaf := range leaves {
+		assertNil(t, leaf.WeightedCountDown(2))
+		if i < len(leaves)-1 {
+			assertNil(t, leaf.CountDown())
+		}
+	}
+	assertEqual(t, uint(1), root.Count())
+	assertEqual(t, false, root.WaitTimeout(100*time.Millisecond))
+
+	go leaves[len(leaves)-1].CountDown()
+	assertEqual(t, true, root.WaitTimeout(time.Second))
+}
+
+func TestCountDownLatch_chainWeighted(t *testing.T) {
+	parent := NewCountDownLatch(10)
+
+	// chaining a completed latch counts down the parent immediately
+	NewCountDownLatch(0).ChainWeighted(parent, 3)
+	assertEqual(t, uint(7), parent.Count())
+
+	child := NewCountDownLatch(1)
+	child.ChainWeighted(parent, 4)
+	child.ChainWeighted(parent, 2)
+	assertNil(t, child.CountDown())
+	assertEqual(t, uint(1), parent.Count())
+
+	// a canceled latch does not count down its parent
+	canceled := NewCountDownLatch(1)
+	canceled.Chain(parent)
+	assertNil(t, canceled.Cancel(nil))
+	canceled.Chain(parent)
+	assertEqual(t, uint(1), parent.Count())
+
+	// chains fire once, for the round in which they were added
+	_, err := child.Reset(1)
+	assertNil(t, err)
+	assertNil(t, child.CountDown())
+	assertEqual(t, uint(1), parent.Count())
+}
+
+func TestCountDownLatch_chainCycle(t *testing.T) {
+	// parents are counted down once the latch is unlocked, so latches chained to each other do not deadlock
+	a, b := NewCountDownLatch(1), NewCountDownLatch(1)
+	a.Chain(b)
+	b.Chain(a)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assertNil(t, a.CountDown())
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Chained latches deadlocked")
+	}
+	assertEqual(t, uint(0), a.Count())
+	assertEqual(t, uint(0), b.Count())
+}
@@ -0,0 +1,306 @@
+// Package circuit provides a circuit breaker, which stops calling a failing downstream for a while, so that it can recover,
+// and callers fail fast rather than waiting on it.
+package circuit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nvn1729/congo"
+)
+
+// A State is the state of a Breaker.
+type State int
+
+const (
+	// Closed is the state of a breaker letting calls through, while counting their failures.
+	Closed State = iota
+
+	// Open is the state of a breaker rejecting calls with ErrOpen, until its cool-down elapses.
+	Open
+
+	// HalfOpen is the state of a breaker letting a number of probe calls through, to find out whether the downstream recovered.
+	HalfOpen
+)
+
+func (state State) String() string {
+	switch state {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// These are the defaults of a Breaker, unless set with options.
+const (
+	// DefaultFailureThreshold is the number of consecutive failures opening a breaker.
+	DefaultFailureThreshold = 5
+
+	// DefaultCoolDown is the time a breaker stays open.
+	DefaultCoolDown = 30 * time.Second
+
+	// DefaultWindow is the duration of the rolling window over which a breaker counts the outcomes of calls.
+	DefaultWindow = time.Minute
+)
+
+// An Option configures a Breaker at creation time.
+type Option func(*options)
+
+type options struct {
+	trip          TripFunc
+	window        time.Duration
+	slowDuration  time.Duration
+	probes        int
+	coolDown      time.Duration
+	onStateChange func(from, to State)
+	clock         congo.Clock
+}
+
+// WithFailureThreshold makes the breaker open after n consecutive failed calls, as WithTrip(ConsecutiveFailures(n)).
+// It defaults to DefaultFailureThreshold.
+func WithFailureThreshold(n int) Option {
+	return WithTrip(ConsecutiveFailures(n))
+}
+
+// WithTrip sets the TripFunc deciding from the counts of the breaker whether it should open, such as FailureRate,
+// replacing the default ConsecutiveFailures(DefaultFailureThreshold).
+func WithTrip(trip TripFunc) Option {
+	return func(o *options) {
+		o.trip = trip
+	}
+}
+
+// WithWindow sets the duration of the rolling window over which the breaker counts the outcomes of calls, for the TripFunc.
+// The window moves forward by a tenth of its duration at a time. It defaults to DefaultWindow.
+func WithWindow(window time.Duration) Option {
+	return func(o *options) {
+		o.window = window
+	}
+}
+
+// WithSlowCallDuration makes the breaker count the calls taking at least the given duration as slow, for SlowCallRate.
+// A slow probe call opens a half-open breaker again, like a failed one. By default, no call is slow.
+func WithSlowCallDuration(duration time.Duration) Option {
+	return func(o *options) {
+		o.slowDuration = duration
+	}
+}
+
+// WithHalfOpenProbes sets the number of probe calls a half-open breaker lets through concurrently,
+// all of which must succeed for the breaker to close. It defaults to 1.
+func WithHalfOpenProbes(n int) Option {
+	return func(o *options) {
+		o.probes = n
+	}
+}
+
+// WithCoolDown sets the time the breaker stays open, rejecting calls, before letting a probe call through.
+// It defaults to DefaultCoolDown.
+func WithCoolDown(coolDown time.Duration) Option {
+	return func(o *options) {
+		o.coolDown = coolDown
+	}
+}
+
+// WithOnStateChange sets a callback invoked on every state change of the breaker, e.g. to alert when it opens.
+// The callback is invoked once the breaker's internal lock is released, so it may call methods such as Stats.
+// It is invoked for one state change at a time, in the order of the changes: by the goroutine changing the state,
+// or by another goroutine still invoking it for earlier changes.
+func WithOnStateChange(callback func(from, to State)) Option {
+	return func(o *options) {
+		o.onStateChange = callback
+	}
+}
+
+// WithClock sets the Clock used by the breaker to measure its cool-down. By default the breaker uses congo.RealClock.
+func WithClock(clock congo.Clock) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// A Breaker is a circuit breaker wrapping the calls to a downstream. It starts closed, letting calls through,
+// and opens once its TripFunc decides so, by default once a number of consecutive calls failed.
+// An open breaker rejects calls with ErrOpen, without making them, until its cool-down elapses. It is then half-open:
+// it lets a number of probe calls through, and closes once they all succeed, or opens again for another cool-down
+// as soon as one fails. Other calls are rejected while the probes are in flight.
+//
+// A breaker composes with the other primitives of congo: the deadline of the context passed to Execute bounds the calls,
+// a failing call being one that timed out, and a semaphore may bound their concurrency.
+type Breaker struct {
+	m          sync.Mutex
+	state      State
+	generation uint64         // incremented on every state change, so that the outcome of calls made in a previous state is ignored
+	window     *rollingWindow // the outcomes of calls, while closed
+	probes     int            // probe calls let through, while half-open
+	succeeded  int            // probe calls that succeeded, while half-open
+	since      time.Time      // when the breaker entered its state
+	rejected   uint64         // calls rejected since the breaker was created
+	changes    []stateChange  // state changes for which the callback is not invoked yet
+	notifying  bool           // set while a goroutine invokes the callback for the pending state changes
+	opts       options
+}
+
+// A stateChange is a state change of a breaker, for which the callback set with WithOnStateChange is invoked.
+type stateChange struct {
+	from, to State
+}
+
+// New creates a closed Breaker.
+// New panics if the TripFunc is nil, the window is not positive, the number of probes is less than 1, or the cool-down is negative.
+func New(opts ...Option) *Breaker {
+	o := options{
+		trip:     ConsecutiveFailures(DefaultFailureThreshold),
+		window:   DefaultWindow,
+		probes:   1,
+		coolDown: DefaultCoolDown,
+		clock:    congo.RealClock(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.trip == nil {
+		panic("circuit: nil trip function")
+	}
+	if o.window <= 0 {
+		panic("circuit: window must be positive")
+	}
+	if o.probes < 1 {
+		panic("circuit: half-open probes must be at least 1")
+	}
+	if o.coolDown < 0 {
+		panic("circuit: negative cool-down")
+	}
+	return &Breaker{window: newRollingWindow(o.window), since: o.clock.Now(), opts: o}
+}
+
+// Execute calls fn with the context, if the breaker lets the call through, and returns its error. Any error counts as a failure.
+// If the breaker is open, or is half-open with all its probes let through, Execute returns ErrOpen without calling fn.
+// If the context is already done, Execute returns the context's error without calling fn.
+// A call that panics counts as a failure, and the panic is propagated.
+func (breaker *Breaker) Execute(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	generation, err := breaker.allow()
+	if err != nil {
+		return err
+	}
+	start := breaker.opts.clock.Now()
+	failed := true
+	defer func() {
+		breaker.record(generation, start, failed)
+	}()
+	err = fn(ctx)
+	failed = err != nil
+	return err
+}
+
+// State returns the current state of the breaker.
+func (breaker *Breaker) State() State {
+	breaker.m.Lock()
+	defer breaker.unlock()
+	breaker.coolDown(breaker.opts.clock.Now())
+	return breaker.state
+}
+
+// allow lets a call through, returning the generation it is made in, or returns ErrOpen.
+func (breaker *Breaker) allow() (uint64, error) {
+	breaker.m.Lock()
+	defer breaker.unlock()
+	breaker.coolDown(breaker.opts.clock.Now())
+	switch breaker.state {
+	case Open:
+		breaker.rejected++
+		return 0, ErrOpen
+	case HalfOpen:
+		if breaker.probes == breaker.opts.probes {
+			breaker.rejected++
+			return 0, ErrOpen
+		}
+		breaker.probes++
+	}
+	return breaker.generation, nil
+}
+
+// record records the outcome of a call made in the given generation, started at the given time.
+func (breaker *Breaker) record(generation uint64, start time.Time, failed bool) {
+	breaker.m.Lock()
+	defer breaker.unlock()
+	if generation != breaker.generation {
+		return
+	}
+	now := breaker.opts.clock.Now()
+	slow := breaker.opts.slowDuration > 0 && now.Sub(start) >= breaker.opts.slowDuration
+	switch breaker.state {
+	case Closed:
+		breaker.window.add(now, failed, slow)
+		if breaker.opts.trip(breaker.window.counts(now)) {
+			breaker.setState(Open)
+		}
+	case HalfOpen:
+		if failed || slow {
+			breaker.setState(Open)
+		} else if breaker.succeeded++; breaker.succeeded == breaker.opts.probes {
+			breaker.setState(Closed)
+		}
+	}
+}
+
+// coolDown makes an open breaker half-open once its cool-down elapsed.
+// This call must be guarded using the breaker mutex.
+func (breaker *Breaker) coolDown(now time.Time) {
+	if breaker.state == Open && now.Sub(breaker.since) >= breaker.opts.coolDown {
+		breaker.setState(HalfOpen)
+	}
+}
+
+// setState changes the state of the breaker, starting a new generation.
+// This call must be guarded using the breaker mutex.
+func (breaker *Breaker) setState(state State) {
+	if breaker.opts.onStateChange != nil {
+		breaker.changes = append(breaker.changes, stateChange{from: breaker.state, to: state})
+	}
+	breaker.state = state
+	breaker.since = breaker.opts.clock.Now()
+	breaker.generation++
+	breaker.window.reset()
+	breaker.probes = 0
+	breaker.succeeded = 0
+}
+
+// unlock releases the breaker mutex, then invokes the callback for the pending state changes, in order,
+// unless another goroutine is already doing so, in which case that goroutine invokes it for these changes too.
+func (breaker *Breaker) unlock() {
+	if breaker.notifying || len(breaker.changes) == 0 {
+		breaker.m.Unlock()
+		return
+	}
+	breaker.notifying = true
+	completed := false
+	defer func() {
+		if !completed {
+			// the callback panicked, let the next state change invoke it again
+			breaker.m.Lock()
+			breaker.notifying = false
+			breaker.m.Unlock()
+		}
+	}()
+	for len(breaker.changes) > 0 {
+		changes := breaker.changes
+		breaker.changes = nil
+		breaker.m.Unlock()
+		for _, change := range changes {
+			breaker.opts.onStateChange(change.from, change.to)
+		}
+		breaker.m.Lock()
+	}
+	breaker.notifying = false
+	completed = true
+	breaker.m.Unlock()
+}
@@ -0,0 +1,184 @@
+package circuit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nvn1729/congo/internal/clocktest"
+)
+
+var errDown = errors.New("downstream is down")
+
+func fail(ctx context.Context) error {
+	return errDown
+}
+
+func succeed(ctx context.Context) error {
+	return nil
+}
+
+func ExampleBreaker() {
+	breaker := New(WithFailureThreshold(2), WithCoolDown(time.Minute))
+	for i := 0; i < 3; i++ {
+		err := breaker.Execute(context.Background(), func(ctx context.Context) error {
+			return errors.New("connection refused")
+		})
+		fmt.Println(err)
+	}
+	fmt.Println(breaker.State())
+	// Output:
+	// connection refused
+	// connection refused
+	// Circuit breaker is open
+	// open
+}
+
+func TestBreaker(t *testing.T) {
+	ctx := context.Background()
+	clock := clocktest.New(time.Unix(0, 0))
+	breaker := New(WithFailureThreshold(3), WithCoolDown(time.Minute), WithClock(clock))
+	assertEqual(t, Closed, breaker.State())
+
+	// a success resets the consecutive failures
+	assertEqual(t, errDown, breaker.Execute(ctx, fail))
+	assertEqual(t, errDown, breaker.Execute(ctx, fail))
+	assertNil(t, breaker.Execute(ctx, succeed))
+	assertEqual(t, errDown, breaker.Execute(ctx, fail))
+	assertEqual(t, errDown, breaker.Execute(ctx, fail))
+	assertEqual(t, Closed, breaker.State())
+	assertEqual(t, errDown, breaker.Execute(ctx, fail))
+	assertEqual(t, Open, breaker.State())
+
+	// an open breaker rejects calls until its cool-down elapses
+	called := false
+	assertEqual(t, ErrOpen, breaker.Execute(ctx, func(ctx context.Context) error {
+		called = true
+		return nil
+	}))
+	assertEqual(t, false, called)
+	clock.Advance(59 * time.Second)
+	assertEqual(t, Open, breaker.State())
+	clock.Advance(time.Second)
+	assertEqual(t, HalfOpen, breaker.State())
+
+	// a failed probe opens the breaker again, a successful one closes it
+	assertEqual(t, errDown, breaker.Execute(ctx, fail))
+	assertEqual(t, Open, breaker.State())
+	clock.Advance(time.Minute)
+	assertNil(t, breaker.Execute(ctx, succeed))
+	assertEqual(t, Closed, breaker.State())
+}
+
+func TestBreaker_singleProbe(t *testing.T) {
+	ctx := context.Background()
+	clock := clocktest.New(time.Unix(0, 0))
+	breaker := New(WithFailureThreshold(1), WithCoolDown(time.Second), WithClock(clock))
+	assertEqual(t, errDown, breaker.Execute(ctx, fail))
+	clock.Advance(time.Second)
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		assertNil(t, breaker.Execute(ctx, func(ctx context.Context) error {
+			close(entered)
+			<-release
+			return nil
+		}))
+	}()
+	<-entered
+	assertEqual(t, ErrOpen, breaker.Execute(ctx, succeed))
+	close(release)
+	wg.Wait()
+	assertEqual(t, Closed, breaker.State())
+}
+
+func TestBreaker_staleOutcome(t *testing.T) {
+	ctx := context.Background()
+	breaker := New(WithFailureThreshold(1))
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- breaker.Execute(ctx, func(ctx context.Context) error {
+			close(entered)
+			<-release
+			return errDown
+		})
+	}()
+	<-entered
+	assertEqual(t, errDown, breaker.Execute(ctx, fail))
+	assertEqual(t, Open, breaker.State())
+
+	// the outcome of a call made while closed does not count once open
+	close(release)
+	assertEqual(t, errDown, <-done)
+	assertEqual(t, Open, breaker.State())
+}
+
+func TestBreaker_panic(t *testing.T) {
+	breaker := New(WithFailureThreshold(1))
+	func() {
+		defer func() {
+			assertEqual(t, "boom", recover())
+		}()
+		breaker.Execute(context.Background(), func(ctx context.Context) error {
+			panic("boom")
+		})
+	}()
+	assertEqual(t, Open, breaker.State())
+}
+
+func TestBreaker_doneContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	breaker := New(WithFailureThreshold(1))
+	assertEqual(t, context.Canceled, breaker.Execute(ctx, fail))
+	assertEqual(t, Closed, breaker.State())
+}
+
+func TestNew_invalid(t *testing.T) {
+	for _, f := range []func(){
+		func() { New(WithFailureThreshold(0)) },
+		func() { New(WithCoolDown(-time.Second)) },
+	} {
+		func() {
+			defer func() {
+				assertNotNil(t, recover())
+			}()
+			f()
+			t.Fatal("Did not panic")
+		}()
+	}
+}
+
+func TestState_String(t *testing.T) {
+	assertEqual(t, "closed", Closed.String())
+	assertEqual(t, "open", Open.String())
+	assertEqual(t, "half-open", HalfOpen.String())
+	assertEqual(t, "unknown", State(42).String())
+}
+
+func assertEqual(t *testing.T, expected interface{}, actual interface{}) {
+	if expected != actual {
+		t.Fatal("Not equal:", "expected:", expected, ", actual:", actual)
+	}
+}
+
+func assertNil(t *testing.T, actual interface{}) {
+	if actual != nil {
+		t.Fatal("Value not nil, actual:", actual)
+	}
+}
+
+func assertNotNil(t *testing.T, actual interface{}) {
+	if actual == nil {
+		t.Fatal("Value is nil")
+	}
+}
@@ -0,0 +1,9 @@
+package circuit
+
+import "errors"
+
+// These are errors related to circuit breakers.
+var (
+	// ErrOpen is returned by Execute when the breaker rejects the call, being open, or half-open with a probe in flight
+	ErrOpen = errors.New("Circuit breaker is open")
+)
@@ -0,0 +1,37 @@
+package circuit
+
+import "time"
+
+// Stats are a point-in-time view of the state of a Breaker, as returned by Stats, e.g. to feed dashboards.
+type Stats struct {
+	// State is the state of the breaker.
+	State State
+
+	// Since is the time at which the breaker entered its state, or was created.
+	Since time.Time
+
+	// Counts are the outcomes of the calls over the rolling window, while the breaker is closed.
+	// They are reset on every state change, so they are empty while the breaker is open or half-open.
+	Counts Counts
+
+	// Rejected is the number of calls rejected with ErrOpen since the breaker was created.
+	Rejected uint64
+
+	// Time is the time at which the snapshot was taken.
+	Time time.Time
+}
+
+// Stats returns the state and counts of the breaker, captured under a single lock acquisition.
+func (breaker *Breaker) Stats() Stats {
+	breaker.m.Lock()
+	defer breaker.unlock()
+	now := breaker.opts.clock.Now()
+	breaker.coolDown(now)
+	return Stats{
+		State:    breaker.state,
+		Since:    breaker.since,
+		Counts:   breaker.window.counts(now),
+		Rejected: breaker.rejected,
+		Time:     now,
+	}
+}
@@ -0,0 +1,103 @@
+package circuit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nvn1729/congo/internal/clocktest"
+)
+
+func TestBreaker_Stats(t *testing.T) {
+	ctx := context.Background()
+	clock := clocktest.New(time.Unix(0, 0))
+	created := clock.Now()
+	breaker := New(WithFailureThreshold(2), WithSlowCallDuration(time.Second), WithClock(clock))
+	assertEqual(t, Stats{State: Closed, Since: created, Time: created}, breaker.Stats())
+
+	assertNil(t, breaker.Execute(ctx, func(ctx context.Context) error {
+		clock.Advance(time.Second)
+		return nil
+	}))
+	assertEqual(t, errDown, breaker.Execute(ctx, fail))
+	stats := breaker.Stats()
+	assertEqual(t, Counts{Successes: 1, Failures: 1, Slow: 1, ConsecutiveFailures: 1}, stats.Counts)
+	assertEqual(t, 2, stats.Counts.Calls())
+	assertEqual(t, clock.Now(), stats.Time)
+
+	clock.Advance(time.Second)
+	opened := clock.Now()
+	assertEqual(t, errDown, breaker.Execute(ctx, fail))
+	assertEqual(t, ErrOpen, breaker.Execute(ctx, succeed))
+	assertEqual(t, ErrOpen, breaker.Execute(ctx, succeed))
+	clock.Advance(time.Second)
+	assertEqual(t, Stats{State: Open, Since: opened, Rejected: 2, Time: clock.Now()}, breaker.Stats())
+}
+
+func TestWithOnStateChange(t *testing.T) {
+	ctx := context.Background()
+	clock := clocktest.New(time.Unix(0, 0))
+	var m sync.Mutex
+	var changes []stateChange
+	var breaker *Breaker
+	breaker = New(WithFailureThreshold(1), WithClock(clock), WithOnStateChange(func(from, to State) {
+		// the callback is invoked outside of the breaker's lock
+		assertEqual(t, to, breaker.State())
+		m.Lock()
+		defer m.Unlock()
+		changes = append(changes, stateChange{from: from, to: to})
+	}))
+	assertEqual(t, errDown, breaker.Execute(ctx, fail))
+	clock.Advance(DefaultCoolDown)
+	assertEqual(t, errDown, breaker.Execute(ctx, fail))
+	clock.Advance(DefaultCoolDown)
+	assertNil(t, breaker.Execute(ctx, succeed))
+
+	m.Lock()
+	defer m.Unlock()
+	expected := []stateChange{{Closed, Open}, {Open, HalfOpen}, {HalfOpen, Open}, {Open, HalfOpen}, {HalfOpen, Closed}}
+	assertEqual(t, len(expected), len(changes))
+	for i, change := range expected {
+		assertEqual(t, change, changes[i])
+	}
+}
+
+func TestWithOnStateChange_order(t *testing.T) {
+	ctx := context.Background()
+	var m sync.Mutex
+	var changes []stateChange
+	// with no cool-down, the state changes as often as calls are made
+	breaker := New(WithFailureThreshold(1), WithCoolDown(0), WithOnStateChange(func(from, to State) {
+		// a slow callback lets other goroutines change the state meanwhile
+		time.Sleep(100 * time.Microsecond)
+		m.Lock()
+		defer m.Unlock()
+		changes = append(changes, stateChange{from: from, to: to})
+	}))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if (i+j)%3 == 0 {
+					breaker.Execute(ctx, succeed)
+				} else {
+					breaker.Execute(ctx, fail)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// every change starts from the state the previous one ended in
+	m.Lock()
+	defer m.Unlock()
+	assertEqual(t, true, len(changes) > 0)
+	state := Closed
+	for _, change := range changes {
+		assertEqual(t, state, change.from)
+		state = change.to
+	}
+}
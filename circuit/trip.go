@@ -0,0 +1,78 @@
+package circuit
+
+// Counts are the outcomes of the calls made through a Breaker while closed, over its rolling window.
+type Counts struct {
+	// Successes and Failures are the numbers of calls that succeeded and failed.
+	Successes, Failures int
+
+	// Slow is the number of calls, successful or not, that took at least the slow call duration set with WithSlowCallDuration.
+	Slow int
+
+	// ConsecutiveFailures is the number of calls that failed since the last successful call, regardless of the window.
+	ConsecutiveFailures int
+}
+
+// Calls returns the number of calls.
+func (counts Counts) Calls() int {
+	return counts.Successes + counts.Failures
+}
+
+// A TripFunc decides from the counts of a closed Breaker whether it should open. It is called after every call made through the breaker.
+type TripFunc func(counts Counts) bool
+
+// ConsecutiveFailures returns a TripFunc opening the breaker after n consecutive failed calls.
+// ConsecutiveFailures panics if n is less than 1.
+func ConsecutiveFailures(n int) TripFunc {
+	if n < 1 {
+		panic("circuit: failure threshold must be at least 1")
+	}
+	return func(counts Counts) bool {
+		return counts.ConsecutiveFailures >= n
+	}
+}
+
+// FailureRate returns a TripFunc opening the breaker once at least the given rate, between 0 and 1, of the calls over the window failed,
+// provided that at least minCalls calls were made over the window, so that a few failures do not open the breaker under a low load.
+// FailureRate panics if the rate is not between 0 and 1, or minCalls is less than 1.
+func FailureRate(rate float64, minCalls int) TripFunc {
+	checkRate(rate, minCalls)
+	return func(counts Counts) bool {
+		calls := counts.Calls()
+		return calls >= minCalls && float64(counts.Failures) >= rate*float64(calls)
+	}
+}
+
+// SlowCallRate returns a TripFunc opening the breaker once at least the given rate, between 0 and 1, of the calls over the window were slow,
+// as set with WithSlowCallDuration, provided that at least minCalls calls were made over the window.
+// A downstream slowing down is often about to fail, and slow calls hold the resources of the callers meanwhile.
+// SlowCallRate panics if the rate is not between 0 and 1, or minCalls is less than 1.
+func SlowCallRate(rate float64, minCalls int) TripFunc {
+	checkRate(rate, minCalls)
+	return func(counts Counts) bool {
+		calls := counts.Calls()
+		return calls >= minCalls && float64(counts.Slow) >= rate*float64(calls)
+	}
+}
+
+// Any returns a TripFunc opening the breaker as soon as any of the given TripFuncs would,
+// e.g. Any(FailureRate(0.5, 20), SlowCallRate(0.8, 20)).
+func Any(trips ...TripFunc) TripFunc {
+	return func(counts Counts) bool {
+		for _, trip := range trips {
+			if trip(counts) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// checkRate panics unless the arguments of a rate TripFunc are valid.
+func checkRate(rate float64, minCalls int) {
+	if !(rate >= 0 && rate <= 1) {
+		panic("circuit: rate must be between 0 and 1")
+	}
+	if minCalls < 1 {
+		panic("circuit: minimum calls must be at least 1")
+	}
+}
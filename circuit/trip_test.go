@@ -0,0 +1,124 @@
+package circuit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nvn1729/congo/internal/clocktest"
+)
+
+func TestTripFuncs(t *testing.T) {
+	consecutive := ConsecutiveFailures(3)
+	assertEqual(t, false, consecutive(Counts{Failures: 5, ConsecutiveFailures: 2}))
+	assertEqual(t, true, consecutive(Counts{Failures: 3, ConsecutiveFailures: 3}))
+
+	failureRate := FailureRate(0.5, 4)
+	assertEqual(t, false, failureRate(Counts{Failures: 3}))
+	assertEqual(t, true, failureRate(Counts{Successes: 2, Failures: 2}))
+	assertEqual(t, false, failureRate(Counts{Successes: 3, Failures: 2}))
+
+	slowCallRate := SlowCallRate(0.8, 5)
+	assertEqual(t, false, slowCallRate(Counts{Successes: 5, Slow: 3}))
+	assertEqual(t, true, slowCallRate(Counts{Successes: 3, Failures: 2, Slow: 4}))
+
+	any := Any(consecutive, slowCallRate)
+	assertEqual(t, false, any(Counts{Successes: 5}))
+	assertEqual(t, true, any(Counts{Failures: 3, ConsecutiveFailures: 3}))
+	assertEqual(t, true, any(Counts{Successes: 5, Slow: 5}))
+	assertEqual(t, false, Any()(Counts{Failures: 10, ConsecutiveFailures: 10}))
+}
+
+func TestBreaker_failureRate(t *testing.T) {
+	ctx := context.Background()
+	clock := clocktest.New(time.Unix(0, 0))
+	breaker := New(WithTrip(FailureRate(0.5, 4)), WithWindow(10*time.Second), WithClock(clock))
+	assertNil(t, breaker.Execute(ctx, succeed))
+	assertEqual(t, errDown, breaker.Execute(ctx, fail))
+	assertEqual(t, errDown, breaker.Execute(ctx, fail))
+	assertEqual(t, Closed, breaker.State())
+
+	// calls slide out of the window
+	clock.Advance(10 * time.Second)
+	assertNil(t, breaker.Execute(ctx, succeed))
+	assertEqual(t, errDown, breaker.Execute(ctx, fail))
+	assertNil(t, breaker.Execute(ctx, succeed))
+	assertEqual(t, Closed, breaker.State())
+	assertEqual(t, errDown, breaker.Execute(ctx, fail))
+	assertEqual(t, Open, breaker.State())
+}
+
+func TestBreaker_slowCallRate(t *testing.T) {
+	ctx := context.Background()
+	clock := clocktest.New(time.Unix(0, 0))
+	breaker := New(WithTrip(SlowCallRate(0.5, 2)), WithSlowCallDuration(time.Second), WithClock(clock))
+	slow := func(ctx context.Context) error {
+		clock.Advance(time.Second)
+		return nil
+	}
+	assertNil(t, breaker.Execute(ctx, succeed))
+	assertNil(t, breaker.Execute(ctx, slow))
+	assertEqual(t, Open, breaker.State())
+
+	// a slow probe opens the breaker again
+	clock.Advance(DefaultCoolDown)
+	assertNil(t, breaker.Execute(ctx, slow))
+	assertEqual(t, Open, breaker.State())
+}
+
+func TestBreaker_halfOpenProbes(t *testing.T) {
+	ctx := context.Background()
+	clock := clocktest.New(time.Unix(0, 0))
+	breaker := New(WithFailureThreshold(1), WithHalfOpenProbes(2), WithClock(clock))
+	assertEqual(t, errDown, breaker.Execute(ctx, fail))
+	clock.Advance(DefaultCoolDown)
+
+	// both probes are let through concurrently, and must succeed for the breaker to close
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error)
+	for i := 0; i < 2; i++ {
+		go func() {
+			done <- breaker.Execute(ctx, func(ctx context.Context) error {
+				entered <- struct{}{}
+				<-release
+				return nil
+			})
+		}()
+		<-entered
+	}
+	assertEqual(t, ErrOpen, breaker.Execute(ctx, succeed))
+	release <- struct{}{}
+	assertNil(t, <-done)
+	assertEqual(t, HalfOpen, breaker.State())
+	release <- struct{}{}
+	assertNil(t, <-done)
+	assertEqual(t, Closed, breaker.State())
+
+	// a single failed probe opens the breaker again
+	assertEqual(t, errDown, breaker.Execute(ctx, fail))
+	clock.Advance(DefaultCoolDown)
+	assertNil(t, breaker.Execute(ctx, succeed))
+	assertEqual(t, errDown, breaker.Execute(ctx, fail))
+	assertEqual(t, Open, breaker.State())
+}
+
+func TestTripFuncs_invalid(t *testing.T) {
+	for _, f := range []func(){
+		func() { ConsecutiveFailures(0) },
+		func() { FailureRate(1.5, 1) },
+		func() { FailureRate(0.5, 0) },
+		func() { SlowCallRate(-0.1, 1) },
+		func() { New(WithTrip(nil)) },
+		func() { New(WithWindow(0)) },
+		func() { New(WithHalfOpenProbes(0)) },
+	} {
+		func() {
+			defer func() {
+				assertNotNil(t, recover())
+			}()
+			f()
+			t.Fatal("Did not panic")
+		}()
+	}
+}
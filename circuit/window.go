@@ -0,0 +1,71 @@
+package circuit
+
+import "time"
+
+// windowBuckets is the number of buckets of a rolling window, which moves forward by a tenth of its duration at a time.
+const windowBuckets = 10
+
+// A rollingWindow counts the outcomes of calls over a rolling window, split into buckets of calls, so that the counts of the window
+// are updated in constant time and memory, however many calls are made.
+type rollingWindow struct {
+	buckets     [windowBuckets]bucket
+	width       time.Duration // the duration of a bucket
+	consecutive int           // consecutive failures
+}
+
+// A bucket counts the outcomes of the calls made over a part of a window.
+type bucket struct {
+	start                     int64 // the index of the part of the window, as a multiple of the width since the Unix epoch
+	successes, failures, slow int
+}
+
+// newRollingWindow creates an empty window of the given duration.
+func newRollingWindow(duration time.Duration) *rollingWindow {
+	width := duration / windowBuckets
+	if width < 1 {
+		width = 1
+	}
+	return &rollingWindow{width: width}
+}
+
+// add counts the outcome of a call made at the given time.
+func (window *rollingWindow) add(now time.Time, failed, slow bool) {
+	index := now.UnixNano() / int64(window.width)
+	i := index % windowBuckets
+	if i < 0 {
+		i += windowBuckets
+	}
+	b := &window.buckets[i]
+	if b.start != index {
+		*b = bucket{start: index}
+	}
+	if failed {
+		b.failures++
+		window.consecutive++
+	} else {
+		b.successes++
+		window.consecutive = 0
+	}
+	if slow {
+		b.slow++
+	}
+}
+
+// counts returns the counts of the window ending at the given time.
+func (window *rollingWindow) counts(now time.Time) Counts {
+	index := now.UnixNano() / int64(window.width)
+	counts := Counts{ConsecutiveFailures: window.consecutive}
+	for _, b := range window.buckets {
+		if index-b.start < windowBuckets && b.start <= index {
+			counts.Successes += b.successes
+			counts.Failures += b.failures
+			counts.Slow += b.slow
+		}
+	}
+	return counts
+}
+
+// reset forgets the outcomes of all calls.
+func (window *rollingWindow) reset() {
+	*window = rollingWindow{width: window.width}
+}
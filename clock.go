@@ -0,0 +1,58 @@
+package congo
+
+import (
+	"time"
+
+	"github.com/nvn1729/congo/internal/clock"
+)
+
+// A Clock provides the current time and timers used for time-based waits such as WaitTimeout and WaitDeadline.
+//
+// The default Clock is backed by the time package. Tests may supply their own Clock with WithClock
+// in order to control the passage of time instead of sleeping for real.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// After waits for the duration to elapse and then sends the current time on the returned channel.
+	After(d time.Duration) <-chan time.Time
+
+	// NewTimer creates a Timer that will send the current time on its channel after at least duration d.
+	NewTimer(d time.Duration) Timer
+}
+
+// A Timer is a single event created by a Clock, mirroring time.Timer:
+// C returns the channel on which the time is delivered when the Timer fires,
+// and Stop prevents the Timer from firing, returning false if the Timer has already fired or been stopped.
+type Timer = clock.Timer
+
+// RealClock returns the Clock backed by the time package.
+func RealClock() Clock {
+	return realClock{}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{timer: time.NewTimer(d)}
+}
+
+type realTimer struct {
+	timer *time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+func (t realTimer) Stop() bool {
+	return t.timer.Stop()
+}
@@ -0,0 +1,75 @@
+package congo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nvn1729/congo/internal/clocktest"
+)
+
+// waitTimeout calls WaitTimeout on a latch or event of the clock that is not released meanwhile,
+// and advances the clock by the timeout once it waits, so that timeouts elapse without sleeping.
+func waitTimeout(clock *clocktest.Clock, waiter interface{ WaitTimeout(time.Duration) bool }, timeout time.Duration) bool {
+	result := make(chan bool)
+	go func() {
+		result <- waiter.WaitTimeout(timeout)
+	}()
+	clock.BlockUntilTimers(1)
+	clock.Advance(timeout)
+	return <-result
+}
+
+func TestCountDownLatch_fakeClockTimeout(t *testing.T) {
+	clock := clocktest.New(time.Unix(0, 0))
+	latch := NewCountDownLatch(1, WithClock(clock))
+
+	result := make(chan bool)
+	go func() {
+		result <- latch.WaitTimeout(time.Hour)
+	}()
+
+	clock.BlockUntilTimers(1)
+	clock.Advance(59 * time.Minute)
+	select {
+	case <-result:
+		t.Fatal("WaitTimeout returned before the timeout")
+	default:
+	}
+	clock.Advance(time.Minute)
+	assertEqual(t, false, <-result)
+	assertEqual(t, uint(1), latch.Count())
+}
+
+func TestCountDownLatch_fakeClockDeadline(t *testing.T) {
+	clock := clocktest.New(time.Unix(0, 0))
+	latch := NewCountDownLatch(1, WithClock(clock))
+
+	// a deadline in the past does not block
+	assertEqual(t, false, latch.WaitDeadline(clock.Now().Add(-time.Second)))
+
+	result := make(chan bool)
+	go func() {
+		result <- latch.WaitDeadline(clock.Now().Add(time.Hour))
+	}()
+
+	clock.BlockUntilTimers(1)
+	assertNil(t, latch.CountDown())
+	assertEqual(t, true, <-result)
+
+	// the timer of the completed wait has been stopped
+	assertEqual(t, 0, clock.Timers())
+
+	// a completed latch reports true even with an expired deadline
+	assertEqual(t, true, latch.WaitDeadline(clock.Now().Add(-time.Second)))
+}
+
+func TestRealClock(t *testing.T) {
+	clock := RealClock()
+	start := clock.Now()
+	fired := <-clock.After(time.Millisecond)
+	assertEqual(t, true, fired.Sub(start) >= time.Millisecond)
+
+	timer := clock.NewTimer(time.Hour)
+	assertEqual(t, true, timer.Stop())
+	assertEqual(t, false, timer.Stop())
+}
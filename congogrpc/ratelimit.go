@@ -0,0 +1,74 @@
+// Package congogrpc provides gRPC interceptors for the primitives in package congo and its subpackages.
+package congogrpc
+
+import (
+	"context"
+	"net"
+
+	"github.com/nvn1729/congo/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// A KeyFunc returns the key of the limiter of a call, given its context and full method name, e.g. PeerIP.
+type KeyFunc func(ctx context.Context, fullMethod string) string
+
+// UnaryServerInterceptor returns a grpc.UnaryServerInterceptor rate limiting the unary calls per key, as returned by the key function.
+// The calls allowed by the limiter of their key are passed to the handler. The others fail with codes.ResourceExhausted,
+// and a retry-after header, in seconds, if the limiter of their key is a ratelimit.Delayer.
+func UnaryServerInterceptor(limiter *ratelimit.KeyedLimiter, key KeyFunc) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		header, err := allow(limiter, key(ctx, info.FullMethod), info.FullMethod)
+		if err != nil {
+			if header != nil {
+				grpc.SetHeader(ctx, header)
+			}
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns a grpc.StreamServerInterceptor rate limiting the streams per key, as returned by the key function,
+// like UnaryServerInterceptor. The limit applies to opening streams, not to their messages.
+func StreamServerInterceptor(limiter *ratelimit.KeyedLimiter, key KeyFunc) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		header, err := allow(limiter, key(stream.Context(), info.FullMethod), info.FullMethod)
+		if err != nil {
+			if header != nil {
+				stream.SetHeader(header)
+			}
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
+
+// PeerIP returns the IP address of the client of a call, without its port, as a key for the interceptors.
+func PeerIP(ctx context.Context, fullMethod string) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// allow returns nil if a call of the key is allowed by its limiter. Otherwise, it returns the status error of the rejected call,
+// and its retry-after header if the limiter of the key is a ratelimit.Delayer.
+func allow(limiter *ratelimit.KeyedLimiter, key string, fullMethod string) (metadata.MD, error) {
+	if limiter.Allow(key) {
+		return nil, nil
+	}
+	var header metadata.MD
+	if delay, ok := limiter.Delay(key); ok {
+		header = metadata.Pairs("retry-after", ratelimit.RetryAfter(delay))
+	}
+	return header, status.Errorf(codes.ResourceExhausted, "%s is rate limited, retry later", fullMethod)
+}
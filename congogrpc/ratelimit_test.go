@@ -0,0 +1,89 @@
+package congogrpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/nvn1729/congo/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	limiter := ratelimit.NewKeyed(func(key string) ratelimit.Limiter {
+		return ratelimit.NewTokenBucket(ratelimit.Per(1, time.Minute), 1)
+	}, time.Hour)
+	interceptor := UnaryServerInterceptor(limiter, PeerIP)
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Call"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1234}})
+
+	resp, err := interceptor(ctx, nil, info, handler)
+	assertNil(t, err)
+	assertEqual(t, "ok", resp)
+	resp, err = interceptor(ctx, nil, info, handler)
+	assertEqual(t, nil, resp)
+	assertEqual(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	limiter := ratelimit.NewKeyed(func(key string) ratelimit.Limiter {
+		return ratelimit.NewTokenBucket(ratelimit.Per(1, time.Minute), 1)
+	}, time.Hour)
+	interceptor := StreamServerInterceptor(limiter, func(ctx context.Context, fullMethod string) string {
+		return fullMethod
+	})
+	info := &grpc.StreamServerInfo{FullMethod: "/test.Service/Stream"}
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		return nil
+	}
+	stream := &fakeStream{ctx: context.Background()}
+
+	assertNil(t, interceptor(nil, stream, info, handler))
+	err := interceptor(nil, stream, info, handler)
+	assertEqual(t, codes.ResourceExhausted, status.Code(err))
+	retryAfter := stream.header.Get("retry-after")
+	assertEqual(t, 1, len(retryAfter))
+	assertEqual(t, "60", retryAfter[0])
+}
+
+func TestPeerIP(t *testing.T) {
+	assertEqual(t, "", PeerIP(context.Background(), ""))
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv6loopback, Port: 80}})
+	assertEqual(t, "::1", PeerIP(ctx, ""))
+}
+
+// A fakeStream is a grpc.ServerStream recording its header.
+type fakeStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	header metadata.MD
+}
+
+func (stream *fakeStream) Context() context.Context {
+	return stream.ctx
+}
+
+func (stream *fakeStream) SetHeader(header metadata.MD) error {
+	stream.header = metadata.Join(stream.header, header)
+	return nil
+}
+
+func assertEqual(t *testing.T, expected interface{}, actual interface{}) {
+	if expected != actual {
+		t.Fatal("Not equal:", "expected:", expected, ", actual:", actual)
+	}
+}
+
+func assertNil(t *testing.T, actual interface{}) {
+	if actual != nil {
+		t.Fatal("Value not nil, actual:", actual)
+	}
+}
@@ -0,0 +1,115 @@
+// Package congoprom provides Prometheus collectors for the primitives in package congo.
+package congoprom
+
+import (
+	"sync"
+	"time"
+
+	"github.com/nvn1729/congo"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// A LatchCollector is a prometheus.Collector reporting the health of tracked CountDownLatches:
+//
+// - <namespace>_latch_remaining_count is a gauge of the remaining count of the latches.
+//
+// - <namespace>_latch_waiters is a gauge of the number of goroutines waiting on the latches.
+//
+// - <namespace>_latch_completion_duration_seconds is a histogram of the time taken by the latches to complete, measured from when they were tracked.
+//
+// All metrics are labeled with the latch name, see congo.WithName. Tracked latches sharing a name are aggregated,
+// which keeps the number of series bounded when many short-lived latches are created for the same purpose.
+type LatchCollector struct {
+	m         sync.Mutex
+	latches   map[*congo.CountDownLatch]chan struct{}
+	remaining *prometheus.Desc
+	waiters   *prometheus.Desc
+	durations *prometheus.HistogramVec
+}
+
+// NewLatchCollector creates a LatchCollector whose metric names are prefixed with the given namespace.
+// The collector must be registered with a prometheus.Registerer to be scraped.
+func NewLatchCollector(namespace string) *LatchCollector {
+	return &LatchCollector{
+		latches: make(map[*congo.CountDownLatch]chan struct{}),
+		remaining: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "latch", "remaining_count"),
+			"Remaining count of tracked latches.",
+			[]string{"latch"}, nil,
+		),
+		waiters: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "latch", "waiters"),
+			"Number of goroutines waiting on tracked latches.",
+			[]string{"latch"}, nil,
+		),
+		durations: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "latch",
+			Name:      "completion_duration_seconds",
+			Help:      "Time taken by tracked latches to complete their count down.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 4, 10),
+		}, []string{"latch"}),
+	}
+}
+
+// Track starts reporting metrics for the latch.
+// The latch is tracked until its count down completes, at which point its completion duration is observed,
+// or until it is canceled or passed to Untrack, in which case no duration is observed.
+// Tracking a latch that is already tracked has no effect.
+func (c *LatchCollector) Track(latch *congo.CountDownLatch) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if _, ok := c.latches[latch]; ok {
+		return
+	}
+	untrackCh := make(chan struct{})
+	c.latches[latch] = untrackCh
+
+	start := time.Now()
+	go func() {
+		select {
+		case <-latch.Done():
+			if latch.Err() == nil {
+				c.durations.WithLabelValues(latch.Name()).Observe(time.Since(start).Seconds())
+			}
+			c.Untrack(latch)
+		case <-untrackCh:
+		}
+	}()
+}
+
+// Untrack stops reporting metrics for the latch.
+func (c *LatchCollector) Untrack(latch *congo.CountDownLatch) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if untrackCh, ok := c.latches[latch]; ok {
+		close(untrackCh)
+		delete(c.latches, latch)
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *LatchCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.remaining
+	ch <- c.waiters
+	c.durations.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *LatchCollector) Collect(ch chan<- prometheus.Metric) {
+	remaining := make(map[string]uint)
+	waiters := make(map[string]int)
+	c.m.Lock()
+	for latch := range c.latches {
+		snapshot := latch.Snapshot()
+		remaining[snapshot.Name] += snapshot.RemainingCount
+		waiters[snapshot.Name] += snapshot.Waiters
+	}
+	c.m.Unlock()
+
+	for name, count := range remaining {
+		ch <- prometheus.MustNewConstMetric(c.remaining, prometheus.GaugeValue, float64(count), name)
+		ch <- prometheus.MustNewConstMetric(c.waiters, prometheus.GaugeValue, float64(waiters[name]), name)
+	}
+	c.durations.Collect(ch)
+}
@@ -0,0 +1,116 @@
+package congoprom
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nvn1729/congo"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestLatchCollector(t *testing.T) {
+	collector := NewLatchCollector("test")
+	registry := prometheus.NewPedanticRegistry()
+	assertNil(t, registry.Register(collector))
+
+	batch1 := congo.New(3, congo.WithName("batch"))
+	batch2 := congo.New(2, congo.WithName("batch"))
+	other := congo.New(1, congo.WithName("other"))
+	collector.Track(batch1)
+	collector.Track(batch2)
+	collector.Track(batch1)
+	collector.Track(other)
+
+	go batch1.Wait()
+	for batch1.Waiters() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	families := gather(t, registry)
+	assertEqual(t, 5.0, gaugeValue(families, "test_latch_remaining_count", "batch"))
+	assertEqual(t, 1.0, gaugeValue(families, "test_latch_remaining_count", "other"))
+	assertEqual(t, 1.0, gaugeValue(families, "test_latch_waiters", "batch"))
+	assertEqual(t, 0.0, gaugeValue(families, "test_latch_waiters", "other"))
+
+	// completed latches are observed and untracked, canceled latches are only untracked
+	assertNil(t, batch1.Complete())
+	assertNil(t, other.Cancel(nil))
+	for {
+		collector.m.Lock()
+		tracked := len(collector.latches)
+		collector.m.Unlock()
+		if tracked == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	families = gather(t, registry)
+	assertEqual(t, 2.0, gaugeValue(families, "test_latch_remaining_count", "batch"))
+	assertEqual(t, -1.0, gaugeValue(families, "test_latch_remaining_count", "other"))
+	assertEqual(t, uint64(1), histogramCount(families, "test_latch_completion_duration_seconds", "batch"))
+	assertEqual(t, uint64(0), histogramCount(families, "test_latch_completion_duration_seconds", "other"))
+
+	collector.Untrack(batch2)
+	families = gather(t, registry)
+	assertEqual(t, -1.0, gaugeValue(families, "test_latch_remaining_count", "batch"))
+}
+
+// gather helpers
+
+func gather(t *testing.T, registry *prometheus.Registry) map[string]*dto.MetricFamily {
+	families, err := registry.Gather()
+	assertNil(t, err)
+	byName := make(map[string]*dto.MetricFamily)
+	for _, family := range families {
+		byName[family.GetName()] = family
+	}
+	return byName
+}
+
+func metric(families map[string]*dto.MetricFamily, name string, latch string) *dto.Metric {
+	family, ok := families[name]
+	if !ok {
+		return nil
+	}
+	for _, m := range family.GetMetric() {
+		for _, label := range m.GetLabel() {
+			if label.GetName() == "latch" && label.GetValue() == latch {
+				return m
+			}
+		}
+	}
+	return nil
+}
+
+// gaugeValue returns the value of the gauge, or -1 if there is no such gauge.
+func gaugeValue(families map[string]*dto.MetricFamily, name string, latch string) float64 {
+	m := metric(families, name, latch)
+	if m == nil {
+		return -1
+	}
+	return m.GetGauge().GetValue()
+}
+
+func histogramCount(families map[string]*dto.MetricFamily, name string, latch string) uint64 {
+	m := metric(families, name, latch)
+	if m == nil {
+		return 0
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+// assertion helpers
+
+func assertEqual(t *testing.T, expected interface{}, actual interface{}) {
+	if expected != actual {
+		t.Fatal("Not equal:", "expected:", expected, ", actual:", actual)
+	}
+}
+
+func assertNil(t *testing.T, actual interface{}) {
+	if actual != nil {
+		t.Fatal("Value not nil, actual:", actual)
+	}
+}
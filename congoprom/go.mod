@@ -0,0 +1,22 @@
+module github.com/nvn1729/congo/congoprom
+
+go 1.25.0
+
+require (
+	github.com/nvn1729/congo v0.0.0-00010101000000-000000000000
+	github.com/prometheus/client_golang v1.24.1
+	github.com/prometheus/client_model v0.6.3
+)
+
+require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/munnerz/goautoneg v0.0.0-20191010083416-a7dc8b61c822 // indirect
+	github.com/prometheus/common v0.70.1 // indirect
+	github.com/prometheus/procfs v0.21.1 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+	google.golang.org/protobuf v1.36.12 // indirect
+)
+
+// The placeholder requirement above resolves through this replace directive until the root module is tagged; require that tag then.
+replace github.com/nvn1729/congo => ../
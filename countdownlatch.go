@@ -2,8 +2,11 @@
 package congo
 
 import (
+	"encoding/json"
 	"time"
 	"sync"
+	"sync/atomic"
+	"runtime"
 )
 
 // A CountDownLatch is used to signal the completion of a specified number of events.
@@ -23,25 +26,65 @@ import (
 // - It has the ability to Complete the count down immediately, unblocking any goroutines waiting on Wait
 //
 // - The count is set one time at latch creation instead of through a separate Add call.
+//
+// A latch may be cycled through repeated rounds of count down with Reset. Each round is identified by an epoch,
+// and AwaitAdvance waits for the round with a given epoch to complete.
 type CountDownLatch struct {
 	m sync.Mutex
 	remainingCount uint
 	countDownCompleteCh chan struct{}
+	epoch uint64
+	resetCh chan struct{} // created on demand by AwaitAdvance, closed by Reset
+	cause error
+	clock Clock
+	name string
+	hooks Hooks
+	strictWeights bool
+	waiters int32 // accessed atomically
+	leaks *leakDetector
+	quorums map[uint]chan struct{} // keyed by the remaining count at which waiters are released
+	chains []chain
+	completedChains []chain // counted down by unlock
+	initialCount uint
+	created time.Time
+	completed time.Time
 }
 
 // NewCountDownLatch creates a CountDownLatch with the provided count.
 // If the count is 0, the latch will be set to immediately signal count down completion for any goroutines that subsequently call Wait or WaitTimeout.
-func NewCountDownLatch(count uint) *CountDownLatch {
+// The latch may be further configured by passing options such as WithClock or WithName.
+func NewCountDownLatch(count uint, opts ...Option) *CountDownLatch {
 	latch := &CountDownLatch{
 		remainingCount: count,
 		countDownCompleteCh: make(chan struct{}),
+		clock: RealClock(),
+	}
+	for _, opt := range opts {
+		opt(latch)
 	}
+	latch.initialCount = count
+	latch.created = latch.getClock().Now()
 	if latch.remainingCount == 0 {
-		close(latch.countDownCompleteCh)
+		latch.finish()
+	}
+	if latch.leaks != nil {
+		latch.leaks.name = latch.name
+		runtime.SetFinalizer(latch, (*CountDownLatch).collected)
 	}
 	return latch
 }
 
+// New creates a CountDownLatch with the provided count, configured by the given options.
+// It is equivalent to NewCountDownLatch.
+func New(count uint, opts ...Option) *CountDownLatch {
+	return NewCountDownLatch(count, opts...)
+}
+
+// Name returns the name given to the latch with WithName, or an empty string if the latch is unnamed.
+func (latch *CountDownLatch) Name() string {
+	return latch.name
+}
+
 // Count returns the remaining count.
 func (latch *CountDownLatch) Count() uint {
 	latch.m.Lock()
@@ -49,63 +92,331 @@ func (latch *CountDownLatch) Count() uint {
 	return latch.remainingCount
 }
 
+// Waiters returns the number of goroutines currently blocked waiting on the latch.
+func (latch *CountDownLatch) Waiters() int {
+	if latch.leaks != nil {
+		return latch.leaks.count()
+	}
+	return int(atomic.LoadInt32(&latch.waiters))
+}
+
 // CountDown is equivalent to WeightedCountDown with a weight of 1.
 func (latch *CountDownLatch) CountDown() error {
 	latch.m.Lock()
-	defer latch.m.Unlock()
+	defer latch.unlock()
 	return latch.doCountDown(1)
 }
 
 // WeightedCountDown reduces the count on the CountDownLatch by the given weight.
-// If given weight exceeds the current latch's count, the latch's count is set to 0.
+// If given weight exceeds the current latch's count, the latch's count is set to 0,
+// unless the latch was created with WithStrictWeights, in which case ErrCountDownOverflow is returned and the count is left unchanged.
 // If the count hits 0, WeightedCountDown signals latch count down completion, waking up any goroutines waiting with Wait or WaitTimeout.
 // An error, ErrCountDownLatchCompleted, is returned if count down has already been completed.
 func (latch *CountDownLatch) WeightedCountDown(weight uint) error {
 	latch.m.Lock()
-	defer latch.m.Unlock()
+	defer latch.unlock()
 	return latch.doCountDown(weight)
 }
 
+// TryCountDown is equivalent to TryWeightedCountDown with a weight of 1.
+func (latch *CountDownLatch) TryCountDown() bool {
+	return latch.TryWeightedCountDown(1)
+}
+
+// TryWeightedCountDown is like WeightedCountDown, but reports whether the count down took place instead of returning an error.
+// It suits callers that treat a count down racing with completion as benign.
+func (latch *CountDownLatch) TryWeightedCountDown(weight uint) bool {
+	latch.m.Lock()
+	defer latch.unlock()
+	return latch.doCountDown(weight) == nil
+}
 
 // Complete reduces the count on the CountDownLatch by its current remaining count.
 // It is equivalent to calling WeightedCountDown with the remaining count.
 func (latch *CountDownLatch) Complete() error {
 	latch.m.Lock()
-	defer latch.m.Unlock()
+	defer latch.unlock()
 	return latch.doCountDown(latch.remainingCount)
 }
 
-// Wait waits indefinitely until the count down is completed.
-// Wait returns immediately if the count down has already been completed.
+// Go runs fn in a new goroutine and counts down the latch by 1 when fn returns.
+// The count down happens even if fn panics, so that a failed goroutine does not leave waiters hanging.
+// The panic is then propagated as usual; use GoRecover to recover it instead.
+func (latch *CountDownLatch) Go(fn func()) {
+	go func() {
+		defer latch.CountDown()
+		fn()
+	}()
+}
+
+// GoRecover is like Go, but recovers a panic raised by fn instead of propagating it.
+// If onPanic is not nil, it is invoked with the recovered value before the latch is counted down.
+func (latch *CountDownLatch) GoRecover(fn func(), onPanic func(recovered interface{})) {
+	go func() {
+		defer latch.CountDown()
+		defer func() {
+			if r := recover(); r != nil && onPanic != nil {
+				onPanic(r)
+			}
+		}()
+		fn()
+	}()
+}
+
+// Wait waits indefinitely until the count down is completed or canceled.
+// Wait returns immediately if the count down has already been completed or canceled.
 func (latch *CountDownLatch) Wait() {
-	<-latch.countDownCompleteCh
+	completeCh := latch.completeCh()
+	select {
+	case <-completeCh:
+		return
+	default:
+	}
+
+	latch.block(completeCh, nil)
+}
+
+// Done returns a channel that is closed when the current round of count down is completed or canceled.
+// It allows waiting on the latch in a select statement alongside other channels.
+// Goroutines receiving from the channel are not included in Waiters.
+func (latch *CountDownLatch) Done() <-chan struct{} {
+	return latch.completeCh()
 }
 
 // WaitTimeout waits until a given timeout for the count down to complete.
-// If the count down is completed before the timeout, WaitTimeout returns true.
+// If the count down is completed or canceled before the timeout, WaitTimeout returns true; Err distinguishes the two.
 // Otherwise it returns false.
 // WaitTimeout returns immediatley if the count down has already been completed.
 func (latch *CountDownLatch) WaitTimeout(timeout time.Duration) bool {
+	completeCh := latch.completeCh()
+	select {
+	case <-completeCh:
+		return true
+	default:
+	}
+
+	timer := latch.getClock().NewTimer(timeout)
+	defer timer.Stop()
+	return latch.block(completeCh, timer.C())
+}
+
+// WaitDeadline waits until a given deadline for the count down to complete.
+// It is equivalent to calling WaitTimeout with the time remaining until the deadline according to the latch's Clock.
+func (latch *CountDownLatch) WaitDeadline(deadline time.Time) bool {
+	return latch.WaitTimeout(deadline.Sub(latch.getClock().Now()))
+}
+
+// Cancel aborts the count down with the given cause, immediately releasing all goroutines waiting on the latch.
+// Subsequent calls to CountDown, WeightedCountDown and Complete return ErrCountDownLatchCanceled,
+// and WaitErr and Err return the cause. If the cause is nil, ErrCountDownLatchCanceled is used as the cause.
+// An error, ErrCountDownLatchCompleted, is returned if the count down has already been completed or canceled.
+func (latch *CountDownLatch) Cancel(cause error) error {
+	latch.m.Lock()
+	defer latch.m.Unlock()
 	select {
 	case <-latch.countDownCompleteCh:
+		return ErrCountDownLatchCompleted
+	default:
+	}
+	if cause == nil {
+		cause = ErrCountDownLatchCanceled
+	}
+	latch.cause = cause
+	latch.finish()
+	latch.chains = nil
+	return nil
+}
+
+// Err returns the cause passed to Cancel if the latch has been canceled, or nil otherwise.
+func (latch *CountDownLatch) Err() error {
+	latch.m.Lock()
+	defer latch.m.Unlock()
+	return latch.cause
+}
+
+// WaitErr is like Wait, but reports how the count down ended.
+// It returns nil if the count down completed, or the cancellation cause if the latch was canceled.
+func (latch *CountDownLatch) WaitErr() error {
+	latch.Wait()
+	return latch.Err()
+}
+
+// Epoch returns the epoch of the current round of count down. The first round has epoch 0,
+// and each call to Reset starts a new round with the next epoch.
+func (latch *CountDownLatch) Epoch() uint64 {
+	latch.m.Lock()
+	defer latch.m.Unlock()
+	return latch.epoch
+}
+
+// Reset starts a new round of count down with the provided count and returns the epoch of the new round.
+// Goroutines that subsequently call Wait or WaitTimeout wait for the new round to complete.
+// A canceled latch may also be Reset, which clears the cancellation.
+// An error, ErrCountDownLatchNotCompleted, is returned if the current round is still in progress,
+// so that goroutines waiting on it are never stranded.
+func (latch *CountDownLatch) Reset(count uint) (uint64, error) {
+	latch.m.Lock()
+	defer latch.m.Unlock()
+	if latch.remainingCount > 0 && latch.cause == nil {
+		return latch.epoch, ErrCountDownLatchNotCompleted
+	}
+	latch.startRound(count)
+	return latch.epoch, nil
+}
+
+// startRound begins the next round of count down with the given count, waking up goroutines waiting in AwaitAdvance for it.
+// This call must be guarded using the latch mutex.
+func (latch *CountDownLatch) startRound(count uint) {
+	latch.epoch++
+	latch.cause = nil
+	latch.remainingCount = count
+	latch.initialCount = count
+	latch.completed = time.Time{}
+	latch.countDownCompleteCh = make(chan struct{})
+	if latch.remainingCount == 0 {
+		latch.finish()
+	}
+	if latch.resetCh != nil {
+		close(latch.resetCh)
+		latch.resetCh = nil
+	}
+}
+
+// AwaitAdvance waits until the round of count down with the given epoch is complete.
+// It returns immediately if that round has already completed. If the round has not started yet,
+// AwaitAdvance waits for it to be started with Reset and then to complete.
+func (latch *CountDownLatch) AwaitAdvance(epoch uint64) {
+	for {
+		latch.m.Lock()
+		if latch.resetCh == nil {
+			latch.resetCh = make(chan struct{})
+		}
+		current, completeCh, resetCh := latch.epoch, latch.countDownCompleteCh, latch.resetCh
+		latch.m.Unlock()
+		switch {
+		case epoch < current:
+			return
+		case epoch == current:
+			latch.block(completeCh, nil)
+			return
+		default:
+			latch.block(resetCh, nil)
+		}
+	}
+}
+
+// block waits until ch is closed or timeoutCh fires, counting the calling goroutine as a waiter on the latch.
+// It returns true if ch was closed.
+// With leak detection enabled, the latch itself is not referenced while blocked, so that an abandoned latch can be garbage collected.
+func (latch *CountDownLatch) block(ch <-chan struct{}, timeoutCh <-chan time.Time) bool {
+	if leaks := latch.leaks; leaks != nil {
+		id := leaks.add()
+		defer leaks.remove(id)
+		return await(ch, timeoutCh)
+	}
+	atomic.AddInt32(&latch.waiters, 1)
+	defer atomic.AddInt32(&latch.waiters, -1)
+	return await(ch, timeoutCh)
+}
+
+// await waits until ch is closed or timeoutCh fires, and returns true if ch was closed.
+// A nil timeoutCh never fires.
+func await(ch <-chan struct{}, timeoutCh <-chan time.Time) bool {
+	select {
+	case <-ch:
 		return true
-	case <-time.After(timeout):
+	case <-timeoutCh:
 		return false
 	}
 }
 
-// This call must be guarded using the latch mutex.
+// completeCh returns the channel closed when the current round of count down completes.
+func (latch *CountDownLatch) completeCh() chan struct{} {
+	latch.m.Lock()
+	defer latch.m.Unlock()
+	return latch.countDownCompleteCh
+}
+
+// getClock returns the latch's Clock, falling back to RealClock for latches not created with NewCountDownLatch.
+func (latch *CountDownLatch) getClock() Clock {
+	if latch.clock == nil {
+		return RealClock()
+	}
+	return latch.clock
+}
+
+// countDownLatchState is the serialized form of a CountDownLatch.
+type countDownLatchState struct {
+	Count uint `json:"count"`
+	Epoch uint64 `json:"epoch,omitempty"`
+}
+
+// MarshalJSON encodes the remaining count of the latch, so that a latch tracking the progress of long running work
+// can be checkpointed and later restored with UnmarshalJSON.
+func (latch *CountDownLatch) MarshalJSON() ([]byte, error) {
+	latch.m.Lock()
+	state := countDownLatchState{Count: latch.remainingCount, Epoch: latch.epoch}
+	latch.m.Unlock()
+	return json.Marshal(state)
+}
+
+// UnmarshalJSON restores a latch from the output of MarshalJSON.
+// The restored latch has the remaining count and epoch of the checkpointed latch, and is complete if that count is 0.
+// UnmarshalJSON replaces the state of the latch, so it must only be used on a new latch, e.g. new(CountDownLatch),
+// before the latch is shared with other goroutines.
+func (latch *CountDownLatch) UnmarshalJSON(data []byte) error {
+	var state countDownLatchState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	latch.m.Lock()
+	defer latch.m.Unlock()
+	latch.remainingCount = state.Count
+	latch.initialCount = state.Count
+	latch.epoch = state.Epoch
+	latch.created = latch.getClock().Now()
+	latch.completed = time.Time{}
+	latch.countDownCompleteCh = make(chan struct{})
+	if latch.remainingCount == 0 {
+		latch.finish()
+	}
+	return nil
+}
+
+// This call must be guarded using the latch mutex, released with unlock to count down the chained parents.
 func (latch *CountDownLatch) doCountDown(weight uint) error {
+	if latch.cause != nil {
+		return ErrCountDownLatchCanceled
+	}
 	select {
 	case <-latch.countDownCompleteCh:
 		return ErrCountDownLatchCompleted
 	default:
+		if latch.strictWeights && weight > latch.remainingCount {
+			return ErrCountDownOverflow
+		}
 		if latch.remainingCount > weight {
 			latch.remainingCount -= weight
+			latch.releaseQuorums()
 		} else {
 			latch.remainingCount = 0
-			close(latch.countDownCompleteCh)
+			latch.finish()
+			latch.completeChains()
+		}
+		if latch.hooks.OnCountDown != nil {
+			latch.hooks.OnCountDown(weight, latch.remainingCount)
+		}
+		if latch.remainingCount == 0 && latch.hooks.OnComplete != nil {
+			latch.hooks.OnComplete()
 		}
 		return nil
 	}
 }
+
+// finish releases the goroutines waiting on the current round of count down and records its completion time.
+// This call must be guarded using the latch mutex.
+func (latch *CountDownLatch) finish() {
+	latch.completed = latch.getClock().Now()
+	close(latch.countDownCompleteCh)
+	latch.releaseAllQuorums()
+}
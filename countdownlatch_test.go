@@ -1,9 +1,13 @@
 package congo
 
 import (
+	"encoding/json"
+	"errors"
 	"testing"
 	"time"
 	"fmt"
+
+	"github.com/nvn1729/congo/internal/clocktest"
 )
 
 func ExampleCountDownLatch() {
@@ -50,13 +54,14 @@ func TestCountDownLatch_zero(t *testing.T) {
 }
 
 func TestCountDownLatch_one(t *testing.T) {
-	latch := NewCountDownLatch(1)
+	clock := clocktest.New(time.Unix(0, 0))
+	latch := NewCountDownLatch(1, WithClock(clock))
 
 	// check count of 1
 	assertEqual(t, uint(1), latch.Count())
 
-	// test should pause a half second, return v as false, no err
-	assertEqual(t, false, latch.WaitTimeout(500*time.Millisecond))
+	// WaitTimeout should time out, return v as false, no err
+	assertEqual(t, false, waitTimeout(clock, latch, 500*time.Millisecond))
 	
 	// count down
 	assertNil(t, latch.CountDown())
@@ -77,17 +82,20 @@ func TestCountDownLatch_one(t *testing.T) {
 }
 
 func TestCountDownLatch_oneasync(t *testing.T) {
-	latch := NewCountDownLatch(1)
+	clock := clocktest.New(time.Unix(0, 0))
+	latch := NewCountDownLatch(1, WithClock(clock))
 
+	release := make(chan struct{})
 	go func() {
-		time.Sleep(time.Second)
+		<-release
 		latch.CountDown()
 	} ()
 
-	// WaitTimeout should return false because count down will take place after a second
-	assertEqual(t, false, latch.WaitTimeout(100*time.Millisecond))
+	// WaitTimeout should return false because count down will take place after the timeout
+	assertEqual(t, false, waitTimeout(clock, latch, 100*time.Millisecond))
 
-	// Wait will return after ~900 msec
+	// Wait will return once counted down
+	close(release)
 	latch.Wait()
 
 	// Check count is 0
@@ -108,13 +116,14 @@ func TestCountDownLatch_complete(t *testing.T) {
 	assertEqual(t, uint(0), latch2.Count())
 	assertNotNil(t, latch2.Complete())
 
-	latch4 := NewCountDownLatch(1)
+	clock := clocktest.New(time.Unix(0, 0))
+	latch4 := NewCountDownLatch(1, WithClock(clock))
 	go func() {
 		latch3.Wait()
 		latch4.CountDown()
 	}()
 	assertNil(t, latch3.WeightedCountDown(3e5))
-	assertEqual(t, false, latch4.WaitTimeout(500*time.Millisecond)) // test will pause half a second
+	assertEqual(t, false, waitTimeout(clock, latch4, 500*time.Millisecond))
 	assertEqual(t, uint(7e5), latch3.Count())
 	assertEqual(t, uint(1), latch4.Count())
 	assertNil(t, latch3.Complete())
@@ -125,8 +134,9 @@ func TestCountDownLatch_complete(t *testing.T) {
 
 func TestCountDownLatch_manyasync(t *testing.T) {
 	count := 1e6
+	clock := clocktest.New(time.Unix(0, 0))
 	latch1 := NewCountDownLatch(uint(count*(count+1)/2)) //sum of numbers 1 to count
-	latch2 := NewCountDownLatch(uint(2*count + 1))
+	latch2 := NewCountDownLatch(uint(2*count + 1), WithClock(clock))
 	latch3 := NewCountDownLatch(uint(3*count))
 	for i := 1; i <= int(count); i++ {
 		go func (weight uint) {
@@ -136,9 +146,7 @@ func TestCountDownLatch_manyasync(t *testing.T) {
 		} (uint(i))
 	}
 	
-	for ; !latch1.WaitTimeout(50*time.Millisecond) ; {
-	//	fmt.Println("latch 1 remaining:", latch1.Count())
-	}
+	latch1.Wait()
 	
 	assertEqual(t, uint(0), latch1.Count())
 	assertEqual(t, uint(2*count + 1), latch2.Count())
@@ -152,11 +160,10 @@ func TestCountDownLatch_manyasync(t *testing.T) {
 	}
 
 	for ; latch2.Count() > 1 ; {
-		latch2.WaitTimeout(50*time.Millisecond)
-	//	fmt.Println("latch 2 remaining:", latch2.Count())
+		time.Sleep(time.Millisecond)
 	}
 
-	assertEqual(t, false, latch2.WaitTimeout(time.Second))
+	assertEqual(t, false, waitTimeout(clock, latch2, time.Second))
 	assertEqual(t, uint(1), latch2.Count())
 	assertEqual(t, uint(3*count), latch3.Count())
 
@@ -164,13 +171,218 @@ func TestCountDownLatch_manyasync(t *testing.T) {
 	assertEqual(t, true, latch2.WaitTimeout(time.Second))
 	assertEqual(t, uint(0), latch2.Count())
 
-	for ; !latch3.WaitTimeout(50*time.Millisecond) ; {
-	//	fmt.Println("latch 3 remaining:", latch3.Count())
-	}
+	latch3.Wait()
 
 	assertEqual(t, uint(0), latch3.Count())
 }
 
+func TestCountDownLatch_json(t *testing.T) {
+	latch := NewCountDownLatch(10)
+	assertNil(t, latch.WeightedCountDown(4))
+
+	data, err := json.Marshal(latch)
+	assertNil(t, err)
+	assertEqual(t, `{"count":6}`, string(data))
+
+	// restore into a new latch and continue counting down
+	clock := clocktest.New(time.Unix(0, 0))
+	restored := NewCountDownLatch(0, WithClock(clock))
+	assertNil(t, json.Unmarshal(data, restored))
+	assertEqual(t, uint(6), restored.Count())
+	assertEqual(t, false, waitTimeout(clock, restored, 100*time.Millisecond))
+	assertNil(t, restored.WeightedCountDown(6))
+	assertEqual(t, true, restored.WaitTimeout(time.Second))
+
+	// a checkpoint of a completed latch restores a completed latch
+	data, err = json.Marshal(restored)
+	assertNil(t, err)
+	completed := new(CountDownLatch)
+	assertNil(t, json.Unmarshal(data, completed))
+	assertEqual(t, uint(0), completed.Count())
+	assertNotNil(t, completed.CountDown())
+	completed.Wait()
+
+	assertNotNil(t, json.Unmarshal([]byte(`{"count":-1}`), new(CountDownLatch)))
+}
+
+func TestCountDownLatch_go(t *testing.T) {
+	latch := NewCountDownLatch(3)
+	results := make(chan int, 3)
+	for i := 0; i < 3; i++ {
+		n := i
+		latch.Go(func() {
+			results <- n
+		})
+	}
+	assertEqual(t, true, latch.WaitTimeout(5*time.Second))
+	assertEqual(t, 3, len(results))
+}
+
+func TestCountDownLatch_goRecover(t *testing.T) {
+	latch := NewCountDownLatch(2)
+	panics := make(chan interface{}, 2)
+	latch.GoRecover(func() {
+		panic("worker failed")
+	}, func(recovered interface{}) {
+		panics <- recovered
+	})
+	latch.GoRecover(func() {
+		panic("no handler")
+	}, nil)
+
+	// both panicking goroutines count down the latch
+	assertEqual(t, true, latch.WaitTimeout(5*time.Second))
+	assertEqual(t, 1, len(panics))
+	assertEqual(t, "worker failed", <-panics)
+}
+
+func TestCountDownLatch_epochs(t *testing.T) {
+	clock := clocktest.New(time.Unix(0, 0))
+	latch := NewCountDownLatch(2, WithClock(clock))
+	assertEqual(t, uint64(0), latch.Epoch())
+
+	// a round in progress cannot be reset
+	epoch, err := latch.Reset(1)
+	assertEqual(t, ErrCountDownLatchNotCompleted, err)
+	assertEqual(t, uint64(0), epoch)
+
+	round0 := NewCountDownLatch(1)
+	round1 := NewCountDownLatch(1, WithClock(clock))
+	go func() {
+		latch.AwaitAdvance(0)
+		round0.CountDown()
+		latch.AwaitAdvance(1)
+		round1.CountDown()
+	}()
+
+	assertNil(t, latch.WeightedCountDown(2))
+	assertEqual(t, true, round0.WaitTimeout(time.Second))
+
+	epoch, err = latch.Reset(1)
+	assertNil(t, err)
+	assertEqual(t, uint64(1), epoch)
+	assertEqual(t, uint64(1), latch.Epoch())
+	assertEqual(t, uint(1), latch.Count())
+	assertEqual(t, false, waitTimeout(clock, latch, 100*time.Millisecond))
+	assertEqual(t, false, waitTimeout(clock, round1, 100*time.Millisecond))
+
+	assertNil(t, latch.CountDown())
+	assertEqual(t, true, round1.WaitTimeout(time.Second))
+	latch.Wait()
+
+	// waiting on a completed round returns immediately
+	latch.AwaitAdvance(0)
+	latch.AwaitAdvance(1)
+
+	// waiting on a future round blocks until that round starts and completes
+	round3 := NewCountDownLatch(1, WithClock(clock))
+	go func() {
+		latch.AwaitAdvance(3)
+		round3.CountDown()
+	}()
+	_, err = latch.Reset(0) // round 2 completes immediately
+	assertNil(t, err)
+	_, err = latch.Reset(1)
+	assertNil(t, err)
+	assertEqual(t, false, waitTimeout(clock, round3, 100*time.Millisecond))
+	assertNil(t, latch.CountDown())
+	assertEqual(t, true, round3.WaitTimeout(time.Second))
+
+	// the epoch is checkpointed along with the count
+	data, err := json.Marshal(latch)
+	assertNil(t, err)
+	assertEqual(t, `{"count":0,"epoch":3}`, string(data))
+	restored := new(CountDownLatch)
+	assertNil(t, json.Unmarshal(data, restored))
+	assertEqual(t, uint64(3), restored.Epoch())
+	restored.AwaitAdvance(3)
+}
+
+func TestCountDownLatch_cancel(t *testing.T) {
+	latch := NewCountDownLatch(3)
+	assertNil(t, latch.CountDown())
+	assertNil(t, latch.Err())
+
+	cause := errors.New("worker failed")
+	released := make(chan error)
+	go func() {
+		released <- latch.WaitErr()
+	}()
+
+	assertNil(t, latch.Cancel(cause))
+	assertEqual(t, cause, <-released)
+	assertEqual(t, cause, latch.Err())
+	assertEqual(t, true, latch.WaitTimeout(time.Second))
+
+	// the remaining count is preserved, further count downs fail
+	assertEqual(t, uint(2), latch.Count())
+	assertEqual(t, ErrCountDownLatchCanceled, latch.CountDown())
+	assertEqual(t, ErrCountDownLatchCanceled, latch.Complete())
+	assertEqual(t, ErrCountDownLatchCompleted, latch.Cancel(nil))
+
+	// a canceled latch can be reset
+	_, err := latch.Reset(1)
+	assertNil(t, err)
+	assertNil(t, latch.Err())
+	assertNil(t, latch.CountDown())
+	assertNil(t, latch.WaitErr())
+
+	// a completed latch cannot be canceled
+	assertEqual(t, ErrCountDownLatchCompleted, latch.Cancel(cause))
+	assertNil(t, latch.Err())
+
+	// nil cause defaults to ErrCountDownLatchCanceled
+	latch = NewCountDownLatch(1)
+	assertNil(t, latch.Cancel(nil))
+	assertEqual(t, ErrCountDownLatchCanceled, latch.WaitErr())
+}
+
+func TestCountDownLatch_try(t *testing.T) {
+	latch := NewCountDownLatch(3)
+	assertEqual(t, true, latch.TryCountDown())
+	assertEqual(t, true, latch.TryWeightedCountDown(2))
+	assertEqual(t, uint(0), latch.Count())
+	assertEqual(t, false, latch.TryCountDown())
+	assertEqual(t, false, latch.TryWeightedCountDown(0))
+	latch.Wait()
+
+	strict := NewCountDownLatch(1, WithStrictWeights())
+	assertEqual(t, false, strict.TryWeightedCountDown(2))
+	assertEqual(t, uint(1), strict.Count())
+}
+
+func TestCountDownLatch_waiters(t *testing.T) {
+	latch := NewCountDownLatch(1)
+	assertEqual(t, 0, latch.Waiters())
+
+	done := NewCountDownLatch(3)
+	go func() {
+		latch.Wait()
+		done.CountDown()
+	}()
+	go func() {
+		latch.WaitTimeout(time.Hour)
+		done.CountDown()
+	}()
+	go func() {
+		latch.AwaitAdvance(0)
+		done.CountDown()
+	}()
+	for latch.Waiters() < 3 {
+		time.Sleep(time.Millisecond)
+	}
+
+	assertNil(t, latch.CountDown())
+	assertEqual(t, true, done.WaitTimeout(time.Second))
+	for latch.Waiters() > 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// waits on a completed latch never block
+	latch.Wait()
+	assertEqual(t, 0, latch.Waiters())
+}
+
 // assertion helpers
 
 func assertEqual(t *testing.T, expected interface{}, actual interface{}) {
@@ -0,0 +1,60 @@
+package congo
+
+import "time"
+
+// A CountUpLatch is the complement of a CountDownLatch: goroutines count up toward a target, and waiters are released once the target is reached.
+//
+// It suits producer-style workloads where the total number of events is known in advance but events accumulate over time,
+// and Count reports the progress made so far.
+type CountUpLatch struct {
+	target uint
+	latch  *CountDownLatch
+}
+
+// NewCountUpLatch creates a CountUpLatch with the provided target.
+// If the target is 0, the latch will be set to immediately signal completion for any goroutines that subsequently call Wait or WaitTimeout.
+func NewCountUpLatch(target uint) *CountUpLatch {
+	return &CountUpLatch{
+		target: target,
+		latch:  NewCountDownLatch(target),
+	}
+}
+
+// Target returns the count at which the latch completes.
+func (latch *CountUpLatch) Target() uint {
+	return latch.target
+}
+
+// Count returns the current count, which is between 0 and the target.
+func (latch *CountUpLatch) Count() uint {
+	return latch.target - latch.latch.Count()
+}
+
+// Increment is equivalent to Add with a value of 1.
+func (latch *CountUpLatch) Increment() error {
+	return latch.Add(1)
+}
+
+// Add increases the count on the CountUpLatch by n.
+// If the count would exceed the target, the count is set to the target.
+// If the count reaches the target, Add signals completion, waking up any goroutines waiting with Wait or WaitTimeout.
+// An error, ErrCountUpLatchCompleted, is returned if the target has already been reached.
+func (latch *CountUpLatch) Add(n uint) error {
+	if err := latch.latch.WeightedCountDown(n); err != nil {
+		return ErrCountUpLatchCompleted
+	}
+	return nil
+}
+
+// Wait waits indefinitely until the target is reached.
+// Wait returns immediately if the target has already been reached.
+func (latch *CountUpLatch) Wait() {
+	latch.latch.Wait()
+}
+
+// WaitTimeout waits until a given timeout for the target to be reached.
+// If the target is reached before the timeout, WaitTimeout returns true.
+// Otherwise it returns false.
+func (latch *CountUpLatch) WaitTimeout(timeout time.Duration) bool {
+	return latch.latch.WaitTimeout(timeout)
+}
@@ -0,0 +1,51 @@
+package congo
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func ExampleCountUpLatch() {
+	latch := NewCountUpLatch(100)
+	for i := 0; i < 10; i++ {
+		go func() {
+			for j := 0; j < 10; j++ {
+				latch.Increment() // record a produced item
+			}
+		}()
+	}
+
+	latch.Wait()
+	fmt.Println("Produced", latch.Count(), "of", latch.Target())
+	// Output:
+	// Produced 100 of 100
+}
+
+func TestCountUpLatch_zero(t *testing.T) {
+	latch := NewCountUpLatch(0)
+
+	assertEqual(t, uint(0), latch.Count())
+	assertEqual(t, uint(0), latch.Target())
+	assertEqual(t, ErrCountUpLatchCompleted, latch.Increment())
+
+	latch.Wait()
+	assertEqual(t, true, latch.WaitTimeout(time.Second))
+}
+
+func TestCountUpLatch_add(t *testing.T) {
+	latch := NewCountUpLatch(10)
+
+	assertNil(t, latch.Increment())
+	assertNil(t, latch.Add(4))
+	assertEqual(t, uint(5), latch.Count())
+	assertEqual(t, false, latch.WaitTimeout(100*time.Millisecond))
+
+	// count is capped at the target
+	assertNil(t, latch.Add(20))
+	assertEqual(t, uint(10), latch.Count())
+	assertEqual(t, true, latch.WaitTimeout(time.Second))
+
+	assertEqual(t, ErrCountUpLatchCompleted, latch.Add(1))
+	assertEqual(t, uint(10), latch.Count())
+}
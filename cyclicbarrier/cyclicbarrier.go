@@ -0,0 +1,338 @@
+// Package cyclicbarrier provides a CyclicBarrier, a reusable synchronization point for a fixed number of goroutines.
+package cyclicbarrier
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// A CyclicBarrier allows a fixed number of goroutines, the parties, to wait for each other to reach a common barrier point.
+//
+// Each party invokes Await when it reaches the barrier point. Await blocks until all parties have arrived,
+// at which point the barrier trips, releasing all of them. The barrier then resets for the next generation,
+// so it can be reused by iterative algorithms and staged pipelines, unlike the one-shot congo.CountDownLatch.
+// The number of parties may be changed between generations with SetParties.
+//
+// If a party gives up waiting, because of a timeout or a canceled context, the barrier is broken:
+// all other parties waiting on it are released with ErrBrokenBarrier, and so is any party that subsequently calls Await,
+// until the barrier is Reset. This mirrors java.util.concurrent.CyclicBarrier, and ensures that no party waits forever
+// for a party that is never going to arrive.
+type CyclicBarrier struct {
+	m          sync.Mutex
+	parties    int
+	newParties int // parties for the next generation, or 0 if unchanged
+	arrived    int
+	generation uint64 // set atomically, so that spinning parties may poll it
+	cycle      *cycle
+	action     func()
+
+	senseReversal bool
+}
+
+// A cycle is the state of one generation of the barrier.
+// With sense reversal, generations that trip share the same cycle, and it is only replaced when the barrier is Reset.
+type cycle struct {
+	tripCh   chan struct{} // closed when the generation is broken, or trips without sense reversal
+	broken   int32         // set atomically, so that spinning parties may poll it
+	brokenAt uint64        // the generation that broke the cycle, set before broken
+}
+
+func (cycle *cycle) isBroken() bool {
+	return atomic.LoadInt32(&cycle.broken) != 0
+}
+
+// brokeAt reports whether the cycle was broken at the given generation, rather than at a later one sharing the cycle.
+func (cycle *cycle) brokeAt(generation uint64) bool {
+	return cycle.isBroken() && cycle.brokenAt == generation
+}
+
+// An Option configures a CyclicBarrier at creation time.
+type Option func(*CyclicBarrier)
+
+// WithAction sets an action to be run each time the barrier trips, e.g. to merge partial results of the parties between phases.
+// The action is run exactly once per generation by the last party to arrive, before any party is released,
+// so its effects are visible to all parties when Await returns.
+//
+// The action is run while the barrier's internal lock is held, so it must not call methods on the barrier.
+// If the action panics, the barrier is broken and the panic is propagated to the last party.
+func WithAction(action func()) Option {
+	return func(barrier *CyclicBarrier) {
+		barrier.action = action
+	}
+}
+
+// WithSenseReversal makes the parties spin, yielding the processor, while waiting for the barrier to trip,
+// rather than block on a channel that is closed and recreated each generation.
+// The last party to arrive publishes the next generation, which the waiting parties poll, so a generation trips without any allocation.
+// Unlike a single sense bit, the generation cannot be missed by a party descheduled while the barrier trips twice,
+// as when parties outnumber a barrier shrunk with SetParties.
+//
+// Sense reversal lowers the latency of each generation in tight loops, such as simulations stepping many times per second,
+// at the cost of burning CPU while waiting. It suits barriers whose parties do not outnumber the available processors,
+// and that do not wait long for each other.
+func WithSenseReversal() Option {
+	return func(barrier *CyclicBarrier) {
+		barrier.senseReversal = true
+	}
+}
+
+// New creates a CyclicBarrier that trips when the given number of parties are waiting on it.
+// The barrier may be further configured by passing options such as WithAction.
+// New panics if parties is less than 1.
+func New(parties int, opts ...Option) *CyclicBarrier {
+	if parties < 1 {
+		panic("cyclicbarrier: parties must be at least 1")
+	}
+	barrier := &CyclicBarrier{
+		parties: parties,
+		cycle:   newCycle(),
+	}
+	for _, opt := range opts {
+		opt(barrier)
+	}
+	return barrier
+}
+
+func newCycle() *cycle {
+	return &cycle{tripCh: make(chan struct{})}
+}
+
+// Await waits until all parties have invoked Await on the barrier.
+//
+// Await returns the arrival index of the calling party: parties-1 for the first party to arrive and 0 for the last,
+// which may be used to elect a party to perform extra work for the generation.
+// The last party to arrive trips the barrier and returns without blocking.
+//
+// If the barrier is or becomes broken, Await returns a *GenerationError wrapping ErrBrokenBarrier.
+func (barrier *CyclicBarrier) Await() (int, error) {
+	return barrier.await(context.Background(), nil)
+}
+
+// AwaitTimeout is like Await, but gives up waiting once the timeout elapses, which breaks the barrier.
+// The returned error is then a *GenerationError identifying the generation that failed, wrapping ErrTimeout.
+func (barrier *CyclicBarrier) AwaitTimeout(timeout time.Duration) (int, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	return barrier.await(context.Background(), timer.C)
+}
+
+// AwaitContext is like Await, but gives up waiting when the context is done, which breaks the barrier.
+// The returned error is then a *GenerationError identifying the generation that failed, wrapping the context's error.
+// If the context is already done, AwaitContext breaks the barrier without waiting.
+func (barrier *CyclicBarrier) AwaitContext(ctx context.Context) (int, error) {
+	return barrier.await(ctx, nil)
+}
+
+// Parties returns the number of parties required to trip the current generation of the barrier.
+func (barrier *CyclicBarrier) Parties() int {
+	barrier.m.Lock()
+	defer barrier.m.Unlock()
+	return barrier.parties
+}
+
+// SetParties changes the number of parties required to trip the barrier, so that elastic worker pools can keep using it.
+// The change takes effect at the next generation, once the current one trips or the barrier is Reset,
+// or immediately if no party is waiting on the barrier.
+//
+// A party leaving the pool should thus still arrive at the current generation, after calling SetParties,
+// and a party joining it should start with the next one. SetParties panics if parties is less than 1.
+func (barrier *CyclicBarrier) SetParties(parties int) {
+	if parties < 1 {
+		panic("cyclicbarrier: parties must be at least 1")
+	}
+	barrier.m.Lock()
+	defer barrier.m.Unlock()
+	if barrier.arrived == 0 && !barrier.cycle.isBroken() {
+		barrier.parties, barrier.newParties = parties, 0
+		return
+	}
+	barrier.newParties = parties
+}
+
+// NumberWaiting returns the number of parties currently waiting on the barrier.
+// Once the barrier is broken, NumberWaiting returns 0 until the barrier is Reset.
+func (barrier *CyclicBarrier) NumberWaiting() int {
+	barrier.m.Lock()
+	defer barrier.m.Unlock()
+	if barrier.cycle.isBroken() {
+		return 0
+	}
+	return barrier.arrived
+}
+
+// Generation returns the current generation of the barrier. It starts at 0,
+// and is incremented each time the barrier trips or is Reset.
+func (barrier *CyclicBarrier) Generation() uint64 {
+	barrier.m.Lock()
+	defer barrier.m.Unlock()
+	return barrier.generation
+}
+
+// IsBroken reports whether the barrier is broken.
+func (barrier *CyclicBarrier) IsBroken() bool {
+	barrier.m.Lock()
+	defer barrier.m.Unlock()
+	return barrier.cycle.isBroken()
+}
+
+// Reset returns the barrier to its initial state and starts a new generation.
+// Parties waiting on the current generation are released with ErrBrokenBarrier.
+//
+// Reset is meant to recover a broken barrier. Resetting a barrier that parties are still using requires
+// them to coordinate in some other way, as they may not all observe the reset.
+func (barrier *CyclicBarrier) Reset() {
+	barrier.m.Lock()
+	defer barrier.m.Unlock()
+	if !barrier.cycle.isBroken() && barrier.arrived > 0 {
+		barrier.breakBarrier()
+	}
+	barrier.nextGeneration()
+}
+
+// await arrives at the barrier and waits for it to trip, the context to be done or timeoutCh to fire.
+// A nil timeoutCh never fires.
+func (barrier *CyclicBarrier) await(ctx context.Context, timeoutCh <-chan time.Time) (int, error) {
+	barrier.m.Lock()
+	cycle, generation := barrier.cycle, barrier.generation
+	if cycle.isBroken() {
+		barrier.m.Unlock()
+		return 0, &GenerationError{Generation: generation, Err: ErrBrokenBarrier}
+	}
+	if err := ctx.Err(); err != nil {
+		barrier.breakBarrier()
+		barrier.m.Unlock()
+		return 0, &GenerationError{Generation: generation, Err: err}
+	}
+	index := barrier.parties - 1 - barrier.arrived
+	barrier.arrived++
+	if barrier.arrived == barrier.parties {
+		defer barrier.m.Unlock()
+		barrier.trip()
+		return index, nil
+	}
+	barrier.m.Unlock()
+
+	err := barrier.wait(ctx, timeoutCh, cycle, generation)
+	if err == nil {
+		return index, nil
+	}
+	if err != ErrBrokenBarrier {
+		barrier.m.Lock()
+		defer barrier.m.Unlock()
+		switch {
+		case barrier.tripped(cycle, generation):
+			// the generation tripped while giving up
+			return index, nil
+		case cycle.isBroken():
+			err = ErrBrokenBarrier
+		default:
+			barrier.breakBarrier()
+		}
+	}
+	return index, &GenerationError{Generation: generation, Err: err}
+}
+
+// wait waits for the generation that a party arrived at, in the given cycle, to trip or be broken.
+// It returns nil if the generation tripped, ErrBrokenBarrier if it was broken,
+// and the reason for giving up if the context is done or timeoutCh fires first.
+func (barrier *CyclicBarrier) wait(ctx context.Context, timeoutCh <-chan time.Time, cycle *cycle, generation uint64) error {
+	if barrier.senseReversal {
+		return barrier.spin(ctx, timeoutCh, cycle, generation)
+	}
+	select {
+	case <-cycle.tripCh:
+		// broken is set before tripCh is closed
+		if cycle.isBroken() {
+			return ErrBrokenBarrier
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timeoutCh:
+		return ErrTimeout
+	}
+}
+
+// spin is like wait, but polls the generation of the barrier until it moves on.
+func (barrier *CyclicBarrier) spin(ctx context.Context, timeoutCh <-chan time.Time, cycle *cycle, generation uint64) error {
+	doneCh := ctx.Done()
+	for {
+		// the generation is checked first, as the cycle is shared with the next generations, which may be broken
+		if atomic.LoadUint64(&barrier.generation) != generation || cycle.isBroken() {
+			if cycle.brokeAt(generation) {
+				return ErrBrokenBarrier
+			}
+			return nil
+		}
+		select {
+		case <-doneCh:
+			return ctx.Err()
+		case <-timeoutCh:
+			return ErrTimeout
+		default:
+		}
+		runtime.Gosched()
+	}
+}
+
+// tripped reports whether the generation that a party arrived at, in the given cycle, has tripped.
+// This call must be guarded using the barrier mutex.
+func (barrier *CyclicBarrier) tripped(cycle *cycle, generation uint64) bool {
+	if barrier.senseReversal {
+		return barrier.generation != generation && !cycle.brokeAt(generation)
+	}
+	return barrier.cycle != cycle && !cycle.isBroken()
+}
+
+// trip runs the barrier action, then releases the parties of the current generation and starts the next one.
+// If the action panics, the barrier is broken instead and the panic propagates.
+// This call must be guarded using the barrier mutex.
+func (barrier *CyclicBarrier) trip() {
+	if barrier.action != nil {
+		completed := false
+		defer func() {
+			if !completed {
+				barrier.breakBarrier()
+			}
+		}()
+		barrier.action()
+		completed = true
+	}
+	if barrier.senseReversal {
+		barrier.arrived = 0
+		barrier.resize()
+		atomic.AddUint64(&barrier.generation, 1)
+		return
+	}
+	cycle := barrier.cycle
+	barrier.nextGeneration()
+	close(cycle.tripCh)
+}
+
+// breakBarrier breaks the current generation, releasing the parties waiting on it.
+// This call must be guarded using the barrier mutex.
+func (barrier *CyclicBarrier) breakBarrier() {
+	barrier.cycle.brokenAt = barrier.generation
+	atomic.StoreInt32(&barrier.cycle.broken, 1)
+	close(barrier.cycle.tripCh)
+}
+
+// nextGeneration starts a new generation of the barrier with a new cycle.
+// This call must be guarded using the barrier mutex.
+func (barrier *CyclicBarrier) nextGeneration() {
+	barrier.cycle = newCycle()
+	barrier.arrived = 0
+	atomic.AddUint64(&barrier.generation, 1)
+	barrier.resize()
+}
+
+// resize applies the number of parties set with SetParties at the start of a generation.
+// This call must be guarded using the barrier mutex.
+func (barrier *CyclicBarrier) resize() {
+	if barrier.newParties != 0 {
+		barrier.parties, barrier.newParties = barrier.newParties, 0
+	}
+}
@@ -0,0 +1,496 @@
+package cyclicbarrier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func ExampleCyclicBarrier() {
+	const workers = 3
+	barrier := New(workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for phase := 0; phase < 2; phase++ {
+				// do this phase's work
+				// ...
+				index, err := barrier.Await()
+				if err != nil {
+					return
+				}
+				if index == 0 {
+					fmt.Println("Phase", phase, "complete")
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	// Output:
+	// Phase 0 complete
+	// Phase 1 complete
+}
+
+func TestCyclicBarrier_one(t *testing.T) {
+	barrier := New(1)
+	// a single party never blocks
+	for i := 0; i < 3; i++ {
+		index, err := barrier.Await()
+		assertNil(t, err)
+		assertEqual(t, 0, index)
+	}
+}
+
+func TestCyclicBarrier_generations(t *testing.T) {
+	testGenerations(t)
+}
+
+func testGenerations(t *testing.T, opts ...Option) {
+	const parties = 4
+	const generations = 100
+	barrier := New(parties, opts...)
+
+	var m sync.Mutex
+	counts := make([]int, generations)
+	indexes := make(chan int, parties*generations)
+	var wg sync.WaitGroup
+	for p := 0; p < parties; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for g := 0; g < generations; g++ {
+				m.Lock()
+				counts[g]++
+				m.Unlock()
+				index, err := barrier.Await()
+				assertNil(t, err)
+				indexes <- index
+				// every party of this generation arrived before any was released
+				m.Lock()
+				assertEqual(t, parties, counts[g])
+				m.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	close(indexes)
+
+	seen := make(map[int]int)
+	for index := range indexes {
+		seen[index]++
+	}
+	for index := 0; index < parties; index++ {
+		assertEqual(t, generations, seen[index])
+	}
+}
+
+func TestCyclicBarrier_blocks(t *testing.T) {
+	barrier := New(2)
+	released := make(chan int)
+	go func() {
+		index, err := barrier.Await()
+		assertNil(t, err)
+		released <- index
+	}()
+
+	select {
+	case <-released:
+		t.Fatal("Await returned before all parties arrived")
+	case <-time.After(100 * time.Millisecond):
+	}
+	index, err := barrier.Await()
+	assertNil(t, err)
+	assertEqual(t, 0, index)
+	assertEqual(t, 1, <-released)
+}
+
+func TestCyclicBarrier_awaitTimeout(t *testing.T) {
+	testAwaitTimeout(t)
+}
+
+func testAwaitTimeout(t *testing.T, opts ...Option) {
+	barrier := New(3, opts...)
+
+	// a party waiting alongside the one that times out learns that the barrier is broken
+	released := make(chan error)
+	go func() {
+		_, err := barrier.Await()
+		released <- err
+	}()
+
+	_, err := barrier.AwaitTimeout(100 * time.Millisecond)
+	assertEqual(t, true, errors.Is(err, ErrTimeout))
+	var generationErr *GenerationError
+	assertEqual(t, true, errors.As(err, &generationErr))
+	assertEqual(t, uint64(0), generationErr.Generation)
+	assertEqual(t, "Barrier generation 0: Barrier wait timed out", err.Error())
+
+	err = <-released
+	assertEqual(t, true, errors.Is(err, ErrBrokenBarrier))
+	assertEqual(t, true, barrier.IsBroken())
+
+	// later parties fail immediately until the barrier is reset
+	_, err = barrier.AwaitTimeout(time.Hour)
+	assertEqual(t, true, errors.Is(err, ErrBrokenBarrier))
+
+	barrier.Reset()
+	assertEqual(t, false, barrier.IsBroken())
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := barrier.AwaitTimeout(time.Second)
+			released <- err
+		}()
+	}
+	_, err = barrier.AwaitTimeout(time.Second)
+	assertNil(t, err)
+	assertNil(t, <-released)
+	assertNil(t, <-released)
+
+	// failures identify the generation they occurred in
+	_, err = barrier.AwaitTimeout(10 * time.Millisecond)
+	assertEqual(t, true, errors.As(err, &generationErr))
+	assertEqual(t, uint64(2), generationErr.Generation)
+}
+
+func TestCyclicBarrier_awaitContext(t *testing.T) {
+	barrier := New(2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	released := make(chan error)
+	go func() {
+		_, err := barrier.AwaitContext(ctx)
+		released <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	assertEqual(t, true, errors.Is(<-released, context.Canceled))
+	assertEqual(t, true, barrier.IsBroken())
+
+	// an already canceled context breaks the barrier without waiting
+	barrier.Reset()
+	_, err := barrier.AwaitContext(ctx)
+	assertEqual(t, true, errors.Is(err, context.Canceled))
+	assertEqual(t, true, barrier.IsBroken())
+
+	barrier.Reset()
+	go func() {
+		_, err := barrier.AwaitContext(context.Background())
+		released <- err
+	}()
+	_, err = barrier.AwaitContext(context.Background())
+	assertNil(t, err)
+	assertNil(t, <-released)
+}
+
+func TestCyclicBarrier_reset(t *testing.T) {
+	testReset(t)
+}
+
+func testReset(t *testing.T, opts ...Option) {
+	barrier := New(2, opts...)
+
+	// resetting releases waiting parties with ErrBrokenBarrier
+	released := make(chan error)
+	go func() {
+		_, err := barrier.Await()
+		released <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	barrier.Reset()
+	assertEqual(t, true, errors.Is(<-released, ErrBrokenBarrier))
+
+	// the new generation is usable
+	assertEqual(t, false, barrier.IsBroken())
+	go func() {
+		_, err := barrier.Await()
+		released <- err
+	}()
+	_, err := barrier.Await()
+	assertNil(t, err)
+	assertNil(t, <-released)
+
+	// resetting an idle barrier leaves it usable
+	barrier.Reset()
+	assertEqual(t, false, barrier.IsBroken())
+}
+
+func TestCyclicBarrier_senseReversal(t *testing.T) {
+	t.Run("generations", func(t *testing.T) {
+		testGenerations(t, WithSenseReversal())
+	})
+	t.Run("awaitTimeout", func(t *testing.T) {
+		testAwaitTimeout(t, WithSenseReversal())
+	})
+	t.Run("reset", func(t *testing.T) {
+		testReset(t, WithSenseReversal())
+	})
+
+	// a generation that trips does not allocate
+	barrier := New(1, WithSenseReversal())
+	allocs := testing.AllocsPerRun(100, func() {
+		barrier.Await()
+	})
+	assertEqual(t, float64(0), allocs)
+}
+
+func TestCyclicBarrier_senseReversalShrink(t *testing.T) {
+	// with more goroutines than parties, the barrier trips again while slow parties of the previous generation are still spinning.
+	// The goroutines share the arrivals, so that each arrival finds the parties of its generation.
+	const goroutines = 8
+	const arrivals = 1600
+	var taken int64
+	barrier := New(4, WithSenseReversal())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var wg sync.WaitGroup
+		for g := 0; g < goroutines; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					arrival := atomic.AddInt64(&taken, 1)
+					if arrival > arrivals {
+						return
+					}
+					if arrival == arrivals/4 {
+						barrier.SetParties(2)
+					}
+					_, err := barrier.Await()
+					assertNil(t, err)
+				}
+			}()
+		}
+		wg.Wait()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Parties missed a generation")
+	}
+	assertEqual(t, 2, barrier.Parties())
+	assertEqual(t, 0, barrier.NumberWaiting())
+}
+
+func TestCyclicBarrier_action(t *testing.T) {
+	const parties = 3
+	const generations = 50
+	trips := 0
+	barrier := New(parties, WithAction(func() {
+		trips++
+	}))
+
+	var wg sync.WaitGroup
+	for p := 0; p < parties; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for g := 0; g < generations; g++ {
+				_, err := barrier.Await()
+				assertNil(t, err)
+				// the action of this generation ran before any party was released,
+				// and the next one cannot run before this party arrives again
+				assertEqual(t, g+1, trips)
+			}
+		}()
+	}
+	wg.Wait()
+	assertEqual(t, generations, trips)
+}
+
+func TestCyclicBarrier_actionPanic(t *testing.T) {
+	barrier := New(2, WithAction(func() {
+		panic("merge failed")
+	}))
+
+	released := make(chan error)
+	go func() {
+		_, err := barrier.Await()
+		released <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	func() {
+		defer func() {
+			assertEqual(t, "merge failed", recover())
+		}()
+		barrier.Await()
+		t.Fatal("Await did not propagate the panic")
+	}()
+	assertEqual(t, true, errors.Is(<-released, ErrBrokenBarrier))
+	assertEqual(t, true, barrier.IsBroken())
+}
+
+func TestCyclicBarrier_introspection(t *testing.T) {
+	barrier := New(3)
+	assertEqual(t, 3, barrier.Parties())
+	assertEqual(t, 0, barrier.NumberWaiting())
+	assertEqual(t, uint64(0), barrier.Generation())
+
+	released := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := barrier.Await()
+			released <- err
+		}()
+	}
+	for barrier.NumberWaiting() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	assertEqual(t, uint64(0), barrier.Generation())
+
+	_, err := barrier.Await()
+	assertNil(t, err)
+	assertNil(t, <-released)
+	assertNil(t, <-released)
+	assertEqual(t, 0, barrier.NumberWaiting())
+	assertEqual(t, uint64(1), barrier.Generation())
+
+	// a broken barrier has no waiting parties
+	go barrier.Await()
+	for barrier.NumberWaiting() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	_, err = barrier.AwaitTimeout(time.Millisecond)
+	assertNotNil(t, err)
+	assertEqual(t, 0, barrier.NumberWaiting())
+	assertEqual(t, uint64(1), barrier.Generation())
+	barrier.Reset()
+	assertEqual(t, uint64(2), barrier.Generation())
+}
+
+func TestCyclicBarrier_setParties(t *testing.T) {
+	// an idle barrier is resized immediately
+	barrier := New(3)
+	barrier.SetParties(2)
+	assertEqual(t, 2, barrier.Parties())
+
+	// a busy barrier is resized when the current generation trips
+	released := make(chan error)
+	go func() {
+		_, err := barrier.Await()
+		released <- err
+	}()
+	for barrier.NumberWaiting() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	barrier.SetParties(3)
+	assertEqual(t, 2, barrier.Parties())
+	_, err := barrier.Await()
+	assertNil(t, err)
+	assertNil(t, <-released)
+	assertEqual(t, 3, barrier.Parties())
+
+	// a party leaving still arrives at the current generation
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := barrier.Await()
+			released <- err
+		}()
+	}
+	for barrier.NumberWaiting() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	barrier.SetParties(1)
+	_, err = barrier.Await()
+	assertNil(t, err)
+	assertNil(t, <-released)
+	assertNil(t, <-released)
+	_, err = barrier.AwaitTimeout(time.Second)
+	assertNil(t, err)
+
+	// a broken barrier is resized when it is reset
+	barrier = New(2, WithSenseReversal())
+	_, err = barrier.AwaitTimeout(time.Millisecond)
+	assertEqual(t, true, errors.Is(err, ErrTimeout))
+	barrier.SetParties(1)
+	assertEqual(t, 2, barrier.Parties())
+	barrier.Reset()
+	assertEqual(t, 1, barrier.Parties())
+	_, err = barrier.Await()
+	assertNil(t, err)
+
+	defer func() {
+		assertNotNil(t, recover())
+	}()
+	barrier.SetParties(0)
+	t.Fatal("SetParties did not panic")
+}
+
+func TestNew_invalid(t *testing.T) {
+	defer func() {
+		assertNotNil(t, recover())
+	}()
+	New(0)
+	t.Fatal("New did not panic")
+}
+
+func BenchmarkCyclicBarrier_4(b *testing.B) {
+	barrier := New(4)
+	benchmarkBarrier(b, 4, func(int) { barrier.Await() })
+}
+
+func BenchmarkCyclicBarrier_senseReversal4(b *testing.B) {
+	barrier := New(4, WithSenseReversal())
+	benchmarkBarrier(b, 4, func(int) { barrier.Await() })
+}
+
+func BenchmarkCyclicBarrier_senseReversal64(b *testing.B) {
+	barrier := New(64, WithSenseReversal())
+	benchmarkBarrier(b, 64, func(int) { barrier.Await() })
+}
+
+func BenchmarkCyclicBarrier_64(b *testing.B) {
+	barrier := New(64)
+	benchmarkBarrier(b, 64, func(int) { barrier.Await() })
+}
+
+func BenchmarkCyclicBarrier_4096(b *testing.B) {
+	barrier := New(4096)
+	benchmarkBarrier(b, 4096, func(int) { barrier.Await() })
+}
+
+// benchmarkBarrier runs b.N generations of a barrier with the given number of parties, each calling await with its id.
+func benchmarkBarrier(b *testing.B, parties int, await func(party int)) {
+	var wg sync.WaitGroup
+	b.ReportAllocs()
+	b.ResetTimer()
+	for party := 0; party < parties; party++ {
+		wg.Add(1)
+		go func(party int) {
+			defer wg.Done()
+			for i := 0; i < b.N; i++ {
+				await(party)
+			}
+		}(party)
+	}
+	wg.Wait()
+}
+
+// assertion helpers
+
+func assertEqual(t *testing.T, expected interface{}, actual interface{}) {
+	if expected != actual {
+		t.Fatal("Not equal:", "expected:", expected, ", actual:", actual)
+	}
+}
+
+func assertNil(t *testing.T, actual interface{}) {
+	if actual != nil {
+		t.Fatal("Value not nil, actual:", actual)
+	}
+}
+
+func assertNotNil(t *testing.T, actual interface{}) {
+	if actual == nil {
+		t.Fatal("Value is nil")
+	}
+}
@@ -0,0 +1,34 @@
+package cyclicbarrier
+
+import (
+	"errors"
+	"fmt"
+)
+
+// These are errors related to CyclicBarrier.
+var (
+	// ErrTimeout is returned by AwaitTimeout when the timeout elapses before all parties arrive
+	ErrTimeout = errors.New("Barrier wait timed out")
+
+	// ErrBrokenBarrier is returned when waiting on a barrier that is broken, or becomes broken while waiting, because a party gave up waiting or the barrier was reset
+	ErrBrokenBarrier = errors.New("Barrier is broken")
+)
+
+// A GenerationError reports the generation of the barrier in which a party failed to wait for the others.
+type GenerationError struct {
+	// Generation is the generation of the barrier that the party was waiting on.
+	Generation uint64
+
+	// Err is the reason the wait failed: ErrTimeout or the error of a canceled context for the party that gave up,
+	// and ErrBrokenBarrier for the other parties.
+	Err error
+}
+
+func (e *GenerationError) Error() string {
+	return fmt.Sprintf("Barrier generation %d: %v", e.Generation, e.Err)
+}
+
+// Unwrap returns the reason the wait failed, so that errors.Is(err, ErrTimeout) and errors.Is(err, context.Canceled) work.
+func (e *GenerationError) Unwrap() error {
+	return e.Err
+}
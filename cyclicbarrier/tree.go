@@ -0,0 +1,105 @@
+package cyclicbarrier
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// A TreeBarrier is a combining tree barrier, for a fixed number of parties too large to funnel through the single lock of a CyclicBarrier.
+//
+// The parties are spread over the leaves of a tree, each node of which waits for at most fanIn children.
+// The last party to arrive at a node climbs to its parent, so arrivals only contend on the node they share with fanIn-1 others,
+// and the party completing the root releases the parties back down the tree.
+//
+// Every party has an id in [0, parties), which it passes to Await. Parties with neighbouring ids share a leaf,
+// so they should preferably run on the same core. A TreeBarrier is reusable like a CyclicBarrier,
+// but it has no timeouts: a party that never arrives blocks the other parties forever.
+type TreeBarrier struct {
+	parties int
+	fanIn   int
+	leaves  []*treeNode
+}
+
+// A treeNode is a node of a TreeBarrier, waiting for its children to arrive.
+type treeNode struct {
+	arrived   int32
+	children  int32
+	parent    *treeNode
+	releaseCh chan struct{} // closed when the current generation of the node is released
+}
+
+// NewTree creates a TreeBarrier for the given number of parties, whose nodes wait for at most fanIn children each.
+// NewTree panics if parties is less than 1 or fanIn is less than 2.
+func NewTree(parties, fanIn int) *TreeBarrier {
+	if parties < 1 {
+		panic("cyclicbarrier: parties must be at least 1")
+	}
+	if fanIn < 2 {
+		panic("cyclicbarrier: fanIn must be at least 2")
+	}
+
+	leaves := newTreeLevel((parties + fanIn - 1) / fanIn)
+	for party := 0; party < parties; party++ {
+		leaves[party/fanIn].children++
+	}
+	for level := leaves; len(level) > 1; {
+		parents := newTreeLevel((len(level) + fanIn - 1) / fanIn)
+		for i, node := range level {
+			node.parent = parents[i/fanIn]
+			node.parent.children++
+		}
+		level = parents
+	}
+	return &TreeBarrier{
+		parties: parties,
+		fanIn:   fanIn,
+		leaves:  leaves,
+	}
+}
+
+func newTreeLevel(n int) []*treeNode {
+	level := make([]*treeNode, n)
+	for i := range level {
+		level[i] = &treeNode{releaseCh: make(chan struct{})}
+	}
+	return level
+}
+
+// Parties returns the number of parties required to trip the barrier.
+func (barrier *TreeBarrier) Parties() int {
+	return barrier.parties
+}
+
+// Await waits until all parties have invoked Await on the barrier.
+// The party argument is the id of the calling party, and each party must call Await exactly once per generation.
+//
+// Await returns true in exactly one party per generation, the last to arrive, which may be used to elect a party
+// to perform extra work for the generation. Await panics if party is not in [0, parties).
+func (barrier *TreeBarrier) Await(party int) bool {
+	if party < 0 || party >= barrier.parties {
+		panic(fmt.Sprintf("cyclicbarrier: party %d out of range [0, %d)", party, barrier.parties))
+	}
+	return barrier.leaves[party/barrier.fanIn].arrive()
+}
+
+// arrive arrives at the node, and returns once the generation of the node is released.
+// The last child to arrive climbs to the parent node, then releases the node for the others.
+// It returns true for the party that completes the root.
+func (node *treeNode) arrive() bool {
+	// releaseCh is replaced only after all children have arrived, so it must be read before arriving
+	releaseCh := node.releaseCh
+	if atomic.AddInt32(&node.arrived, 1) < node.children {
+		<-releaseCh
+		return false
+	}
+
+	last := true
+	if node.parent != nil {
+		last = node.parent.arrive()
+	}
+	// reset the node for the next generation before releasing any party that might arrive at it again
+	node.releaseCh = make(chan struct{})
+	atomic.StoreInt32(&node.arrived, 0)
+	close(releaseCh)
+	return last
+}
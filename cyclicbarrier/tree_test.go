@@ -0,0 +1,65 @@
+package cyclicbarrier
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestTreeBarrier_generations(t *testing.T) {
+	for _, tc := range []struct{ parties, fanIn int }{{1, 2}, {2, 2}, {5, 2}, {16, 4}, {100, 3}} {
+		barrier := NewTree(tc.parties, tc.fanIn)
+		assertEqual(t, tc.parties, barrier.Parties())
+
+		const generations = 20
+		var arrived, lasts int32
+		var wg sync.WaitGroup
+		for party := 0; party < tc.parties; party++ {
+			wg.Add(1)
+			go func(party int) {
+				defer wg.Done()
+				for g := 1; g <= generations; g++ {
+					atomic.AddInt32(&arrived, 1)
+					if barrier.Await(party) {
+						atomic.AddInt32(&lasts, 1)
+					}
+					// no party is released before all parties arrived, and none can arrive again before this one does
+					n := atomic.LoadInt32(&arrived)
+					if n < int32(g*tc.parties) || n > int32((g+1)*tc.parties-1) {
+						t.Errorf("%d parties arrived after generation %d of %d parties", n, g, tc.parties)
+					}
+				}
+			}(party)
+		}
+		wg.Wait()
+		assertEqual(t, int32(generations), lasts)
+	}
+}
+
+func TestNewTree_invalid(t *testing.T) {
+	for _, args := range [][2]int{{0, 2}, {2, 1}} {
+		func() {
+			defer func() {
+				assertNotNil(t, recover())
+			}()
+			NewTree(args[0], args[1])
+			t.Fatal("NewTree did not panic")
+		}()
+	}
+
+	defer func() {
+		assertNotNil(t, recover())
+	}()
+	NewTree(2, 2).Await(2)
+	t.Fatal("Await did not panic")
+}
+
+func BenchmarkTreeBarrier_64(b *testing.B) {
+	barrier := NewTree(64, 4)
+	benchmarkBarrier(b, 64, func(party int) { barrier.Await(party) })
+}
+
+func BenchmarkTreeBarrier_4096(b *testing.B) {
+	barrier := NewTree(4096, 8)
+	benchmarkBarrier(b, 4096, func(party int) { barrier.Await(party) })
+}
@@ -0,0 +1,373 @@
+// Package deadlock detects deadlocks among the locks of the lock package and the semaphores of the semaphore package at runtime.
+// Striped and StripedRW locks, which are made of sync package mutexes, are not covered.
+//
+// Detection is opt-in, with Enable: the primitives then record which goroutines hold them, and which goroutines wait for them,
+// forming a waits-for graph. A watchdog periodically looks for goroutines that can never be released, because every goroutine
+// that could release what they wait for is itself waiting, such as two goroutines each waiting for a lock held by the other,
+// and reports them with their stack traces. Detection records the stack of every acquisition, so it is intended for tests
+// and staging rather than production. While it is disabled, the primitives only pay for an atomic load per operation.
+//
+// The functions other than Enable and Disable are called by the primitives, and by other primitives wishing to take part in detection.
+package deadlock
+
+import (
+	"fmt"
+	"log"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nvn1729/congo/internal/goid"
+)
+
+// A Deadlock describes goroutines that can never be released: each of them waits for a resource whose holders are all deadlocked.
+type Deadlock struct {
+	Goroutines []Goroutine
+}
+
+// A Goroutine is a deadlocked goroutine.
+type Goroutine struct {
+	// ID is the id of the goroutine, as printed in its stack traces.
+	ID int64
+
+	// WaitingFor describes the resource the goroutine waits for, with its type and address.
+	WaitingFor string
+
+	// Since is the time at which the goroutine started waiting.
+	Since time.Time
+
+	// Stack is the stack trace of the goroutine at the time it started waiting.
+	Stack string
+
+	// Holding are the resources held by the goroutine.
+	Holding []Held
+}
+
+// Held describes units of a resource held by a goroutine, such as a lock, or permits of a semaphore.
+type Held struct {
+	// Resource describes the resource, with its type and address.
+	Resource string
+
+	// Units is the number of units held, such as 1 for a lock.
+	Units int64
+
+	// Stack is the stack trace of the goroutine at the time it acquired the units.
+	Stack string
+}
+
+// String formats the deadlock as a warning including the stack traces of the deadlocked goroutines.
+func (deadlock Deadlock) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "deadlock: %d goroutine(s) deadlocked", len(deadlock.Goroutines))
+	for _, g := range deadlock.Goroutines {
+		fmt.Fprintf(&b, "\n\ngoroutine %d waiting for %s for %v, at\n\n%s", g.ID, g.WaitingFor, time.Since(g.Since).Round(time.Millisecond), g.Stack)
+		for _, held := range g.Holding {
+			fmt.Fprintf(&b, "\n\ngoroutine %d holding %d unit(s) of %s, acquired at\n\n%s", g.ID, held.Units, held.Resource, held.Stack)
+		}
+	}
+	return b.String()
+}
+
+// enabled is 1 while detection is enabled. It is set atomically.
+var enabled int32
+
+// current is the detector of the current Enable, guarded by currentM.
+var (
+	currentM sync.Mutex
+	current  *detector
+)
+
+// Enable enables detection, with a watchdog looking for deadlocks every interval. A goroutine must have been waiting for at least interval
+// to be considered deadlocked, so that waits with a timeout are given a chance to return. Each deadlock is reported once.
+// If report is nil, deadlocks are written to the standard logger. Enabling detection again replaces the interval and report.
+//
+// Only the resources acquired while detection is enabled are tracked. Enable panics if interval is not positive.
+func Enable(interval time.Duration, report func(Deadlock)) {
+	if interval <= 0 {
+		panic("deadlock: interval must be positive")
+	}
+	if report == nil {
+		report = func(deadlock Deadlock) {
+			log.Print(deadlock.String())
+		}
+	}
+	currentM.Lock()
+	defer currentM.Unlock()
+	if current != nil {
+		current.stop()
+	}
+	current = newDetector(interval, report)
+	atomic.StoreInt32(&enabled, 1)
+}
+
+// Disable disables detection, forgetting the resources tracked so far.
+func Disable() {
+	currentM.Lock()
+	defer currentM.Unlock()
+	atomic.StoreInt32(&enabled, 0)
+	if current != nil {
+		current.stop()
+		current = nil
+	}
+}
+
+// Enabled reports whether detection is enabled.
+func Enabled() bool {
+	return atomic.LoadInt32(&enabled) == 1
+}
+
+// A Wait is a goroutine waiting for a resource, as recorded by Waiting. The methods of a nil Wait do nothing.
+type Wait struct {
+	d         *detector
+	resource  interface{}
+	goroutine int64
+}
+
+// Waiting records that the calling goroutine starts waiting for the resource, until Acquired or Abandoned is called on the returned Wait.
+// It returns nil if detection is disabled.
+func Waiting(resource interface{}) *Wait {
+	d := detectorIfEnabled()
+	if d == nil {
+		return nil
+	}
+	stack := captureStack()
+	w := &Wait{d: d, resource: resource, goroutine: goid.Current()}
+	d.waiting(resource, w.goroutine, stack)
+	return w
+}
+
+// Acquired records that the waiting goroutine acquired n units of the resource, ending its wait.
+// It may be called by another goroutine, such as one handing the resource over to the waiting goroutine.
+func (w *Wait) Acquired(n int64) {
+	if w != nil {
+		w.d.acquired(w.resource, w.goroutine, n, "")
+	}
+}
+
+// Abandoned records that the waiting goroutine gave up waiting for the resource.
+func (w *Wait) Abandoned() {
+	if w != nil {
+		w.d.abandoned(w.goroutine)
+	}
+}
+
+// Acquired records that the calling goroutine acquired n units of the resource without waiting.
+func Acquired(resource interface{}, n int64) {
+	if d := detectorIfEnabled(); d != nil {
+		stack := captureStack()
+		d.acquired(resource, goid.Current(), n, stack)
+	}
+}
+
+// Released records that n units of the resource were released, preferably those held by the calling goroutine,
+// and then the oldest ones, as resources such as a lock.Mutex may be released by another goroutine than the one holding them.
+// It must be called before the units can be acquired by another goroutine.
+func Released(resource interface{}, n int64) {
+	if d := detectorIfEnabled(); d != nil {
+		d.released(resource, goid.Current(), n)
+	}
+}
+
+// detectorIfEnabled returns the current detector, or nil if detection is disabled.
+func detectorIfEnabled() *detector {
+	if !Enabled() {
+		return nil
+	}
+	currentM.Lock()
+	defer currentM.Unlock()
+	return current
+}
+
+// A detector tracks the waits-for graph of the resources acquired since detection was enabled.
+type detector struct {
+	m        sync.Mutex
+	interval time.Duration
+	report   func(Deadlock)
+	waits    map[int64]*wait         // the waits of goroutines, by goroutine id
+	holds    map[interface{}][]*hold // the holds of resources, in the order they were acquired
+	reported map[*wait]bool          // the waits already reported as deadlocked
+	done     chan struct{}
+}
+
+// A wait is a goroutine waiting for a resource.
+type wait struct {
+	resource interface{}
+	since    time.Time
+	stack    string
+}
+
+// A hold is a number of units of a resource held by a goroutine.
+type hold struct {
+	goroutine int64
+	units     int64
+	stack     string
+}
+
+// newDetector creates a detector, and starts its watchdog.
+func newDetector(interval time.Duration, report func(Deadlock)) *detector {
+	d := &detector{
+		interval: interval,
+		report:   report,
+		waits:    make(map[int64]*wait),
+		holds:    make(map[interface{}][]*hold),
+		reported: make(map[*wait]bool),
+		done:     make(chan struct{}),
+	}
+	go d.watch()
+	return d
+}
+
+// stop stops the watchdog.
+func (d *detector) stop() {
+	close(d.done)
+}
+
+// watch checks for deadlocks every interval, until the detector is stopped.
+func (d *detector) watch() {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if deadlock, ok := d.check(time.Now()); ok {
+				d.report(deadlock)
+			}
+		case <-d.done:
+			return
+		}
+	}
+}
+
+func (d *detector) waiting(resource interface{}, goroutine int64, stack string) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	d.waits[goroutine] = &wait{resource: resource, since: time.Now(), stack: stack}
+}
+
+// acquired records that a goroutine acquired n units of a resource, at the given stack, or at the stack of its wait if empty.
+func (d *detector) acquired(resource interface{}, goroutine int64, n int64, stack string) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	if w, ok := d.waits[goroutine]; ok && stack == "" {
+		stack = w.stack
+	}
+	d.endWait(goroutine)
+	d.holds[resource] = append(d.holds[resource], &hold{goroutine: goroutine, units: n, stack: stack})
+}
+
+func (d *detector) abandoned(goroutine int64) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	d.endWait(goroutine)
+}
+
+func (d *detector) released(resource interface{}, goroutine int64, n int64) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	holds := d.holds[resource]
+	for i := len(holds) - 1; i >= 0 && n > 0; i-- {
+		if holds[i].goroutine == goroutine {
+			holds, n = releaseAt(holds, i, n)
+		}
+	}
+	for len(holds) > 0 && n > 0 {
+		holds, n = releaseAt(holds, 0, n)
+	}
+	if len(holds) == 0 {
+		delete(d.holds, resource)
+	} else {
+		d.holds[resource] = holds
+	}
+}
+
+// releaseAt releases up to n units of the hold at index i, and returns the holds left, and the number of units left to release.
+func releaseAt(holds []*hold, i int, n int64) ([]*hold, int64) {
+	if holds[i].units > n {
+		holds[i].units -= n
+		return holds, 0
+	}
+	n -= holds[i].units
+	return append(holds[:i], holds[i+1:]...), n
+}
+
+// endWait forgets the wait of a goroutine.
+// This call must be guarded using the detector mutex.
+func (d *detector) endWait(goroutine int64) {
+	if w, ok := d.waits[goroutine]; ok {
+		delete(d.waits, goroutine)
+		delete(d.reported, w)
+	}
+}
+
+// check looks for goroutines waiting for at least the interval that cannot be released, and reports whether it found any
+// that were not reported yet.
+//
+// A goroutine can be released if it does not wait, or waited for less than the interval, or waits for a resource that has no holder,
+// or that has a holder that can be released. The goroutines left once no more can be found to be released are deadlocked.
+func (d *detector) check(now time.Time) (Deadlock, bool) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	stuck := make(map[int64]*wait)
+	for goroutine, w := range d.waits {
+		if now.Sub(w.since) >= d.interval {
+			stuck[goroutine] = w
+		}
+	}
+	for released := true; released; {
+		released = false
+		for goroutine, w := range stuck {
+			if !d.blocked(w, stuck) {
+				delete(stuck, goroutine)
+				released = true
+			}
+		}
+	}
+
+	fresh := false
+	var deadlock Deadlock
+	for goroutine, w := range stuck {
+		if !d.reported[w] {
+			fresh = true
+			d.reported[w] = true
+		}
+		g := Goroutine{ID: goroutine, WaitingFor: describe(w.resource), Since: w.since, Stack: w.stack}
+		for resource, holds := range d.holds {
+			for _, h := range holds {
+				if h.goroutine == goroutine {
+					g.Holding = append(g.Holding, Held{Resource: describe(resource), Units: h.units, Stack: h.stack})
+				}
+			}
+		}
+		deadlock.Goroutines = append(deadlock.Goroutines, g)
+	}
+	sort.Slice(deadlock.Goroutines, func(i, j int) bool {
+		return deadlock.Goroutines[i].ID < deadlock.Goroutines[j].ID
+	})
+	return deadlock, fresh
+}
+
+// blocked reports whether a wait has holders to wait for, all stuck.
+// This call must be guarded using the detector mutex.
+func (d *detector) blocked(w *wait, stuck map[int64]*wait) bool {
+	holds := d.holds[w.resource]
+	for _, h := range holds {
+		if stuck[h.goroutine] == nil {
+			return false
+		}
+	}
+	return len(holds) > 0
+}
+
+// describe describes a resource by its type and address.
+func describe(resource interface{}) string {
+	return fmt.Sprintf("%T(%p)", resource, resource)
+}
+
+// captureStack returns the stack trace of the calling goroutine.
+func captureStack() string {
+	buf := make([]byte, 4096)
+	return string(buf[:runtime.Stack(buf, false)])
+}
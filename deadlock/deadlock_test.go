@@ -0,0 +1,144 @@
+package deadlock
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetector_check(t *testing.T) {
+	d := newDetector(time.Hour, nil)
+	defer d.stop()
+	r1, r2 := new(int), new(int)
+	later := time.Now().Add(2 * time.Hour)
+
+	// goroutine 1 waits for r2, held by goroutine 2, which is not waiting
+	d.acquired(r1, 1, 1, "stack 1")
+	d.acquired(r2, 2, 1, "stack 2")
+	d.waiting(r2, 1, "wait 1")
+	_, ok := d.check(later)
+	assertEqual(t, false, ok)
+
+	// goroutine 2 now waits for r1, held by goroutine 1, once both waited long enough
+	d.waiting(r1, 2, "wait 2")
+	_, ok = d.check(time.Now())
+	assertEqual(t, false, ok)
+	deadlock, ok := d.check(later)
+	assertEqual(t, true, ok)
+	assertEqual(t, 2, len(deadlock.Goroutines))
+	g := deadlock.Goroutines[0]
+	assertEqual(t, int64(1), g.ID)
+	assertEqual(t, describe(r2), g.WaitingFor)
+	assertEqual(t, "wait 1", g.Stack)
+	assertEqual(t, 1, len(g.Holding))
+	assertEqual(t, Held{Resource: describe(r1), Units: 1, Stack: "stack 1"}, g.Holding[0])
+	assertEqual(t, int64(2), deadlock.Goroutines[1].ID)
+	assertEqual(t, describe(r1), deadlock.Goroutines[1].WaitingFor)
+
+	// the deadlock is reported once, unless another goroutine joins it
+	_, ok = d.check(later)
+	assertEqual(t, false, ok)
+	d.waiting(r1, 3, "wait 3")
+	deadlock, ok = d.check(later)
+	assertEqual(t, true, ok)
+	assertEqual(t, 3, len(deadlock.Goroutines))
+	assertEqual(t, 0, len(deadlock.Goroutines[2].Holding))
+
+	// releasing r1 from another goroutine releases the oldest holder
+	d.released(r1, 4, 1)
+	_, ok = d.check(later)
+	assertEqual(t, false, ok)
+	d.acquired(r1, 2, 1, "")
+	d.abandoned(3)
+	d.released(r2, 2, 1)
+	d.acquired(r2, 1, 1, "")
+	assertEqual(t, 0, len(d.waits))
+	assertEqual(t, "wait 1", d.holds[r2][0].stack)
+}
+
+func TestDetector_check_self(t *testing.T) {
+	d := newDetector(time.Hour, nil)
+	defer d.stop()
+	r := new(int)
+	later := time.Now().Add(2 * time.Hour)
+
+	// goroutine 1 holds 2 units, and waits for more, which only goroutine 2 may release
+	d.acquired(r, 1, 2, "stack 1")
+	d.acquired(r, 2, 1, "stack 2")
+	d.waiting(r, 1, "wait 1")
+	_, ok := d.check(later)
+	assertEqual(t, false, ok)
+	d.released(r, 2, 1)
+	deadlock, ok := d.check(later)
+	assertEqual(t, true, ok)
+	assertEqual(t, 1, len(deadlock.Goroutines))
+	assertEqual(t, int64(2), deadlock.Goroutines[0].Holding[0].Units)
+
+	// releases are attributed to the releasing goroutine first
+	d.acquired(r, 2, 1, "stack 2")
+	d.released(r, 1, 1)
+	assertEqual(t, 2, len(d.holds[r]))
+	assertEqual(t, int64(1), d.holds[r][0].units)
+	d.released(r, 3, 2)
+	assertEqual(t, 0, len(d.holds))
+}
+
+func TestEnable(t *testing.T) {
+	assertEqual(t, false, Enabled())
+	assertEqual(t, (*Wait)(nil), Waiting(t))
+	reports := make(chan Deadlock, 1)
+	Enable(10*time.Millisecond, func(deadlock Deadlock) {
+		reports <- deadlock
+	})
+	defer Disable()
+	assertEqual(t, true, Enabled())
+
+	// a goroutine waits for a resource it holds
+	resource := new(int)
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Acquired(resource, 1)
+		wait := Waiting(resource)
+		<-release
+		Released(resource, 1)
+		wait.Acquired(1)
+		Released(resource, 1)
+	}()
+	deadlock := <-reports
+	assertEqual(t, 1, len(deadlock.Goroutines))
+	assertEqual(t, describe(resource), deadlock.Goroutines[0].WaitingFor)
+	assertEqual(t, true, strings.Contains(deadlock.Goroutines[0].Stack, "TestEnable"))
+	s := deadlock.String()
+	assertEqual(t, true, strings.HasPrefix(s, "deadlock: 1 goroutine(s) deadlocked\n\ngoroutine "))
+	assertEqual(t, true, strings.Contains(s, "holding 1 unit(s) of "+describe(resource)))
+	close(release)
+	<-done
+
+	Disable()
+	assertEqual(t, false, Enabled())
+	assertEqual(t, (*Wait)(nil), Waiting(resource))
+}
+
+func TestEnable_invalid(t *testing.T) {
+	defer func() {
+		assertNotNil(t, recover())
+	}()
+	Enable(0, nil)
+	t.Fatal("Did not panic")
+}
+
+func assertEqual(t *testing.T, expected interface{}, actual interface{}) {
+	t.Helper()
+	if expected != actual {
+		t.Fatal("Not equal:", "expected:", expected, ", actual:", actual)
+	}
+}
+
+func assertNotNil(t *testing.T, actual interface{}) {
+	t.Helper()
+	if actual == nil {
+		t.Fatal("Value is nil")
+	}
+}
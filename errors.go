@@ -2,10 +2,25 @@ package congo
 
 import "errors"
 
-// These are errors related to CountDownLatch.
+// These are errors related to CountDownLatch and CountUpLatch.
 var (
 	// ErrCountDownLatchCompleted is returned when CountDown or WeightedCountDown is called after the count down is already complete
 	ErrCountDownLatchCompleted = errors.New("Latch count down already complete")
+
+	// ErrCountDownOverflow is returned by WeightedCountDown on a latch created with WithStrictWeights when the weight exceeds the remaining count
+	ErrCountDownOverflow = errors.New("Latch count down weight exceeds remaining count")
+
+	// ErrCountDownLatchNotCompleted is returned by Reset when the current round of count down is still in progress
+	ErrCountDownLatchNotCompleted = errors.New("Latch count down not complete")
+
+	// ErrCountDownLatchCanceled is returned when counting down a latch that has been canceled, and is the default cancellation cause
+	ErrCountDownLatchCanceled = errors.New("Latch count down canceled")
+
+	// ErrCountDownLatchAbandoned is returned by Close when goroutines are still blocked waiting on a latch whose count down is not complete
+	ErrCountDownLatchAbandoned = errors.New("Latch closed with goroutines waiting on it")
+
+	// ErrCountUpLatchCompleted is returned when Increment or Add is called on a CountUpLatch that has already reached its target
+	ErrCountUpLatchCompleted = errors.New("Latch count up already complete")
 )
 	
 
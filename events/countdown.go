@@ -0,0 +1,166 @@
+// Package events provides events, synchronization points that goroutines wait for until other goroutines signal them.
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nvn1729/congo"
+)
+
+// A CountDownEvent is an event set when its count reaches zero, in the style of .NET's CountdownEvent: goroutines Signal it
+// as they complete units of work, and waiters are released once the count reaches zero. Unlike a congo.CountDownLatch,
+// the count may grow with AddCount while the event is not set, as work is discovered, such as when processing a tree
+// whose nodes spawn the processing of their children, and the event may be reused with Reset.
+//
+// The zero value is an event set with a count of zero, and a CountDownEvent must not be copied after first use.
+type CountDownEvent struct {
+	m       sync.Mutex
+	initial int
+	count   int
+	ch      chan struct{} // closed once the count reaches zero, nil while the event has never been reset from a count of zero
+	clock   congo.Clock
+}
+
+// NewCountDownEvent creates a CountDownEvent with the given initial count, set if it is zero.
+// The event may be further configured by passing options such as WithClock. NewCountDownEvent panics if count is negative.
+func NewCountDownEvent(count int, opts ...Option) *CountDownEvent {
+	event := &CountDownEvent{clock: newOptions(opts).clock}
+	event.Reset(count)
+	return event
+}
+
+// InitialCount returns the count the event was created or last reset with.
+func (event *CountDownEvent) InitialCount() int {
+	event.m.Lock()
+	defer event.m.Unlock()
+	return event.initial
+}
+
+// CurrentCount returns the remaining count.
+func (event *CountDownEvent) CurrentCount() int {
+	event.m.Lock()
+	defer event.m.Unlock()
+	return event.count
+}
+
+// IsSet reports whether the count reached zero.
+func (event *CountDownEvent) IsSet() bool {
+	return event.CurrentCount() == 0
+}
+
+// AddCount increases the count by n, for work to be signaled before the event is set.
+// It returns ErrEventSet if the count already reached zero, as waiters may have been released. AddCount panics if n is negative.
+func (event *CountDownEvent) AddCount(n int) error {
+	if n < 0 {
+		panic("events: negative count")
+	}
+	event.m.Lock()
+	defer event.m.Unlock()
+	if event.count == 0 {
+		return ErrEventSet
+	}
+	event.count += n
+	return nil
+}
+
+// TryAddCount increases the count by n, like AddCount, and reports whether it did.
+func (event *CountDownEvent) TryAddCount(n int) bool {
+	return event.AddCount(n) == nil
+}
+
+// Signal decreases the count by n, and reports whether it reached zero, setting the event and releasing the waiting goroutines.
+// It returns ErrEventSet if the count already reached zero, and ErrSignalOverflow if n exceeds the remaining count,
+// in which case the count is left unchanged. Signal panics if n is negative.
+func (event *CountDownEvent) Signal(n int) (bool, error) {
+	if n < 0 {
+		panic("events: negative count")
+	}
+	event.m.Lock()
+	defer event.m.Unlock()
+	if event.count == 0 {
+		return false, ErrEventSet
+	}
+	if n > event.count {
+		return false, ErrSignalOverflow
+	}
+	event.count -= n
+	if event.count > 0 {
+		return false, nil
+	}
+	close(event.ch)
+	return true, nil
+}
+
+// Reset sets the count, and the initial count, to count, making future waiters wait until it reaches zero again, unless it is zero.
+// The goroutines waiting for the event are released only when the new count reaches zero. Reset panics if count is negative.
+func (event *CountDownEvent) Reset(count int) {
+	if count < 0 {
+		panic("events: negative count")
+	}
+	event.m.Lock()
+	defer event.m.Unlock()
+	event.initial = count
+	if event.count == 0 && count > 0 {
+		event.ch = make(chan struct{})
+	}
+	if event.count > 0 && count == 0 {
+		close(event.ch)
+	}
+	event.count = count
+}
+
+// Done returns a channel that is closed when the count reaches zero, to wait for the event in a select statement
+// alongside other channels.
+func (event *CountDownEvent) Done() <-chan struct{} {
+	event.m.Lock()
+	defer event.m.Unlock()
+	if event.ch == nil {
+		event.ch = make(chan struct{})
+		close(event.ch)
+	}
+	return event.ch
+}
+
+// Wait waits indefinitely until the count reaches zero. Wait returns immediately if the event is set.
+func (event *CountDownEvent) Wait() {
+	<-event.Done()
+}
+
+// WaitTimeout waits until a given timeout for the count to reach zero.
+// If the event is set before the timeout, WaitTimeout returns true. Otherwise it returns false.
+// WaitTimeout returns immediately if the event is set.
+func (event *CountDownEvent) WaitTimeout(timeout time.Duration) bool {
+	done := event.Done()
+	select {
+	case <-done:
+		return true
+	default:
+	}
+	timer := clockOr(event.clock).NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C():
+		return false
+	}
+}
+
+// WaitContext waits until the count reaches zero or the context is done.
+// If the context is done first, WaitContext returns the context's error. WaitContext returns nil immediately if the event is set.
+func (event *CountDownEvent) WaitContext(ctx context.Context) error {
+	done := event.Done()
+	select {
+	case <-done:
+		return nil
+	default:
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
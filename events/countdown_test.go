@@ -0,0 +1,166 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nvn1729/congo/internal/clocktest"
+)
+
+// node is a tree node, whose processing spawns the processing of its children.
+type node struct {
+	value    int
+	children []*node
+}
+
+func ExampleCountDownEvent() {
+	tree := &node{1, []*node{{2, nil}, {3, []*node{{4, nil}}}}}
+	event := NewCountDownEvent(1)
+	var m sync.Mutex
+	sum := 0
+	var process func(n *node)
+	process = func(n *node) {
+		event.AddCount(len(n.children))
+		for _, child := range n.children {
+			go process(child)
+		}
+		m.Lock()
+		sum += n.value
+		m.Unlock()
+		event.Signal(1)
+	}
+	go process(tree)
+
+	event.Wait()
+	fmt.Println("Sum:", sum)
+	// Output:
+	// Sum: 10
+}
+
+func TestCountDownEvent(t *testing.T) {
+	clock := clocktest.New(time.Unix(0, 0))
+	event := NewCountDownEvent(2, WithClock(clock))
+	assertEqual(t, 2, event.InitialCount())
+	assertEqual(t, 2, event.CurrentCount())
+	assertEqual(t, false, event.IsSet())
+	assertEqual(t, false, waitTimeout(clock, event, time.Second))
+
+	assertNil(t, event.AddCount(3))
+	assertEqual(t, true, event.TryAddCount(0))
+	assertEqual(t, 5, event.CurrentCount())
+	assertEqual(t, 2, event.InitialCount())
+	assertSignal(t, false, nil)(event.Signal(2))
+	assertSignal(t, false, ErrSignalOverflow)(event.Signal(4))
+	assertEqual(t, 3, event.CurrentCount())
+
+	released := make(chan error)
+	go func() {
+		released <- event.WaitContext(context.Background())
+	}()
+	assertSignal(t, false, nil)(event.Signal(1))
+	assertSignal(t, true, nil)(event.Signal(2))
+	assertNil(t, <-released)
+	event.Wait()
+	assertEqual(t, true, event.IsSet())
+	assertEqual(t, true, event.WaitTimeout(0))
+
+	// the count cannot grow nor decrease once set
+	assertEqual(t, ErrEventSet, event.AddCount(1))
+	assertEqual(t, false, event.TryAddCount(1))
+	assertSignal(t, false, ErrEventSet)(event.Signal(1))
+	assertEqual(t, 0, event.CurrentCount())
+}
+
+func TestCountDownEvent_reset(t *testing.T) {
+	clock := clocktest.New(time.Unix(0, 0))
+	event := NewCountDownEvent(0, WithClock(clock))
+	assertEqual(t, true, event.IsSet())
+	event.Wait()
+
+	event.Reset(1)
+	assertEqual(t, 1, event.InitialCount())
+	assertEqual(t, false, waitTimeout(clock, event, time.Second))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assertEqual(t, context.Canceled, event.WaitContext(ctx))
+
+	// waiters keep waiting across a reset to a positive count, and are released by a reset to zero
+	done := event.Done()
+	event.Reset(3)
+	assertEqual(t, 3, event.CurrentCount())
+	select {
+	case <-done:
+		t.Fatal("Released by reset")
+	default:
+	}
+	event.Reset(0)
+	<-done
+	assertEqual(t, true, event.IsSet())
+
+	// the zero value is a set event, which times its waits on the real clock
+	var zero CountDownEvent
+	assertEqual(t, true, zero.IsSet())
+	assertEqual(t, true, zero.WaitTimeout(time.Hour))
+}
+
+func TestCountDownEvent_negative(t *testing.T) {
+	event := NewCountDownEvent(1)
+	for _, f := range []func(){
+		func() { NewCountDownEvent(-1) },
+		func() { event.AddCount(-1) },
+		func() { event.Signal(-1) },
+		func() { event.Reset(-1) },
+	} {
+		func() {
+			defer func() {
+				assertNotNil(t, recover())
+			}()
+			f()
+			t.Fatal("Did not panic")
+		}()
+	}
+}
+
+// waitTimeout calls WaitTimeout on the waiter, and advances the clock past the timeout once the waiter's timer is pending.
+func waitTimeout(clock *clocktest.Clock, waiter interface{ WaitTimeout(time.Duration) bool }, timeout time.Duration) bool {
+	result := make(chan bool)
+	go func() {
+		result <- waiter.WaitTimeout(timeout)
+	}()
+	clock.BlockUntilTimers(1)
+	clock.Advance(timeout)
+	return <-result
+}
+
+// assertSignal returns a function asserting the result of Signal.
+func assertSignal(t *testing.T, set bool, err error) func(bool, error) {
+	return func(actualSet bool, actualErr error) {
+		t.Helper()
+		assertEqual(t, set, actualSet)
+		assertEqual(t, err, actualErr)
+	}
+}
+
+func assertEqual(t *testing.T, expected interface{}, actual interface{}) {
+	t.Helper()
+	if expected != actual {
+		t.Fatal("Not equal:", "expected:", expected, ", actual:", actual)
+	}
+}
+
+func assertNil(t *testing.T, actual interface{}) {
+	t.Helper()
+	if actual != nil {
+		t.Fatal("Value not nil, actual:", actual)
+	}
+}
+
+func assertNotNil(t *testing.T, actual interface{}) {
+	t.Helper()
+	if actual == nil {
+		t.Fatal("Value is nil")
+	}
+}
@@ -0,0 +1,12 @@
+package events
+
+import "errors"
+
+// These are errors related to events.
+var (
+	// ErrEventSet is returned by AddCount and Signal when the count of a CountDownEvent already reached zero
+	ErrEventSet = errors.New("Event already set")
+
+	// ErrSignalOverflow is returned by Signal when signaling more than the remaining count of a CountDownEvent
+	ErrSignalOverflow = errors.New("Event signaled more than its remaining count")
+)
@@ -0,0 +1,123 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nvn1729/congo"
+)
+
+// A Notifier broadcasts notifications identified by epochs: each Broadcast increments the epoch, and Wait(epoch) waits until
+// the epoch passes the last one its caller saw. A notification broadcast between two waits is therefore never missed,
+// unlike with a sync.Cond signaled without holding its lock, and no lock needs to be held around the state notified about.
+// It suits loops reacting to changes, such as invalidating a cache or reloading a configuration:
+//
+//	epoch := notifier.Epoch()
+//	for {
+//		reload()
+//		epoch = notifier.Wait(epoch)
+//	}
+//
+// Several broadcasts between two waits are coalesced into one wake-up. The zero value is a notifier at epoch 0,
+// and a Notifier must not be copied after first use.
+type Notifier struct {
+	m     sync.Mutex
+	epoch uint64
+	chs   map[uint64]chan struct{} // the channels closed once the epoch passes their key, created when waited for
+	clock congo.Clock
+}
+
+// NewNotifier creates a Notifier at epoch 0, like the zero value, which may be further configured by passing options such as WithClock.
+func NewNotifier(opts ...Option) *Notifier {
+	return &Notifier{clock: newOptions(opts).clock}
+}
+
+// closedCh is a closed channel, returned by Changed for the epochs already passed.
+var closedCh = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
+
+// Epoch returns the current epoch, the number of broadcasts so far.
+func (notifier *Notifier) Epoch() uint64 {
+	notifier.m.Lock()
+	defer notifier.m.Unlock()
+	return notifier.epoch
+}
+
+// Broadcast increments the epoch, waking up the goroutines waiting for it to pass, and returns the new epoch.
+func (notifier *Notifier) Broadcast() uint64 {
+	notifier.m.Lock()
+	defer notifier.m.Unlock()
+	if ch, ok := notifier.chs[notifier.epoch]; ok {
+		close(ch)
+		delete(notifier.chs, notifier.epoch)
+	}
+	notifier.epoch++
+	return notifier.epoch
+}
+
+// Changed returns a channel that is closed once the epoch passes the given one, to wait for it in a select statement
+// alongside other channels. The channel is already closed if the epoch already passed,
+// and a future epoch is waited for until the broadcasts pass it, not just until the next one.
+func (notifier *Notifier) Changed(epoch uint64) <-chan struct{} {
+	notifier.m.Lock()
+	defer notifier.m.Unlock()
+	if notifier.epoch > epoch {
+		return closedCh
+	}
+	ch, ok := notifier.chs[epoch]
+	if !ok {
+		if notifier.chs == nil {
+			notifier.chs = make(map[uint64]chan struct{})
+		}
+		ch = make(chan struct{})
+		notifier.chs[epoch] = ch
+	}
+	return ch
+}
+
+// Wait waits until the epoch passes the given one, usually the last one seen, and returns the current epoch.
+// Wait returns immediately if the epoch already passed.
+func (notifier *Notifier) Wait(epoch uint64) uint64 {
+	<-notifier.Changed(epoch)
+	return notifier.Epoch()
+}
+
+// WaitTimeout waits until a given timeout for the epoch to pass the given one, and returns the current epoch.
+// If the epoch passes before the timeout, WaitTimeout returns true. Otherwise it returns false.
+func (notifier *Notifier) WaitTimeout(epoch uint64, timeout time.Duration) (uint64, bool) {
+	changed := notifier.Changed(epoch)
+	select {
+	case <-changed:
+		return notifier.Epoch(), true
+	default:
+	}
+	timer := clockOr(notifier.clock).NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-changed:
+		return notifier.Epoch(), true
+	case <-timer.C():
+		return notifier.Epoch(), false
+	}
+}
+
+// WaitContext waits until the epoch passes the given one or the context is done, and returns the current epoch.
+// If the context is done first, WaitContext returns the context's error. WaitContext returns immediately if the epoch already passed.
+func (notifier *Notifier) WaitContext(ctx context.Context, epoch uint64) (uint64, error) {
+	changed := notifier.Changed(epoch)
+	select {
+	case <-changed:
+		return notifier.Epoch(), nil
+	default:
+	}
+	select {
+	case <-changed:
+		return notifier.Epoch(), nil
+	case <-ctx.Done():
+		return notifier.Epoch(), ctx.Err()
+	}
+}
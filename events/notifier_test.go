@@ -0,0 +1,139 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nvn1729/congo/internal/clocktest"
+)
+
+func ExampleNotifier() {
+	var notifier Notifier
+	var m sync.Mutex
+	config := "v1"
+	reloaded := make(chan string)
+	go func() {
+		epoch := notifier.Epoch()
+		for {
+			m.Lock()
+			reloaded <- config
+			done := config == "v2"
+			m.Unlock()
+			if done {
+				return
+			}
+			epoch = notifier.Wait(epoch)
+		}
+	}()
+
+	fmt.Println("Loaded", <-reloaded)
+	m.Lock()
+	config = "v2"
+	m.Unlock()
+	notifier.Broadcast()
+	fmt.Println("Loaded", <-reloaded)
+	// Output:
+	// Loaded v1
+	// Loaded v2
+}
+
+func TestNotifier(t *testing.T) {
+	clock := clocktest.New(time.Unix(0, 0))
+	notifier := NewNotifier(WithClock(clock))
+	assertEqual(t, uint64(0), notifier.Epoch())
+	timedOut := make(chan bool)
+	go func() {
+		_, ok := notifier.WaitTimeout(0, time.Second)
+		timedOut <- !ok
+	}()
+	clock.BlockUntilTimers(1)
+	clock.Advance(time.Second)
+	assertEqual(t, true, <-timedOut)
+
+	// all the waiters are woken up
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assertEqual(t, uint64(1), notifier.Wait(0))
+		}()
+	}
+	changed := notifier.Changed(0)
+	assertEqual(t, uint64(1), notifier.Broadcast())
+	<-changed
+	wg.Wait()
+
+	// broadcasts are not missed between waits, and are coalesced
+	assertEqual(t, uint64(2), notifier.Broadcast())
+	assertEqual(t, uint64(3), notifier.Broadcast())
+	assertEqual(t, uint64(3), notifier.Wait(1))
+	epoch, ok := notifier.WaitTimeout(2, 0)
+	assertEqual(t, uint64(3), epoch)
+	assertEqual(t, true, ok)
+	epoch, err := notifier.WaitContext(context.Background(), 0)
+	assertEqual(t, uint64(3), epoch)
+	assertNil(t, err)
+
+	// a blocked waiter is woken up before its timeout
+	released := make(chan uint64)
+	go func() {
+		epoch, ok := notifier.WaitTimeout(3, time.Minute)
+		assertEqual(t, true, ok)
+		released <- epoch
+	}()
+	clock.BlockUntilTimers(1)
+	notifier.Broadcast()
+	assertEqual(t, uint64(4), <-released)
+	assertEqual(t, 0, clock.Timers())
+}
+
+func TestNotifier_context(t *testing.T) {
+	var notifier Notifier
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	epoch, err := notifier.WaitContext(ctx, 0)
+	assertEqual(t, uint64(0), epoch)
+	assertEqual(t, context.Canceled, err)
+
+	// a notification already broadcast is returned even with a done context
+	notifier.Broadcast()
+	epoch, err = notifier.WaitContext(ctx, 0)
+	assertEqual(t, uint64(1), epoch)
+	assertNil(t, err)
+}
+
+func TestNotifier_futureEpoch(t *testing.T) {
+	clock := clocktest.New(time.Unix(0, 0))
+	notifier := NewNotifier(WithClock(clock))
+	next := notifier.Epoch() + 1
+	released := make(chan uint64)
+	go func() {
+		released <- notifier.Wait(next)
+	}()
+	changed := notifier.Changed(next)
+
+	// the next broadcast does not pass the epoch waited for
+	notifier.Broadcast()
+	timedOut := make(chan bool)
+	go func() {
+		epoch, ok := notifier.WaitTimeout(next, time.Second)
+		assertEqual(t, uint64(1), epoch)
+		timedOut <- !ok
+	}()
+	clock.BlockUntilTimers(1)
+	clock.Advance(time.Second)
+	assertEqual(t, true, <-timedOut)
+	select {
+	case <-changed:
+		t.Fatal("Epoch 1 not passed yet")
+	default:
+	}
+
+	notifier.Broadcast()
+	<-changed
+	assertEqual(t, uint64(2), <-released)
+}
@@ -0,0 +1,35 @@
+package events
+
+import "github.com/nvn1729/congo"
+
+// An Option configures an event at creation time.
+type Option func(*options)
+
+type options struct {
+	clock congo.Clock
+}
+
+// WithClock sets the Clock used by the event for time-based waits.
+// By default the event uses congo.RealClock.
+func WithClock(clock congo.Clock) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// newOptions applies the given options to the defaults.
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// clockOr returns the given Clock, falling back to congo.RealClock for events not created with WithClock, such as zero values.
+func clockOr(clock congo.Clock) congo.Clock {
+	if clock == nil {
+		return congo.RealClock()
+	}
+	return clock
+}
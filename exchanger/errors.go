@@ -0,0 +1,12 @@
+package exchanger
+
+import "errors"
+
+// These are errors related to Exchanger and Rendezvous.
+var (
+	// ErrTimeout is returned by ExchangeTimeout when the timeout elapses before another goroutine arrives to exchange with
+	ErrTimeout = errors.New("Exchange timed out")
+
+	// ErrBrokenRendezvous is returned when exchanging at a rendezvous that is broken, or becomes broken while waiting, because a party gave up waiting or the rendezvous was reset
+	ErrBrokenRendezvous = errors.New("Rendezvous is broken")
+)
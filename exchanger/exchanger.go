@@ -0,0 +1,90 @@
+// Package exchanger provides an Exchanger, a synchronization point at which pairs of goroutines swap values.
+package exchanger
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// An Exchanger is a synchronization point at which two goroutines rendezvous and swap values.
+//
+// Each goroutine offers a value to Exchange, which waits for another goroutine to arrive, then returns the value it offered.
+// This suits pipeline hand-offs, and double buffering, where a producer filling a buffer swaps it
+// for the empty buffer of a consumer. Like java.util.concurrent.Exchanger, any number of goroutines may use an exchanger,
+// which pairs them in the order they arrive.
+type Exchanger[T any] struct {
+	m       sync.Mutex
+	waiting *offer[T] // the offer of the goroutine waiting for a partner, if any
+}
+
+// An offer is the value of a goroutine waiting for a partner, and the channel on which the partner's value is sent.
+type offer[T any] struct {
+	value T
+	ch    chan T
+}
+
+// New creates an Exchanger.
+func New[T any]() *Exchanger[T] {
+	return &Exchanger[T]{}
+}
+
+// Exchange offers a value, waits for another goroutine to arrive, and returns the value that it offered.
+func (exchanger *Exchanger[T]) Exchange(value T) T {
+	partner, _ := exchanger.exchange(context.Background(), nil, value)
+	return partner
+}
+
+// ExchangeTimeout is like Exchange, but gives up waiting once the timeout elapses, and returns ErrTimeout.
+// The value is then not exchanged.
+func (exchanger *Exchanger[T]) ExchangeTimeout(value T, timeout time.Duration) (T, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	return exchanger.exchange(context.Background(), timer.C, value)
+}
+
+// ExchangeContext is like Exchange, but gives up waiting when the context is done, and returns the context's error.
+// The value is then not exchanged. If the context is already done, ExchangeContext does not exchange the value,
+// even with a waiting goroutine.
+func (exchanger *Exchanger[T]) ExchangeContext(ctx context.Context, value T) (T, error) {
+	return exchanger.exchange(ctx, nil, value)
+}
+
+// exchange exchanges a value, giving up when the context is done or timeoutCh fires. A nil timeoutCh never fires.
+func (exchanger *Exchanger[T]) exchange(ctx context.Context, timeoutCh <-chan time.Time, value T) (T, error) {
+	var zero T
+	exchanger.m.Lock()
+	if err := ctx.Err(); err != nil {
+		exchanger.m.Unlock()
+		return zero, err
+	}
+	if waiting := exchanger.waiting; waiting != nil {
+		exchanger.waiting = nil
+		exchanger.m.Unlock()
+		waiting.ch <- value
+		return waiting.value, nil
+	}
+	mine := &offer[T]{value: value, ch: make(chan T, 1)}
+	exchanger.waiting = mine
+	exchanger.m.Unlock()
+
+	var err error
+	select {
+	case partner := <-mine.ch:
+		return partner, nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-timeoutCh:
+		err = ErrTimeout
+	}
+
+	exchanger.m.Lock()
+	if exchanger.waiting == mine {
+		exchanger.waiting = nil
+		exchanger.m.Unlock()
+		return zero, err
+	}
+	exchanger.m.Unlock()
+	// a partner took the offer while giving up, and is sending its value
+	return <-mine.ch, nil
+}
@@ -0,0 +1,123 @@
+package exchanger
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func ExampleExchanger() {
+	// the producer fills a buffer while the consumer drains the other
+	exchanger := New[[]int]()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var buffer []int
+		for i := 0; i < 3; i++ {
+			buffer = exchanger.Exchange(buffer[:0])
+			fmt.Println(buffer)
+		}
+	}()
+
+	buffer := make([]int, 0, 3)
+	for i := 0; i < 3; i++ {
+		buffer = append(buffer, i, i+1, i+2)
+		buffer = exchanger.Exchange(buffer)
+	}
+	<-done
+	// Output:
+	// [0 1 2]
+	// [1 2 3]
+	// [2 3 4]
+}
+
+func TestExchanger_exchange(t *testing.T) {
+	exchanger := New[string]()
+	got := make(chan string)
+	go func() {
+		got <- exchanger.Exchange("a")
+	}()
+	select {
+	case <-got:
+		t.Fatal("Exchange returned before a partner arrived")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assertEqual(t, "a", exchanger.Exchange("b"))
+	assertEqual(t, "b", <-got)
+}
+
+func TestExchanger_pairs(t *testing.T) {
+	exchanger := New[int]()
+	const goroutines = 100
+	got := make([]int, goroutines)
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			got[i] = exchanger.Exchange(i)
+		}(i)
+	}
+	wg.Wait()
+	// the goroutines are paired
+	for i, partner := range got {
+		assertEqual(t, true, partner != i)
+		assertEqual(t, i, got[partner])
+	}
+}
+
+func TestExchanger_exchangeTimeout(t *testing.T) {
+	exchanger := New[int]()
+	value, err := exchanger.ExchangeTimeout(1, 50*time.Millisecond)
+	assertEqual(t, 0, value)
+	assertEqual(t, ErrTimeout, err)
+
+	// the timed out offer is withdrawn
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		exchanger.Exchange(2)
+	}()
+	value, err = exchanger.ExchangeTimeout(3, time.Second)
+	assertEqual(t, 2, value)
+	assertNil(t, err)
+}
+
+func TestExchanger_exchangeContext(t *testing.T) {
+	exchanger := New[int]()
+	ctx, cancel := context.WithCancel(context.Background())
+	got := make(chan error)
+	go func() {
+		_, err := exchanger.ExchangeContext(ctx, 1)
+		got <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	assertEqual(t, context.Canceled, <-got)
+
+	// a done context does not exchange with a waiting goroutine
+	waiting := make(chan int)
+	go func() {
+		waiting <- exchanger.Exchange(2)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	_, err := exchanger.ExchangeContext(ctx, 3)
+	assertEqual(t, context.Canceled, err)
+	value, err := exchanger.ExchangeContext(context.Background(), 4)
+	assertEqual(t, 2, value)
+	assertNil(t, err)
+	assertEqual(t, 4, <-waiting)
+}
+
+func assertEqual(t *testing.T, expected interface{}, actual interface{}) {
+	if expected != actual {
+		t.Fatal("Not equal:", "expected:", expected, ", actual:", actual)
+	}
+}
+
+func assertNil(t *testing.T, actual interface{}) {
+	if actual != nil {
+		t.Fatal("Value not nil, actual:", actual)
+	}
+}
@@ -0,0 +1,146 @@
+package exchanger
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// A Rendezvous is a synchronization point at which a fixed number of goroutines, the parties, exchange values:
+// each party offers a value, and receives the values of all parties once they have all arrived.
+//
+// Like a cyclicbarrier.CyclicBarrier, a Rendezvous is reusable: once all parties have exchanged their values,
+// it resets for the next round. If a party gives up waiting, because of a timeout or a canceled context,
+// the rendezvous is broken: all other parties waiting on it are released with ErrBrokenRendezvous,
+// and so is any party that subsequently calls Exchange, until the rendezvous is Reset.
+type Rendezvous[T any] struct {
+	m       sync.Mutex
+	parties int
+	round   *round[T]
+}
+
+// A round is the state of one round of exchange of the rendezvous.
+type round[T any] struct {
+	values []T
+	doneCh chan struct{} // closed when all parties have arrived, or the round is broken
+	broken bool          // set before doneCh is closed
+}
+
+// NewRendezvous creates a Rendezvous for the given number of parties.
+// NewRendezvous panics if parties is less than 1.
+func NewRendezvous[T any](parties int) *Rendezvous[T] {
+	if parties < 1 {
+		panic("exchanger: parties must be at least 1")
+	}
+	rendezvous := &Rendezvous[T]{parties: parties}
+	rendezvous.round = rendezvous.newRound()
+	return rendezvous
+}
+
+func (rendezvous *Rendezvous[T]) newRound() *round[T] {
+	return &round[T]{
+		values: make([]T, 0, rendezvous.parties),
+		doneCh: make(chan struct{}),
+	}
+}
+
+// Parties returns the number of parties exchanging values at the rendezvous.
+func (rendezvous *Rendezvous[T]) Parties() int {
+	return rendezvous.parties
+}
+
+// Exchange offers a value, waits until all parties have arrived, and returns the values of all parties
+// in the order they arrived, which includes the offered value. All parties receive the same slice, so it must not be modified.
+//
+// If the rendezvous is or becomes broken, Exchange returns ErrBrokenRendezvous.
+func (rendezvous *Rendezvous[T]) Exchange(value T) ([]T, error) {
+	return rendezvous.exchange(context.Background(), nil, value)
+}
+
+// ExchangeTimeout is like Exchange, but gives up waiting once the timeout elapses, which breaks the rendezvous,
+// and returns ErrTimeout.
+func (rendezvous *Rendezvous[T]) ExchangeTimeout(value T, timeout time.Duration) ([]T, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	return rendezvous.exchange(context.Background(), timer.C, value)
+}
+
+// ExchangeContext is like Exchange, but gives up waiting when the context is done, which breaks the rendezvous,
+// and returns the context's error. If the context is already done, ExchangeContext breaks the rendezvous without waiting.
+func (rendezvous *Rendezvous[T]) ExchangeContext(ctx context.Context, value T) ([]T, error) {
+	return rendezvous.exchange(ctx, nil, value)
+}
+
+// IsBroken reports whether the rendezvous is broken.
+func (rendezvous *Rendezvous[T]) IsBroken() bool {
+	rendezvous.m.Lock()
+	defer rendezvous.m.Unlock()
+	return rendezvous.round.broken
+}
+
+// Reset returns the rendezvous to its initial state and starts a new round.
+// Parties waiting on the current round are released with ErrBrokenRendezvous.
+func (rendezvous *Rendezvous[T]) Reset() {
+	rendezvous.m.Lock()
+	defer rendezvous.m.Unlock()
+	if !rendezvous.round.broken && len(rendezvous.round.values) > 0 {
+		rendezvous.breakRound()
+	}
+	rendezvous.round = rendezvous.newRound()
+}
+
+// exchange offers a value and waits for the round to complete, the context to be done or timeoutCh to fire.
+// A nil timeoutCh never fires.
+func (rendezvous *Rendezvous[T]) exchange(ctx context.Context, timeoutCh <-chan time.Time, value T) ([]T, error) {
+	rendezvous.m.Lock()
+	round := rendezvous.round
+	if round.broken {
+		rendezvous.m.Unlock()
+		return nil, ErrBrokenRendezvous
+	}
+	if err := ctx.Err(); err != nil {
+		rendezvous.breakRound()
+		rendezvous.m.Unlock()
+		return nil, err
+	}
+	round.values = append(round.values, value)
+	if len(round.values) == rendezvous.parties {
+		rendezvous.round = rendezvous.newRound()
+		rendezvous.m.Unlock()
+		close(round.doneCh)
+		return round.values, nil
+	}
+	rendezvous.m.Unlock()
+
+	var err error
+	select {
+	case <-round.doneCh:
+		if round.broken {
+			return nil, ErrBrokenRendezvous
+		}
+		return round.values, nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-timeoutCh:
+		err = ErrTimeout
+	}
+
+	rendezvous.m.Lock()
+	defer rendezvous.m.Unlock()
+	switch {
+	case round.broken:
+		return nil, ErrBrokenRendezvous
+	case rendezvous.round != round:
+		// the round completed while giving up
+		return round.values, nil
+	}
+	rendezvous.breakRound()
+	return nil, err
+}
+
+// breakRound breaks the current round, releasing the parties waiting on it.
+// This call must be guarded using the rendezvous mutex.
+func (rendezvous *Rendezvous[T]) breakRound() {
+	rendezvous.round.broken = true
+	close(rendezvous.round.doneCh)
+}
@@ -0,0 +1,133 @@
+package exchanger
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func ExampleRendezvous() {
+	// each worker shares its partial result with the others
+	rendezvous := NewRendezvous[int](3)
+	var wg sync.WaitGroup
+	for i := 1; i <= 3; i++ {
+		wg.Add(1)
+		go func(partial int) {
+			defer wg.Done()
+			partials, _ := rendezvous.Exchange(partial)
+			total := 0
+			for _, p := range partials {
+				total += p
+			}
+			if partial == 10 {
+				fmt.Println("Total:", total)
+			}
+		}(i * 10)
+	}
+	wg.Wait()
+	// Output:
+	// Total: 60
+}
+
+func TestRendezvous_rounds(t *testing.T) {
+	const parties = 5
+	rendezvous := NewRendezvous[int](parties)
+	for r := 0; r < 3; r++ {
+		got := make(chan []int, parties)
+		var wg sync.WaitGroup
+		for i := 0; i < parties; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				values, err := rendezvous.Exchange(r*parties + i)
+				assertNil(t, err)
+				got <- values
+			}(i)
+		}
+		wg.Wait()
+		close(got)
+		for values := range got {
+			sorted := append([]int(nil), values...)
+			sort.Ints(sorted)
+			assertEqual(t, fmt.Sprint([]int{r * parties, r*parties + 1, r*parties + 2, r*parties + 3, r*parties + 4}), fmt.Sprint(sorted))
+		}
+	}
+}
+
+func TestRendezvous_exchangeTimeout(t *testing.T) {
+	rendezvous := NewRendezvous[int](3)
+	got := make(chan error)
+	go func() {
+		_, err := rendezvous.Exchange(1)
+		got <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	_, err := rendezvous.ExchangeTimeout(2, 50*time.Millisecond)
+	assertEqual(t, ErrTimeout, err)
+	assertEqual(t, ErrBrokenRendezvous, <-got)
+	assertEqual(t, true, rendezvous.IsBroken())
+
+	// the rendezvous stays broken until it is reset
+	_, err = rendezvous.Exchange(3)
+	assertEqual(t, ErrBrokenRendezvous, err)
+	rendezvous.Reset()
+	assertEqual(t, false, rendezvous.IsBroken())
+	go func() {
+		_, err := rendezvous.Exchange(1)
+		got <- err
+	}()
+	go func() {
+		_, err := rendezvous.Exchange(2)
+		got <- err
+	}()
+	values, err := rendezvous.ExchangeTimeout(3, time.Second)
+	assertNil(t, err)
+	assertEqual(t, 3, len(values))
+	assertNil(t, <-got)
+	assertNil(t, <-got)
+}
+
+func TestRendezvous_exchangeContext(t *testing.T) {
+	rendezvous := NewRendezvous[int](2)
+	ctx, cancel := context.WithCancel(context.Background())
+	got := make(chan error)
+	go func() {
+		_, err := rendezvous.ExchangeContext(ctx, 1)
+		got <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	assertEqual(t, context.Canceled, <-got)
+	assertEqual(t, true, rendezvous.IsBroken())
+
+	// a done context breaks the rendezvous without waiting
+	rendezvous.Reset()
+	_, err := rendezvous.ExchangeContext(ctx, 1)
+	assertEqual(t, context.Canceled, err)
+	assertEqual(t, true, rendezvous.IsBroken())
+}
+
+func TestRendezvous_reset(t *testing.T) {
+	rendezvous := NewRendezvous[int](2)
+	got := make(chan error)
+	go func() {
+		_, err := rendezvous.Exchange(1)
+		got <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	rendezvous.Reset()
+	assertEqual(t, ErrBrokenRendezvous, <-got)
+	assertEqual(t, false, rendezvous.IsBroken())
+}
+
+func TestNewRendezvous_invalid(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Did not panic")
+		}
+	}()
+	NewRendezvous[int](0)
+}
@@ -0,0 +1,191 @@
+package executor
+
+import (
+	"sync"
+	"time"
+
+	"github.com/nvn1729/congo/queue"
+)
+
+// An ElasticPool is an Executor starting worker goroutines on demand, up to a maximum number,
+// and retiring them once they have been idle for a timeout, like a cached java.util.concurrent.ThreadPoolExecutor.
+//
+// It suits bursty workloads, for which a FixedPool either keeps idle workers around, or has too few of them during bursts.
+// A worker is started when a task is submitted while no worker is idle, unless the pool has its maximum number of workers,
+// in which case the task is queued until a worker is available, in submission order. The queue is bounded like that of a FixedPool:
+// its size is set with WithQueueSize, and what happens when it is full with WithRejectionPolicy.
+//
+// A pool should be shut down once it is not needed anymore, so that its workers exit.
+type ElasticPool struct {
+	tasks       *queue.BlockingQueue[task]
+	maxWorkers  int
+	idleTimeout time.Duration
+	rejection   RejectionPolicy
+	m           sync.Mutex
+	idle        int // workers waiting for a task, or starting
+	lifecycle   *lifecycle
+}
+
+// NewElasticPool creates an ElasticPool, which has up to maxWorkers workers, retired once idle for idleTimeout.
+// The pool has no workers until tasks are submitted. It may be further configured by passing options such as WithQueueSize.
+// NewElasticPool panics if maxWorkers or the queue size is less than 1, or idleTimeout is not positive.
+func NewElasticPool(maxWorkers int, idleTimeout time.Duration, opts ...Option) *ElasticPool {
+	if maxWorkers < 1 {
+		panic("executor: workers must be at least 1")
+	}
+	if idleTimeout <= 0 {
+		panic("executor: idle timeout must be positive")
+	}
+	o := options{queueSize: DefaultQueueSize}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.queueSize < 1 {
+		panic("executor: queue size must be at least 1")
+	}
+	return &ElasticPool{
+		tasks:       queue.NewBlocking[task](o.queueSize),
+		maxWorkers:  maxWorkers,
+		idleTimeout: idleTimeout,
+		rejection:   o.rejection,
+		lifecycle:   newLifecycle(0, o),
+	}
+}
+
+// Execute submits a task to be run by a worker of the pool, starting a worker if none is idle,
+// unless the pool has its maximum number of workers. If the queue is full, the task is handled according to the RejectionPolicy of the pool.
+// Execute returns ErrShutdown if the pool is shut down.
+func (pool *ElasticPool) Execute(fn func()) error {
+	return pool.execute(taskOf(fn))
+}
+
+// Workers returns the number of workers of the pool, which varies with the load.
+func (pool *ElasticPool) Workers() int {
+	return pool.lifecycle.workerCount()
+}
+
+// MaxWorkers returns the maximum number of workers of the pool.
+func (pool *ElasticPool) MaxWorkers() int {
+	return pool.maxWorkers
+}
+
+// Queued returns the number of tasks waiting for a worker.
+func (pool *ElasticPool) Queued() int {
+	return pool.tasks.Len()
+}
+
+// Shutdown shuts the pool down gracefully: it stops accepting tasks, while its workers keep running the queued ones,
+// then exit. Shutdown does not wait for that to happen, which AwaitTermination does. Shutting a pool down again has no effect.
+func (pool *ElasticPool) Shutdown() {
+	pool.m.Lock()
+	pool.tasks.Close()
+	// the queued tasks may need a worker, which must be started before the pool may terminate
+	pool.grow()
+	pool.m.Unlock()
+	pool.lifecycle.shut()
+}
+
+// ShutdownNow shuts the pool down, without running the queued tasks: it stops accepting tasks,
+// and aborts the tasks that were submitted with Submit, whose futures fail with ErrShutdown,
+// which cancels the context of those being run. The workers exit once they are done with their current task.
+//
+// ShutdownNow returns the queued tasks that were passed to Execute, which were not started.
+// Tasks passed to Execute that are being run cannot be interrupted.
+func (pool *ElasticPool) ShutdownNow() []func() {
+	running := pool.lifecycle.stop()
+	pool.tasks.Close()
+	var unstarted []func()
+	for _, task := range pool.tasks.DrainTo(0) {
+		if task.abort != nil {
+			task.discard(ErrShutdown)
+		} else {
+			unstarted = append(unstarted, task.fn())
+		}
+	}
+	for _, task := range running {
+		task.discard(ErrShutdown)
+	}
+	return unstarted
+}
+
+// AwaitTermination waits until a given timeout for the pool to terminate, once it is shut down,
+// and reports whether it did: all its workers have exited, so all the tasks it accepted are done.
+func (pool *ElasticPool) AwaitTermination(timeout time.Duration) bool {
+	return pool.lifecycle.awaitTermination(timeout)
+}
+
+// IsShutdown reports whether the pool was shut down.
+func (pool *ElasticPool) IsShutdown() bool {
+	return pool.lifecycle.isShutdown()
+}
+
+// IsTerminated reports whether the pool was shut down, and all its workers have exited.
+func (pool *ElasticPool) IsTerminated() bool {
+	return pool.lifecycle.isTerminated()
+}
+
+// Stats returns a snapshot of the state and counters of the pool.
+func (pool *ElasticPool) Stats() Stats {
+	stats := pool.lifecycle.stats()
+	stats.Workers = pool.lifecycle.workerCount()
+	stats.Queued = pool.tasks.Len()
+	return stats
+}
+
+// execute queues a task, or handles it according to the RejectionPolicy of the pool if the queue is full,
+// and starts a worker to run it if needed.
+func (pool *ElasticPool) execute(task task) error {
+	task.queued = time.Now()
+	callerRuns, err := enqueue(pool.tasks, pool.rejection, pool.lifecycle, task)
+	pool.lifecycle.submit(err == nil)
+	if callerRuns {
+		pool.lifecycle.run(&task)
+		return nil
+	}
+	if err == nil {
+		pool.m.Lock()
+		pool.grow()
+		pool.m.Unlock()
+	}
+	return err
+}
+
+// grow starts a worker if more tasks are queued than the idle workers may take,
+// unless the pool has its maximum number of workers. The worker is counted as idle until it takes a task.
+// This call must be guarded using the pool mutex.
+func (pool *ElasticPool) grow() {
+	if pool.tasks.Len() > pool.idle && pool.lifecycle.spawn(pool.maxWorkers) {
+		pool.idle++
+		go pool.work()
+	}
+}
+
+// work runs the tasks of the pool, one at a time, until it has been idle for the idle timeout,
+// or the pool is shut down and its queue is empty.
+func (pool *ElasticPool) work() {
+	for {
+		task, ok := pool.tasks.PollTimeout(pool.idleTimeout)
+		pool.m.Lock()
+		if !ok {
+			// the worker stays if the queued tasks need it, which a task queued as it timed out may
+			if pool.tasks.Len() < pool.idle {
+				pool.idle--
+				pool.lifecycle.exit()
+				pool.m.Unlock()
+				return
+			}
+			pool.m.Unlock()
+			continue
+		}
+		pool.idle--
+		// the other queued tasks may need another worker
+		pool.grow()
+		pool.m.Unlock()
+
+		pool.lifecycle.run(&task)
+
+		pool.m.Lock()
+		pool.idle++
+		pool.m.Unlock()
+	}
+}
@@ -0,0 +1,144 @@
+package executor
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestElasticPool(t *testing.T) {
+	pool := NewElasticPool(3, 50*time.Millisecond)
+	defer pool.Shutdown()
+	assertEqual(t, 0, pool.Workers())
+	assertEqual(t, 3, pool.MaxWorkers())
+
+	// workers are started on demand, up to the maximum
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		assertNil(t, pool.Execute(func() {
+			defer wg.Done()
+			started <- struct{}{}
+			<-release
+		}))
+	}
+	for i := 0; i < 3; i++ {
+		<-started
+	}
+	assertEqual(t, 3, pool.Workers())
+	assertEqual(t, 1, pool.Queued())
+	stats := pool.Stats()
+	assertEqual(t, 3, stats.Workers)
+	assertEqual(t, 3, stats.Active)
+	assertEqual(t, 1, stats.Queued)
+	close(release)
+	<-started
+	wg.Wait()
+
+	// idle workers are retired
+	waitUntil(t, func() bool {
+		return pool.Workers() == 0
+	})
+
+	// an idle worker runs the next task
+	for i := 0; i < 5; i++ {
+		i := i
+		assertEqual(t, i, must(Submit(pool, func(ctx context.Context) (int, error) {
+			return i, nil
+		}).Get()))
+		waitUntil(t, func() bool {
+			pool.m.Lock()
+			defer pool.m.Unlock()
+			return pool.idle == 1
+		})
+	}
+	assertEqual(t, 1, pool.Workers())
+}
+
+func TestElasticPool_concurrent(t *testing.T) {
+	pool := NewElasticPool(4, time.Millisecond)
+	var ran int32
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				pool.Execute(func() {
+					atomic.AddInt32(&ran, 1)
+				})
+				if j%10 == 0 {
+					time.Sleep(2 * time.Millisecond)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	assertEqual(t, true, pool.Workers() <= 4)
+	pool.Shutdown()
+	assertEqual(t, true, pool.AwaitTermination(time.Second))
+	assertEqual(t, int32(800), atomic.LoadInt32(&ran))
+}
+
+func TestElasticPool_shutdown(t *testing.T) {
+	// a pool with no workers terminates right away
+	pool := NewElasticPool(1, time.Minute)
+	pool.Shutdown()
+	assertEqual(t, true, pool.IsShutdown())
+	assertEqual(t, true, pool.AwaitTermination(time.Second))
+	assertEqual(t, ErrShutdown, pool.Execute(func() {}))
+
+	// the queued tasks are run before the pool terminates
+	pool = NewElasticPool(1, time.Minute)
+	var ran int32
+	for i := 0; i < 10; i++ {
+		pool.Execute(func() {
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&ran, 1)
+		})
+	}
+	pool.Shutdown()
+	assertEqual(t, true, pool.AwaitTermination(time.Second))
+	assertEqual(t, true, pool.IsTerminated())
+	assertEqual(t, int32(10), atomic.LoadInt32(&ran))
+
+	pool = NewElasticPool(1, time.Minute)
+	started := make(chan struct{})
+	running := Submit(pool, func(ctx context.Context) (int, error) {
+		close(started)
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	<-started
+	queued := Submit(pool, func(ctx context.Context) (int, error) {
+		return 1, nil
+	})
+	pool.Execute(func() {})
+	assertEqual(t, 1, len(pool.ShutdownNow()))
+	_, err := queued.Get()
+	assertEqual(t, ErrShutdown, err)
+	_, err = running.Get()
+	assertEqual(t, ErrShutdown, err)
+	assertEqual(t, true, pool.AwaitTermination(time.Second))
+	assertEqual(t, 0, pool.Workers())
+}
+
+func TestNewElasticPool_invalid(t *testing.T) {
+	for _, f := range []func(){
+		func() { NewElasticPool(0, time.Second) },
+		func() { NewElasticPool(1, 0) },
+		func() { NewElasticPool(1, time.Second, WithQueueSize(0)) },
+	} {
+		func() {
+			defer func() {
+				assertNotNil(t, recover())
+			}()
+			f()
+			t.Fatal("Did not panic")
+		}()
+	}
+}
@@ -0,0 +1,16 @@
+package executor
+
+import "errors"
+
+// These are errors related to executors.
+var (
+	// ErrRejected is returned when submitting a task to a pool whose queue is full, with the Reject policy
+	ErrRejected = errors.New("Task was rejected")
+
+	// ErrDiscarded is the error of the future of a task discarded by a pool, with the DiscardOldest policy
+	ErrDiscarded = errors.New("Task was discarded")
+
+	// ErrShutdown is returned when submitting a task to an executor that is shut down,
+	// and is the error of the future of a task aborted by ShutdownNow
+	ErrShutdown = errors.New("Executor is shut down")
+)
@@ -0,0 +1,127 @@
+// Package executor provides executors, pools of goroutines running tasks submitted to them,
+// with results delivered as futures.
+package executor
+
+import (
+	"context"
+	"time"
+
+	"github.com/nvn1729/congo/future"
+)
+
+// An Executor runs tasks asynchronously, such as a FixedPool.
+type Executor interface {
+	// Execute runs a task asynchronously, or returns an error if the executor cannot run it.
+	Execute(task func()) error
+}
+
+// An Option configures an executor at creation time.
+type Option func(*options)
+
+type options struct {
+	queueSize    int
+	rejection    RejectionPolicy
+	onPanic      func(err *future.PanicError)
+	onDiscard    func(task func())
+	crashOnPanic bool
+	hooks        Hooks
+}
+
+// A task is a task queued by an executor, with the function failing its future, if it has one, if the task is discarded.
+type task struct {
+	run    func() error
+	abort  func(err error)
+	queued time.Time // when the task was queued, or was due, for a scheduled task
+}
+
+// taskOf returns the task running a function passed to Execute.
+func taskOf(fn func()) task {
+	return task{run: func() error {
+		fn()
+		return nil
+	}}
+}
+
+// fn returns a function running the task, ignoring its error.
+func (task task) fn() func() {
+	return func() {
+		task.run()
+	}
+}
+
+// discard aborts a task that is not going to be run, failing its future with the given error.
+func (task task) discard(err error) {
+	if task.abort != nil {
+		task.abort(err)
+	}
+}
+
+// A taskExecutor is an executor of this package, which may discard the tasks submitted to it.
+type taskExecutor interface {
+	execute(task task) error
+}
+
+// Submit submits fn to be run by an executor, and returns a future completed with its result.
+//
+// The future is cancelable, as if created by future.NewPromiseContext: fn is passed a context,
+// which is canceled when the future is canceled, and fn should return early when it is done.
+// If the future is canceled before the executor starts running fn, fn is not run at all.
+// If the executor cannot run fn, the future fails with the error returned by Execute,
+// and if it discards fn, such as a pool with the DiscardOldest policy, the future fails with ErrDiscarded.
+func Submit[T any](executor Executor, fn func(ctx context.Context) (T, error)) *future.Future[T] {
+	return SubmitContext(context.Background(), executor, fn)
+}
+
+// SubmitTimeout is like Submit, but the task is given a deadline: its context is done once the timeout elapses,
+// counting from its submission, so including the time it waits for a worker.
+// The future is then canceled with context.DeadlineExceeded.
+func SubmitTimeout[T any](executor Executor, timeout time.Duration, fn func(ctx context.Context) (T, error)) *future.Future[T] {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	result := SubmitContext(ctx, executor, fn)
+	result.OnDone(func(T, error) {
+		cancel()
+	})
+	return result
+}
+
+// SubmitContext is like Submit, but the context of the task is derived from the given context,
+// which carries its deadline and values. If the context is done before the task completes,
+// the future is canceled with the context's error, and if the task fails once it is done, the future fails with the context's error.
+// If the context is already done, the task is not submitted.
+func SubmitContext[T any](parent context.Context, executor Executor, fn func(ctx context.Context) (T, error)) *future.Future[T] {
+	promise := future.NewPromiseContext[T](parent)
+	if parent.Err() != nil {
+		return promise.Future()
+	}
+	run := func() error {
+		ctx := promise.Context()
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// the future may have been completed already, if the task was aborted while running
+		value, err := fn(ctx)
+		if err != nil && parent.Err() != nil {
+			err = parent.Err()
+		}
+		if err != nil {
+			promise.TrySetError(err)
+			return err
+		}
+		promise.TrySet(value)
+		return nil
+	}
+	var err error
+	if tasks, ok := executor.(taskExecutor); ok {
+		err = tasks.execute(task{run: run, abort: func(err error) {
+			promise.TrySetError(err)
+		}})
+	} else {
+		err = executor.Execute(func() {
+			run()
+		})
+	}
+	if err != nil {
+		promise.TrySetError(err)
+	}
+	return promise.Future()
+}
@@ -0,0 +1,124 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSubmit(t *testing.T) {
+	pool := NewFixedPool(1)
+	value, err := Submit(pool, func(ctx context.Context) (int, error) {
+		return 1, nil
+	}).Get()
+	assertEqual(t, 1, value)
+	assertNil(t, err)
+
+	errFailed := errors.New("failed")
+	_, err = Submit(pool, func(ctx context.Context) (int, error) {
+		return 0, errFailed
+	}).Get()
+	assertEqual(t, errFailed, err)
+}
+
+func TestSubmit_cancel(t *testing.T) {
+	pool := NewFixedPool(1)
+	started := make(chan struct{})
+	running := Submit(pool, func(ctx context.Context) (int, error) {
+		close(started)
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	ran := false
+	queued := Submit(pool, func(ctx context.Context) (int, error) {
+		ran = true
+		return 1, nil
+	})
+	<-started
+
+	// a task that is not started yet is not run
+	assertEqual(t, true, queued.Cancel())
+	assertEqual(t, true, running.Cancel())
+	_, err := running.Get()
+	assertEqual(t, context.Canceled, err)
+	value, err := Submit(pool, func(ctx context.Context) (int, error) {
+		return 2, nil
+	}).Get()
+	assertEqual(t, 2, value)
+	assertNil(t, err)
+	assertEqual(t, false, ran)
+}
+
+func TestSubmitContext(t *testing.T) {
+	pool := NewFixedPool(1)
+	type key struct{}
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), key{}, 1))
+	value, err := SubmitContext(ctx, pool, func(ctx context.Context) (int, error) {
+		return ctx.Value(key{}).(int), nil
+	}).Get()
+	assertEqual(t, 1, value)
+	assertNil(t, err)
+
+	started := make(chan struct{})
+	running := SubmitContext(ctx, pool, func(ctx context.Context) (int, error) {
+		close(started)
+		<-ctx.Done()
+		return 0, errors.New("stopped")
+	})
+	<-started
+	cancel()
+	_, err = running.Get()
+	assertEqual(t, context.Canceled, err)
+
+	// a task whose context is done is not submitted
+	_, err = SubmitContext(ctx, rejectingExecutor{}, func(ctx context.Context) (int, error) {
+		return 1, nil
+	}).Get()
+	assertEqual(t, context.Canceled, err)
+}
+
+func TestSubmitTimeout(t *testing.T) {
+	pool := NewFixedPool(1)
+	value, err := SubmitTimeout(pool, time.Second, func(ctx context.Context) (int, error) {
+		_, ok := ctx.Deadline()
+		assertEqual(t, true, ok)
+		return 1, nil
+	}).Get()
+	assertEqual(t, 1, value)
+	assertNil(t, err)
+
+	running := SubmitTimeout(pool, 50*time.Millisecond, func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	// the deadline of a task includes the time it waits for a worker
+	ran := false
+	queued := SubmitTimeout(pool, 10*time.Millisecond, func(ctx context.Context) (int, error) {
+		ran = true
+		return 1, nil
+	})
+	_, err = running.Get()
+	assertEqual(t, context.DeadlineExceeded, err)
+	_, err = queued.Get()
+	assertEqual(t, context.DeadlineExceeded, err)
+	assertEqual(t, 2, must(Submit(pool, func(ctx context.Context) (int, error) {
+		return 2, nil
+	}).Get()))
+	assertEqual(t, false, ran)
+}
+
+type rejectingExecutor struct{}
+
+var errRejected = errors.New("rejected")
+
+func (rejectingExecutor) Execute(task func()) error {
+	return errRejected
+}
+
+func TestSubmit_rejected(t *testing.T) {
+	_, err := Submit(rejectingExecutor{}, func(ctx context.Context) (int, error) {
+		return 1, nil
+	}).GetTimeout(time.Second)
+	assertEqual(t, errRejected, err)
+}
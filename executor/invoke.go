@@ -0,0 +1,59 @@
+package executor
+
+import (
+	"context"
+
+	"github.com/nvn1729/congo/future"
+)
+
+// InvokeAll submits tasks to an executor, and waits for all of them to complete.
+// It returns their futures, in the same order as the tasks, all completed, whether the tasks succeeded or failed.
+//
+// The contexts of the tasks are derived from ctx, as with SubmitContext: once ctx is done,
+// InvokeAll stops waiting, and the futures of the tasks that are not completed yet are canceled with the context's error.
+func InvokeAll[T any](ctx context.Context, executor Executor, tasks []func(ctx context.Context) (T, error)) []*future.Future[T] {
+	futures := make([]*future.Future[T], len(tasks))
+	for i, fn := range tasks {
+		futures[i] = SubmitContext(ctx, executor, fn)
+	}
+	for _, result := range futures {
+		<-result.Done()
+	}
+	return futures
+}
+
+// InvokeAny submits tasks to an executor, and returns the value of the first one to succeed,
+// once it does. The other tasks are then canceled: those that are not started are not run,
+// and the contexts of those being run are canceled.
+//
+// If all the tasks fail, InvokeAny returns the error of the last one to fail.
+// The contexts of the tasks are derived from ctx, as with SubmitContext: once ctx is done,
+// InvokeAny stops waiting, cancels the tasks, and returns the context's error.
+// InvokeAny panics if no tasks are given, as it would have no value to return.
+func InvokeAny[T any](ctx context.Context, executor Executor, tasks []func(ctx context.Context) (T, error)) (T, error) {
+	if len(tasks) == 0 {
+		panic("executor: InvokeAny of no tasks")
+	}
+	type result struct {
+		value T
+		err   error
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make(chan result, len(tasks))
+	for _, fn := range tasks {
+		SubmitContext(ctx, executor, fn).OnDone(func(value T, err error) {
+			results <- result{value, err}
+		})
+	}
+	var err error
+	for range tasks {
+		result := <-results
+		if result.err == nil {
+			return result.value, nil
+		}
+		err = result.err
+	}
+	var zero T
+	return zero, err
+}
@@ -0,0 +1,144 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func ExampleInvokeAny() {
+	pool := NewFixedPool(2)
+	defer pool.Shutdown()
+	// query replicas, and keep the first answer
+	value, err := InvokeAny(context.Background(), pool, []func(ctx context.Context) (string, error){
+		func(ctx context.Context) (string, error) {
+			select {
+			case <-time.After(time.Second):
+				return "slow replica", nil
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+		},
+		func(ctx context.Context) (string, error) {
+			return "fast replica", nil
+		},
+	})
+	fmt.Println(value, err)
+	// Output:
+	// fast replica <nil>
+}
+
+func TestInvokeAll(t *testing.T) {
+	pool := NewFixedPool(2)
+	defer pool.Shutdown()
+	failure := errors.New("failure")
+	tasks := make([]func(ctx context.Context) (int, error), 10)
+	for i := range tasks {
+		i := i
+		tasks[i] = func(ctx context.Context) (int, error) {
+			time.Sleep(time.Duration(10-i) * time.Millisecond)
+			if i == 5 {
+				return 0, failure
+			}
+			return i, nil
+		}
+	}
+	futures := InvokeAll(context.Background(), pool, tasks)
+	assertEqual(t, 10, len(futures))
+	for i, result := range futures {
+		select {
+		case <-result.Done():
+		default:
+			t.Fatal("Future not completed")
+		}
+		value, err := result.Get()
+		if i == 5 {
+			assertEqual(t, failure, err)
+		} else {
+			assertEqual(t, i, value)
+		}
+	}
+	assertEqual(t, 0, len(InvokeAll[int](context.Background(), pool, nil)))
+}
+
+func TestInvokeAll_context(t *testing.T) {
+	pool := NewFixedPool(1)
+	defer pool.Shutdown()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	futures := InvokeAll(ctx, pool, []func(ctx context.Context) (int, error){
+		func(ctx context.Context) (int, error) {
+			return 1, nil
+		},
+		func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		},
+		func(ctx context.Context) (int, error) {
+			t.Error("Task run after the context was done")
+			return 3, nil
+		},
+	})
+	assertEqual(t, 1, must(futures[0].Get()))
+	for _, result := range futures[1:] {
+		_, err := result.Get()
+		assertEqual(t, context.DeadlineExceeded, err)
+	}
+}
+
+func TestInvokeAny(t *testing.T) {
+	pool := NewFixedPool(3)
+	defer pool.Shutdown()
+	failure := errors.New("failure")
+	canceled := make(chan error, 1)
+	value, err := InvokeAny(context.Background(), pool, []func(ctx context.Context) (int, error){
+		func(ctx context.Context) (int, error) {
+			return 0, failure
+		},
+		func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			canceled <- ctx.Err()
+			return 0, ctx.Err()
+		},
+		func(ctx context.Context) (int, error) {
+			time.Sleep(10 * time.Millisecond)
+			return 3, nil
+		},
+	})
+	assertEqual(t, 3, value)
+	assertNil(t, err)
+	// the other tasks are canceled
+	assertEqual(t, context.Canceled, <-canceled)
+
+	// the error of the last task to fail is returned if they all fail
+	_, err = InvokeAny(context.Background(), pool, []func(ctx context.Context) (int, error){
+		func(ctx context.Context) (int, error) {
+			return 0, errors.New("first")
+		},
+		func(ctx context.Context) (int, error) {
+			time.Sleep(20 * time.Millisecond)
+			return 0, failure
+		},
+	})
+	assertEqual(t, failure, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = InvokeAny(ctx, pool, []func(ctx context.Context) (int, error){
+		func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		},
+	})
+	assertEqual(t, context.DeadlineExceeded, err)
+}
+
+func TestInvokeAny_invalid(t *testing.T) {
+	defer func() {
+		assertNotNil(t, recover())
+	}()
+	InvokeAny[int](context.Background(), NewFixedPool(1), nil)
+	t.Fatal("Did not panic")
+}
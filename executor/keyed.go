@@ -0,0 +1,214 @@
+package executor
+
+import (
+	"sync"
+	"time"
+)
+
+// A KeyedPool is an executor running tasks on a fixed number of worker goroutines, where the tasks submitted with the same key
+// are run one at a time, in submission order, while tasks of different keys are run concurrently,
+// e.g. to apply the updates of each account in order, without serializing all of them.
+//
+// Each key with queued tasks takes turns with the others: a worker runs the first task of the key,
+// then queues the key again behind the other keys if it has more tasks, so that a busy key does not starve the others.
+// The queues of the keys are not bounded, so the pool never waits or rejects tasks, unless it is shut down.
+// A key takes no memory once its tasks are done. A pool should be shut down once it is not needed anymore, so that its workers exit.
+type KeyedPool[K comparable] struct {
+	m         sync.Mutex
+	notEmpty  *sync.Cond         // signaled when a key becomes ready
+	keys      map[K]*keyQueue[K] // keys with tasks queued or running
+	ready     []*keyQueue[K]     // keys with tasks queued, and none running, in the order they became ready
+	queued    int
+	workers   int
+	lifecycle *lifecycle
+}
+
+// A keyQueue is the queue of the tasks submitted with a key.
+type keyQueue[K comparable] struct {
+	key   K
+	tasks []task
+}
+
+// NewKeyedPool creates a KeyedPool, and starts its workers.
+// The pool may be further configured by passing options such as WithPanicHandler.
+// The options of a FixedPool's queue, WithQueueSize and WithRejectionPolicy, do not apply, as its queues are not bounded.
+// NewKeyedPool panics if workers is less than 1.
+func NewKeyedPool[K comparable](workers int, opts ...Option) *KeyedPool[K] {
+	if workers < 1 {
+		panic("executor: workers must be at least 1")
+	}
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	pool := &KeyedPool[K]{
+		keys:      make(map[K]*keyQueue[K]),
+		workers:   workers,
+		lifecycle: newLifecycle(workers, o),
+	}
+	pool.notEmpty = sync.NewCond(&pool.m)
+	for i := 0; i < workers; i++ {
+		go pool.work()
+	}
+	return pool
+}
+
+// Execute queues a task to be run by a worker of the pool, once the tasks previously submitted with the same key are done.
+// Execute returns ErrShutdown if the pool is shut down.
+func (pool *KeyedPool[K]) Execute(key K, fn func()) error {
+	return pool.execute(key, taskOf(fn))
+}
+
+// Key returns an Executor queuing tasks to the pool with the given key. Tasks may be passed to its Execute method, or to Submit:
+//
+//	result := executor.Submit(pool.Key(account), fn)
+func (pool *KeyedPool[K]) Key(key K) Executor {
+	return keyExecutor[K]{pool: pool, key: key}
+}
+
+// Workers returns the number of workers of the pool.
+func (pool *KeyedPool[K]) Workers() int {
+	return pool.workers
+}
+
+// Queued returns the number of tasks waiting for a worker, whatever their key.
+func (pool *KeyedPool[K]) Queued() int {
+	pool.m.Lock()
+	defer pool.m.Unlock()
+	return pool.queued
+}
+
+// Shutdown shuts the pool down gracefully: it stops accepting tasks, while its workers keep running the queued ones,
+// then exit. Shutdown does not wait for that to happen, which AwaitTermination does. Shutting a pool down again has no effect.
+func (pool *KeyedPool[K]) Shutdown() {
+	pool.m.Lock()
+	defer pool.m.Unlock()
+	pool.lifecycle.shut()
+	pool.notEmpty.Broadcast()
+}
+
+// ShutdownNow shuts the pool down, without running the queued tasks: it stops accepting tasks,
+// and aborts the tasks that were submitted with Submit, whose futures fail with ErrShutdown,
+// which cancels the context of those being run. The workers exit once they are done with their current task.
+//
+// ShutdownNow returns the queued tasks that were passed to Execute, which were not started, in submission order for each key.
+// Tasks passed to Execute that are being run cannot be interrupted.
+func (pool *KeyedPool[K]) ShutdownNow() []func() {
+	pool.m.Lock()
+	running := pool.lifecycle.stop()
+	var queued []task
+	for _, pending := range pool.keys {
+		queued = append(queued, pending.tasks...)
+		pending.tasks = nil
+	}
+	pool.ready = nil
+	pool.queued = 0
+	pool.notEmpty.Broadcast()
+	pool.m.Unlock()
+
+	var unstarted []func()
+	for _, task := range queued {
+		if task.abort != nil {
+			task.discard(ErrShutdown)
+		} else {
+			unstarted = append(unstarted, task.fn())
+		}
+	}
+	for _, task := range running {
+		task.discard(ErrShutdown)
+	}
+	return unstarted
+}
+
+// AwaitTermination waits until a given timeout for the pool to terminate, once it is shut down,
+// and reports whether it did: all its workers have exited, so all the tasks it accepted are done.
+func (pool *KeyedPool[K]) AwaitTermination(timeout time.Duration) bool {
+	return pool.lifecycle.awaitTermination(timeout)
+}
+
+// IsShutdown reports whether the pool was shut down.
+func (pool *KeyedPool[K]) IsShutdown() bool {
+	return pool.lifecycle.isShutdown()
+}
+
+// IsTerminated reports whether the pool was shut down, and all its workers have exited.
+func (pool *KeyedPool[K]) IsTerminated() bool {
+	return pool.lifecycle.isTerminated()
+}
+
+// Stats returns a snapshot of the state and counters of the pool.
+func (pool *KeyedPool[K]) Stats() Stats {
+	stats := pool.lifecycle.stats()
+	stats.Workers = pool.workers
+	stats.Queued = pool.Queued()
+	return stats
+}
+
+// execute queues a task behind the other tasks of its key, and makes the key ready if it has no other task.
+func (pool *KeyedPool[K]) execute(key K, task task) error {
+	task.queued = time.Now()
+	pool.m.Lock()
+	if pool.lifecycle.isShutdown() {
+		pool.m.Unlock()
+		pool.lifecycle.submit(false)
+		return ErrShutdown
+	}
+	pending, ok := pool.keys[key]
+	if !ok {
+		pending = &keyQueue[K]{key: key}
+		pool.keys[key] = pending
+		pool.ready = append(pool.ready, pending)
+		pool.notEmpty.Signal()
+	}
+	pending.tasks = append(pending.tasks, task)
+	pool.queued++
+	pool.m.Unlock()
+	pool.lifecycle.submit(true)
+	return nil
+}
+
+// work runs the first task of the ready keys, one at a time, until the pool is shut down and no key is ready.
+func (pool *KeyedPool[K]) work() {
+	defer pool.lifecycle.exit()
+	pool.m.Lock()
+	defer pool.m.Unlock()
+	for {
+		for len(pool.ready) == 0 {
+			if pool.lifecycle.isShutdown() {
+				return
+			}
+			pool.notEmpty.Wait()
+		}
+		pending := pool.ready[0]
+		pool.ready[0] = nil
+		pool.ready = pool.ready[1:]
+		next := pending.tasks[0]
+		pending.tasks[0] = task{}
+		pending.tasks = pending.tasks[1:]
+		pool.queued--
+		pool.m.Unlock()
+
+		pool.lifecycle.run(&next)
+
+		pool.m.Lock()
+		if len(pending.tasks) > 0 {
+			pool.ready = append(pool.ready, pending)
+		} else {
+			delete(pool.keys, pending.key)
+		}
+	}
+}
+
+// A keyExecutor queues tasks to a KeyedPool with a given key.
+type keyExecutor[K comparable] struct {
+	pool *KeyedPool[K]
+	key  K
+}
+
+func (executor keyExecutor[K]) Execute(fn func()) error {
+	return executor.pool.execute(executor.key, taskOf(fn))
+}
+
+func (executor keyExecutor[K]) execute(task task) error {
+	return executor.pool.execute(executor.key, task)
+}
@@ -0,0 +1,160 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nvn1729/congo/future"
+)
+
+func ExampleKeyedPool() {
+	pool := NewKeyedPool[string](4)
+	defer pool.Shutdown()
+	var m sync.Mutex
+	balances := map[string]int{}
+	var wg sync.WaitGroup
+	for _, update := range []struct {
+		account string
+		amount  int
+	}{{"alice", 100}, {"bob", 50}, {"alice", -30}, {"bob", -50}, {"alice", -70}} {
+		update := update
+		wg.Add(1)
+		// the updates of each account are applied in order
+		pool.Execute(update.account, func() {
+			defer wg.Done()
+			m.Lock()
+			defer m.Unlock()
+			balances[update.account] += update.amount
+			if balances[update.account] < 0 {
+				fmt.Println("Overdrawn", update.account)
+			}
+		})
+	}
+	wg.Wait()
+	fmt.Println(balances["alice"], balances["bob"])
+	// Output:
+	// 0 0
+}
+
+func TestKeyedPool_order(t *testing.T) {
+	pool := NewKeyedPool[int](4)
+	defer pool.Shutdown()
+	assertEqual(t, 4, pool.Workers())
+	const keys, tasks = 8, 100
+	var running [keys]int32
+	var m sync.Mutex
+	order := make(map[int][]int)
+	var wg sync.WaitGroup
+	for i := 0; i < tasks; i++ {
+		for key := 0; key < keys; key++ {
+			i, key := i, key
+			wg.Add(1)
+			assertNil(t, pool.Execute(key, func() {
+				defer wg.Done()
+				if atomic.AddInt32(&running[key], 1) != 1 {
+					t.Error("Tasks of the same key run concurrently")
+				}
+				m.Lock()
+				order[key] = append(order[key], i)
+				m.Unlock()
+				atomic.AddInt32(&running[key], -1)
+			}))
+		}
+	}
+	wg.Wait()
+	for key := 0; key < keys; key++ {
+		for i, value := range order[key] {
+			assertEqual(t, i, value)
+		}
+	}
+	// the keys are forgotten once their tasks are done
+	waitUntil(t, func() bool {
+		pool.m.Lock()
+		defer pool.m.Unlock()
+		return len(pool.keys) == 0
+	})
+}
+
+func TestKeyedPool_concurrent(t *testing.T) {
+	pool := NewKeyedPool[string](2)
+	defer pool.Shutdown()
+	// a key blocked on a task does not hold up the other keys
+	release := make(chan struct{})
+	pool.Execute("a", func() {
+		<-release
+	})
+	blocked := Submit(pool.Key("a"), func(ctx context.Context) (int, error) {
+		return 1, nil
+	})
+	assertEqual(t, 2, must(Submit(pool.Key("b"), func(ctx context.Context) (int, error) {
+		return 2, nil
+	}).GetTimeout(time.Second)))
+	assertEqual(t, 1, pool.Queued())
+	assertEqual(t, 1, pool.Stats().Queued)
+	close(release)
+	assertEqual(t, 1, must(blocked.Get()))
+}
+
+func TestKeyedPool_panic(t *testing.T) {
+	pool := NewKeyedPool[string](1, WithPanicHandler(func(err *future.PanicError) {}))
+	defer pool.Shutdown()
+	// the tasks following a panicking task still run
+	failed := Submit(pool.Key("a"), func(ctx context.Context) (int, error) {
+		panic("failure")
+	})
+	next := Submit(pool.Key("a"), func(ctx context.Context) (int, error) {
+		return 1, nil
+	})
+	_, err := failed.Get()
+	assertNotNil(t, err)
+	assertEqual(t, 1, must(next.Get()))
+}
+
+func TestKeyedPool_shutdown(t *testing.T) {
+	pool := NewKeyedPool[int](2)
+	var ran int32
+	for i := 0; i < 10; i++ {
+		pool.Execute(i%3, func() {
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&ran, 1)
+		})
+	}
+	pool.Shutdown()
+	assertEqual(t, true, pool.IsShutdown())
+	assertEqual(t, ErrShutdown, pool.Execute(0, func() {}))
+	assertEqual(t, true, pool.AwaitTermination(time.Second))
+	assertEqual(t, true, pool.IsTerminated())
+	assertEqual(t, int32(10), atomic.LoadInt32(&ran))
+
+	pool = NewKeyedPool[int](2)
+	started := make(chan struct{})
+	running := Submit(pool.Key(0), func(ctx context.Context) (int, error) {
+		close(started)
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	<-started
+	queued := Submit(pool.Key(0), func(ctx context.Context) (int, error) {
+		return 1, nil
+	})
+	pool.Execute(0, func() {})
+	assertEqual(t, 1, len(pool.ShutdownNow()))
+	_, err := queued.Get()
+	assertEqual(t, ErrShutdown, err)
+	_, err = running.Get()
+	assertEqual(t, ErrShutdown, err)
+	assertEqual(t, true, pool.AwaitTermination(time.Second))
+	assertEqual(t, 0, pool.Queued())
+}
+
+func TestNewKeyedPool_invalid(t *testing.T) {
+	defer func() {
+		assertNotNil(t, recover())
+	}()
+	NewKeyedPool[int](0)
+	t.Fatal("Did not panic")
+}
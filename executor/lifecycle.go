@@ -0,0 +1,277 @@
+package executor
+
+import (
+	"context"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/nvn1729/congo"
+	"github.com/nvn1729/congo/future"
+)
+
+// A lifecycle tracks the workers of an executor, and the tasks they run, to shut the executor down,
+// handles the panics of the tasks, and counts them for Stats.
+type lifecycle struct {
+	m          sync.Mutex
+	shutdown   bool
+	stopped    bool               // set by ShutdownNow, after which no task is started
+	running    map[*task]struct{} // tasks being run, that may be aborted
+	workers    int                // workers that have not exited
+	size       int                // workers that the executor should have, set by resize
+	idleCtx    context.Context    // canceled to wake the idle workers, once the executor has too many
+	wakeIdle   context.CancelFunc
+	terminated *congo.CountDownLatch
+	counters   Stats
+
+	onPanic      func(err *future.PanicError)
+	onDiscard    func(task func())
+	crashOnPanic bool
+	hooks        Hooks
+}
+
+func newLifecycle(workers int, o options) *lifecycle {
+	idleCtx, wakeIdle := context.WithCancel(context.Background())
+	return &lifecycle{
+		running:      make(map[*task]struct{}),
+		workers:      workers,
+		size:         workers,
+		idleCtx:      idleCtx,
+		wakeIdle:     wakeIdle,
+		terminated:   congo.NewCountDownLatch(1),
+		counters:     Stats{Created: time.Now()},
+		onPanic:      o.onPanic,
+		onDiscard:    o.onDiscard,
+		crashOnPanic: o.crashOnPanic,
+		hooks:        o.hooks,
+	}
+}
+
+// shut marks the executor as shut down, and reports whether it was not already.
+func (lifecycle *lifecycle) shut() bool {
+	lifecycle.m.Lock()
+	defer lifecycle.m.Unlock()
+	if lifecycle.shutdown {
+		return false
+	}
+	lifecycle.shutdown = true
+	lifecycle.terminate()
+	return true
+}
+
+// stop marks the executor as shut down and stopped, and returns the tasks being run that may be aborted.
+func (lifecycle *lifecycle) stop() []*task {
+	lifecycle.m.Lock()
+	defer lifecycle.m.Unlock()
+	lifecycle.shutdown, lifecycle.stopped = true, true
+	lifecycle.terminate()
+	running := make([]*task, 0, len(lifecycle.running))
+	for task := range lifecycle.running {
+		running = append(running, task)
+	}
+	return running
+}
+
+// submit counts a task submitted to the executor, which accepted it or not.
+func (lifecycle *lifecycle) submit(accepted bool) {
+	lifecycle.m.Lock()
+	defer lifecycle.m.Unlock()
+	if accepted {
+		lifecycle.counters.Submitted++
+	} else {
+		lifecycle.counters.Rejected++
+	}
+}
+
+// discard counts a queued task discarded to make room for a newer one, and fails its future with ErrDiscarded,
+// or hands it to the discard handler of the executor, if it was passed to Execute.
+func (lifecycle *lifecycle) discard(task task) {
+	lifecycle.m.Lock()
+	lifecycle.counters.Discarded++
+	lifecycle.m.Unlock()
+	switch {
+	case task.abort != nil:
+		task.discard(ErrDiscarded)
+	case lifecycle.onDiscard != nil:
+		lifecycle.onDiscard(task.fn())
+	}
+}
+
+// begin is called by a worker before running a task, and reports whether the task may run,
+// which it may not once the executor is stopped.
+func (lifecycle *lifecycle) begin(task *task) bool {
+	lifecycle.m.Lock()
+	if lifecycle.stopped {
+		lifecycle.m.Unlock()
+		return false
+	}
+	if task.abort != nil {
+		lifecycle.running[task] = struct{}{}
+	}
+	wait := time.Since(task.queued)
+	if wait < 0 {
+		wait = 0
+	}
+	lifecycle.counters.Active++
+	lifecycle.counters.QueueWait.observe(wait)
+	lifecycle.m.Unlock()
+
+	if lifecycle.hooks.OnStart != nil {
+		lifecycle.hooks.OnStart(wait)
+	}
+	return true
+}
+
+// end is called by a worker once it has run a task, with the time taken and its error.
+func (lifecycle *lifecycle) end(task *task, runTime time.Duration, err error) {
+	lifecycle.m.Lock()
+	delete(lifecycle.running, task)
+	lifecycle.counters.Active--
+	lifecycle.counters.Completed++
+	if err != nil {
+		lifecycle.counters.Failed++
+	}
+	lifecycle.counters.RunTime.observe(runTime)
+	lifecycle.m.Unlock()
+
+	if lifecycle.hooks.OnComplete != nil {
+		lifecycle.hooks.OnComplete(runTime, err)
+	}
+}
+
+// stats returns a snapshot of the counters of the executor.
+func (lifecycle *lifecycle) stats() Stats {
+	lifecycle.m.Lock()
+	defer lifecycle.m.Unlock()
+	stats := lifecycle.counters
+	stats.Time = time.Now()
+	return stats
+}
+
+// spawn counts a worker to be started, and reports whether it may be, which it may not if the executor
+// has the given maximum number of workers, or is stopped.
+func (lifecycle *lifecycle) spawn(max int) bool {
+	lifecycle.m.Lock()
+	defer lifecycle.m.Unlock()
+	if lifecycle.stopped || lifecycle.workers >= max {
+		return false
+	}
+	lifecycle.workers++
+	return true
+}
+
+// exit is called by a worker when it exits. The executor is terminated once it is shut down, and all its workers have exited.
+func (lifecycle *lifecycle) exit() {
+	lifecycle.m.Lock()
+	defer lifecycle.m.Unlock()
+	lifecycle.workers--
+	lifecycle.terminate()
+}
+
+// terminate marks the executor as terminated if it is shut down, and has no workers.
+// This call must be guarded using the lifecycle mutex.
+func (lifecycle *lifecycle) terminate() {
+	if lifecycle.shutdown && lifecycle.workers == 0 {
+		lifecycle.terminated.CountDown()
+	}
+}
+
+// workerCount returns the number of workers of the executor that have not exited.
+func (lifecycle *lifecycle) workerCount() int {
+	lifecycle.m.Lock()
+	defer lifecycle.m.Unlock()
+	return lifecycle.workers
+}
+
+// resize sets the number of workers that the executor should have, and returns the number of workers to start.
+// If the executor has too many workers, the idle ones are woken, and the excess workers exit once they are done with their current task.
+// Resizing a shut down executor has no effect.
+func (lifecycle *lifecycle) resize(size int) int {
+	lifecycle.m.Lock()
+	defer lifecycle.m.Unlock()
+	if lifecycle.shutdown {
+		return 0
+	}
+	lifecycle.size = size
+	if lifecycle.workers > size {
+		lifecycle.wakeIdle()
+		lifecycle.idleCtx, lifecycle.wakeIdle = context.WithCancel(context.Background())
+		return 0
+	}
+	start := size - lifecycle.workers
+	lifecycle.workers = size
+	return start
+}
+
+// idle is called by a worker before waiting for a task. It returns a context, which is canceled if the executor is resized
+// to fewer workers, and reports whether the worker may keep running: it may not if the executor has too many workers,
+// in which case it is counted as exited.
+func (lifecycle *lifecycle) idle() (context.Context, bool) {
+	lifecycle.m.Lock()
+	defer lifecycle.m.Unlock()
+	if lifecycle.workers > lifecycle.size {
+		lifecycle.workers--
+		return nil, false
+	}
+	return lifecycle.idleCtx, true
+}
+
+// poolSize returns the number of workers that the executor should have.
+func (lifecycle *lifecycle) poolSize() int {
+	lifecycle.m.Lock()
+	defer lifecycle.m.Unlock()
+	return lifecycle.size
+}
+
+func (lifecycle *lifecycle) isShutdown() bool {
+	lifecycle.m.Lock()
+	defer lifecycle.m.Unlock()
+	return lifecycle.shutdown
+}
+
+func (lifecycle *lifecycle) isTerminated() bool {
+	return lifecycle.terminated.Count() == 0
+}
+
+func (lifecycle *lifecycle) awaitTermination(timeout time.Duration) bool {
+	return lifecycle.terminated.WaitTimeout(timeout)
+}
+
+// run runs a task on behalf of a worker, unless the executor is stopped, in which case the task is discarded.
+func (lifecycle *lifecycle) run(task *task) {
+	if !lifecycle.begin(task) {
+		task.discard(ErrShutdown)
+		return
+	}
+	start := time.Now()
+	err := lifecycle.call(task)
+	lifecycle.end(task, time.Since(start), err)
+}
+
+// call runs a task, and returns its error. It handles the panic of the task, if it panics: unless the executor crashes on panics,
+// the panic is recovered, so that the worker running the task survives it,
+// and the future of the task fails with a *future.PanicError, which is returned.
+func (lifecycle *lifecycle) call(task *task) (err error) {
+	if lifecycle.crashOnPanic {
+		return task.run()
+	}
+	completed := false
+	defer func() {
+		if !completed {
+			panicErr := &future.PanicError{Value: recover(), Stack: debug.Stack()}
+			err = panicErr
+			task.discard(panicErr)
+			switch {
+			case lifecycle.onPanic != nil:
+				lifecycle.onPanic(panicErr)
+			case task.abort == nil:
+				// the panic is not reported by a future
+				log.Printf("executor: task panicked: %v\n%s", panicErr.Value, panicErr.Stack)
+			}
+		}
+	}()
+	err = task.run()
+	completed = true
+	return err
+}
@@ -0,0 +1,24 @@
+package executor
+
+import "github.com/nvn1729/congo/future"
+
+// WithPanicHandler sets a handler called with the panics of the tasks run by an executor, once they are recovered,
+// e.g. to report them to an error tracker.
+//
+// A panicking task never kills the worker running it. By default, the panic is recovered, and the future of the task,
+// if it was submitted with Submit, fails with a *future.PanicError. The panics of other tasks, whose futures cannot report them,
+// are logged with the log package, unless a handler is set. The handler is called by the worker running the task.
+func WithPanicHandler(handler func(err *future.PanicError)) Option {
+	return func(o *options) {
+		o.onPanic = handler
+	}
+}
+
+// WithCrashOnPanic makes the panics of the tasks run by an executor crash the process, as they would if the tasks
+// were run by goroutines of their own, rather than be recovered. It suits services that would rather fail fast
+// than keep running in a state that the panic may have corrupted.
+func WithCrashOnPanic() Option {
+	return func(o *options) {
+		o.crashOnPanic = true
+	}
+}
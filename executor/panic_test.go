@@ -0,0 +1,80 @@
+package executor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nvn1729/congo/future"
+)
+
+func TestWithPanicHandler(t *testing.T) {
+	// by default, the panic fails the future of the task
+	pool := NewFixedPool(1)
+	_, err := Submit(pool, func(ctx context.Context) (int, error) {
+		panic("failed")
+	}).Get()
+	panicErr, ok := err.(*future.PanicError)
+	assertEqual(t, true, ok)
+	assertEqual(t, "failed", panicErr.Value)
+	assertEqual(t, true, len(panicErr.Stack) > 0)
+
+	// the worker survives the panic
+	pool.Execute(func() {
+		panic("logged")
+	})
+	assertEqual(t, 1, must(Submit(pool, func(ctx context.Context) (int, error) {
+		return 1, nil
+	}).Get()))
+
+	panics := make(chan interface{}, 2)
+	pool = NewFixedPool(1, WithPanicHandler(func(err *future.PanicError) {
+		panics <- err.Value
+	}))
+	pool.Execute(func() {
+		panic(1)
+	})
+	_, err = Submit(pool, func(ctx context.Context) (int, error) {
+		panic(2)
+	}).Get()
+	assertNotNil(t, err)
+	assertEqual(t, 1, <-panics)
+	assertEqual(t, 2, <-panics)
+
+	// periodic tasks keep running
+	executor := NewScheduled(1, WithPanicHandler(func(err *future.PanicError) {
+		select {
+		case panics <- err.Value:
+		default:
+		}
+	}))
+	task := executor.ScheduleWithFixedDelay(0, time.Millisecond, func() {
+		panic(3)
+	})
+	assertEqual(t, 3, <-panics)
+	assertEqual(t, 3, <-panics)
+	task.Cancel()
+}
+
+func TestWithCrashOnPanic(t *testing.T) {
+	// the panic is not recovered, which is observed with a task run in the caller's goroutine
+	pool := NewFixedPool(1, WithQueueSize(1), WithRejectionPolicy(CallerRuns), WithCrashOnPanic())
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	pool.Execute(func() {
+		close(started)
+		<-release
+	})
+	<-started
+	pool.Execute(func() {
+		<-release
+	})
+	defer func() {
+		assertEqual(t, "crashed", recover())
+	}()
+	pool.Execute(func() {
+		panic("crashed")
+	})
+	t.Fatal("Did not panic")
+}
@@ -0,0 +1,245 @@
+package executor
+
+import (
+	"time"
+
+	"github.com/nvn1729/congo/queue"
+)
+
+// DefaultQueueSize is the number of tasks that a pool holds, waiting for a worker, unless set with WithQueueSize.
+const DefaultQueueSize = 1024
+
+// A FixedPool is an Executor running tasks on a fixed number of worker goroutines, which may be changed with Resize.
+//
+// Spawning a goroutine per task is cheap, but unbounded: a burst of tasks competes for the same resources,
+// such as connections or memory, all at once. A pool bounds the number of tasks running at a time to its number of workers,
+// and holds the others in a bounded queue, in submission order, until a worker is available.
+// What happens when a task is submitted once the queue is full depends on the RejectionPolicy of the pool.
+// By default, submitting the task waits for room in the queue, applying backpressure to the submitters.
+//
+// A pool should be shut down once it is not needed anymore, so that its workers exit.
+type FixedPool struct {
+	tasks     *queue.BlockingQueue[task]
+	rejection RejectionPolicy
+	lifecycle *lifecycle
+}
+
+// A RejectionPolicy determines what happens to a task submitted to a pool whose queue is full,
+// like the RejectedExecutionHandler of a java.util.concurrent.ThreadPoolExecutor.
+type RejectionPolicy int
+
+// These are the rejection policies of a pool.
+const (
+	// Block waits for room in the queue, applying backpressure to the submitters. It is the default policy.
+	Block RejectionPolicy = iota
+
+	// Reject rejects the task: Execute returns ErrRejected, and the future returned by Submit fails with it.
+	// It suits services that shed load rather than let requests wait.
+	Reject
+
+	// CallerRuns runs the task in the goroutine submitting it, which slows down the submitters without rejecting any task.
+	CallerRuns
+
+	// DiscardOldest discards the task that has waited in the queue the longest, to queue the new one.
+	// The future of the discarded task fails with ErrDiscarded, and a discarded task passed to Execute is handed
+	// to the handler set with WithDiscardHandler, if any. It suits tasks whose results go stale, such as refreshes.
+	DiscardOldest
+)
+
+// WithQueueSize sets the number of tasks that a pool holds, waiting for a worker. It defaults to DefaultQueueSize.
+func WithQueueSize(size int) Option {
+	return func(o *options) {
+		o.queueSize = size
+	}
+}
+
+// WithRejectionPolicy sets what happens to a task submitted to a pool whose queue is full. It defaults to Block.
+func WithRejectionPolicy(policy RejectionPolicy) Option {
+	return func(o *options) {
+		o.rejection = policy
+	}
+}
+
+// WithDiscardHandler sets a handler called with the tasks passed to Execute that a pool discards, with the DiscardOldest policy,
+// e.g. to log them or run them elsewhere. Tasks submitted with Submit are not passed to the handler, as their futures fail with ErrDiscarded.
+// The handler is called by the goroutine submitting the task that took the place of the discarded one.
+func WithDiscardHandler(handler func(task func())) Option {
+	return func(o *options) {
+		o.onDiscard = handler
+	}
+}
+
+// NewFixedPool creates a FixedPool, and starts its workers.
+// The pool may be further configured by passing options such as WithQueueSize.
+// NewFixedPool panics if workers or the queue size is less than 1.
+func NewFixedPool(workers int, opts ...Option) *FixedPool {
+	if workers < 1 {
+		panic("executor: workers must be at least 1")
+	}
+	o := options{queueSize: DefaultQueueSize}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.queueSize < 1 {
+		panic("executor: queue size must be at least 1")
+	}
+	pool := &FixedPool{
+		tasks:     queue.NewBlocking[task](o.queueSize),
+		rejection: o.rejection,
+		lifecycle: newLifecycle(workers, o),
+	}
+	for i := 0; i < workers; i++ {
+		go pool.work()
+	}
+	return pool
+}
+
+// Execute queues a task to be run by a worker of the pool. Tasks are started in the order they were queued.
+// If the queue is full, the task is handled according to the RejectionPolicy of the pool.
+// Execute returns ErrShutdown if the pool is shut down.
+func (pool *FixedPool) Execute(fn func()) error {
+	return pool.execute(taskOf(fn))
+}
+
+// Workers returns the number of workers of the pool.
+func (pool *FixedPool) Workers() int {
+	return pool.lifecycle.poolSize()
+}
+
+// Resize sets the number of workers of the pool, e.g. to follow the load without recreating the pool.
+// Workers are added immediately, while excess workers exit once they are done with their current task.
+// Resizing a shut down pool has no effect. Resize panics if workers is less than 1.
+func (pool *FixedPool) Resize(workers int) {
+	if workers < 1 {
+		panic("executor: workers must be at least 1")
+	}
+	for i := pool.lifecycle.resize(workers); i > 0; i-- {
+		go pool.work()
+	}
+}
+
+// Queued returns the number of tasks waiting for a worker.
+func (pool *FixedPool) Queued() int {
+	return pool.tasks.Len()
+}
+
+// Shutdown shuts the pool down gracefully: it stops accepting tasks, while its workers keep running the queued ones,
+// then exit. Shutdown does not wait for that to happen, which AwaitTermination does. Shutting a pool down again has no effect.
+func (pool *FixedPool) Shutdown() {
+	pool.lifecycle.shut()
+	pool.tasks.Close()
+}
+
+// ShutdownNow shuts the pool down, without running the queued tasks: it stops accepting tasks,
+// and aborts the tasks that were submitted with Submit, whose futures fail with ErrShutdown,
+// which cancels the context of those being run. The workers exit once they are done with their current task.
+//
+// ShutdownNow returns the queued tasks that were passed to Execute, which were not started.
+// Tasks passed to Execute that are being run cannot be interrupted.
+func (pool *FixedPool) ShutdownNow() []func() {
+	running := pool.lifecycle.stop()
+	pool.tasks.Close()
+	var unstarted []func()
+	for _, task := range pool.tasks.DrainTo(0) {
+		if task.abort != nil {
+			task.discard(ErrShutdown)
+		} else {
+			unstarted = append(unstarted, task.fn())
+		}
+	}
+	for _, task := range running {
+		task.discard(ErrShutdown)
+	}
+	return unstarted
+}
+
+// AwaitTermination waits until a given timeout for the pool to terminate, once it is shut down,
+// and reports whether it did: all its workers have exited, so all the tasks it accepted are done.
+func (pool *FixedPool) AwaitTermination(timeout time.Duration) bool {
+	return pool.lifecycle.awaitTermination(timeout)
+}
+
+// IsShutdown reports whether the pool was shut down.
+func (pool *FixedPool) IsShutdown() bool {
+	return pool.lifecycle.isShutdown()
+}
+
+// IsTerminated reports whether the pool was shut down, and all its workers have exited.
+func (pool *FixedPool) IsTerminated() bool {
+	return pool.lifecycle.isTerminated()
+}
+
+// Stats returns a snapshot of the state and counters of the pool.
+func (pool *FixedPool) Stats() Stats {
+	stats := pool.lifecycle.stats()
+	stats.Workers = pool.lifecycle.poolSize()
+	stats.Queued = pool.tasks.Len()
+	return stats
+}
+
+// execute queues a task, or handles it according to the RejectionPolicy of the pool if the queue is full.
+func (pool *FixedPool) execute(task task) error {
+	task.queued = time.Now()
+	callerRuns, err := enqueue(pool.tasks, pool.rejection, pool.lifecycle, task)
+	pool.lifecycle.submit(err == nil)
+	if callerRuns {
+		pool.lifecycle.run(&task)
+	}
+	return err
+}
+
+// enqueue queues a task, or handles it according to a RejectionPolicy if the queue is full,
+// and reports whether the task must be run by the caller. Discarded tasks are handed to the lifecycle of the executor.
+func enqueue(tasks *queue.BlockingQueue[task], rejection RejectionPolicy, lifecycle *lifecycle, task task) (bool, error) {
+	if rejection == Block {
+		if err := tasks.Put(task); err != nil {
+			return false, ErrShutdown
+		}
+		return false, nil
+	}
+	if tasks.Offer(task) {
+		return false, nil
+	}
+	if tasks.IsClosed() {
+		return false, ErrShutdown
+	}
+	switch rejection {
+	case CallerRuns:
+		return true, nil
+	case DiscardOldest:
+		for {
+			if oldest, ok := tasks.Poll(); ok {
+				lifecycle.discard(oldest)
+			}
+			if tasks.Offer(task) {
+				return false, nil
+			}
+			if tasks.IsClosed() {
+				return false, ErrShutdown
+			}
+		}
+	default:
+		return false, ErrRejected
+	}
+}
+
+// work runs the tasks of the pool, one at a time, until the pool is shut down and its queue is empty,
+// or the pool is resized to fewer workers.
+func (pool *FixedPool) work() {
+	for {
+		ctx, ok := pool.lifecycle.idle()
+		if !ok {
+			return
+		}
+		task, err := pool.tasks.TakeContext(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				// the pool was resized
+				continue
+			}
+			pool.lifecycle.exit()
+			return
+		}
+		pool.lifecycle.run(&task)
+	}
+}
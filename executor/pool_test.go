@@ -0,0 +1,382 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nvn1729/congo/future"
+)
+
+func ExampleFixedPool() {
+	// at most 4 downloads at a time
+	pool := NewFixedPool(4)
+	var sizes []*future.Future[int]
+	for i := 0; i < 10; i++ {
+		sizes = append(sizes, Submit(pool, func(ctx context.Context) (int, error) {
+			// download
+			// ...
+			return 100, nil
+		}))
+	}
+	total := 0
+	for _, size := range sizes {
+		n, _ := size.Get()
+		total += n
+	}
+	fmt.Println("Downloaded", total, "bytes")
+	// Output:
+	// Downloaded 1000 bytes
+}
+
+func TestFixedPool_workers(t *testing.T) {
+	const workers = 4
+	pool := NewFixedPool(workers)
+	assertEqual(t, workers, pool.Workers())
+	var running, maxRunning int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		assertNil(t, pool.Execute(func() {
+			defer wg.Done()
+			r := atomic.AddInt32(&running, 1)
+			for {
+				m := atomic.LoadInt32(&maxRunning)
+				if r <= m || atomic.CompareAndSwapInt32(&maxRunning, m, r) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&running, -1)
+		}))
+	}
+	wg.Wait()
+	assertEqual(t, int32(workers), maxRunning)
+}
+
+func TestFixedPool_queue(t *testing.T) {
+	pool := NewFixedPool(1, WithQueueSize(2))
+	release := make(chan struct{})
+	var order []int
+	var wg sync.WaitGroup
+	wg.Add(4)
+	pool.Execute(func() {
+		defer wg.Done()
+		<-release
+	})
+	for i := 1; i <= 2; i++ {
+		i := i
+		pool.Execute(func() {
+			defer wg.Done()
+			order = append(order, i)
+		})
+	}
+	time.Sleep(50 * time.Millisecond)
+	assertEqual(t, 2, pool.Queued())
+
+	// the queue is full
+	queued := make(chan struct{})
+	go func() {
+		pool.Execute(func() {
+			defer wg.Done()
+			order = append(order, 3)
+		})
+		close(queued)
+	}()
+	select {
+	case <-queued:
+		t.Fatal("Execute returned while the queue was full")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	<-queued
+	wg.Wait()
+
+	// tasks are started in order
+	assertEqual(t, 3, len(order))
+	for i, n := range order {
+		assertEqual(t, i+1, n)
+	}
+}
+
+func TestFixedPool_rejectionPolicy(t *testing.T) {
+	// saturate returns a pool whose worker is busy until release is closed, and whose queue is full
+	saturate := func(policy RejectionPolicy) (*FixedPool, *future.Future[int], chan struct{}) {
+		pool := NewFixedPool(1, WithQueueSize(1), WithRejectionPolicy(policy))
+		release := make(chan struct{})
+		started := make(chan struct{})
+		pool.Execute(func() {
+			close(started)
+			<-release
+		})
+		<-started
+		queued := Submit(pool, func(ctx context.Context) (int, error) {
+			return 1, nil
+		})
+		return pool, queued, release
+	}
+
+	pool, queued, release := saturate(Reject)
+	assertEqual(t, ErrRejected, pool.Execute(func() {}))
+	_, err := Submit(pool, func(ctx context.Context) (int, error) {
+		return 2, nil
+	}).Get()
+	assertEqual(t, ErrRejected, err)
+	close(release)
+	assertEqual(t, 1, must(queued.Get()))
+
+	pool, queued, release = saturate(CallerRuns)
+	value, err := Submit(pool, func(ctx context.Context) (int, error) {
+		return 2, nil
+	}).GetTimeout(0)
+	assertEqual(t, 2, value)
+	assertNil(t, err)
+	close(release)
+	assertEqual(t, 1, must(queued.Get()))
+
+	pool, queued, release = saturate(DiscardOldest)
+	newest := Submit(pool, func(ctx context.Context) (int, error) {
+		return 2, nil
+	})
+	_, err = queued.GetTimeout(0)
+	assertEqual(t, ErrDiscarded, err)
+	close(release)
+	assertEqual(t, 2, must(newest.Get()))
+
+	pool, queued, release = saturate(Block)
+	submitted := make(chan *future.Future[int])
+	go func() {
+		submitted <- Submit(pool, func(ctx context.Context) (int, error) {
+			return 2, nil
+		})
+	}()
+	select {
+	case <-submitted:
+		t.Fatal("Submit returned while the queue was full")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	assertEqual(t, 1, must(queued.Get()))
+	assertEqual(t, 2, must((<-submitted).Get()))
+}
+
+func TestWithDiscardHandler(t *testing.T) {
+	discarded := make(chan func(), 1)
+	pool := NewFixedPool(1, WithQueueSize(1), WithRejectionPolicy(DiscardOldest), WithDiscardHandler(func(task func()) {
+		discarded <- task
+	}))
+	release := make(chan struct{})
+	started := make(chan struct{})
+	pool.Execute(func() {
+		close(started)
+		<-release
+	})
+	<-started
+	ran := make(chan int, 3)
+	pool.Execute(func() {
+		ran <- 1
+	})
+	assertNil(t, pool.Execute(func() {
+		ran <- 2
+	}))
+
+	// the oldest task passed to Execute is handed to the handler, rather than vanishing
+	task := <-discarded
+	task()
+	assertEqual(t, 1, <-ran)
+	close(release)
+	assertEqual(t, 2, <-ran)
+	pool.Shutdown()
+	pool.AwaitTermination(time.Second)
+
+	stats := pool.Stats()
+	assertEqual(t, uint64(3), stats.Submitted)
+	assertEqual(t, uint64(1), stats.Discarded)
+	assertEqual(t, uint64(2), stats.Completed)
+}
+
+func TestFixedPool_resize(t *testing.T) {
+	pool := NewFixedPool(1)
+	defer pool.Shutdown()
+
+	// added workers run queued tasks right away
+	started := make(chan struct{})
+	release := make(chan struct{})
+	for i := 0; i < 3; i++ {
+		pool.Execute(func() {
+			started <- struct{}{}
+			<-release
+		})
+	}
+	<-started
+	pool.Resize(3)
+	assertEqual(t, 3, pool.Workers())
+	assertEqual(t, 3, pool.Stats().Workers)
+	<-started
+	<-started
+
+	// excess workers exit once they are done with their current task
+	pool.Resize(1)
+	assertEqual(t, 1, pool.Workers())
+	assertEqual(t, 3, liveWorkers(pool.lifecycle))
+	close(release)
+	waitUntil(t, func() bool {
+		return liveWorkers(pool.lifecycle) == 1
+	})
+	var running, maxRunning int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		pool.Execute(func() {
+			defer wg.Done()
+			if r := atomic.AddInt32(&running, 1); r > atomic.LoadInt32(&maxRunning) {
+				atomic.StoreInt32(&maxRunning, r)
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&running, -1)
+		})
+	}
+	wg.Wait()
+	assertEqual(t, int32(1), maxRunning)
+
+	// idle workers exit right away
+	pool.Resize(4)
+	assertEqual(t, 4, liveWorkers(pool.lifecycle))
+	pool.Resize(2)
+	waitUntil(t, func() bool {
+		return liveWorkers(pool.lifecycle) == 2
+	})
+
+	// a shut down pool is not resized, and terminates
+	pool.Shutdown()
+	pool.Resize(3)
+	assertEqual(t, 2, pool.Workers())
+	assertEqual(t, true, pool.AwaitTermination(time.Second))
+}
+
+func TestFixedPool_shutdown(t *testing.T) {
+	pool := NewFixedPool(2)
+	var ran int32
+	for i := 0; i < 10; i++ {
+		pool.Execute(func() {
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&ran, 1)
+		})
+	}
+	pool.Shutdown()
+	pool.Shutdown()
+	assertEqual(t, true, pool.IsShutdown())
+	assertEqual(t, ErrShutdown, pool.Execute(func() {}))
+	_, err := Submit(pool, func(ctx context.Context) (int, error) {
+		return 1, nil
+	}).Get()
+	assertEqual(t, ErrShutdown, err)
+
+	// the queued tasks are run before the pool terminates
+	assertEqual(t, false, pool.IsTerminated())
+	assertEqual(t, true, pool.AwaitTermination(time.Second))
+	assertEqual(t, true, pool.IsTerminated())
+	assertEqual(t, int32(10), atomic.LoadInt32(&ran))
+
+	pool = NewFixedPool(1, WithRejectionPolicy(CallerRuns))
+	pool.Shutdown()
+	assertEqual(t, ErrShutdown, pool.Execute(func() {
+		t.Fatal("Task run after shutdown")
+	}))
+}
+
+func TestFixedPool_shutdownNow(t *testing.T) {
+	pool := NewFixedPool(1)
+	started := make(chan struct{})
+	running := Submit(pool, func(ctx context.Context) (int, error) {
+		close(started)
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	<-started
+	queued := Submit(pool, func(ctx context.Context) (int, error) {
+		return 1, nil
+	})
+	var ran int32
+	pool.Execute(func() {
+		atomic.StoreInt32(&ran, 1)
+	})
+
+	unstarted := pool.ShutdownNow()
+	assertEqual(t, 1, len(unstarted))
+	_, err := queued.Get()
+	assertEqual(t, ErrShutdown, err)
+	_, err = running.Get()
+	assertEqual(t, ErrShutdown, err)
+	assertEqual(t, true, pool.AwaitTermination(time.Second))
+	assertEqual(t, int32(0), atomic.LoadInt32(&ran))
+
+	// the unstarted tasks may be run by the caller
+	unstarted[0]()
+	assertEqual(t, int32(1), atomic.LoadInt32(&ran))
+
+	// a pool that is not shut down does not terminate
+	assertEqual(t, false, NewFixedPool(1).AwaitTermination(50*time.Millisecond))
+}
+
+func TestNewFixedPool_invalid(t *testing.T) {
+	for _, f := range []func(){
+		func() { NewFixedPool(0) },
+		func() { NewFixedPool(1, WithQueueSize(0)) },
+		func() { NewFixedPool(1).Resize(0) },
+	} {
+		func() {
+			defer func() {
+				assertNotNil(t, recover())
+			}()
+			f()
+			t.Fatal("Did not panic")
+		}()
+	}
+}
+
+// liveWorkers returns the number of workers of an executor that have not exited.
+func liveWorkers(lifecycle *lifecycle) int {
+	lifecycle.m.Lock()
+	defer lifecycle.m.Unlock()
+	return lifecycle.workers
+}
+
+// waitUntil waits for a condition to hold, failing the test after a second.
+func waitUntil(t *testing.T, condition func() bool) {
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("Condition not met")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func assertEqual(t *testing.T, expected interface{}, actual interface{}) {
+	if expected != actual {
+		t.Fatal("Not equal:", "expected:", expected, ", actual:", actual)
+	}
+}
+
+func assertNil(t *testing.T, actual interface{}) {
+	if actual != nil {
+		t.Fatal("Value not nil, actual:", actual)
+	}
+}
+
+func assertNotNil(t *testing.T, actual interface{}) {
+	if actual == nil {
+		t.Fatal("Value is nil")
+	}
+}
+
+func must[T any](value T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
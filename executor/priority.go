@@ -0,0 +1,199 @@
+package executor
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/nvn1729/congo/queue"
+)
+
+// DefaultPriority is the priority of the tasks passed to the Execute method of a PriorityPool.
+const DefaultPriority = 0
+
+// A PriorityPool is an Executor running tasks on a fixed number of worker goroutines, which may be changed with Resize,
+// which always start the queued task with the highest priority, e.g. so that latency-critical work
+// jumps ahead of batch work sharing the same pool. Tasks of equal priority are started in the order they were queued.
+//
+// Tasks are queued in a queue.PriorityBlockingQueue, which is not bounded, so the pool never waits or rejects tasks,
+// unless it is shut down. A task of low priority may wait forever while tasks of higher priority keep being submitted.
+// A pool should be shut down once it is not needed anymore, so that its workers exit.
+type PriorityPool struct {
+	tasks     *queue.PriorityBlockingQueue[prioritized]
+	seq       uint64 // orders tasks of equal priority in the order they were queued, set atomically
+	lifecycle *lifecycle
+}
+
+// A prioritized is a task queued by a PriorityPool.
+type prioritized struct {
+	task
+	priority int
+	seq      uint64
+}
+
+// NewPriorityPool creates a PriorityPool, and starts its workers.
+// The pool may be further configured by passing options such as WithPanicHandler.
+// The options of a FixedPool's queue, WithQueueSize and WithRejectionPolicy, do not apply, as its queue is not bounded.
+// NewPriorityPool panics if workers is less than 1.
+func NewPriorityPool(workers int, opts ...Option) *PriorityPool {
+	if workers < 1 {
+		panic("executor: workers must be at least 1")
+	}
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	pool := &PriorityPool{
+		tasks: queue.NewPriority(func(a, b prioritized) bool {
+			if a.priority == b.priority {
+				return a.seq < b.seq
+			}
+			return a.priority > b.priority
+		}),
+		lifecycle: newLifecycle(workers, o),
+	}
+	for i := 0; i < workers; i++ {
+		go pool.work()
+	}
+	return pool
+}
+
+// Execute queues a task to be run by a worker of the pool, with DefaultPriority.
+// Execute returns ErrShutdown if the pool is shut down.
+func (pool *PriorityPool) Execute(fn func()) error {
+	return pool.execute(taskOf(fn))
+}
+
+// Priority returns an Executor queuing tasks to the pool with the given priority: the higher the priority,
+// the sooner the task is started. Tasks may be passed to its Execute method, or to Submit:
+//
+//	result := executor.Submit(pool.Priority(10), fn)
+func (pool *PriorityPool) Priority(priority int) Executor {
+	return priorityExecutor{pool: pool, priority: priority}
+}
+
+// Workers returns the number of workers of the pool.
+func (pool *PriorityPool) Workers() int {
+	return pool.lifecycle.poolSize()
+}
+
+// Resize sets the number of workers of the pool, e.g. to follow the load without recreating the pool.
+// Workers are added immediately, while excess workers exit once they are done with their current task.
+// Resizing a shut down pool has no effect. Resize panics if workers is less than 1.
+func (pool *PriorityPool) Resize(workers int) {
+	if workers < 1 {
+		panic("executor: workers must be at least 1")
+	}
+	for i := pool.lifecycle.resize(workers); i > 0; i-- {
+		go pool.work()
+	}
+}
+
+// Queued returns the number of tasks waiting for a worker.
+func (pool *PriorityPool) Queued() int {
+	return pool.tasks.Len()
+}
+
+// Shutdown shuts the pool down gracefully: it stops accepting tasks, while its workers keep running the queued ones,
+// then exit. Shutdown does not wait for that to happen, which AwaitTermination does. Shutting a pool down again has no effect.
+func (pool *PriorityPool) Shutdown() {
+	pool.lifecycle.shut()
+	pool.tasks.Close()
+}
+
+// ShutdownNow shuts the pool down, without running the queued tasks: it stops accepting tasks,
+// and aborts the tasks that were submitted with Submit, whose futures fail with ErrShutdown,
+// which cancels the context of those being run. The workers exit once they are done with their current task.
+//
+// ShutdownNow returns the queued tasks that were passed to Execute, which were not started, in priority order.
+// Tasks passed to Execute that are being run cannot be interrupted.
+func (pool *PriorityPool) ShutdownNow() []func() {
+	running := pool.lifecycle.stop()
+	pool.tasks.Close()
+	var unstarted []func()
+	for _, task := range pool.tasks.DrainTo(0) {
+		if task.abort != nil {
+			task.discard(ErrShutdown)
+		} else {
+			unstarted = append(unstarted, task.fn())
+		}
+	}
+	for _, task := range running {
+		task.discard(ErrShutdown)
+	}
+	return unstarted
+}
+
+// AwaitTermination waits until a given timeout for the pool to terminate, once it is shut down,
+// and reports whether it did: all its workers have exited, so all the tasks it accepted are done.
+func (pool *PriorityPool) AwaitTermination(timeout time.Duration) bool {
+	return pool.lifecycle.awaitTermination(timeout)
+}
+
+// IsShutdown reports whether the pool was shut down.
+func (pool *PriorityPool) IsShutdown() bool {
+	return pool.lifecycle.isShutdown()
+}
+
+// IsTerminated reports whether the pool was shut down, and all its workers have exited.
+func (pool *PriorityPool) IsTerminated() bool {
+	return pool.lifecycle.isTerminated()
+}
+
+// Stats returns a snapshot of the state and counters of the pool.
+func (pool *PriorityPool) Stats() Stats {
+	stats := pool.lifecycle.stats()
+	stats.Workers = pool.lifecycle.poolSize()
+	stats.Queued = pool.tasks.Len()
+	return stats
+}
+
+// execute queues a task with DefaultPriority.
+func (pool *PriorityPool) execute(task task) error {
+	return pool.enqueue(task, DefaultPriority)
+}
+
+// enqueue queues a task with the given priority.
+func (pool *PriorityPool) enqueue(task task, priority int) error {
+	task.queued = time.Now()
+	err := pool.tasks.Put(prioritized{task: task, priority: priority, seq: atomic.AddUint64(&pool.seq, 1)})
+	if err != nil {
+		err = ErrShutdown
+	}
+	pool.lifecycle.submit(err == nil)
+	return err
+}
+
+// work runs the tasks of the pool, one at a time, until the pool is shut down and its queue is empty,
+// or the pool is resized to fewer workers.
+func (pool *PriorityPool) work() {
+	for {
+		ctx, ok := pool.lifecycle.idle()
+		if !ok {
+			return
+		}
+		queued, err := pool.tasks.TakeContext(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				// the pool was resized
+				continue
+			}
+			pool.lifecycle.exit()
+			return
+		}
+		pool.lifecycle.run(&queued.task)
+	}
+}
+
+// A priorityExecutor queues tasks to a PriorityPool with a given priority.
+type priorityExecutor struct {
+	pool     *PriorityPool
+	priority int
+}
+
+func (executor priorityExecutor) Execute(fn func()) error {
+	return executor.pool.enqueue(taskOf(fn), executor.priority)
+}
+
+func (executor priorityExecutor) execute(task task) error {
+	return executor.pool.enqueue(task, executor.priority)
+}
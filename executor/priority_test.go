@@ -0,0 +1,179 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func ExamplePriorityPool() {
+	pool := NewPriorityPool(1)
+	// hold the worker, while tasks are queued
+	release := make(chan struct{})
+	pool.Execute(func() {
+		<-release
+	})
+	var done sync.WaitGroup
+	for _, name := range []string{"reindex", "backup"} {
+		name := name
+		done.Add(1)
+		pool.Execute(func() {
+			defer done.Done()
+			fmt.Println("Ran", name)
+		})
+	}
+	done.Add(1)
+	pool.Priority(10).Execute(func() {
+		defer done.Done()
+		fmt.Println("Ran page oncall")
+	})
+	close(release)
+	done.Wait()
+	// Output:
+	// Ran page oncall
+	// Ran reindex
+	// Ran backup
+}
+
+func TestPriorityPool_order(t *testing.T) {
+	pool := NewPriorityPool(1)
+	defer pool.Shutdown()
+	assertEqual(t, 1, pool.Workers())
+	started, release := make(chan struct{}), make(chan struct{})
+	pool.Execute(func() {
+		close(started)
+		<-release
+	})
+	<-started
+
+	var m sync.Mutex
+	var order []int
+	var wg sync.WaitGroup
+	for i, priority := range []int{0, 5, -1, 5, 10, 0} {
+		i := i
+		wg.Add(1)
+		assertNil(t, pool.Priority(priority).Execute(func() {
+			defer wg.Done()
+			m.Lock()
+			defer m.Unlock()
+			order = append(order, i)
+		}))
+	}
+	assertEqual(t, 6, pool.Queued())
+	assertEqual(t, 6, pool.Stats().Queued)
+	close(release)
+	wg.Wait()
+	// by priority, then in the order they were queued
+	assertEqual(t, "[4 1 3 0 5 2]", fmt.Sprint(order))
+}
+
+func TestPriorityPool_submit(t *testing.T) {
+	pool := NewPriorityPool(1)
+	defer pool.Shutdown()
+	release := make(chan struct{})
+	pool.Execute(func() {
+		<-release
+	})
+	low := Submit(pool.Priority(-1), func(ctx context.Context) (time.Time, error) {
+		return time.Now(), nil
+	})
+	high := SubmitTimeout(pool.Priority(1), time.Second, func(ctx context.Context) (time.Time, error) {
+		return time.Now(), nil
+	})
+	close(release)
+	assertEqual(t, true, must(high.Get()).Before(must(low.Get())))
+
+	// an unstarted task whose future is canceled is not run
+	release = make(chan struct{})
+	defer close(release)
+	pool.Execute(func() {
+		<-release
+	})
+	canceled := Submit(pool.Priority(1), func(ctx context.Context) (int, error) {
+		t.Error("Canceled task was run")
+		return 0, nil
+	})
+	canceled.Cancel()
+	_, err := canceled.Get()
+	assertNotNil(t, err)
+}
+
+func TestPriorityPool_shutdown(t *testing.T) {
+	pool := NewPriorityPool(1)
+	release := make(chan struct{})
+	pool.Execute(func() {
+		<-release
+	})
+	var ran []int
+	for i := 0; i < 3; i++ {
+		i := i
+		pool.Priority(i).Execute(func() {
+			ran = append(ran, i)
+		})
+	}
+	pool.Shutdown()
+	assertEqual(t, true, pool.IsShutdown())
+	assertEqual(t, ErrShutdown, pool.Execute(func() {}))
+	_, err := Submit(pool.Priority(1), func(ctx context.Context) (int, error) {
+		return 1, nil
+	}).Get()
+	assertEqual(t, ErrShutdown, err)
+	close(release)
+	assertEqual(t, true, pool.AwaitTermination(time.Second))
+	assertEqual(t, true, pool.IsTerminated())
+	assertEqual(t, "[2 1 0]", fmt.Sprint(ran))
+	assertEqual(t, uint64(2), pool.Stats().Rejected)
+
+	pool = NewPriorityPool(1)
+	started := make(chan struct{})
+	running := Submit(pool, func(ctx context.Context) (int, error) {
+		close(started)
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	<-started
+	queued := Submit(pool.Priority(1), func(ctx context.Context) (int, error) {
+		return 1, nil
+	})
+	pool.Execute(func() {})
+	assertEqual(t, 1, len(pool.ShutdownNow()))
+	_, err = queued.Get()
+	assertEqual(t, ErrShutdown, err)
+	_, err = running.Get()
+	assertEqual(t, ErrShutdown, err)
+	assertEqual(t, true, pool.AwaitTermination(time.Second))
+	assertEqual(t, 0, pool.Queued())
+}
+
+func TestPriorityPool_resize(t *testing.T) {
+	pool := NewPriorityPool(1)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	for i := 0; i < 2; i++ {
+		pool.Execute(func() {
+			started <- struct{}{}
+			<-release
+		})
+	}
+	<-started
+	pool.Resize(2)
+	assertEqual(t, 2, pool.Workers())
+	<-started
+	pool.Resize(1)
+	close(release)
+	waitUntil(t, func() bool {
+		return liveWorkers(pool.lifecycle) == 1
+	})
+	pool.Shutdown()
+	assertEqual(t, true, pool.AwaitTermination(time.Second))
+}
+
+func TestNewPriorityPool_invalid(t *testing.T) {
+	defer func() {
+		assertNotNil(t, recover())
+	}()
+	NewPriorityPool(0)
+	t.Fatal("Did not panic")
+}
@@ -0,0 +1,280 @@
+package executor
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nvn1729/congo/queue"
+)
+
+// A ScheduledExecutor is an Executor running tasks after a delay, or periodically, on a fixed number of worker goroutines,
+// so that deferred and periodic work does not require a goroutine with its own time.Timer or time.Ticker.
+//
+// Scheduled tasks are held in a queue.DelayQueue until they are due, and run in the order they are due.
+// A periodic task is run by one worker at a time: it is only scheduled again once its run is over, so its runs never overlap.
+//
+// An executor should be shut down once it is not needed anymore, so that its workers exit.
+type ScheduledExecutor struct {
+	m         sync.Mutex // orders scheduling tasks and shutting down
+	tasks     *queue.DelayQueue[*ScheduledTask]
+	workers   int
+	lifecycle *lifecycle
+}
+
+// A ScheduledTask is a handle to a task scheduled with a ScheduledExecutor, to cancel it.
+type ScheduledTask struct {
+	task
+	at        time.Time     // when the task is due, only used by the worker running it
+	period    time.Duration // between the runs of a periodic task, or 0 for a one-shot task
+	fixedRate bool
+	state     int32 // set atomically
+	doneCh    chan struct{}
+	tasks     *queue.DelayQueue[*ScheduledTask] // of the executor, from which Cancel removes the task
+}
+
+// These are the states of a ScheduledTask.
+const (
+	taskScheduled int32 = iota
+	taskRunning         // a one-shot task, which can no longer be canceled
+	taskDone
+	taskCanceled
+)
+
+// NewScheduled creates a ScheduledExecutor, and starts its workers.
+// The executor may be further configured by passing options such as WithPanicHandler.
+// The options of a pool's queue, WithQueueSize and WithRejectionPolicy, do not apply, as scheduled tasks are not bounded.
+// NewScheduled panics if workers is less than 1.
+func NewScheduled(workers int, opts ...Option) *ScheduledExecutor {
+	if workers < 1 {
+		panic("executor: workers must be at least 1")
+	}
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	executor := &ScheduledExecutor{
+		tasks:     queue.NewDelay[*ScheduledTask](),
+		workers:   workers,
+		lifecycle: newLifecycle(workers, o),
+	}
+	for i := 0; i < workers; i++ {
+		go executor.work()
+	}
+	return executor
+}
+
+// Execute queues a task to be run by a worker as soon as possible, like Schedule with no delay.
+// Execute returns ErrShutdown if the executor is shut down.
+func (executor *ScheduledExecutor) Execute(fn func()) error {
+	return executor.execute(taskOf(fn))
+}
+
+// Schedule schedules a task to be run once, after the given delay.
+// If the executor is shut down, the task is canceled rather than scheduled.
+func (executor *ScheduledExecutor) Schedule(delay time.Duration, fn func()) *ScheduledTask {
+	scheduled := &ScheduledTask{task: taskOf(fn)}
+	executor.schedule(scheduled, delay)
+	return scheduled
+}
+
+// ScheduleAtFixedRate schedules a task to be run periodically, first after the given initial delay,
+// then every period after the time the previous run was due, regardless of how long the runs take.
+// If a run takes longer than the period, the next one starts late, once it is over.
+//
+// The task is run until it is canceled, or the executor is shut down. ScheduleAtFixedRate panics if period is not positive.
+func (executor *ScheduledExecutor) ScheduleAtFixedRate(initialDelay, period time.Duration, fn func()) *ScheduledTask {
+	if period <= 0 {
+		panic("executor: period must be positive")
+	}
+	scheduled := &ScheduledTask{task: taskOf(fn), period: period, fixedRate: true}
+	executor.schedule(scheduled, initialDelay)
+	return scheduled
+}
+
+// ScheduleWithFixedDelay schedules a task to be run periodically, first after the given initial delay,
+// then with the given delay between the end of a run and the start of the next.
+//
+// The task is run until it is canceled, or the executor is shut down. ScheduleWithFixedDelay panics if delay is not positive.
+func (executor *ScheduledExecutor) ScheduleWithFixedDelay(initialDelay, delay time.Duration, fn func()) *ScheduledTask {
+	if delay <= 0 {
+		panic("executor: delay must be positive")
+	}
+	scheduled := &ScheduledTask{task: taskOf(fn), period: delay}
+	executor.schedule(scheduled, initialDelay)
+	return scheduled
+}
+
+// Workers returns the number of workers of the executor.
+func (executor *ScheduledExecutor) Workers() int {
+	return executor.workers
+}
+
+// Shutdown shuts the executor down gracefully: it stops accepting tasks, and cancels the periodic tasks,
+// while its workers keep running the one-shot tasks as they are due, then exit.
+// Shutdown does not wait for that to happen, which AwaitTermination does. Shutting an executor down again has no effect.
+func (executor *ScheduledExecutor) Shutdown() {
+	executor.m.Lock()
+	defer executor.m.Unlock()
+	if !executor.lifecycle.shut() {
+		return
+	}
+	for _, task := range executor.tasks.Clear() {
+		if task.period == 0 && !task.IsCanceled() {
+			executor.tasks.PutAt(task, task.at)
+		} else {
+			task.Cancel()
+		}
+	}
+	executor.tasks.Close()
+}
+
+// ShutdownNow shuts the executor down, without running the scheduled tasks: it stops accepting tasks, cancels the scheduled ones,
+// and aborts the tasks that were submitted with Submit, whose futures fail with ErrShutdown,
+// which cancels the context of those being run. The workers exit once they are done with their current task.
+//
+// ShutdownNow returns the scheduled tasks, other than those submitted with Submit, which were not started.
+// Tasks that are being run, other than those submitted with Submit, cannot be interrupted.
+func (executor *ScheduledExecutor) ShutdownNow() []func() {
+	executor.m.Lock()
+	running := executor.lifecycle.stop()
+	scheduled := executor.tasks.Clear()
+	executor.tasks.Close()
+	executor.m.Unlock()
+
+	var unstarted []func()
+	for _, task := range scheduled {
+		if !task.Cancel() {
+			continue
+		}
+		if task.abort != nil {
+			task.discard(ErrShutdown)
+		} else {
+			unstarted = append(unstarted, task.fn())
+		}
+	}
+	for _, task := range running {
+		task.discard(ErrShutdown)
+	}
+	return unstarted
+}
+
+// AwaitTermination waits until a given timeout for the executor to terminate, once it is shut down,
+// and reports whether it did: all its workers have exited, so all the tasks it accepted are done.
+func (executor *ScheduledExecutor) AwaitTermination(timeout time.Duration) bool {
+	return executor.lifecycle.awaitTermination(timeout)
+}
+
+// IsShutdown reports whether the executor was shut down.
+func (executor *ScheduledExecutor) IsShutdown() bool {
+	return executor.lifecycle.isShutdown()
+}
+
+// IsTerminated reports whether the executor was shut down, and all its workers have exited.
+func (executor *ScheduledExecutor) IsTerminated() bool {
+	return executor.lifecycle.isTerminated()
+}
+
+// Stats returns a snapshot of the state and counters of the executor.
+func (executor *ScheduledExecutor) Stats() Stats {
+	stats := executor.lifecycle.stats()
+	stats.Workers = executor.workers
+	stats.Queued = executor.tasks.Len()
+	return stats
+}
+
+// Cancel cancels the task, so that it is not run anymore, and reports whether it did.
+// A run of the task that has already started is not interrupted, but a periodic task is not scheduled again.
+// Cancel returns false if the task is already canceled, or is a one-shot task that has already started.
+//
+// A canceled task is removed from the executor at once, so that it does not hold the task until it is due.
+func (task *ScheduledTask) Cancel() bool {
+	if !atomic.CompareAndSwapInt32(&task.state, taskScheduled, taskCanceled) {
+		return false
+	}
+	close(task.doneCh)
+	if task.tasks != nil {
+		task.tasks.Remove(func(scheduled *ScheduledTask) bool {
+			return scheduled == task
+		})
+	}
+	return true
+}
+
+// IsCanceled reports whether the task was canceled.
+func (task *ScheduledTask) IsCanceled() bool {
+	return atomic.LoadInt32(&task.state) == taskCanceled
+}
+
+// Done returns a channel that is closed once a one-shot task has run, or once the task is canceled.
+func (task *ScheduledTask) Done() <-chan struct{} {
+	return task.doneCh
+}
+
+// execute queues a task to be run as soon as possible.
+func (executor *ScheduledExecutor) execute(task task) error {
+	return executor.schedule(&ScheduledTask{task: task}, 0)
+}
+
+// schedule queues a task to be run after the given delay, or cancels it if the executor is shut down.
+func (executor *ScheduledExecutor) schedule(task *ScheduledTask, delay time.Duration) error {
+	task.doneCh = make(chan struct{})
+	task.tasks = executor.tasks
+	task.at = time.Now().Add(delay)
+	task.queued = task.at
+	executor.m.Lock()
+	defer executor.m.Unlock()
+	if executor.lifecycle.isShutdown() {
+		executor.lifecycle.submit(false)
+		task.Cancel()
+		return ErrShutdown
+	}
+	executor.lifecycle.submit(true)
+	return executor.tasks.PutAt(task, task.at)
+}
+
+// work runs the tasks of the executor as they are due, one at a time,
+// until the executor is shut down and no task is scheduled anymore.
+func (executor *ScheduledExecutor) work() {
+	defer executor.lifecycle.exit()
+	for {
+		task, err := executor.tasks.Take()
+		if err != nil {
+			return
+		}
+		executor.run(task)
+	}
+}
+
+// run runs a task that is due, then schedules its next run if it is periodic.
+func (executor *ScheduledExecutor) run(task *ScheduledTask) {
+	if task.period == 0 {
+		if !atomic.CompareAndSwapInt32(&task.state, taskScheduled, taskRunning) {
+			return
+		}
+		executor.lifecycle.run(&task.task)
+		atomic.StoreInt32(&task.state, taskDone)
+		close(task.doneCh)
+		return
+	}
+
+	if task.IsCanceled() {
+		return
+	}
+	executor.lifecycle.run(&task.task)
+	if task.fixedRate {
+		task.at = task.at.Add(task.period)
+	} else {
+		task.at = time.Now().Add(task.period)
+	}
+	task.queued = task.at
+	executor.m.Lock()
+	defer executor.m.Unlock()
+	if executor.lifecycle.isShutdown() {
+		task.Cancel()
+		return
+	}
+	if !task.IsCanceled() {
+		executor.tasks.PutAt(task, task.at)
+	}
+}
@@ -0,0 +1,179 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func ExampleScheduledExecutor() {
+	executor := NewScheduled(1)
+	reminder := executor.Schedule(10*time.Millisecond, func() {
+		fmt.Println("Reminder")
+	})
+	<-reminder.Done()
+	// Output:
+	// Reminder
+}
+
+func TestScheduledExecutor_schedule(t *testing.T) {
+	executor := NewScheduled(2)
+	assertEqual(t, 2, executor.Workers())
+	start := time.Now()
+	var ranAt atomic.Value
+	task := executor.Schedule(50*time.Millisecond, func() {
+		ranAt.Store(time.Now())
+	})
+	<-task.Done()
+	assertEqual(t, true, ranAt.Load().(time.Time).Sub(start) >= 50*time.Millisecond)
+	assertEqual(t, false, task.Cancel())
+	assertEqual(t, false, task.IsCanceled())
+
+	ran := make(chan struct{})
+	assertNil(t, executor.Execute(func() {
+		close(ran)
+	}))
+	<-ran
+}
+
+func TestScheduledExecutor_order(t *testing.T) {
+	executor := NewScheduled(1)
+	order := make(chan int, 3)
+	for _, i := range []int{3, 1, 2} {
+		i := i
+		executor.Schedule(time.Duration(i)*20*time.Millisecond, func() {
+			order <- i
+		})
+	}
+	for i := 1; i <= 3; i++ {
+		assertEqual(t, i, <-order)
+	}
+}
+
+func TestScheduledExecutor_cancel(t *testing.T) {
+	executor := NewScheduled(1)
+	var ran int32
+	task := executor.Schedule(50*time.Millisecond, func() {
+		atomic.StoreInt32(&ran, 1)
+	})
+	assertEqual(t, 1, executor.Stats().Queued)
+	assertEqual(t, true, task.Cancel())
+	assertEqual(t, false, task.Cancel())
+	assertEqual(t, true, task.IsCanceled())
+	// a canceled task is removed from the executor before it is due
+	assertEqual(t, 0, executor.Stats().Queued)
+	<-task.Done()
+	time.Sleep(100 * time.Millisecond)
+	assertEqual(t, int32(0), atomic.LoadInt32(&ran))
+}
+
+func TestScheduledExecutor_fixedRate(t *testing.T) {
+	executor := NewScheduled(2)
+	var runs int32
+	start := time.Now()
+	task := executor.ScheduleAtFixedRate(20*time.Millisecond, 20*time.Millisecond, func() {
+		atomic.AddInt32(&runs, 1)
+		// the runs are due at a fixed rate, regardless of how long they take
+		time.Sleep(10 * time.Millisecond)
+	})
+	time.Sleep(210 * time.Millisecond)
+	assertEqual(t, true, task.Cancel())
+	elapsed := time.Since(start)
+	n := atomic.LoadInt32(&runs)
+	assertEqual(t, true, n >= 8 && int64(n) <= int64(elapsed/(20*time.Millisecond)))
+
+	// a canceled periodic task is not run anymore
+	time.Sleep(50 * time.Millisecond)
+	assertEqual(t, n, atomic.LoadInt32(&runs))
+}
+
+func TestScheduledExecutor_fixedDelay(t *testing.T) {
+	executor := NewScheduled(2)
+	var runs, running, overlapped int32
+	task := executor.ScheduleWithFixedDelay(0, 20*time.Millisecond, func() {
+		if atomic.AddInt32(&running, 1) > 1 {
+			atomic.StoreInt32(&overlapped, 1)
+		}
+		atomic.AddInt32(&runs, 1)
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+	})
+	time.Sleep(210 * time.Millisecond)
+	task.Cancel()
+
+	// the delay is between the end of a run and the start of the next
+	n := atomic.LoadInt32(&runs)
+	assertEqual(t, true, n >= 3 && n <= 6)
+	assertEqual(t, int32(0), atomic.LoadInt32(&overlapped))
+}
+
+func TestScheduledExecutor_shutdown(t *testing.T) {
+	executor := NewScheduled(1)
+	var runs int32
+	periodic := executor.ScheduleAtFixedRate(0, 10*time.Millisecond, func() {
+		atomic.AddInt32(&runs, 1)
+	})
+	oneShot := executor.Schedule(50*time.Millisecond, func() {})
+	time.Sleep(25 * time.Millisecond)
+	executor.Shutdown()
+	assertEqual(t, true, executor.IsShutdown())
+
+	// periodic tasks are canceled, while one-shot tasks are still run when due
+	assertEqual(t, true, periodic.IsCanceled())
+	assertEqual(t, ErrShutdown, executor.Execute(func() {}))
+	rejected := executor.Schedule(0, func() {})
+	assertEqual(t, true, rejected.IsCanceled())
+	assertEqual(t, false, executor.AwaitTermination(0))
+	<-oneShot.Done()
+	assertEqual(t, false, oneShot.IsCanceled())
+	assertEqual(t, true, executor.AwaitTermination(time.Second))
+	assertEqual(t, true, executor.IsTerminated())
+	n := atomic.LoadInt32(&runs)
+	assertEqual(t, true, n >= 2)
+}
+
+func TestScheduledExecutor_shutdownNow(t *testing.T) {
+	executor := NewScheduled(1)
+	started := make(chan struct{})
+	running := Submit(executor, func(ctx context.Context) (int, error) {
+		close(started)
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	<-started
+	queued := Submit(executor, func(ctx context.Context) (int, error) {
+		return 1, nil
+	})
+	delayed := executor.Schedule(time.Hour, func() {})
+	periodic := executor.ScheduleWithFixedDelay(time.Hour, time.Hour, func() {})
+
+	unstarted := executor.ShutdownNow()
+	assertEqual(t, 2, len(unstarted))
+	assertEqual(t, true, delayed.IsCanceled())
+	assertEqual(t, true, periodic.IsCanceled())
+	_, err := queued.Get()
+	assertEqual(t, ErrShutdown, err)
+	_, err = running.Get()
+	assertEqual(t, ErrShutdown, err)
+
+	// the executor terminates without waiting for the delayed tasks to be due
+	assertEqual(t, true, executor.AwaitTermination(time.Second))
+}
+
+func TestNewScheduled_invalid(t *testing.T) {
+	for _, f := range []func(){
+		func() { NewScheduled(0) },
+		func() { NewScheduled(1).ScheduleAtFixedRate(0, 0, func() {}) },
+		func() { NewScheduled(1).ScheduleWithFixedDelay(0, -1, func() {}) },
+	} {
+		func() {
+			defer func() {
+				assertNotNil(t, recover())
+			}()
+			f()
+			t.Fatal("Did not panic")
+		}()
+	}
+}
@@ -0,0 +1,123 @@
+package executor
+
+import "time"
+
+// Stats are a point-in-time view of the state and throughput of an executor, as returned by Stats,
+// to size it from data: e.g. tasks waiting long for a worker, while all the workers are busy, call for more workers.
+//
+// The counters are cumulative since the executor was created: rates are derived from the difference between two snapshots,
+// e.g. (b.Completed - a.Completed) / b.Time.Sub(a.Time).Seconds() tasks completed per second.
+type Stats struct {
+	// Workers is the number of workers of the executor.
+	Workers int
+
+	// Active is the number of tasks being run, by workers or, with the CallerRuns policy, by submitters.
+	Active int
+
+	// Queued is the number of tasks waiting for a worker, or scheduled, for a ScheduledExecutor.
+	Queued int
+
+	// Submitted is the number of tasks accepted by the executor.
+	Submitted uint64
+
+	// Rejected is the number of tasks not accepted by the executor, as its queue was full, with the Reject policy,
+	// or it was shut down.
+	Rejected uint64
+
+	// Discarded is the number of tasks accepted by the executor, then discarded from its queue to make room for newer ones,
+	// with the DiscardOldest policy.
+	Discarded uint64
+
+	// Completed is the number of tasks run, including those that failed. Each run of a periodic task is counted.
+	Completed uint64
+
+	// Failed is the number of tasks that panicked, or were submitted with Submit and returned an error.
+	Failed uint64
+
+	// QueueWait is the distribution of the time that the tasks run waited for a worker,
+	// or, for a ScheduledExecutor, waited past the time they were due.
+	QueueWait Histogram
+
+	// RunTime is the distribution of the time taken to run the tasks.
+	RunTime Histogram
+
+	// Created is the time at which the executor was created.
+	Created time.Time
+
+	// Time is the time at which the snapshot was taken.
+	Time time.Time
+}
+
+// HistogramBounds are the upper bounds of the buckets of a Histogram.
+var HistogramBounds = [...]time.Duration{
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+	10 * time.Second,
+	time.Minute,
+}
+
+// A Histogram is a distribution of durations, counted in buckets bounded by HistogramBounds.
+type Histogram struct {
+	// Counts holds the number of durations in each bucket: Counts[i] is the number of durations greater than HistogramBounds[i-1],
+	// if i > 0, and at most HistogramBounds[i]. The last count is of the durations greater than all the bounds.
+	Counts [len(HistogramBounds) + 1]uint64
+
+	// Sum is the sum of the durations.
+	Sum time.Duration
+
+	// Max is the longest duration.
+	Max time.Duration
+}
+
+// Count returns the number of durations in the histogram.
+func (histogram Histogram) Count() uint64 {
+	var count uint64
+	for _, n := range histogram.Counts {
+		count += n
+	}
+	return count
+}
+
+// Mean returns the mean of the durations in the histogram, or 0 if it is empty.
+func (histogram Histogram) Mean() time.Duration {
+	count := histogram.Count()
+	if count == 0 {
+		return 0
+	}
+	return histogram.Sum / time.Duration(count)
+}
+
+func (histogram *Histogram) observe(d time.Duration) {
+	i := 0
+	for i < len(HistogramBounds) && d > HistogramBounds[i] {
+		i++
+	}
+	histogram.Counts[i]++
+	histogram.Sum += d
+	if d > histogram.Max {
+		histogram.Max = d
+	}
+}
+
+// Hooks are callbacks invoked as tasks are run by an executor, e.g. to feed metrics. Nil callbacks are skipped.
+//
+// Hooks are invoked by the goroutine running the task, outside of the executor's internal locks,
+// so they may call methods such as Stats.
+type Hooks struct {
+	// OnStart is invoked before a task is run, with the time it waited for a worker, as counted in Stats.QueueWait.
+	OnStart func(wait time.Duration)
+
+	// OnComplete is invoked after a task is run, with the time taken to run it, and its error:
+	// a *future.PanicError if it panicked, the error returned by the task if it was submitted with Submit, or nil.
+	OnComplete func(runTime time.Duration, err error)
+}
+
+// WithHooks sets callbacks to be invoked as tasks are run by an executor.
+func WithHooks(hooks Hooks) Option {
+	return func(o *options) {
+		o.hooks = hooks
+	}
+}
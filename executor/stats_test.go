@@ -0,0 +1,112 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFixedPool_stats(t *testing.T) {
+	pool := NewFixedPool(2, WithQueueSize(1), WithRejectionPolicy(Reject))
+	stats := pool.Stats()
+	assertEqual(t, 2, stats.Workers)
+	assertEqual(t, false, stats.Created.IsZero())
+
+	release := make(chan struct{})
+	for i := 0; i < 2; i++ {
+		started := make(chan struct{})
+		pool.Execute(func() {
+			close(started)
+			<-release
+		})
+		<-started
+	}
+	failed := Submit(pool, func(ctx context.Context) (int, error) {
+		return 0, errors.New("failed")
+	})
+	assertEqual(t, ErrRejected, pool.Execute(func() {}))
+	stats = pool.Stats()
+	assertEqual(t, 2, stats.Active)
+	assertEqual(t, 1, stats.Queued)
+	assertEqual(t, uint64(3), stats.Submitted)
+	assertEqual(t, uint64(1), stats.Rejected)
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	failed.Get()
+	pool.Shutdown()
+	pool.AwaitTermination(time.Second)
+	stats = pool.Stats()
+	assertEqual(t, 0, stats.Active)
+	assertEqual(t, 0, stats.Queued)
+	assertEqual(t, uint64(3), stats.Completed)
+	assertEqual(t, uint64(1), stats.Failed)
+	assertEqual(t, uint64(3), stats.QueueWait.Count())
+	assertEqual(t, true, stats.QueueWait.Max >= 20*time.Millisecond)
+	assertEqual(t, uint64(3), stats.RunTime.Count())
+	assertEqual(t, true, stats.RunTime.Max >= 20*time.Millisecond)
+	assertEqual(t, true, stats.RunTime.Mean() <= stats.RunTime.Max)
+	assertEqual(t, true, !stats.Time.Before(stats.Created))
+}
+
+func TestScheduledExecutor_stats(t *testing.T) {
+	executor := NewScheduled(1)
+	task := executor.ScheduleWithFixedDelay(0, time.Millisecond, func() {})
+	executor.Schedule(time.Hour, func() {})
+	time.Sleep(50 * time.Millisecond)
+	task.Cancel()
+	stats := executor.Stats()
+	assertEqual(t, 1, stats.Workers)
+	assertEqual(t, uint64(2), stats.Submitted)
+	assertEqual(t, true, stats.Completed > 1)
+	assertEqual(t, true, stats.Queued >= 1)
+}
+
+func TestHistogram(t *testing.T) {
+	var histogram Histogram
+	assertEqual(t, time.Duration(0), histogram.Mean())
+	for _, d := range []time.Duration{0, time.Millisecond, 2 * time.Millisecond, time.Hour} {
+		histogram.observe(d)
+	}
+	assertEqual(t, uint64(4), histogram.Count())
+	assertEqual(t, uint64(1), histogram.Counts[0])
+	assertEqual(t, uint64(1), histogram.Counts[1])
+	assertEqual(t, uint64(1), histogram.Counts[2])
+	assertEqual(t, uint64(1), histogram.Counts[len(HistogramBounds)])
+	assertEqual(t, time.Hour, histogram.Max)
+	assertEqual(t, (time.Hour+3*time.Millisecond)/4, histogram.Mean())
+}
+
+func TestWithHooks(t *testing.T) {
+	var m sync.Mutex
+	var waits []time.Duration
+	var errs []error
+	pool := NewFixedPool(1, WithHooks(Hooks{
+		OnStart: func(wait time.Duration) {
+			m.Lock()
+			defer m.Unlock()
+			waits = append(waits, wait)
+		},
+		OnComplete: func(runTime time.Duration, err error) {
+			m.Lock()
+			defer m.Unlock()
+			errs = append(errs, err)
+		},
+	}))
+	errFailed := errors.New("failed")
+	Submit(pool, func(ctx context.Context) (int, error) {
+		return 0, errFailed
+	}).Get()
+	pool.Execute(func() {})
+	pool.Shutdown()
+	pool.AwaitTermination(time.Second)
+
+	m.Lock()
+	defer m.Unlock()
+	assertEqual(t, 2, len(waits))
+	assertEqual(t, 2, len(errs))
+	assertEqual(t, errFailed, errs[0])
+	assertNil(t, errs[1])
+}
@@ -0,0 +1,278 @@
+package executor
+
+import (
+	"math/rand"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nvn1729/congo/queue"
+)
+
+// A StealingPool is an Executor running tasks on a fixed number of worker goroutines that steal work from each other,
+// for fine-grained tasks, where the single queue of a FixedPool becomes a point of contention.
+//
+// Each worker owns a queue.Deque, from which it runs its tasks, and an inbox, to which tasks are submitted round-robin.
+// A worker moves the tasks of its inbox to its deque once the deque is empty. A worker with no task left
+// steals the tasks of the other workers, from their deques or inboxes, before it goes idle.
+// Submitters and workers thus mostly contend on the inbox of a single worker, rather than on a queue shared by all.
+//
+// The tasks are not run in the order they were submitted, and their queue is not bounded, so the pool never waits
+// or rejects tasks, unless it is shut down. A pool should be shut down once it is not needed anymore, so that its workers exit.
+type StealingPool struct {
+	workers   []*stealer
+	next      uint32       // the worker to which the next task is submitted, set atomically
+	pending   int64        // tasks submitted, and not taken by a worker yet, set atomically
+	idle      int32        // workers waiting for a task, set atomically
+	submit    sync.RWMutex // orders submitting tasks and shutting down
+	m         sync.Mutex
+	wake      *sync.Cond // signaled when a task is submitted while workers are idle
+	lifecycle *lifecycle
+}
+
+// A stealer is a worker of a StealingPool.
+type stealer struct {
+	index int
+	deque *queue.Deque[task]
+	m     sync.Mutex
+	inbox []task
+}
+
+// NewStealingPool creates a StealingPool, and starts its workers.
+// The pool may be further configured by passing options such as WithPanicHandler.
+// The options of a FixedPool's queue, WithQueueSize and WithRejectionPolicy, do not apply, as its queues are not bounded.
+// NewStealingPool panics if workers is less than 1.
+func NewStealingPool(workers int, opts ...Option) *StealingPool {
+	if workers < 1 {
+		panic("executor: workers must be at least 1")
+	}
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	pool := &StealingPool{
+		workers:   make([]*stealer, workers),
+		lifecycle: newLifecycle(workers, o),
+	}
+	pool.wake = sync.NewCond(&pool.m)
+	for i := range pool.workers {
+		pool.workers[i] = &stealer{index: i, deque: queue.NewDeque[task]()}
+	}
+	for _, worker := range pool.workers {
+		go pool.work(worker)
+	}
+	return pool
+}
+
+// Execute submits a task to be run by a worker of the pool. Execute returns ErrShutdown if the pool is shut down.
+func (pool *StealingPool) Execute(fn func()) error {
+	return pool.execute(taskOf(fn))
+}
+
+// Workers returns the number of workers of the pool.
+func (pool *StealingPool) Workers() int {
+	return len(pool.workers)
+}
+
+// Shutdown shuts the pool down gracefully: it stops accepting tasks, while its workers keep running the submitted ones,
+// then exit. Shutdown does not wait for that to happen, which AwaitTermination does. Shutting a pool down again has no effect.
+func (pool *StealingPool) Shutdown() {
+	pool.submit.Lock()
+	pool.lifecycle.shut()
+	pool.submit.Unlock()
+	pool.wakeAll()
+}
+
+// ShutdownNow shuts the pool down, without running the submitted tasks: it stops accepting tasks,
+// and aborts the tasks that were submitted with Submit, whose futures fail with ErrShutdown,
+// which cancels the context of those being run. The workers exit once they are done with their current task.
+//
+// ShutdownNow returns the tasks that were passed to Execute, which were not started.
+// Tasks passed to Execute that are being run cannot be interrupted.
+func (pool *StealingPool) ShutdownNow() []func() {
+	pool.submit.Lock()
+	running := pool.lifecycle.stop()
+	pool.submit.Unlock()
+
+	var unstarted []func()
+	for _, worker := range pool.workers {
+		for {
+			task, ok := pool.take(worker)
+			if !ok {
+				break
+			}
+			if task.abort != nil {
+				task.discard(ErrShutdown)
+			} else {
+				unstarted = append(unstarted, task.fn())
+			}
+		}
+	}
+	for _, task := range running {
+		task.discard(ErrShutdown)
+	}
+	pool.wakeAll()
+	return unstarted
+}
+
+// AwaitTermination waits until a given timeout for the pool to terminate, once it is shut down,
+// and reports whether it did: all its workers have exited, so all the tasks it accepted are done.
+func (pool *StealingPool) AwaitTermination(timeout time.Duration) bool {
+	return pool.lifecycle.awaitTermination(timeout)
+}
+
+// IsShutdown reports whether the pool was shut down.
+func (pool *StealingPool) IsShutdown() bool {
+	return pool.lifecycle.isShutdown()
+}
+
+// IsTerminated reports whether the pool was shut down, and all its workers have exited.
+func (pool *StealingPool) IsTerminated() bool {
+	return pool.lifecycle.isTerminated()
+}
+
+// Stats returns a snapshot of the state and counters of the pool.
+func (pool *StealingPool) Stats() Stats {
+	stats := pool.lifecycle.stats()
+	stats.Workers = len(pool.workers)
+	stats.Queued = int(atomic.LoadInt64(&pool.pending))
+	return stats
+}
+
+// execute submits a task to the inbox of the next worker, round-robin, and wakes an idle worker.
+func (pool *StealingPool) execute(task task) error {
+	task.queued = time.Now()
+	pool.submit.RLock()
+	if pool.lifecycle.isShutdown() {
+		pool.submit.RUnlock()
+		pool.lifecycle.submit(false)
+		return ErrShutdown
+	}
+	worker := pool.workers[atomic.AddUint32(&pool.next, 1)%uint32(len(pool.workers))]
+	worker.m.Lock()
+	worker.inbox = append(worker.inbox, task)
+	worker.m.Unlock()
+	atomic.AddInt64(&pool.pending, 1)
+	pool.submit.RUnlock()
+	pool.lifecycle.submit(true)
+
+	// pending is incremented before idle is read, while idle workers increment idle before reading pending,
+	// so either the task is seen by a worker going idle, or the worker is woken
+	if atomic.LoadInt32(&pool.idle) > 0 {
+		pool.m.Lock()
+		pool.wake.Signal()
+		pool.m.Unlock()
+	}
+	return nil
+}
+
+// work runs tasks, until the pool is shut down and all the submitted tasks were taken.
+func (pool *StealingPool) work(worker *stealer) {
+	defer pool.lifecycle.exit()
+	for spins := 0; ; spins++ {
+		if task, ok := pool.find(worker); ok {
+			pool.lifecycle.run(&task)
+			spins = 0
+			continue
+		}
+		// fine-grained tasks are usually submitted soon enough to be worth yielding for, rather than parking
+		if spins < idleSpins {
+			runtime.Gosched()
+			continue
+		}
+		if !pool.park() {
+			return
+		}
+	}
+}
+
+// idleSpins is the number of times a worker yields, looking for a task, before it goes idle.
+const idleSpins = 4
+
+// find returns the next task for a worker to run: from its own deque, once refilled from its inbox if needed,
+// or else stolen from another worker, starting from a random one.
+func (pool *StealingPool) find(worker *stealer) (task, bool) {
+	if task, ok := worker.deque.Pop(); ok {
+		atomic.AddInt64(&pool.pending, -1)
+		return task, true
+	}
+	if worker.refill() {
+		if task, ok := worker.deque.Pop(); ok {
+			atomic.AddInt64(&pool.pending, -1)
+			return task, true
+		}
+	}
+	n := len(pool.workers)
+	start := rand.Intn(n)
+	for i := 0; i < n; i++ {
+		victim := pool.workers[(start+i)%n]
+		if victim == worker {
+			continue
+		}
+		if task, ok := pool.take(victim); ok {
+			return task, true
+		}
+	}
+	return task{}, false
+}
+
+// take steals a task from a worker, from its deque or else its inbox.
+func (pool *StealingPool) take(victim *stealer) (task, bool) {
+	task, ok := victim.deque.Steal()
+	if !ok {
+		task, ok = victim.poll()
+	}
+	if ok {
+		atomic.AddInt64(&pool.pending, -1)
+	}
+	return task, ok
+}
+
+// park waits until a task is submitted, and reports whether one was, or the pool was shut down with no task left.
+func (pool *StealingPool) park() bool {
+	pool.m.Lock()
+	defer pool.m.Unlock()
+	atomic.AddInt32(&pool.idle, 1)
+	defer atomic.AddInt32(&pool.idle, -1)
+	for atomic.LoadInt64(&pool.pending) == 0 {
+		if pool.lifecycle.isShutdown() {
+			return false
+		}
+		pool.wake.Wait()
+	}
+	return true
+}
+
+// wakeAll wakes the idle workers, once the pool is shut down.
+func (pool *StealingPool) wakeAll() {
+	pool.m.Lock()
+	defer pool.m.Unlock()
+	pool.wake.Broadcast()
+}
+
+// refill moves the tasks of the inbox of the worker to its deque, and reports whether there were any.
+// The tasks are pushed newest first, so that the worker pops the oldest first.
+func (worker *stealer) refill() bool {
+	worker.m.Lock()
+	inbox := worker.inbox
+	worker.inbox = nil
+	worker.m.Unlock()
+	for i := len(inbox) - 1; i >= 0; i-- {
+		worker.deque.Push(inbox[i])
+	}
+	return len(inbox) > 0
+}
+
+// poll removes the oldest task of the inbox of the worker, if any.
+func (worker *stealer) poll() (task, bool) {
+	worker.m.Lock()
+	defer worker.m.Unlock()
+	if len(worker.inbox) == 0 {
+		return task{}, false
+	}
+	oldest := worker.inbox[0]
+	worker.inbox[0] = task{}
+	worker.inbox = worker.inbox[1:]
+	return oldest, true
+}
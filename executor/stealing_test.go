@@ -0,0 +1,126 @@
+package executor
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStealingPool(t *testing.T) {
+	const workers = 4
+	pool := NewStealingPool(workers)
+	assertEqual(t, workers, pool.Workers())
+	var running, maxRunning int32
+	var wg sync.WaitGroup
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		assertNil(t, pool.Execute(func() {
+			defer wg.Done()
+			r := atomic.AddInt32(&running, 1)
+			for {
+				m := atomic.LoadInt32(&maxRunning)
+				if r <= m || atomic.CompareAndSwapInt32(&maxRunning, m, r) {
+					break
+				}
+			}
+			time.Sleep(100 * time.Microsecond)
+			atomic.AddInt32(&running, -1)
+		}))
+	}
+	wg.Wait()
+	assertEqual(t, true, maxRunning <= workers)
+	assertEqual(t, 1, must(Submit(pool, func(ctx context.Context) (int, error) {
+		return 1, nil
+	}).Get()))
+	assertEqual(t, uint64(201), pool.Stats().Completed)
+}
+
+func TestStealingPool_steal(t *testing.T) {
+	pool := NewStealingPool(2)
+	// a worker busy with a long task does not hold up the tasks submitted to it
+	release := make(chan struct{})
+	defer close(release)
+	var blocked sync.WaitGroup
+	blocked.Add(1)
+	pool.Execute(func() {
+		blocked.Done()
+		<-release
+	})
+	blocked.Wait()
+	for i := 0; i < 10; i++ {
+		i := i
+		assertEqual(t, i, must(Submit(pool, func(ctx context.Context) (int, error) {
+			return i, nil
+		}).GetTimeout(time.Second)))
+	}
+}
+
+func TestStealingPool_shutdown(t *testing.T) {
+	pool := NewStealingPool(2)
+	var ran int32
+	for i := 0; i < 100; i++ {
+		pool.Execute(func() {
+			atomic.AddInt32(&ran, 1)
+		})
+	}
+	pool.Shutdown()
+	assertEqual(t, true, pool.IsShutdown())
+	assertEqual(t, ErrShutdown, pool.Execute(func() {}))
+	assertEqual(t, true, pool.AwaitTermination(time.Second))
+	assertEqual(t, true, pool.IsTerminated())
+	assertEqual(t, int32(100), atomic.LoadInt32(&ran))
+
+	pool = NewStealingPool(1)
+	started := make(chan struct{})
+	running := Submit(pool, func(ctx context.Context) (int, error) {
+		close(started)
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	<-started
+	queued := Submit(pool, func(ctx context.Context) (int, error) {
+		return 1, nil
+	})
+	pool.Execute(func() {})
+	assertEqual(t, 1, len(pool.ShutdownNow()))
+	_, err := queued.Get()
+	assertEqual(t, ErrShutdown, err)
+	_, err = running.Get()
+	assertEqual(t, ErrShutdown, err)
+	assertEqual(t, true, pool.AwaitTermination(time.Second))
+	assertEqual(t, 0, pool.Stats().Queued)
+}
+
+func TestNewStealingPool_invalid(t *testing.T) {
+	defer func() {
+		assertNotNil(t, recover())
+	}()
+	NewStealingPool(0)
+	t.Fatal("Did not panic")
+}
+
+func BenchmarkFixedPool(b *testing.B) {
+	pool := NewFixedPool(4)
+	defer pool.Shutdown()
+	benchmarkExecutor(b, pool)
+}
+
+func BenchmarkStealingPool(b *testing.B) {
+	pool := NewStealingPool(4)
+	defer pool.Shutdown()
+	benchmarkExecutor(b, pool)
+}
+
+// benchmarkExecutor runs fine-grained tasks, submitted by parallel goroutines.
+func benchmarkExecutor(b *testing.B, executor Executor) {
+	var wg sync.WaitGroup
+	wg.Add(b.N)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			executor.Execute(wg.Done)
+		}
+	})
+	wg.Wait()
+}
@@ -0,0 +1,37 @@
+package future
+
+import (
+	"context"
+	"runtime/debug"
+)
+
+// Async runs fn in a new goroutine, and returns a future completed with its result.
+//
+// The future is cancelable, as if created by NewPromiseContext: fn is passed a context derived from ctx,
+// which is canceled when the future is canceled, and fn should return early when it is done.
+// If fn panics, the panic is recovered and the future fails with a *PanicError.
+func Async[T any](ctx context.Context, fn func(ctx context.Context) (T, error)) *Future[T] {
+	promise := NewPromiseContext[T](ctx)
+	go func() {
+		value, err := call(promise.Context(), fn)
+		if err != nil {
+			promise.SetError(err)
+			return
+		}
+		promise.Set(value)
+	}()
+	return promise.Future()
+}
+
+// call calls fn with the given context, and recovers a panic into a *PanicError.
+func call[T any](ctx context.Context, fn func(ctx context.Context) (T, error)) (value T, err error) {
+	completed := false
+	defer func() {
+		if !completed {
+			err = &PanicError{Value: recover(), Stack: debug.Stack()}
+		}
+	}()
+	value, err = fn(ctx)
+	completed = true
+	return value, err
+}
@@ -0,0 +1,61 @@
+package future
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func ExampleAsync() {
+	answer := Async(context.Background(), func(ctx context.Context) (int, error) {
+		// compute the answer
+		// ...
+		return 42, nil
+	})
+	fmt.Println(answer.Get())
+	// Output:
+	// 42 <nil>
+}
+
+func TestAsync(t *testing.T) {
+	value, err := Async(context.Background(), func(context.Context) (string, error) {
+		return "done", nil
+	}).Get()
+	assertEqual(t, "done", value)
+	assertNil(t, err)
+
+	errFailed := errors.New("failed")
+	value, err = Async(context.Background(), func(context.Context) (string, error) {
+		return "ignored", errFailed
+	}).Get()
+	assertEqual(t, "", value)
+	assertEqual(t, errFailed, err)
+}
+
+func TestAsync_panic(t *testing.T) {
+	_, err := Async(context.Background(), func(context.Context) (int, error) {
+		panic("boom")
+	}).Get()
+	var panicErr *PanicError
+	assertEqual(t, true, errors.As(err, &panicErr))
+	assertEqual(t, "boom", panicErr.Value)
+	assertEqual(t, true, strings.Contains(string(panicErr.Stack), "TestAsync_panic"))
+}
+
+func TestAsync_cancel(t *testing.T) {
+	started := make(chan struct{})
+	stopped := make(chan error)
+	future := Async(context.Background(), func(ctx context.Context) (int, error) {
+		close(started)
+		<-ctx.Done()
+		stopped <- ctx.Err()
+		return 0, ctx.Err()
+	})
+	<-started
+	assertEqual(t, true, future.Cancel())
+	assertEqual(t, context.Canceled, <-stopped)
+	_, err := future.Get()
+	assertEqual(t, context.Canceled, err)
+}
@@ -0,0 +1,65 @@
+package future
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// CollectAll runs the given functions concurrently, and returns a future completed with their values,
+// in the same order as the functions.
+//
+// At most limit functions run at the same time, or all of them if limit is 0. The functions are passed a context
+// derived from ctx, which is canceled as soon as one of them fails, or the returned future is canceled.
+// The future then fails with the same error, and the functions that did not start yet are not run.
+// A function that panics fails the future with a *PanicError, like in Async. CollectAll panics if limit is negative.
+func CollectAll[T any](ctx context.Context, limit int, fns ...func(ctx context.Context) (T, error)) *Future[[]T] {
+	if limit < 0 {
+		panic("future: negative limit")
+	}
+	if limit == 0 || limit > len(fns) {
+		limit = len(fns)
+	}
+
+	promise := NewPromiseContext[[]T](ctx)
+	ctx = promise.Context()
+	values := make([]T, len(fns))
+	next := int64(-1)
+	var wg sync.WaitGroup
+	wg.Add(limit)
+	for worker := 0; worker < limit; worker++ {
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(atomic.AddInt64(&next, 1))
+				if i >= len(fns) || ctx.Err() != nil {
+					return
+				}
+				value, err := call(ctx, fns[i])
+				if err != nil {
+					// only the first failure completes the future
+					promise.future.complete(nil, err)
+					return
+				}
+				values[i] = value
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		promise.future.complete(values, nil)
+	}()
+	return promise.Future()
+}
+
+// MapAll is like CollectAll, but runs fn for each of the given inputs, and collects the values in the same order as the inputs.
+func MapAll[In, T any](ctx context.Context, limit int, inputs []In, fn func(ctx context.Context, input In) (T, error)) *Future[[]T] {
+	fns := make([]func(context.Context) (T, error), len(inputs))
+	for i, input := range inputs {
+		input := input
+		fns[i] = func(ctx context.Context) (T, error) {
+			return fn(ctx, input)
+		}
+	}
+	return CollectAll(ctx, limit, fns...)
+}
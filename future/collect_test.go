@@ -0,0 +1,90 @@
+package future
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func ExampleMapAll() {
+	urls := []string{"https://example.com/a", "https://example.com/b", "https://example.com/c"}
+	// fetch at most 2 pages at a time
+	pages := MapAll(context.Background(), 2, urls, func(ctx context.Context, url string) (string, error) {
+		// fetch the page
+		// ...
+		return strings.ToUpper(url[len(url)-1:]), nil
+	})
+	fmt.Println(pages.Get())
+	// Output:
+	// [A B C] <nil>
+}
+
+func TestCollectAll(t *testing.T) {
+	var running, maxRunning int32
+	fns := make([]func(context.Context) (int, error), 20)
+	for i := range fns {
+		i := i
+		fns[i] = func(context.Context) (int, error) {
+			r := atomic.AddInt32(&running, 1)
+			for {
+				m := atomic.LoadInt32(&maxRunning)
+				if r <= m || atomic.CompareAndSwapInt32(&maxRunning, m, r) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return i * i, nil
+		}
+	}
+	values, err := CollectAll(context.Background(), 3, fns...).Get()
+	assertNil(t, err)
+	assertEqual(t, 20, len(values))
+	for i, value := range values {
+		assertEqual(t, i*i, value)
+	}
+	assertEqual(t, true, maxRunning <= 3)
+
+	values, err = CollectAll[int](context.Background(), 0).Get()
+	assertEqual(t, 0, len(values))
+	assertNil(t, err)
+}
+
+func TestCollectAll_failure(t *testing.T) {
+	errFailed := errors.New("failed")
+	var started int32
+	canceled := make(chan struct{})
+	values, err := MapAll(context.Background(), 2, []int{0, 1, 2, 3, 4}, func(ctx context.Context, i int) (int, error) {
+		atomic.AddInt32(&started, 1)
+		switch i {
+		case 0:
+			// the other functions are canceled
+			<-ctx.Done()
+			close(canceled)
+		case 1:
+			return 0, errFailed
+		}
+		return i, nil
+	}).Get()
+	assertEqual(t, 0, len(values))
+	assertEqual(t, errFailed, err)
+	<-canceled
+	assertEqual(t, int32(2), atomic.LoadInt32(&started))
+
+	// a panic fails the future
+	_, err = CollectAll(context.Background(), 0, func(context.Context) (int, error) {
+		panic("boom")
+	}).Get()
+	var panicErr *PanicError
+	assertEqual(t, true, errors.As(err, &panicErr))
+
+	defer func() {
+		assertNotNil(t, recover())
+	}()
+	CollectAll[int](context.Background(), -1)
+	t.Fatal("Negative limit did not panic")
+}
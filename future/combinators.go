@@ -0,0 +1,118 @@
+package future
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Then returns a future completed with the result of applying fn to the value of the given future,
+// once it is completed. If the given future fails, fn is not called and the returned future fails with the same error.
+// If fn panics, the panic is recovered and the returned future fails with a *PanicError.
+//
+// Then is a function rather than a method, as Go methods may not have type parameters of their own.
+func Then[T, U any](future *Future[T], fn func(T) U) *Future[U] {
+	next := newFuture[U]()
+	go func() {
+		value, err := future.Get()
+		if err != nil {
+			var zero U
+			next.complete(zero, err)
+			return
+		}
+		next.complete(call(context.Background(), func(context.Context) (U, error) {
+			return fn(value), nil
+		}))
+	}()
+	return next
+}
+
+// FlatMap is like Then, but for a fn that is itself asynchronous: the returned future is completed
+// with the result of the future returned by fn. If fn returns a nil future, the returned future fails with ErrNilFuture.
+func FlatMap[T, U any](future *Future[T], fn func(T) *Future[U]) *Future[U] {
+	next := newFuture[U]()
+	go func() {
+		value, err := future.Get()
+		if err != nil {
+			var zero U
+			next.complete(zero, err)
+			return
+		}
+		next.complete(call(context.Background(), func(context.Context) (U, error) {
+			flat := fn(value)
+			if flat == nil {
+				var zero U
+				return zero, ErrNilFuture
+			}
+			return flat.Get()
+		}))
+	}()
+	return next
+}
+
+// Catch returns a future that recovers from the failure of the future: it is completed with the result of applying fn
+// to the error if the future fails, or with the value of the future otherwise.
+// If fn panics, the panic is recovered and the returned future fails with a *PanicError.
+func (future *Future[T]) Catch(fn func(error) T) *Future[T] {
+	next := newFuture[T]()
+	go func() {
+		value, err := future.Get()
+		if err != nil {
+			value, err = call(context.Background(), func(context.Context) (T, error) {
+				return fn(err), nil
+			})
+		}
+		next.complete(value, err)
+	}()
+	return next
+}
+
+// WhenAll returns a future completed with the values of the given futures, in the same order, once they are all completed.
+// If any of them fails, the returned future fails as soon as it does, with the same error.
+// WhenAll of no futures returns a future already completed with an empty slice.
+func WhenAll[T any](futures ...*Future[T]) *Future[[]T] {
+	all := newFuture[[]T]()
+	values := make([]T, len(futures))
+	remaining := int32(len(futures))
+	if remaining == 0 {
+		all.complete(values, nil)
+		return all
+	}
+	for i, future := range futures {
+		go func(i int, future *Future[T]) {
+			select {
+			case <-future.doneCh:
+			case <-all.doneCh:
+				// another future failed
+				return
+			}
+			if future.err != nil {
+				all.complete(nil, future.err)
+				return
+			}
+			values[i] = future.value
+			if atomic.AddInt32(&remaining, -1) == 0 {
+				all.complete(values, nil)
+			}
+		}(i, future)
+	}
+	return all
+}
+
+// WhenAny returns a future completed with the result of the first of the given futures to be completed,
+// whether it succeeds or fails. WhenAny panics if no futures are given, as the returned future would never be completed.
+func WhenAny[T any](futures ...*Future[T]) *Future[T] {
+	if len(futures) == 0 {
+		panic("future: WhenAny of no futures")
+	}
+	first := newFuture[T]()
+	for _, future := range futures {
+		go func(future *Future[T]) {
+			select {
+			case <-future.doneCh:
+				first.complete(future.value, future.err)
+			case <-first.doneCh:
+			}
+		}(future)
+	}
+	return first
+}
@@ -0,0 +1,170 @@
+package future
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func ExampleThen() {
+	promise := NewPromise[string]()
+	length := Then(promise.Future(), func(s string) int {
+		return len(s)
+	})
+	promise.Set("congo")
+	fmt.Println(length.Get())
+	// Output:
+	// 5 <nil>
+}
+
+func TestThen(t *testing.T) {
+	promise := NewPromise[int]()
+	doubled := Then(promise.Future(), func(i int) int { return i * 2 })
+	formatted := Then(doubled, strconv.Itoa)
+	promise.Set(21)
+	value, err := formatted.Get()
+	assertEqual(t, "42", value)
+	assertNil(t, err)
+
+	// a failure skips the function
+	errFailed := errors.New("failed")
+	called := false
+	value, err = Then(Failed[int](errFailed), func(i int) string {
+		called = true
+		return strconv.Itoa(i)
+	}).Get()
+	assertEqual(t, "", value)
+	assertEqual(t, errFailed, err)
+	assertEqual(t, false, called)
+
+	// a panic fails the future
+	var panicErr *PanicError
+	value, err = Then(Completed(1), func(int) string {
+		panic("then failed")
+	}).Get()
+	assertEqual(t, "", value)
+	assertEqual(t, true, errors.As(err, &panicErr))
+	assertEqual(t, "then failed", panicErr.Value)
+}
+
+func TestFlatMap(t *testing.T) {
+	errFailed := errors.New("failed")
+	parse := func(s string) *Future[int] {
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			return Failed[int](errFailed)
+		}
+		return Completed(i)
+	}
+
+	value, err := FlatMap(Completed("42"), parse).Get()
+	assertEqual(t, 42, value)
+	assertNil(t, err)
+
+	value, err = FlatMap(Completed("congo"), parse).Get()
+	assertEqual(t, 0, value)
+	assertEqual(t, errFailed, err)
+
+	value, err = FlatMap(Failed[string](errFailed), parse).Get()
+	assertEqual(t, 0, value)
+	assertEqual(t, errFailed, err)
+
+	// a nil future or a panic fails the future
+	value, err = FlatMap(Completed("42"), func(string) *Future[int] {
+		return nil
+	}).Get()
+	assertEqual(t, 0, value)
+	assertEqual(t, ErrNilFuture, err)
+
+	var panicErr *PanicError
+	value, err = FlatMap(Completed("42"), func(string) *Future[int] {
+		panic("flat map failed")
+	}).Get()
+	assertEqual(t, 0, value)
+	assertEqual(t, true, errors.As(err, &panicErr))
+	assertEqual(t, "flat map failed", panicErr.Value)
+}
+
+func TestFuture_catch(t *testing.T) {
+	errFailed := errors.New("failed")
+	var caught error
+	value, err := Failed[int](errFailed).Catch(func(err error) int {
+		caught = err
+		return -1
+	}).Get()
+	assertEqual(t, -1, value)
+	assertNil(t, err)
+	assertEqual(t, errFailed, caught)
+
+	// a success skips the function
+	value, err = Completed(1).Catch(func(error) int {
+		t.Fatal("Catch called on a successful future")
+		return -1
+	}).Get()
+	assertEqual(t, 1, value)
+	assertNil(t, err)
+
+	// a panic fails the future
+	var panicErr *PanicError
+	value, err = Failed[int](errFailed).Catch(func(error) int {
+		panic("catch failed")
+	}).Get()
+	assertEqual(t, 0, value)
+	assertEqual(t, true, errors.As(err, &panicErr))
+	assertEqual(t, "catch failed", panicErr.Value)
+}
+
+func TestWhenAll(t *testing.T) {
+	promises := []*Promise[int]{NewPromise[int](), NewPromise[int](), NewPromise[int]()}
+	all := WhenAll(promises[0].Future(), promises[1].Future(), promises[2].Future())
+	promises[2].Set(3)
+	promises[0].Set(1)
+	select {
+	case <-all.Done():
+		t.Fatal("WhenAll completed before all futures")
+	case <-time.After(50 * time.Millisecond):
+	}
+	promises[1].Set(2)
+	values, err := all.Get()
+	assertNil(t, err)
+	assertEqual(t, "[1 2 3]", fmt.Sprint(values))
+
+	// a failure completes the future without waiting for the others
+	errFailed := errors.New("failed")
+	values, err = WhenAll(NewPromise[int]().Future(), Failed[int](errFailed)).Get()
+	assertEqual(t, 0, len(values))
+	assertEqual(t, errFailed, err)
+
+	values, err = WhenAll[int]().Get()
+	assertEqual(t, 0, len(values))
+	assertNil(t, err)
+}
+
+func TestWhenAny(t *testing.T) {
+	slow, fast := NewPromise[string](), NewPromise[string]()
+	first := WhenAny(slow.Future(), fast.Future())
+	select {
+	case <-first.Done():
+		t.Fatal("WhenAny completed before any future")
+	case <-time.After(50 * time.Millisecond):
+	}
+	fast.Set("fast")
+	value, err := first.Get()
+	assertEqual(t, "fast", value)
+	assertNil(t, err)
+	slow.Set("slow")
+
+	// a failure completes the future too
+	errFailed := errors.New("failed")
+	value, err = WhenAny(NewPromise[string]().Future(), Failed[string](errFailed)).Get()
+	assertEqual(t, "", value)
+	assertEqual(t, errFailed, err)
+
+	defer func() {
+		assertNotNil(t, recover())
+	}()
+	WhenAny[int]()
+	t.Fatal("WhenAny of no futures did not panic")
+}
@@ -0,0 +1,28 @@
+package future
+
+import (
+	"errors"
+	"fmt"
+)
+
+// These are errors related to Future.
+var (
+	// ErrTimeout is returned by GetTimeout when the timeout elapses before the future is completed
+	ErrTimeout = errors.New("Future wait timed out")
+
+	// ErrNilFuture is the error of a future returned by FlatMap when its function returns a nil future
+	ErrNilFuture = errors.New("Future function returned a nil future")
+)
+
+// A PanicError is the error of a future whose computation, run by Async or a combinator such as Then, panicked.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value interface{}
+
+	// Stack is the stack trace of the goroutine that panicked, as formatted by runtime/debug.Stack.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("Future computation panicked: %v", e.Value)
+}
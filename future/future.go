@@ -0,0 +1,283 @@
+// Package future provides a generic Future, the result of a computation that may not have completed yet,
+// and the Promise completing it.
+package future
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// A Future is the result of a computation that may not have completed yet: a value, or an error.
+//
+// Many users of a CountDownLatch are really waiting on a single result. A Future carries that result with its type,
+// and is completed once, by its Promise. Any number of goroutines may wait for it with Get.
+//
+// A future may be canceled, which completes it with context.Canceled. If it was created with a context,
+// by NewPromiseContext, canceling it also cancels the context of the computation.
+type Future[T any] struct {
+	m         sync.Mutex
+	done      bool
+	canceled  bool
+	doneCh    chan struct{}
+	cancel    context.CancelFunc // cancels the context of the computation, if any
+	callbacks []func()           // run once the future is completed
+	value     T
+	err       error
+}
+
+func newFuture[T any]() *Future[T] {
+	return &Future[T]{doneCh: make(chan struct{})}
+}
+
+// Completed returns a future already completed with the given value.
+func Completed[T any](value T) *Future[T] {
+	future := newFuture[T]()
+	future.complete(value, nil)
+	return future
+}
+
+// Failed returns a future already completed with the given error.
+func Failed[T any](err error) *Future[T] {
+	future := newFuture[T]()
+	var zero T
+	future.complete(zero, err)
+	return future
+}
+
+// Get waits until the future is completed, and returns its value and error.
+// Get returns immediately if the future is already completed.
+func (future *Future[T]) Get() (T, error) {
+	<-future.doneCh
+	return future.value, future.err
+}
+
+// GetTimeout waits until a given timeout for the future to be completed.
+// If the future is completed before the timeout, GetTimeout returns its value and error.
+// Otherwise it returns the zero value of T and ErrTimeout.
+func (future *Future[T]) GetTimeout(timeout time.Duration) (T, error) {
+	select {
+	case <-future.doneCh:
+		return future.value, future.err
+	default:
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-future.doneCh:
+		return future.value, future.err
+	case <-timer.C:
+		var zero T
+		return zero, ErrTimeout
+	}
+}
+
+// GetContext waits until the future is completed or the context is done.
+// If the future is completed first, or was already, GetContext returns its value and error.
+// Otherwise it returns the zero value of T and the context's error. The computation of the future is not affected.
+func (future *Future[T]) GetContext(ctx context.Context) (T, error) {
+	select {
+	case <-future.doneCh:
+		return future.value, future.err
+	default:
+	}
+
+	select {
+	case <-future.doneCh:
+		return future.value, future.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// GetOrDefault waits until a given timeout for the future to be completed, and returns its value,
+// or the given default value if the future failed or is not completed in time.
+// The computation of the future is not affected, so a later call may return its value.
+func (future *Future[T]) GetOrDefault(timeout time.Duration, def T) T {
+	value, err := future.GetTimeout(timeout)
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// OrElse returns the value of the future if it is already completed successfully, or the given default value otherwise,
+// without waiting.
+func (future *Future[T]) OrElse(def T) T {
+	select {
+	case <-future.doneCh:
+		if future.err == nil {
+			return future.value
+		}
+	default:
+	}
+	return def
+}
+
+// Done returns a channel that is closed when the future is completed, for use in select statements.
+func (future *Future[T]) Done() <-chan struct{} {
+	return future.doneCh
+}
+
+// Cancel cancels the future, completing it with context.Canceled if it is not completed yet, and reports whether it did.
+// If the future was created with a context, Cancel also cancels the context, signalling the computation to stop.
+func (future *Future[T]) Cancel() bool {
+	canceled := future.completeCanceled(context.Canceled)
+	if future.cancel != nil {
+		future.cancel()
+	}
+	return canceled
+}
+
+// IsCanceled reports whether the future was canceled, by Cancel or by its parent context being done.
+func (future *Future[T]) IsCanceled() bool {
+	future.m.Lock()
+	defer future.m.Unlock()
+	return future.canceled
+}
+
+// OnDone registers a callback to be called with the value and error of the future once it is completed,
+// for consumers that cannot block in Get. If the future is already completed, the callback is called immediately.
+//
+// Callbacks are called in the order they were registered, by the goroutine completing the future, e.g. calling Promise.Set,
+// so they should be quick and must not wait for that goroutine.
+func (future *Future[T]) OnDone(callback func(value T, err error)) {
+	future.m.Lock()
+	if !future.done {
+		future.callbacks = append(future.callbacks, func() {
+			callback(future.value, future.err)
+		})
+		future.m.Unlock()
+		return
+	}
+	future.m.Unlock()
+	callback(future.value, future.err)
+}
+
+// OnSuccess is like OnDone, but the callback is only called with the value of the future if it succeeds.
+func (future *Future[T]) OnSuccess(callback func(value T)) {
+	future.OnDone(func(value T, err error) {
+		if err == nil {
+			callback(value)
+		}
+	})
+}
+
+// OnFailure is like OnDone, but the callback is only called with the error of the future if it fails.
+func (future *Future[T]) OnFailure(callback func(err error)) {
+	future.OnDone(func(value T, err error) {
+		if err != nil {
+			callback(err)
+		}
+	})
+}
+
+// complete completes the future with the given value and error, and reports whether it did,
+// as the future may only be completed once.
+func (future *Future[T]) complete(value T, err error) bool {
+	return future.completeWith(value, err, false)
+}
+
+// completeCanceled is like complete, but marks the future as canceled with the given error.
+func (future *Future[T]) completeCanceled(err error) bool {
+	var zero T
+	return future.completeWith(zero, err, true)
+}
+
+// completeWith completes the future, then runs its callbacks once the mutex is released, so that they may use the future.
+func (future *Future[T]) completeWith(value T, err error, canceled bool) bool {
+	future.m.Lock()
+	if future.done {
+		future.m.Unlock()
+		return false
+	}
+	future.done, future.canceled = true, canceled
+	future.value, future.err = value, err
+	callbacks := future.callbacks
+	future.callbacks = nil
+	close(future.doneCh)
+	future.m.Unlock()
+
+	for _, callback := range callbacks {
+		callback()
+	}
+	return true
+}
+
+// A Promise is the producer side of a Future: the computation completes the promise once,
+// with either a value or an error, releasing the goroutines waiting on the future.
+type Promise[T any] struct {
+	future *Future[T]
+	ctx    context.Context
+}
+
+// NewPromise creates a Promise, and the Future that it completes.
+func NewPromise[T any]() *Promise[T] {
+	return &Promise[T]{future: newFuture[T](), ctx: context.Background()}
+}
+
+// NewPromiseContext creates a Promise for a computation that may be canceled, and the Future that it completes.
+// The context of the computation, returned by Context, is derived from the given parent context.
+// It is canceled when the future is canceled, and once the promise is completed.
+//
+// If the parent context is done before the promise is completed, the future is canceled with the context's error.
+func NewPromiseContext[T any](parent context.Context) *Promise[T] {
+	future := newFuture[T]()
+	ctx, cancel := context.WithCancel(parent)
+	future.cancel = cancel
+	go func() {
+		select {
+		case <-ctx.Done():
+			future.completeCanceled(parent.Err())
+		case <-future.doneCh:
+		}
+		cancel()
+	}()
+	return &Promise[T]{future: future, ctx: ctx}
+}
+
+// Future returns the future completed by the promise.
+func (promise *Promise[T]) Future() *Future[T] {
+	return promise.future
+}
+
+// Context returns the context of the computation completing the promise, which is done once its future is canceled.
+// The context of a promise created with NewPromise is never done.
+func (promise *Promise[T]) Context() context.Context {
+	return promise.ctx
+}
+
+// Set completes the future of the promise with the given value.
+// Set panics if the promise is already completed, unless its future was canceled, in which case the value is discarded.
+func (promise *Promise[T]) Set(value T) {
+	promise.set(value, nil)
+}
+
+// SetError completes the future of the promise with the given error.
+// A nil error completes it with the zero value of T, like Set.
+// SetError panics if the promise is already completed, unless its future was canceled, in which case the error is discarded.
+func (promise *Promise[T]) SetError(err error) {
+	var zero T
+	promise.set(zero, err)
+}
+
+// TrySet is like Set, but reports whether it completed the future, rather than panic if the promise is already completed.
+// It lets several goroutines race to complete the promise, e.g. running hedged or duplicated requests,
+// where the first to complete it wins and the others' results are discarded.
+func (promise *Promise[T]) TrySet(value T) bool {
+	return promise.future.complete(value, nil)
+}
+
+// TrySetError is like SetError, but reports whether it completed the future, rather than panic if the promise is already completed.
+func (promise *Promise[T]) TrySetError(err error) bool {
+	var zero T
+	return promise.future.complete(zero, err)
+}
+
+func (promise *Promise[T]) set(value T, err error) {
+	if !promise.future.complete(value, err) && !promise.future.IsCanceled() {
+		panic("future: promise already completed")
+	}
+}
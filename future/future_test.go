@@ -0,0 +1,279 @@
+package future
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func ExamplePromise() {
+	promise := NewPromise[int]()
+	go func() {
+		// compute the answer
+		// ...
+		promise.Set(42)
+	}()
+
+	answer, err := promise.Future().Get()
+	fmt.Println(answer, err)
+	// Output:
+	// 42 <nil>
+}
+
+func TestPromise_set(t *testing.T) {
+	promise := NewPromise[string]()
+	future := promise.Future()
+	select {
+	case <-future.Done():
+		t.Fatal("Future completed before the promise")
+	default:
+	}
+
+	got := make(chan string)
+	for i := 0; i < 3; i++ {
+		go func() {
+			value, err := future.Get()
+			assertNil(t, err)
+			got <- value
+		}()
+	}
+	select {
+	case <-got:
+		t.Fatal("Get returned before the promise was completed")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	promise.Set("done")
+	for i := 0; i < 3; i++ {
+		assertEqual(t, "done", <-got)
+	}
+	<-future.Done()
+	value, err := future.Get()
+	assertEqual(t, "done", value)
+	assertNil(t, err)
+}
+
+func TestPromise_setError(t *testing.T) {
+	errFailed := errors.New("failed")
+	promise := NewPromise[int]()
+	promise.SetError(errFailed)
+	value, err := promise.Future().Get()
+	assertEqual(t, 0, value)
+	assertEqual(t, errFailed, err)
+
+	// a promise is completed once
+	for _, complete := range []func(){
+		func() { promise.Set(1) },
+		func() { promise.SetError(errFailed) },
+	} {
+		func() {
+			defer func() {
+				assertNotNil(t, recover())
+			}()
+			complete()
+			t.Fatal("Completing a completed promise did not panic")
+		}()
+	}
+}
+
+func TestFuture_getTimeout(t *testing.T) {
+	promise := NewPromise[int]()
+	value, err := promise.Future().GetTimeout(50 * time.Millisecond)
+	assertEqual(t, 0, value)
+	assertEqual(t, ErrTimeout, err)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		promise.Set(1)
+	}()
+	value, err = promise.Future().GetTimeout(time.Second)
+	assertEqual(t, 1, value)
+	assertNil(t, err)
+
+	// a completed future is returned even with no time to wait
+	value, err = promise.Future().GetTimeout(0)
+	assertEqual(t, 1, value)
+	assertNil(t, err)
+}
+
+func TestFuture_getContext(t *testing.T) {
+	promise := NewPromise[int]()
+	ctx, cancel := context.WithCancel(context.Background())
+	got := make(chan error)
+	go func() {
+		_, err := promise.Future().GetContext(ctx)
+		got <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	assertEqual(t, context.Canceled, <-got)
+
+	// a completed future is returned even with a done context
+	promise.Set(1)
+	value, err := promise.Future().GetContext(ctx)
+	assertEqual(t, 1, value)
+	assertNil(t, err)
+}
+
+func TestPromise_trySet(t *testing.T) {
+	promise := NewPromise[int]()
+	var wins int32
+	var wg sync.WaitGroup
+	for i := 1; i <= 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var won bool
+			if i%2 == 0 {
+				won = promise.TrySet(i)
+			} else {
+				won = promise.TrySetError(errors.New("failed"))
+			}
+			if won {
+				atomic.AddInt32(&wins, 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+	assertEqual(t, int32(1), wins)
+	<-promise.Future().Done()
+
+	// completing a completed promise is reported rather than panic
+	assertEqual(t, false, promise.TrySet(0))
+	assertEqual(t, false, promise.TrySetError(errors.New("failed")))
+}
+
+func TestCompleted(t *testing.T) {
+	value, err := Completed(3).Get()
+	assertEqual(t, 3, value)
+	assertNil(t, err)
+
+	errFailed := errors.New("failed")
+	value, err = Failed[int](errFailed).Get()
+	assertEqual(t, 0, value)
+	assertEqual(t, errFailed, err)
+}
+
+func TestFuture_getOrDefault(t *testing.T) {
+	promise := NewPromise[string]()
+	assertEqual(t, "default", promise.Future().GetOrDefault(50*time.Millisecond, "default"))
+	assertEqual(t, "default", promise.Future().OrElse("default"))
+
+	// the computation continues for later calls
+	promise.Set("value")
+	assertEqual(t, "value", promise.Future().GetOrDefault(50*time.Millisecond, "default"))
+	assertEqual(t, "value", promise.Future().OrElse("default"))
+
+	failed := Failed[string](errors.New("failed"))
+	assertEqual(t, "default", failed.GetOrDefault(50*time.Millisecond, "default"))
+	assertEqual(t, "default", failed.OrElse("default"))
+}
+
+func TestFuture_onDone(t *testing.T) {
+	promise := NewPromise[int]()
+	future := promise.Future()
+	var calls []string
+	future.OnDone(func(value int, err error) {
+		calls = append(calls, fmt.Sprint("done ", value, " ", err))
+	})
+	future.OnSuccess(func(value int) {
+		calls = append(calls, fmt.Sprint("success ", value))
+	})
+	future.OnFailure(func(err error) {
+		calls = append(calls, fmt.Sprint("failure ", err))
+	})
+	assertEqual(t, 0, len(calls))
+
+	promise.Set(1)
+	assertEqual(t, "[done 1 <nil> success 1]", fmt.Sprint(calls))
+
+	// callbacks registered on a completed future are called immediately
+	calls = nil
+	failed := Failed[int](errors.New("failed"))
+	failed.OnSuccess(func(value int) {
+		calls = append(calls, fmt.Sprint("success ", value))
+	})
+	failed.OnFailure(func(err error) {
+		calls = append(calls, fmt.Sprint("failure ", err))
+	})
+	assertEqual(t, "[failure failed]", fmt.Sprint(calls))
+
+	// callbacks may use the future
+	promise = NewPromise[int]()
+	got := make(chan int, 1)
+	promise.Future().OnDone(func(int, error) {
+		value, _ := promise.Future().Get()
+		got <- value
+	})
+	promise.Set(2)
+	assertEqual(t, 2, <-got)
+}
+
+func TestFuture_cancel(t *testing.T) {
+	promise := NewPromiseContext[int](context.Background())
+	future := promise.Future()
+	assertNil(t, promise.Context().Err())
+	assertEqual(t, false, future.IsCanceled())
+
+	assertEqual(t, true, future.Cancel())
+	assertEqual(t, false, future.Cancel())
+	<-promise.Context().Done()
+	value, err := future.Get()
+	assertEqual(t, 0, value)
+	assertEqual(t, context.Canceled, err)
+	assertEqual(t, true, future.IsCanceled())
+
+	// the result of the canceled computation is discarded
+	promise.Set(1)
+	value, _ = future.Get()
+	assertEqual(t, 0, value)
+
+	// a completed future is not canceled, and its context is released
+	promise = NewPromiseContext[int](context.Background())
+	promise.Set(1)
+	assertEqual(t, false, promise.Future().Cancel())
+	<-promise.Context().Done()
+	value, err = promise.Future().Get()
+	assertEqual(t, 1, value)
+	assertNil(t, err)
+	assertEqual(t, false, promise.Future().IsCanceled())
+
+	// futures without a context may be canceled too
+	promise = NewPromise[int]()
+	assertEqual(t, true, promise.Future().Cancel())
+	_, err = promise.Future().Get()
+	assertEqual(t, context.Canceled, err)
+	assertNil(t, promise.Context().Err())
+}
+
+func TestFuture_cancelParent(t *testing.T) {
+	parent, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	promise := NewPromiseContext[int](parent)
+	_, err := promise.Future().Get()
+	assertEqual(t, context.DeadlineExceeded, err)
+	assertEqual(t, true, promise.Future().IsCanceled())
+	<-promise.Context().Done()
+}
+
+func assertEqual(t *testing.T, expected interface{}, actual interface{}) {
+	if expected != actual {
+		t.Fatal("Not equal:", "expected:", expected, ", actual:", actual)
+	}
+}
+
+func assertNil(t *testing.T, actual interface{}) {
+	if actual != nil {
+		t.Fatal("Value not nil, actual:", actual)
+	}
+}
+
+func assertNotNil(t *testing.T, actual interface{}) {
+	if actual == nil {
+		t.Fatal("Value is nil")
+	}
+}
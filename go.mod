@@ -0,0 +1,3 @@
+module github.com/nvn1729/congo
+
+go 1.18
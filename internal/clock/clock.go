@@ -0,0 +1,14 @@
+// Package clock defines the Timer of congo.Clock, so that internal/clocktest can create timers of that type
+// without importing package congo, whose own tests use it.
+package clock
+
+import "time"
+
+// A Timer is a single event created by a Clock, mirroring time.Timer.
+type Timer interface {
+	// C returns the channel on which the time is delivered when the Timer fires.
+	C() <-chan time.Time
+
+	// Stop prevents the Timer from firing. It returns false if the Timer has already fired or been stopped.
+	Stop() bool
+}
@@ -0,0 +1,99 @@
+// Package clocktest provides a fake congo.Clock for the tests of package congo and its subpackages,
+// whose time only moves when the test advances it, so that timeouts elapse without sleeping.
+package clocktest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/nvn1729/congo/internal/clock"
+)
+
+// A Clock is a fake clock whose time only moves when Advance is called.
+type Clock struct {
+	m      sync.Mutex
+	now    time.Time
+	timers []*timer
+}
+
+type timer struct {
+	clock    *Clock
+	deadline time.Time
+	ch       chan time.Time
+}
+
+// New creates a Clock starting at the given time.
+func New(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+// Now returns the current time of the clock.
+func (clock *Clock) Now() time.Time {
+	clock.m.Lock()
+	defer clock.m.Unlock()
+	return clock.now
+}
+
+// After returns a channel receiving the time of the clock once it is advanced by d, as the channel of a timer created by NewTimer.
+func (clock *Clock) After(d time.Duration) <-chan time.Time {
+	return clock.NewTimer(d).C()
+}
+
+// NewTimer creates a timer firing once the clock is advanced by d. A timer of a non-positive duration fires immediately.
+func (clock *Clock) NewTimer(d time.Duration) clock.Timer {
+	clock.m.Lock()
+	defer clock.m.Unlock()
+	timer := &timer{clock: clock, deadline: clock.now.Add(d), ch: make(chan time.Time, 1)}
+	if d <= 0 {
+		timer.ch <- clock.now
+	} else {
+		clock.timers = append(clock.timers, timer)
+	}
+	return timer
+}
+
+// Advance moves the clock forward, firing any timers whose deadline has passed.
+func (clock *Clock) Advance(d time.Duration) {
+	clock.m.Lock()
+	defer clock.m.Unlock()
+	clock.now = clock.now.Add(d)
+	pending := clock.timers[:0]
+	for _, timer := range clock.timers {
+		if clock.now.Before(timer.deadline) {
+			pending = append(pending, timer)
+		} else {
+			timer.ch <- clock.now
+		}
+	}
+	clock.timers = pending
+}
+
+// Timers returns the number of timers pending on the clock.
+func (clock *Clock) Timers() int {
+	clock.m.Lock()
+	defer clock.m.Unlock()
+	return len(clock.timers)
+}
+
+// BlockUntilTimers waits until the given number of timers are pending on the clock.
+func (clock *Clock) BlockUntilTimers(n int) {
+	for clock.Timers() < n {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (timer *timer) C() <-chan time.Time {
+	return timer.ch
+}
+
+func (timer *timer) Stop() bool {
+	timer.clock.m.Lock()
+	defer timer.clock.m.Unlock()
+	for i, pending := range timer.clock.timers {
+		if pending == timer {
+			timer.clock.timers = append(timer.clock.timers[:i], timer.clock.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+// Package goid provides the ids of goroutines, which the runtime does not expose, parsed from their stack traces.
+package goid
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+)
+
+// Current returns the id of the calling goroutine. Ids are positive, and not reused while the goroutine is alive.
+func Current() int64 {
+	var buf [64]byte
+	id := Parse(buf[:runtime.Stack(buf[:], false)])
+	if id == 0 {
+		panic("goid: cannot parse goroutine id")
+	}
+	return id
+}
+
+// Parse parses the goroutine id from the "goroutine 42 [running]:" header of a stack trace, or returns 0 if it has none.
+func Parse(stack []byte) int64 {
+	stack = bytes.TrimPrefix(stack, []byte("goroutine "))
+	if i := bytes.IndexByte(stack, ' '); i >= 0 {
+		stack = stack[:i]
+	}
+	id, err := strconv.ParseInt(string(stack), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
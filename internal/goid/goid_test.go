@@ -0,0 +1,27 @@
+package goid
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	assertEqual(t, int64(42), Parse([]byte("goroutine 42 [running]:\nmain.main()")))
+	assertEqual(t, int64(0), Parse([]byte("main.main()")))
+}
+
+func TestCurrent(t *testing.T) {
+	id := Current()
+	assertEqual(t, true, id > 0)
+	assertEqual(t, id, Current())
+
+	other := make(chan int64)
+	go func() {
+		other <- Current()
+	}()
+	assertEqual(t, false, id == <-other)
+}
+
+func assertEqual(t *testing.T, expected interface{}, actual interface{}) {
+	t.Helper()
+	if expected != actual {
+		t.Fatal("Not equal:", "expected:", expected, ", actual:", actual)
+	}
+}
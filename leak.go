@@ -0,0 +1,133 @@
+package congo
+
+import (
+	"fmt"
+	"log"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// A WaiterLeak describes goroutines left blocked waiting on a latch that was abandoned before its count down completed.
+// Those goroutines can never be released.
+type WaiterLeak struct {
+	// Name is the name given to the latch with WithName.
+	Name string
+
+	// Collected is true if the leak was detected when the latch was garbage collected, and false if it was detected by Close.
+	Collected bool
+
+	// Stacks holds, for each blocked goroutine, its stack trace at the time it started waiting.
+	Stacks []string
+}
+
+// String formats the leak as a warning including the stack traces of the blocked goroutines.
+func (leak WaiterLeak) String() string {
+	how := "closed"
+	if leak.Collected {
+		how = "garbage collected"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "congo: latch %q %s with %d goroutine(s) blocked waiting on it", leak.Name, how, len(leak.Stacks))
+	for _, stack := range leak.Stacks {
+		b.WriteString("\n\n")
+		b.WriteString(stack)
+	}
+	return b.String()
+}
+
+// WithLeakDetection enables diagnostics for the "latch abandoned, waiters stuck forever" class of bugs.
+// A leak is reported if the latch is closed with Close, or garbage collected, while goroutines are still blocked in Wait or WaitTimeout.
+// If report is nil, leaks are written to the standard logger.
+//
+// Leak detection records the stack of every waiting goroutine, so it is intended for tests and staging rather than production.
+func WithLeakDetection(report func(WaiterLeak)) Option {
+	if report == nil {
+		report = func(leak WaiterLeak) {
+			log.Print(leak.String())
+		}
+	}
+	return func(latch *CountDownLatch) {
+		latch.leaks = &leakDetector{
+			stacks: make(map[uint64]string),
+			report: report,
+		}
+	}
+}
+
+// Close declares that the latch is no longer going to be counted down.
+// If goroutines are still blocked waiting on the count down, they can never be released:
+// Close then returns ErrCountDownLatchAbandoned and, with leak detection enabled, reports the leak.
+// Close does not otherwise change the state of the latch.
+func (latch *CountDownLatch) Close() error {
+	if latch.leaks != nil {
+		runtime.SetFinalizer(latch, nil)
+	}
+	select {
+	case <-latch.completeCh():
+		return nil
+	default:
+	}
+	if latch.Waiters() == 0 {
+		return nil
+	}
+	if latch.leaks != nil {
+		latch.leaks.check(false)
+	}
+	return ErrCountDownLatchAbandoned
+}
+
+// collected is the finalizer of latches with leak detection enabled.
+func (latch *CountDownLatch) collected() {
+	select {
+	case <-latch.countDownCompleteCh:
+	default:
+		latch.leaks.check(true)
+	}
+}
+
+// A leakDetector tracks the goroutines waiting on a latch.
+// It must not reference the latch, so that goroutines blocked on an abandoned latch do not keep it from being garbage collected.
+type leakDetector struct {
+	m      sync.Mutex
+	name   string
+	nextID uint64
+	stacks map[uint64]string
+	report func(WaiterLeak)
+}
+
+// add records the calling goroutine as a waiter and returns an id to pass to remove.
+func (detector *leakDetector) add() uint64 {
+	buf := make([]byte, 4096)
+	stack := string(buf[:runtime.Stack(buf, false)])
+	detector.m.Lock()
+	defer detector.m.Unlock()
+	detector.nextID++
+	detector.stacks[detector.nextID] = stack
+	return detector.nextID
+}
+
+func (detector *leakDetector) remove(id uint64) {
+	detector.m.Lock()
+	defer detector.m.Unlock()
+	delete(detector.stacks, id)
+}
+
+func (detector *leakDetector) count() int {
+	detector.m.Lock()
+	defer detector.m.Unlock()
+	return len(detector.stacks)
+}
+
+// check reports a leak if any goroutines are waiting.
+func (detector *leakDetector) check(collected bool) {
+	detector.m.Lock()
+	leak := WaiterLeak{Name: detector.name, Collected: collected}
+	for _, stack := range detector.stacks {
+		leak.Stacks = append(leak.Stacks, stack)
+	}
+	detector.m.Unlock()
+	if len(leak.Stacks) > 0 {
+		detector.report(leak)
+	}
+}
@@ -0,0 +1,61 @@
+package congo
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCountDownLatch_closeLeak(t *testing.T) {
+	leaks := make(chan WaiterLeak, 1)
+	latch := New(1, WithName("abandoned"), WithLeakDetection(func(leak WaiterLeak) {
+		leaks <- leak
+	}))
+
+	go latch.Wait()
+	for latch.Waiters() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	assertEqual(t, ErrCountDownLatchAbandoned, latch.Close())
+	leak := <-leaks
+	assertEqual(t, "abandoned", leak.Name)
+	assertEqual(t, false, leak.Collected)
+	assertEqual(t, 1, len(leak.Stacks))
+	assertEqual(t, true, strings.Contains(leak.Stacks[0], "TestCountDownLatch_closeLeak"))
+	assertEqual(t, true, strings.Contains(leak.String(), `latch "abandoned" closed with 1 goroutine(s)`))
+
+	// closing a completed latch is not a leak
+	assertNil(t, latch.CountDown())
+	assertNil(t, latch.Close())
+	assertNil(t, New(0).Close())
+	assertEqual(t, 0, len(leaks))
+}
+
+func TestCountDownLatch_collectedLeak(t *testing.T) {
+	leaks := make(chan WaiterLeak, 1)
+	func() {
+		latch := New(1, WithName("dropped"), WithLeakDetection(func(leak WaiterLeak) {
+			leaks <- leak
+		}))
+		go latch.Wait()
+		for latch.Waiters() < 1 {
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		runtime.GC()
+		select {
+		case leak := <-leaks:
+			assertEqual(t, "dropped", leak.Name)
+			assertEqual(t, true, leak.Collected)
+			assertEqual(t, 1, len(leak.Stacks))
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	t.Fatal("Leak of garbage collected latch not reported")
+}
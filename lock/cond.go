@@ -0,0 +1,103 @@
+package lock
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// A Cond is a condition variable like sync.Cond, a rendezvous point for goroutines waiting for or announcing a change
+// of the state guarded by its locker L, whose waits can also be bounded by a timeout or a context.
+// Like with sync.Cond, L must be held while waiting, and waiters check their condition again in a loop once woken up.
+//
+// Waiters are woken up in arrival order. A waiter giving up by a timeout or a context never swallows a signal:
+// if it was signaled meanwhile, it reports that it was woken up. Signal and Broadcast may be called with or without L held.
+//
+// The zero value is usable once L is set, and a Cond must not be copied after first use.
+type Cond struct {
+	// L is held while observing or changing the condition.
+	L sync.Locker
+
+	m       sync.Mutex
+	waiters list.List // the channels of the waiting goroutines, closed when they are woken up
+}
+
+// NewCond creates a Cond with locker l.
+func NewCond(l sync.Locker) *Cond {
+	return &Cond{L: l}
+}
+
+// Wait unlocks L, waits until woken up by Signal or Broadcast, and locks L again before returning.
+func (cond *Cond) Wait() {
+	cond.wait(nil, nil)
+}
+
+// WaitTimeout unlocks L, waits until woken up by Signal or Broadcast, or until a given timeout,
+// and locks L again before returning. It reports whether it was woken up before the timeout.
+func (cond *Cond) WaitTimeout(timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	return cond.wait(timer.C, nil)
+}
+
+// WaitContext unlocks L, waits until woken up by Signal or Broadcast, or until the context is done,
+// and locks L again before returning. If the context is done first, WaitContext returns the context's error.
+// If the context is already done, WaitContext returns its error without unlocking L.
+func (cond *Cond) WaitContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !cond.wait(nil, ctx.Done()) {
+		return ctx.Err()
+	}
+	return nil
+}
+
+// Signal wakes up the goroutine waiting the longest, if any.
+func (cond *Cond) Signal() {
+	cond.m.Lock()
+	defer cond.m.Unlock()
+	if e := cond.waiters.Front(); e != nil {
+		close(cond.waiters.Remove(e).(chan struct{}))
+	}
+}
+
+// Broadcast wakes up all the waiting goroutines.
+func (cond *Cond) Broadcast() {
+	cond.m.Lock()
+	defer cond.m.Unlock()
+	for e := cond.waiters.Front(); e != nil; e = e.Next() {
+		close(e.Value.(chan struct{}))
+	}
+	cond.waiters.Init()
+}
+
+// wait unlocks L, waits until woken up, timeout fires or done is closed, and locks L again.
+// A nil timeout or done never fires. wait reports whether it was woken up.
+func (cond *Cond) wait(timeout <-chan time.Time, done <-chan struct{}) bool {
+	ch := make(chan struct{})
+	cond.m.Lock()
+	e := cond.waiters.PushBack(ch)
+	cond.m.Unlock()
+
+	cond.L.Unlock()
+	defer cond.L.Lock()
+	select {
+	case <-ch:
+		return true
+	case <-timeout:
+	case <-done:
+	}
+
+	cond.m.Lock()
+	defer cond.m.Unlock()
+	select {
+	case <-ch:
+		// woken up while giving up
+		return true
+	default:
+	}
+	cond.waiters.Remove(e)
+	return false
+}
@@ -0,0 +1,119 @@
+package lock
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func ExampleCond_WaitTimeout() {
+	var m sync.Mutex
+	cond := NewCond(&m)
+	var queue []int
+	go func() {
+		m.Lock()
+		defer m.Unlock()
+		queue = append(queue, 42)
+		cond.Signal()
+	}()
+
+	m.Lock()
+	defer m.Unlock()
+	for len(queue) == 0 {
+		if !cond.WaitTimeout(time.Second) {
+			fmt.Println("Timed out")
+			return
+		}
+	}
+	fmt.Println(queue[0])
+	// Output: 42
+}
+
+func TestCond(t *testing.T) {
+	var m sync.Mutex
+	cond := NewCond(&m)
+
+	// waiters are woken up in arrival order
+	woken := make(chan int, 3)
+	for i := 0; i < 3; i++ {
+		go func(i int) {
+			m.Lock()
+			defer m.Unlock()
+			cond.Wait()
+			woken <- i
+		}(i)
+		waitWaiters(cond, i+1)
+	}
+	for i := 0; i < 3; i++ {
+		cond.Signal()
+		assertEqual(t, i, <-woken)
+	}
+	cond.Signal()
+
+	for i := 0; i < 3; i++ {
+		go func(i int) {
+			m.Lock()
+			defer m.Unlock()
+			assertEqual(t, true, cond.WaitTimeout(time.Minute))
+			woken <- i
+		}(i)
+	}
+	waitWaiters(cond, 3)
+	m.Lock()
+	cond.Broadcast()
+	m.Unlock()
+	for i := 0; i < 3; i++ {
+		<-woken
+	}
+	waitWaiters(cond, 0)
+}
+
+func TestCond_timeout(t *testing.T) {
+	var m Mutex
+	cond := NewCond(&m)
+	m.Lock()
+	start := time.Now()
+	assertEqual(t, false, cond.WaitTimeout(10*time.Millisecond))
+	assertEqual(t, true, time.Since(start) >= 10*time.Millisecond)
+	assertEqual(t, false, m.TryLock())
+	waitWaiters(cond, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assertEqual(t, context.Canceled, cond.WaitContext(ctx))
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assertEqual(t, context.DeadlineExceeded, cond.WaitContext(ctx))
+	assertEqual(t, false, m.TryLock())
+
+	// a waiter signaled by the time it gives up reports that it was woken up
+	done := make(chan error)
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		m.Lock()
+		defer m.Unlock()
+		done <- cond.WaitContext(ctx)
+	}()
+	m.Unlock()
+	waitWaiters(cond, 1)
+	m.Lock()
+	cond.Signal()
+	cancel()
+	m.Unlock()
+	assertNil(t, <-done)
+}
+
+// waitWaiters waits until the given number of goroutines wait for the condition.
+func waitWaiters(cond *Cond, n int) {
+	for {
+		cond.m.Lock()
+		waiters := cond.waiters.Len()
+		cond.m.Unlock()
+		if waiters == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,111 @@
+package lock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nvn1729/congo/deadlock"
+)
+
+func TestMutex_deadlock(t *testing.T) {
+	reports := make(chan deadlock.Deadlock, 1)
+	deadlock.Enable(10*time.Millisecond, func(d deadlock.Deadlock) {
+		reports <- d
+	})
+	defer deadlock.Disable()
+
+	// two goroutines lock two mutexes in opposite orders
+	var first, second Mutex
+	locked, start := make(chan struct{}), make(chan struct{})
+	done := make(chan struct{}, 2)
+	go func() {
+		first.Lock()
+		locked <- struct{}{}
+		<-start
+		second.Lock()
+		done <- struct{}{}
+	}()
+	go func() {
+		second.Lock()
+		locked <- struct{}{}
+		<-start
+		first.Lock()
+		done <- struct{}{}
+	}()
+	<-locked
+	<-locked
+	close(start)
+	d := <-reports
+	assertEqual(t, 2, len(d.Goroutines))
+	for _, g := range d.Goroutines {
+		assertEqual(t, 1, len(g.Holding))
+		assertEqual(t, true, g.WaitingFor != g.Holding[0].Resource)
+	}
+
+	// mutexes may be unlocked by other goroutines, which breaks the deadlock
+	first.Unlock()
+	<-done
+	second.Unlock()
+	<-done
+}
+
+func TestTicketLock_deadlock(t *testing.T) {
+	reports := make(chan deadlock.Deadlock, 1)
+	deadlock.Enable(10*time.Millisecond, func(d deadlock.Deadlock) {
+		reports <- d
+	})
+	defer deadlock.Disable()
+
+	// a goroutine locks a lock it holds
+	var lock TicketLock
+	done := make(chan struct{})
+	go func() {
+		lock.Lock()
+		lock.Lock()
+		close(done)
+	}()
+	d := <-reports
+	assertEqual(t, 1, len(d.Goroutines))
+	assertEqual(t, d.Goroutines[0].WaitingFor, d.Goroutines[0].Holding[0].Resource)
+	lock.Unlock()
+	<-done
+	lock.Unlock()
+}
+
+func TestReentrantRWLock_deadlock(t *testing.T) {
+	reports := make(chan deadlock.Deadlock, 1)
+	deadlock.Enable(10*time.Millisecond, func(d deadlock.Deadlock) {
+		reports <- d
+	})
+	defer deadlock.Disable()
+
+	// a reader waits for a mutex held by a writer waiting for the reader to unlock
+	var lock ReentrantRWLock
+	var mutex Mutex
+	locked, start := make(chan struct{}), make(chan struct{})
+	done := make(chan struct{}, 2)
+	go func() {
+		lock.RLock()
+		locked <- struct{}{}
+		<-start
+		mutex.Lock()
+		lock.RUnlock()
+		done <- struct{}{}
+	}()
+	go func() {
+		mutex.Lock()
+		locked <- struct{}{}
+		<-start
+		lock.Lock()
+		lock.Unlock()
+		done <- struct{}{}
+	}()
+	<-locked
+	<-locked
+	close(start)
+	d := <-reports
+	assertEqual(t, 2, len(d.Goroutines))
+	mutex.Unlock()
+	<-done
+	<-done
+}
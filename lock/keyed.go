@@ -0,0 +1,129 @@
+package lock
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// A KeyedMutex maintains a Mutex per key, serializing the operations on resources identified at runtime, such as files, records or users,
+// with a single object.
+//
+// The mutex of a key is created when the key is first locked, and evicted as soon as the key is unlocked with no goroutine waiting for it,
+// so that keys do not accumulate over time. Like a Mutex, the lock of a key is not associated with a particular goroutine.
+type KeyedMutex struct {
+	m       sync.Mutex
+	entries map[string]*keyedMutexEntry
+}
+
+// A keyedMutexEntry is the mutex of a key, and the number of goroutines holding or waiting for it.
+type keyedMutexEntry struct {
+	mutex Mutex
+	refs  int
+}
+
+// NewKeyedMutex creates a KeyedMutex with no key locked.
+func NewKeyedMutex() *KeyedMutex {
+	return &KeyedMutex{entries: make(map[string]*keyedMutexEntry)}
+}
+
+// Lock locks the key, waiting until it is available, like Mutex.Lock.
+func (keyed *KeyedMutex) Lock(key string) {
+	keyed.lock(key, func(mutex *Mutex) bool {
+		mutex.Lock()
+		return true
+	})
+}
+
+// TryLock locks the key only if it is available without waiting, and reports whether it did, like Mutex.TryLock.
+func (keyed *KeyedMutex) TryLock(key string) bool {
+	return keyed.lock(key, func(mutex *Mutex) bool {
+		return mutex.TryLock()
+	})
+}
+
+// LockTimeout locks the key, waiting until a given timeout for it to be available, like Mutex.LockTimeout.
+func (keyed *KeyedMutex) LockTimeout(key string, timeout time.Duration) bool {
+	return keyed.lock(key, func(mutex *Mutex) bool {
+		return mutex.LockTimeout(timeout)
+	})
+}
+
+// LockContext locks the key, waiting until it is available or the context is done, like Mutex.LockContext.
+func (keyed *KeyedMutex) LockContext(ctx context.Context, key string) error {
+	var err error
+	keyed.lock(key, func(mutex *Mutex) bool {
+		err = mutex.LockContext(ctx)
+		return err == nil
+	})
+	return err
+}
+
+// Unlock unlocks the key, evicting its mutex if no goroutine waits for it. Unlock panics if the key is not locked.
+func (keyed *KeyedMutex) Unlock(key string) {
+	keyed.m.Lock()
+	defer keyed.m.Unlock()
+	entry, ok := keyed.entries[key]
+	if !ok {
+		panic("lock: unlock of unlocked key")
+	}
+	entry.mutex.Unlock()
+	keyed.release(key, entry)
+}
+
+// Locker returns a sync.Locker locking and unlocking the key.
+func (keyed *KeyedMutex) Locker(key string) sync.Locker {
+	return keyLocker{keyed: keyed, key: key}
+}
+
+// Len returns the number of keys locked or waited for.
+func (keyed *KeyedMutex) Len() int {
+	keyed.m.Lock()
+	defer keyed.m.Unlock()
+	return len(keyed.entries)
+}
+
+// lock locks the mutex of the key with the given function, which reports whether it did.
+func (keyed *KeyedMutex) lock(key string, lock func(*Mutex) bool) bool {
+	keyed.m.Lock()
+	entry, ok := keyed.entries[key]
+	if !ok {
+		entry = &keyedMutexEntry{}
+		keyed.entries[key] = entry
+	}
+	entry.refs++
+	keyed.m.Unlock()
+
+	locked := false
+	defer func() {
+		if !locked {
+			keyed.m.Lock()
+			defer keyed.m.Unlock()
+			keyed.release(key, entry)
+		}
+	}()
+	locked = lock(&entry.mutex)
+	return locked
+}
+
+// release drops a reference to the mutex of the key, evicting it once no goroutine holds or waits for it.
+// This call must be guarded using the keyed mutex internal mutex.
+func (keyed *KeyedMutex) release(key string, entry *keyedMutexEntry) {
+	if entry.refs--; entry.refs == 0 {
+		delete(keyed.entries, key)
+	}
+}
+
+// A keyLocker is a sync.Locker locking a key of a KeyedMutex.
+type keyLocker struct {
+	keyed *KeyedMutex
+	key   string
+}
+
+func (locker keyLocker) Lock() {
+	locker.keyed.Lock(locker.key)
+}
+
+func (locker keyLocker) Unlock() {
+	locker.keyed.Unlock(locker.key)
+}
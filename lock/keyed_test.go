@@ -0,0 +1,73 @@
+package lock
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestKeyedMutex(t *testing.T) {
+	keyed := NewKeyedMutex()
+	counters := make([]int, 5)
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				k := (i + j) % len(counters)
+				keyed.Lock(strconv.Itoa(k))
+				counters[k]++
+				keyed.Unlock(strconv.Itoa(k))
+			}
+		}(i)
+	}
+	wg.Wait()
+	for _, counter := range counters {
+		assertEqual(t, 1000, counter)
+	}
+	assertEqual(t, 0, keyed.Len())
+}
+
+func TestKeyedMutex_keys(t *testing.T) {
+	keyed := NewKeyedMutex()
+	assertEqual(t, true, keyed.TryLock("a"))
+	assertEqual(t, false, keyed.TryLock("a"))
+	assertEqual(t, true, keyed.LockTimeout("b", time.Millisecond))
+	assertEqual(t, false, keyed.LockTimeout("b", 10*time.Millisecond))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assertEqual(t, context.DeadlineExceeded, keyed.LockContext(ctx, "a"))
+	assertEqual(t, 2, keyed.Len())
+
+	// keys are evicted once unlocked with no goroutine waiting
+	locked := make(chan struct{})
+	go func() {
+		keyed.Locker("a").Lock()
+		close(locked)
+	}()
+	for {
+		keyed.m.Lock()
+		refs := keyed.entries["a"].refs
+		keyed.m.Unlock()
+		if refs == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	keyed.Unlock("a")
+	<-locked
+	assertEqual(t, 2, keyed.Len())
+	keyed.Locker("a").Unlock()
+	keyed.Unlock("b")
+	assertEqual(t, 0, keyed.Len())
+	assertNil(t, keyed.LockContext(context.Background(), "c"))
+	assertEqual(t, 1, keyed.Len())
+}
+
+func TestKeyedMutex_unlockUnlocked(t *testing.T) {
+	keyed := NewKeyedMutex()
+	assertPanics(t, func() { keyed.Unlock("a") })
+}
@@ -0,0 +1,112 @@
+package lock
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/nvn1729/congo/deadlock"
+)
+
+// An MCSLock is a mutual exclusion lock in the style of Mellor-Crummey and Scott's queue lock: waiting goroutines queue up,
+// and each spins on a flag of its own queue node, on its own cache line, until its predecessor hands the lock over.
+// Locks on which all waiters spin on a shared word, like a ticket lock, make every handover invalidate the cache line
+// of every waiter, and their throughput collapses as the number of cores grows; an MCSLock hands over with a single write
+// to the cache line of the next waiter. Like a TicketLock, it is granted in arrival order.
+//
+// Waiters spin rather than sleep, yielding the processor between rounds of spinning, so an MCSLock suits short critical sections
+// contended by no more goroutines than there are cores. Otherwise, spinning wastes the processors the holder needs: use a Mutex.
+//
+// The zero value is an unlocked lock, and an MCSLock must not be copied after first use.
+// Like a Mutex, a locked MCSLock is not associated with a particular goroutine.
+type MCSLock struct {
+	tail  unsafe.Pointer // the *mcsNode of the last goroutine to queue up, set atomically, or nil while unlocked
+	owner *mcsNode       // the node of the goroutine owning the lock
+	held  *acquisition   // the acquisition holding the lock, while profiling
+}
+
+// An mcsNode is the queue node of a goroutine locking an MCSLock, padded to its own cache line.
+type mcsNode struct {
+	next    unsafe.Pointer // the *mcsNode of the next goroutine in the queue, set atomically
+	waiting uint32         // set atomically, 1 until the lock is handed over
+	_       [64 - 12]byte
+}
+
+// mcsSpins is the number of times a waiter checks its node before yielding the processor.
+const mcsSpins = 64
+
+// mcsNodes recycles the queue nodes.
+var mcsNodes = sync.Pool{
+	New: func() interface{} {
+		return new(mcsNode)
+	},
+}
+
+// Lock locks the lock, waiting for the goroutines queued before the calling goroutine.
+func (lock *MCSLock) Lock() {
+	a := startAcquisition()
+	node := mcsNodes.Get().(*mcsNode)
+	atomic.StorePointer(&node.next, nil)
+	atomic.StoreUint32(&node.waiting, 1)
+	prev := (*mcsNode)(atomic.SwapPointer(&lock.tail, unsafe.Pointer(node)))
+	if prev == nil {
+		deadlock.Acquired(lock, 1)
+	} else {
+		wait := deadlock.Waiting(lock)
+		atomic.StorePointer(&prev.next, unsafe.Pointer(node))
+		for i := 1; atomic.LoadUint32(&node.waiting) == 1; i++ {
+			if i%mcsSpins == 0 {
+				runtime.Gosched()
+			}
+		}
+		wait.Acquired(1)
+	}
+	lock.owner = node
+	lock.held = a.acquire(prev != nil)
+}
+
+// TryLock locks the lock only if it is available without waiting, and reports whether it did.
+func (lock *MCSLock) TryLock() bool {
+	a := startAcquisition()
+	node := mcsNodes.Get().(*mcsNode)
+	atomic.StorePointer(&node.next, nil)
+	if !atomic.CompareAndSwapPointer(&lock.tail, nil, unsafe.Pointer(node)) {
+		mcsNodes.Put(node)
+		return false
+	}
+	deadlock.Acquired(lock, 1)
+	lock.owner = node
+	lock.held = a.acquire(false)
+	return true
+}
+
+// Unlock unlocks the lock, handing it over to the next queued goroutine, if any. It may be called by a goroutine other than
+// the one that locked the lock. Unlock panics if the lock is not locked.
+func (lock *MCSLock) Unlock() {
+	node := lock.owner
+	if node == nil {
+		panic("lock: unlock of unlocked MCS lock")
+	}
+	lock.owner = nil
+	held := lock.held
+	lock.held = nil
+	held.release()
+	deadlock.Released(lock, 1)
+	next := atomic.LoadPointer(&node.next)
+	if next == nil {
+		if atomic.CompareAndSwapPointer(&lock.tail, unsafe.Pointer(node), nil) {
+			mcsNodes.Put(node)
+			return
+		}
+		// a goroutine queued up, and is about to link its node to ours
+		for i := 1; next == nil; i++ {
+			if i%mcsSpins == 0 {
+				runtime.Gosched()
+			}
+			next = atomic.LoadPointer(&node.next)
+		}
+	}
+	atomic.StoreUint32(&(*mcsNode)(next).waiting, 0)
+	mcsNodes.Put(node)
+}
@@ -0,0 +1,104 @@
+package lock
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestMCSLock(t *testing.T) {
+	var lock MCSLock
+	var _ sync.Locker = &lock
+	counter := 0
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				lock.Lock()
+				counter++
+				lock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assertEqual(t, 5000, counter)
+	assertEqual(t, true, lock.TryLock())
+	lock.Unlock()
+}
+
+func TestMCSLock_tryLock(t *testing.T) {
+	var lock MCSLock
+	assertEqual(t, true, lock.TryLock())
+	assertEqual(t, false, lock.TryLock())
+
+	// the lock may be unlocked by another goroutine, handing it over to the queued goroutine
+	locked := make(chan struct{})
+	go func() {
+		lock.Lock()
+		close(locked)
+	}()
+	inGoroutine(lock.Unlock)
+	<-locked
+	assertEqual(t, false, lock.TryLock())
+	lock.Unlock()
+	assertEqual(t, true, lock.TryLock())
+}
+
+func TestMCSLock_unlockUnlocked(t *testing.T) {
+	var lock MCSLock
+	assertPanics(t, lock.Unlock)
+}
+
+func BenchmarkMCSLock_4(b *testing.B) {
+	benchmarkLocker(b, 4, &MCSLock{})
+}
+
+func BenchmarkMCSLock_64(b *testing.B) {
+	benchmarkLocker(b, 64, &MCSLock{})
+}
+
+func BenchmarkTicketLock_4(b *testing.B) {
+	benchmarkLocker(b, 4, &TicketLock{})
+}
+
+func BenchmarkTicketLock_64(b *testing.B) {
+	benchmarkLocker(b, 64, &TicketLock{})
+}
+
+func BenchmarkMutex_4(b *testing.B) {
+	benchmarkLocker(b, 4, &Mutex{})
+}
+
+func BenchmarkMutex_64(b *testing.B) {
+	benchmarkLocker(b, 64, &Mutex{})
+}
+
+func BenchmarkSyncMutex_4(b *testing.B) {
+	benchmarkLocker(b, 4, &sync.Mutex{})
+}
+
+func BenchmarkSyncMutex_64(b *testing.B) {
+	benchmarkLocker(b, 64, &sync.Mutex{})
+}
+
+// benchmarkLocker runs b.N short critical sections guarded by the lock, shared among the given number of goroutines,
+// to compare how the throughput of locks scales with contention, e.g. with -cpu 1,4,16,64.
+func benchmarkLocker(b *testing.B, goroutines int, lock sync.Locker) {
+	var wg sync.WaitGroup
+	var counter [8]uint64
+	b.ReportAllocs()
+	b.ResetTimer()
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := g; i < b.N; i += goroutines {
+				lock.Lock()
+				counter[i%len(counter)]++
+				lock.Unlock()
+			}
+		}(g)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,89 @@
+package lock
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// A Monitor bundles a Mutex with named conditions, each a Cond of the mutex, in the style of the monitors of Hoare and Hansen:
+// goroutines lock the monitor, wait for a condition by name, such as "notEmpty", until another goroutine signals it,
+// and check again the state guarded by the monitor. Conditions are created on first use, and kept for the life of the monitor.
+//
+// The methods waiting for a condition panic if the monitor is not locked, rather than waiting for a signal that cannot come.
+// Signal and Broadcast may be called with or without the monitor locked.
+//
+// The zero value is an unlocked monitor, and a Monitor must not be copied after first use.
+type Monitor struct {
+	Mutex
+
+	m     sync.Mutex
+	conds map[string]*Cond
+}
+
+// Wait unlocks the monitor, waits until the named condition is signaled, and locks the monitor again before returning.
+// Wait panics if the monitor is not locked.
+func (monitor *Monitor) Wait(name string) {
+	monitor.cond(name).Wait()
+}
+
+// WaitTimeout unlocks the monitor, waits until the named condition is signaled, or until a given timeout,
+// and locks the monitor again before returning. It reports whether the condition was signaled before the timeout.
+// WaitTimeout panics if the monitor is not locked.
+func (monitor *Monitor) WaitTimeout(name string, timeout time.Duration) bool {
+	return monitor.cond(name).WaitTimeout(timeout)
+}
+
+// WaitContext unlocks the monitor, waits until the named condition is signaled, or until the context is done,
+// and locks the monitor again before returning. If the context is done first, WaitContext returns the context's error.
+// WaitContext panics if the monitor is not locked.
+func (monitor *Monitor) WaitContext(ctx context.Context, name string) error {
+	return monitor.cond(name).WaitContext(ctx)
+}
+
+// WaitUntil waits for the named condition until ready returns true, checking it first, with the monitor locked.
+// WaitUntil panics if the monitor is not locked.
+func (monitor *Monitor) WaitUntil(name string, ready func() bool) {
+	cond := monitor.cond(name)
+	for !ready() {
+		cond.Wait()
+	}
+}
+
+// Signal wakes up the goroutine waiting the longest for the named condition, if any.
+func (monitor *Monitor) Signal(name string) {
+	monitor.m.Lock()
+	cond := monitor.conds[name]
+	monitor.m.Unlock()
+	if cond != nil {
+		cond.Signal()
+	}
+}
+
+// Broadcast wakes up all the goroutines waiting for the named condition.
+func (monitor *Monitor) Broadcast(name string) {
+	monitor.m.Lock()
+	cond := monitor.conds[name]
+	monitor.m.Unlock()
+	if cond != nil {
+		cond.Broadcast()
+	}
+}
+
+// cond returns the named condition, creating it on first use, to wait for it. cond panics if the monitor is not locked.
+func (monitor *Monitor) cond(name string) *Cond {
+	if len(monitor.getCh()) == 0 {
+		panic("lock: wait on unlocked monitor")
+	}
+	monitor.m.Lock()
+	defer monitor.m.Unlock()
+	cond, ok := monitor.conds[name]
+	if !ok {
+		if monitor.conds == nil {
+			monitor.conds = make(map[string]*Cond)
+		}
+		cond = NewCond(&monitor.Mutex)
+		monitor.conds[name] = cond
+	}
+	return cond
+}
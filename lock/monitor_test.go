@@ -0,0 +1,107 @@
+package lock
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// A boundedBuffer is a classic bounded buffer guarded by a Monitor.
+type boundedBuffer struct {
+	monitor Monitor
+	items   []int
+	size    int
+}
+
+func (buffer *boundedBuffer) put(item int) {
+	buffer.monitor.Lock()
+	defer buffer.monitor.Unlock()
+	buffer.monitor.WaitUntil("notFull", func() bool { return len(buffer.items) < buffer.size })
+	buffer.items = append(buffer.items, item)
+	buffer.monitor.Signal("notEmpty")
+}
+
+func (buffer *boundedBuffer) take() int {
+	buffer.monitor.Lock()
+	defer buffer.monitor.Unlock()
+	for len(buffer.items) == 0 {
+		buffer.monitor.Wait("notEmpty")
+	}
+	item := buffer.items[0]
+	buffer.items = buffer.items[1:]
+	buffer.monitor.Signal("notFull")
+	return item
+}
+
+func ExampleMonitor() {
+	buffer := &boundedBuffer{size: 2}
+	go func() {
+		for i := 1; i <= 5; i++ {
+			buffer.put(i)
+		}
+	}()
+
+	sum := 0
+	for i := 0; i < 5; i++ {
+		sum += buffer.take()
+	}
+	fmt.Println("Sum:", sum)
+	// Output:
+	// Sum: 15
+}
+
+func TestMonitor(t *testing.T) {
+	buffer := &boundedBuffer{size: 3}
+	done := make(chan int)
+	for p := 0; p < 4; p++ {
+		go func() {
+			for i := 0; i < 100; i++ {
+				buffer.put(1)
+			}
+		}()
+		go func() {
+			sum := 0
+			for i := 0; i < 100; i++ {
+				sum += buffer.take()
+			}
+			done <- sum
+		}()
+	}
+	total := 0
+	for p := 0; p < 4; p++ {
+		total += <-done
+	}
+	assertEqual(t, 400, total)
+	assertEqual(t, 0, len(buffer.items))
+}
+
+func TestMonitor_timeout(t *testing.T) {
+	var monitor Monitor
+	monitor.Signal("ready")
+	monitor.Broadcast("ready")
+	monitor.Lock()
+	assertEqual(t, false, monitor.WaitTimeout("ready", 10*time.Millisecond))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assertEqual(t, context.DeadlineExceeded, monitor.WaitContext(ctx, "ready"))
+
+	// conditions are independent
+	woken := make(chan bool)
+	go func() {
+		monitor.Lock()
+		defer monitor.Unlock()
+		woken <- monitor.WaitTimeout("ready", time.Minute)
+	}()
+	monitor.Unlock()
+	waitWaiters(monitor.conds["ready"], 1)
+	monitor.Broadcast("other")
+	monitor.Broadcast("ready")
+	assertEqual(t, true, <-woken)
+}
+
+func TestMonitor_waitUnlocked(t *testing.T) {
+	var monitor Monitor
+	assertPanics(t, func() { monitor.Wait("ready") })
+	assertPanics(t, func() { monitor.WaitUntil("ready", func() bool { return true }) })
+}
@@ -0,0 +1,126 @@
+// Package lock provides locks complementing those of the sync package.
+package lock
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nvn1729/congo/deadlock"
+)
+
+// A Mutex is a mutual exclusion lock like sync.Mutex, which can also be acquired without waiting, with a timeout or with a context.
+// Like sync.Mutex, the zero value is an unlocked mutex, a Mutex must not be copied after first use,
+// and a locked Mutex is not associated with a particular goroutine.
+type Mutex struct {
+	once sync.Once
+	ch   chan struct{} // holds a value while the mutex is locked
+	held *acquisition  // the acquisition holding the mutex, while profiling
+}
+
+// getCh returns the channel of the mutex, creating it on first use.
+func (mutex *Mutex) getCh() chan struct{} {
+	mutex.once.Do(func() {
+		mutex.ch = make(chan struct{}, 1)
+	})
+	return mutex.ch
+}
+
+// Lock locks the mutex, waiting until it is available.
+func (mutex *Mutex) Lock() {
+	a := startAcquisition()
+	if mutex.tryLock() {
+		mutex.held = a.acquire(false)
+		return
+	}
+	wait := deadlock.Waiting(mutex)
+	mutex.getCh() <- struct{}{}
+	wait.Acquired(1)
+	mutex.held = a.acquire(true)
+}
+
+// TryLock locks the mutex only if it is available without waiting, and reports whether it did.
+func (mutex *Mutex) TryLock() bool {
+	a := startAcquisition()
+	if !mutex.tryLock() {
+		return false
+	}
+	mutex.held = a.acquire(false)
+	return true
+}
+
+// LockTimeout locks the mutex, waiting until a given timeout for it to be available.
+// If the mutex is locked before the timeout, LockTimeout returns true. Otherwise it returns false.
+func (mutex *Mutex) LockTimeout(timeout time.Duration) bool {
+	a := startAcquisition()
+	if mutex.tryLock() {
+		mutex.held = a.acquire(false)
+		return true
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	wait := deadlock.Waiting(mutex)
+	select {
+	case mutex.getCh() <- struct{}{}:
+		wait.Acquired(1)
+		mutex.held = a.acquire(true)
+		return true
+	case <-timer.C:
+		wait.Abandoned()
+		a.abandon()
+		return false
+	}
+}
+
+// LockContext locks the mutex, waiting until it is available or the context is done.
+// If the context is done first, LockContext returns the context's error without locking the mutex.
+// If the context is already done, LockContext does not lock the mutex.
+func (mutex *Mutex) LockContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	a := startAcquisition()
+	if mutex.tryLock() {
+		mutex.held = a.acquire(false)
+		return nil
+	}
+	wait := deadlock.Waiting(mutex)
+	select {
+	case mutex.getCh() <- struct{}{}:
+		wait.Acquired(1)
+		mutex.held = a.acquire(true)
+		return nil
+	case <-ctx.Done():
+		wait.Abandoned()
+		a.abandon()
+		return ctx.Err()
+	}
+}
+
+// Unlock unlocks the mutex. It may be called by a goroutine other than the one that locked the mutex.
+// Unlock panics if the mutex is not locked.
+func (mutex *Mutex) Unlock() {
+	if len(mutex.getCh()) == 0 {
+		panic("lock: unlock of unlocked mutex")
+	}
+	held := mutex.held
+	mutex.held = nil
+	held.release()
+	deadlock.Released(mutex, 1)
+	select {
+	case <-mutex.getCh():
+	default:
+		panic("lock: unlock of unlocked mutex")
+	}
+}
+
+// tryLock locks the mutex only if it is available without waiting, and reports whether it did.
+func (mutex *Mutex) tryLock() bool {
+	select {
+	case mutex.getCh() <- struct{}{}:
+		deadlock.Acquired(mutex, 1)
+		return true
+	default:
+		return false
+	}
+}
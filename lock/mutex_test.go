@@ -0,0 +1,105 @@
+package lock
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func ExampleMutex_LockTimeout() {
+	var mutex Mutex
+	mutex.Lock()
+	if !mutex.LockTimeout(10 * time.Millisecond) {
+		fmt.Println("Mutex busy")
+	}
+	mutex.Unlock()
+	// Output:
+	// Mutex busy
+}
+
+func TestMutex(t *testing.T) {
+	var mutex Mutex
+	var _ sync.Locker = &mutex
+	counter := 0
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				mutex.Lock()
+				counter++
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assertEqual(t, 5000, counter)
+}
+
+func TestMutex_tryLock(t *testing.T) {
+	var mutex Mutex
+	assertEqual(t, true, mutex.TryLock())
+	assertEqual(t, false, mutex.TryLock())
+	mutex.Unlock()
+	assertEqual(t, true, mutex.TryLock())
+}
+
+func TestMutex_lockTimeout(t *testing.T) {
+	var mutex Mutex
+	assertEqual(t, true, mutex.LockTimeout(time.Second))
+	assertEqual(t, false, mutex.LockTimeout(50*time.Millisecond))
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		mutex.Unlock()
+	}()
+	assertEqual(t, true, mutex.LockTimeout(time.Second))
+}
+
+func TestMutex_lockContext(t *testing.T) {
+	var mutex Mutex
+	assertNil(t, mutex.LockContext(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	locked := make(chan error)
+	go func() {
+		locked <- mutex.LockContext(ctx)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	assertEqual(t, context.Canceled, <-locked)
+
+	// a done context does not lock an available mutex
+	mutex.Unlock()
+	assertEqual(t, context.Canceled, mutex.LockContext(ctx))
+	assertEqual(t, true, mutex.TryLock())
+}
+
+func TestMutex_unlockUnlocked(t *testing.T) {
+	defer func() {
+		assertNotNil(t, recover())
+	}()
+	var mutex Mutex
+	mutex.Unlock()
+	t.Fatal("Unlock did not panic")
+}
+
+func assertEqual(t *testing.T, expected interface{}, actual interface{}) {
+	if expected != actual {
+		t.Fatal("Not equal:", "expected:", expected, ", actual:", actual)
+	}
+}
+
+func assertNil(t *testing.T, actual interface{}) {
+	if actual != nil {
+		t.Fatal("Value not nil, actual:", actual)
+	}
+}
+
+func assertNotNil(t *testing.T, actual interface{}) {
+	if actual == nil {
+		t.Fatal("Value is nil")
+	}
+}
@@ -0,0 +1,283 @@
+package lock
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+)
+
+// A SiteProfile is the contention profile of the locks acquired from a call site, as recorded while profiling is enabled
+// with EnableProfiling.
+type SiteProfile struct {
+	// Site is the function and the file:line of the call site: the first caller outside of the lock package.
+	Site string
+
+	// Acquisitions is the number of times the locks were acquired, including those given up by a timeout or a context.
+	Acquisitions int64
+
+	// Contended is the number of acquisitions that waited for the locks.
+	Contended int64
+
+	// Abandoned is the number of acquisitions given up by a timeout or a context.
+	Abandoned int64
+
+	// Wait is the total time waited for the locks.
+	Wait time.Duration
+
+	// MaxWait is the longest time waited for the locks.
+	MaxWait time.Duration
+
+	// Hold is the total time the locks were held. Hold times are only recorded for exclusive locks:
+	// Mutex, ReentrantLock, TicketLock, MCSLock, KeyedMutex, and the write locks of ReentrantRWLock and StampedLock.
+	Hold time.Duration
+
+	// MaxHold is the longest time the locks were held.
+	MaxHold time.Duration
+}
+
+// profiling is 1 while profiling is enabled. It is set atomically.
+var profiling int32
+
+// profile holds the contention profiles recorded so far, by stack.
+var profile = struct {
+	m      sync.Mutex
+	stacks map[profileStack]*stackProfile
+}{stacks: make(map[profileStack]*stackProfile)}
+
+// maxProfileDepth is the maximum number of frames recorded per acquisition.
+const maxProfileDepth = 32
+
+// A profileStack is the stack of an acquisition, padded with zeros.
+type profileStack [maxProfileDepth]uintptr
+
+// A stackProfile is the contention profile of the acquisitions from a stack.
+type stackProfile struct {
+	acquisitions, contended, abandoned int64
+	wait, maxWait, hold, maxHold       time.Duration
+}
+
+// EnableProfiling enables the profiling of the locks of the package, except Striped and StripedRW, which are made of sync package mutexes.
+// Profiling records the stack of every acquisition, with the time it waited for the lock, and, for exclusive locks, the time it held it,
+// to find hot locks. Profiles accumulate until ResetProfile is called, and are returned by Profile, WriteReport,
+// WriteWaitProfile and WriteHoldProfile. While profiling is disabled, locks only pay for an atomic load per acquisition.
+func EnableProfiling() {
+	atomic.StoreInt32(&profiling, 1)
+}
+
+// DisableProfiling disables profiling. The acquisitions in progress are still recorded, and the profiles recorded so far are kept.
+func DisableProfiling() {
+	atomic.StoreInt32(&profiling, 0)
+}
+
+// ResetProfile forgets the profiles recorded so far.
+func ResetProfile() {
+	profile.m.Lock()
+	defer profile.m.Unlock()
+	profile.stacks = make(map[profileStack]*stackProfile)
+}
+
+// Profile returns the profiles recorded so far per call site, by decreasing wait time, and then by decreasing hold time.
+func Profile() []SiteProfile {
+	profile.m.Lock()
+	defer profile.m.Unlock()
+	bySite := make(map[string]*SiteProfile)
+	for stack, p := range profile.stacks {
+		site := callSite(stack)
+		s, ok := bySite[site]
+		if !ok {
+			s = &SiteProfile{Site: site}
+			bySite[site] = s
+		}
+		s.Acquisitions += p.acquisitions
+		s.Contended += p.contended
+		s.Abandoned += p.abandoned
+		s.Wait += p.wait
+		s.Hold += p.hold
+		if p.maxWait > s.MaxWait {
+			s.MaxWait = p.maxWait
+		}
+		if p.maxHold > s.MaxHold {
+			s.MaxHold = p.maxHold
+		}
+	}
+	sites := make([]SiteProfile, 0, len(bySite))
+	for _, s := range bySite {
+		sites = append(sites, *s)
+	}
+	sort.Slice(sites, func(i, j int) bool {
+		if sites[i].Wait != sites[j].Wait {
+			return sites[i].Wait > sites[j].Wait
+		}
+		if sites[i].Hold != sites[j].Hold {
+			return sites[i].Hold > sites[j].Hold
+		}
+		return sites[i].Site < sites[j].Site
+	})
+	return sites
+}
+
+// WriteReport writes the profiles returned by Profile as a table.
+func WriteReport(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "ACQUIRED\tCONTENDED\tABANDONED\tWAIT\tMAX WAIT\tHOLD\tMAX HOLD\t  SITE")
+	for _, s := range Profile() {
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%v\t%v\t%v\t%v\t  %s\n", s.Acquisitions, s.Contended, s.Abandoned, s.Wait, s.MaxWait, s.Hold, s.MaxHold, s.Site)
+	}
+	return tw.Flush()
+}
+
+// WriteWaitProfile writes the time waited for the locks per stack, in the legacy text format of contention profiles,
+// which go tool pprof reads, given the binary of the program.
+func WriteWaitProfile(w io.Writer) error {
+	return writeProfile(w, func(p *stackProfile) time.Duration {
+		return p.wait
+	})
+}
+
+// WriteHoldProfile writes the time the locks were held per stack, like WriteWaitProfile.
+func WriteHoldProfile(w io.Writer) error {
+	return writeProfile(w, func(p *stackProfile) time.Duration {
+		return p.hold
+	})
+}
+
+// writeProfile writes the given value of the profiles per stack, in nanoseconds, as the delay of a contention profile.
+func writeProfile(w io.Writer, value func(*stackProfile) time.Duration) error {
+	profile.m.Lock()
+	defer profile.m.Unlock()
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "--- contention:\ncycles/second=%d\nsampling period=1\n", time.Second)
+	for stack, p := range profile.stacks {
+		if value(p) == 0 {
+			continue
+		}
+		fmt.Fprintf(bw, "%d %d @", value(p), p.acquisitions)
+		for _, pc := range stack {
+			if pc == 0 {
+				break
+			}
+			fmt.Fprintf(bw, " %#x", pc)
+		}
+		fmt.Fprintln(bw)
+		frames := runtime.CallersFrames(trimStack(stack))
+		for {
+			frame, more := frames.Next()
+			fmt.Fprintf(bw, "#\t%#x\t%s+%#x\t%s:%d\n", frame.PC, frame.Function, frame.PC-frame.Entry, frame.File, frame.Line)
+			if !more {
+				break
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// An acquisition is an acquisition of a lock being profiled. The methods of a nil acquisition do nothing.
+type acquisition struct {
+	stack    profileStack
+	start    time.Time // when the lock was asked for
+	acquired time.Time // when the lock was acquired
+}
+
+// startAcquisition records the stack and the start of an acquisition, or returns nil if profiling is disabled.
+func startAcquisition() *acquisition {
+	if atomic.LoadInt32(&profiling) == 0 {
+		return nil
+	}
+	a := &acquisition{start: time.Now()}
+	runtime.Callers(2, a.stack[:])
+	return a
+}
+
+// acquire records that the lock was acquired, after waiting if contended, and returns the acquisition, to be released.
+func (a *acquisition) acquire(contended bool) *acquisition {
+	if a == nil {
+		return nil
+	}
+	a.acquired = time.Now()
+	a.record(func(p *stackProfile) {
+		p.acquisitions++
+		if contended {
+			wait := a.acquired.Sub(a.start)
+			p.contended++
+			p.wait += wait
+			if wait > p.maxWait {
+				p.maxWait = wait
+			}
+		}
+	})
+	return a
+}
+
+// abandon records that waiting for the lock was given up.
+func (a *acquisition) abandon() {
+	if a == nil {
+		return
+	}
+	wait := time.Since(a.start)
+	a.record(func(p *stackProfile) {
+		p.acquisitions++
+		p.contended++
+		p.abandoned++
+		p.wait += wait
+		if wait > p.maxWait {
+			p.maxWait = wait
+		}
+	})
+}
+
+// release records that the lock was released.
+func (a *acquisition) release() {
+	if a == nil {
+		return
+	}
+	hold := time.Since(a.acquired)
+	a.record(func(p *stackProfile) {
+		p.hold += hold
+		if hold > p.maxHold {
+			p.maxHold = hold
+		}
+	})
+}
+
+// record updates the profile of the stack of the acquisition.
+func (a *acquisition) record(update func(*stackProfile)) {
+	profile.m.Lock()
+	defer profile.m.Unlock()
+	p, ok := profile.stacks[a.stack]
+	if !ok {
+		p = &stackProfile{}
+		profile.stacks[a.stack] = p
+	}
+	update(p)
+}
+
+// trimStack returns the non-zero frames of a stack.
+func trimStack(stack profileStack) []uintptr {
+	n := 0
+	for n < len(stack) && stack[n] != 0 {
+		n++
+	}
+	return stack[:n]
+}
+
+// packagePrefix is the prefix of the functions of the lock package.
+const packagePrefix = "github.com/nvn1729/congo/lock."
+
+// callSite returns the first frame of a stack outside of the lock package, or of its tests, as "function (file:line)".
+func callSite(stack profileStack) string {
+	frames := runtime.CallersFrames(trimStack(stack))
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, packagePrefix) || strings.HasSuffix(frame.File, "_test.go") || !more {
+			return fmt.Sprintf("%s (%s:%d)", frame.Function, filepath.Base(frame.File), frame.Line)
+		}
+	}
+}
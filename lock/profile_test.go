@@ -0,0 +1,115 @@
+package lock
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProfile(t *testing.T) {
+	ResetProfile()
+	EnableProfiling()
+	defer DisableProfiling()
+
+	// a contended acquisition, an abandoned one, and an uncontended one
+	var mutex Mutex
+	mutex.Lock()
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		mutex.Unlock()
+	}()
+	mutex.Lock()
+	assertEqual(t, false, mutex.TryLock())
+	assertEqual(t, false, mutex.LockTimeout(time.Millisecond))
+	mutex.Unlock()
+	var reentrant ReentrantLock
+	reentrant.Lock()
+	reentrant.Lock()
+	reentrant.Unlock()
+	reentrant.Unlock()
+
+	var total SiteProfile
+	for _, site := range Profile() {
+		assertEqual(t, true, strings.HasPrefix(site.Site, "github.com/nvn1729/congo/lock.TestProfile (profile_test.go:"))
+		total.Acquisitions += site.Acquisitions
+		total.Contended += site.Contended
+		total.Abandoned += site.Abandoned
+		total.Wait += site.Wait
+		total.Hold += site.Hold
+		assertEqual(t, true, site.MaxWait <= site.Wait && site.MaxHold <= site.Hold)
+	}
+	assertEqual(t, int64(4), total.Acquisitions)
+	assertEqual(t, int64(2), total.Contended)
+	assertEqual(t, int64(1), total.Abandoned)
+	assertEqual(t, true, total.Wait >= 20*time.Millisecond)
+	assertEqual(t, true, total.Hold >= 20*time.Millisecond)
+
+	// acquisitions are not recorded while profiling is disabled, and the profile is kept until reset
+	DisableProfiling()
+	mutex.Lock()
+	mutex.Unlock()
+	assertEqual(t, 4, len(Profile()))
+	ResetProfile()
+	assertEqual(t, 0, len(Profile()))
+}
+
+func TestProfile_locks(t *testing.T) {
+	ResetProfile()
+	EnableProfiling()
+	defer DisableProfiling()
+
+	ticket := NewTicketLock(TicketHooks{})
+	ticket.Lock()
+	assertEqual(t, false, ticket.TryLock())
+	ticket.Unlock()
+	rw := &ReentrantRWLock{}
+	rw.RLock()
+	assertEqual(t, true, rw.TryUpgrade())
+	rw.Unlock()
+	rw.RUnlock()
+	assertEqual(t, true, rw.LockTimeout(time.Second))
+	rw.Downgrade()
+	rw.RUnlock()
+	var stamped StampedLock
+	stamped.Read(func() {}) // optimistic
+	stamped.Lock()
+	stamped.Unlock()
+	assertNil(t, NewKeyedMutex().LockContext(context.Background(), "a"))
+
+	var acquisitions int64
+	for _, site := range Profile() {
+		acquisitions += site.Acquisitions
+	}
+	assertEqual(t, int64(6), acquisitions)
+}
+
+func TestWriteProfile(t *testing.T) {
+	ResetProfile()
+	EnableProfiling()
+	defer DisableProfiling()
+	var mutex Mutex
+	mutex.Lock()
+	go func() {
+		time.Sleep(time.Millisecond)
+		mutex.Unlock()
+	}()
+	mutex.Lock()
+	mutex.Unlock()
+
+	var b bytes.Buffer
+	assertNil(t, WriteReport(&b))
+	assertEqual(t, true, strings.Contains(b.String(), "ACQUIRED  CONTENDED  ABANDONED"))
+	assertEqual(t, 3, strings.Count(b.String(), "\n"))
+	for _, write := range []func(*bytes.Buffer) error{
+		func(b *bytes.Buffer) error { return WriteWaitProfile(b) },
+		func(b *bytes.Buffer) error { return WriteHoldProfile(b) },
+	} {
+		b.Reset()
+		assertNil(t, write(&b))
+		assertEqual(t, true, strings.HasPrefix(b.String(), "--- contention:\ncycles/second=1000000000\nsampling period=1\n"))
+		assertEqual(t, true, strings.Contains(b.String(), " 1 @ 0x"))
+		assertEqual(t, true, strings.Contains(b.String(), "\tgithub.com/nvn1729/congo/lock.TestWriteProfile+0x"))
+	}
+}
@@ -0,0 +1,136 @@
+package lock
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nvn1729/congo/internal/goid"
+)
+
+// A ReentrantLock is a mutual exclusion lock owned by the goroutine that locked it, which may lock it again without deadlocking,
+// as when porting Java code relying on recursive locking. The lock is released once the owner unlocked it as many times as it locked it.
+//
+// The owner is the goroutine, rather than a token passed along, so a ReentrantLock must be unlocked by the goroutine that locked it,
+// and cannot be handed over to another goroutine. Identifying the goroutine takes a call to runtime.Stack per method call,
+// so locks that are never locked recursively should rather be a Mutex.
+//
+// The zero value is an unlocked lock, and a ReentrantLock must not be copied after first use.
+type ReentrantLock struct {
+	mutex Mutex      // locked while the lock is owned
+	m     sync.Mutex // guards owner and holds
+	owner int64      // the id of the goroutine owning the lock, or 0
+	holds int        // the number of times the owner locked the lock
+}
+
+// Lock locks the lock, waiting until it is available, unless the calling goroutine already owns it.
+func (lock *ReentrantLock) Lock() {
+	id := goid.Current()
+	if lock.reenter(id) {
+		return
+	}
+	lock.mutex.Lock()
+	lock.own(id)
+}
+
+// TryLock locks the lock only if it is available without waiting, or the calling goroutine already owns it, and reports whether it did.
+func (lock *ReentrantLock) TryLock() bool {
+	id := goid.Current()
+	if lock.reenter(id) {
+		return true
+	}
+	if !lock.mutex.TryLock() {
+		return false
+	}
+	lock.own(id)
+	return true
+}
+
+// LockTimeout locks the lock, waiting until a given timeout for it to be available, unless the calling goroutine already owns it.
+// If the lock is locked before the timeout, LockTimeout returns true. Otherwise it returns false.
+func (lock *ReentrantLock) LockTimeout(timeout time.Duration) bool {
+	id := goid.Current()
+	if lock.reenter(id) {
+		return true
+	}
+	if !lock.mutex.LockTimeout(timeout) {
+		return false
+	}
+	lock.own(id)
+	return true
+}
+
+// LockContext locks the lock, waiting until it is available or the context is done, unless the calling goroutine already owns it.
+// If the context is done first, LockContext returns the context's error without locking the lock.
+// If the context is already done, LockContext does not lock the lock, even if the calling goroutine owns it.
+func (lock *ReentrantLock) LockContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	id := goid.Current()
+	if lock.reenter(id) {
+		return nil
+	}
+	if err := lock.mutex.LockContext(ctx); err != nil {
+		return err
+	}
+	lock.own(id)
+	return nil
+}
+
+// Unlock unlocks the lock once, releasing it if the calling goroutine unlocked it as many times as it locked it.
+// Unlock panics if the calling goroutine does not own the lock.
+func (lock *ReentrantLock) Unlock() {
+	id := goid.Current()
+	lock.m.Lock()
+	if lock.owner != id {
+		lock.m.Unlock()
+		panic("lock: unlock of reentrant lock not owned by the goroutine")
+	}
+	lock.holds--
+	release := lock.holds == 0
+	if release {
+		lock.owner = 0
+	}
+	lock.m.Unlock()
+	if release {
+		lock.mutex.Unlock()
+	}
+}
+
+// HoldCount returns the number of times the calling goroutine locked the lock without unlocking it, which is 0 if it does not own it.
+func (lock *ReentrantLock) HoldCount() int {
+	id := goid.Current()
+	lock.m.Lock()
+	defer lock.m.Unlock()
+	if lock.owner != id {
+		return 0
+	}
+	return lock.holds
+}
+
+// IsLocked reports whether the lock is owned by any goroutine. The result may be stale by the time it is used, e.g. for monitoring.
+func (lock *ReentrantLock) IsLocked() bool {
+	lock.m.Lock()
+	defer lock.m.Unlock()
+	return lock.owner != 0
+}
+
+// reenter locks the lock again if it is owned by the goroutine with the given id, and reports whether it did.
+func (lock *ReentrantLock) reenter(id int64) bool {
+	lock.m.Lock()
+	defer lock.m.Unlock()
+	if lock.owner != id {
+		return false
+	}
+	lock.holds++
+	return true
+}
+
+// own makes the goroutine with the given id the owner of the lock, once it locked the mutex.
+func (lock *ReentrantLock) own(id int64) {
+	lock.m.Lock()
+	defer lock.m.Unlock()
+	lock.owner = id
+	lock.holds = 1
+}
@@ -0,0 +1,112 @@
+package lock
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func ExampleReentrantLock() {
+	var lock ReentrantLock
+	var visit func(depth int)
+	visit = func(depth int) {
+		lock.Lock()
+		defer lock.Unlock()
+		if depth < 3 {
+			visit(depth + 1)
+		} else {
+			fmt.Println("Hold count", lock.HoldCount())
+		}
+	}
+	visit(1)
+	fmt.Println("Locked", lock.IsLocked())
+	// Output:
+	// Hold count 3
+	// Locked false
+}
+
+func TestReentrantLock(t *testing.T) {
+	var lock ReentrantLock
+	var _ sync.Locker = &lock
+	counter := 0
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				lock.Lock()
+				lock.Lock()
+				counter++
+				lock.Unlock()
+				lock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assertEqual(t, 1000, counter)
+	assertEqual(t, false, lock.IsLocked())
+}
+
+func TestReentrantLock_otherGoroutine(t *testing.T) {
+	var lock ReentrantLock
+	assertEqual(t, true, lock.TryLock())
+	assertEqual(t, true, lock.LockTimeout(time.Millisecond))
+	assertNil(t, lock.LockContext(context.Background()))
+	assertEqual(t, 3, lock.HoldCount())
+
+	// other goroutines can neither lock nor unlock the lock
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assertEqual(t, 0, lock.HoldCount())
+		assertEqual(t, false, lock.TryLock())
+		assertEqual(t, false, lock.LockTimeout(10*time.Millisecond))
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assertEqual(t, context.DeadlineExceeded, lock.LockContext(ctx))
+		defer func() {
+			assertNotNil(t, recover())
+		}()
+		lock.Unlock()
+	}()
+	<-done
+
+	lock.Unlock()
+	lock.Unlock()
+	assertEqual(t, true, lock.IsLocked())
+	acquired := make(chan struct{})
+	go func() {
+		lock.Lock()
+		assertEqual(t, 1, lock.HoldCount())
+		lock.Unlock()
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("Lock acquired while held")
+	case <-time.After(10 * time.Millisecond):
+	}
+	lock.Unlock()
+	<-acquired
+}
+
+func TestReentrantLock_lockContextDone(t *testing.T) {
+	var lock ReentrantLock
+	lock.Lock()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assertEqual(t, context.Canceled, lock.LockContext(ctx))
+	assertEqual(t, 1, lock.HoldCount())
+}
+
+func TestReentrantLock_unlockUnlocked(t *testing.T) {
+	var lock ReentrantLock
+	defer func() {
+		assertNotNil(t, recover())
+	}()
+	lock.Unlock()
+	t.Fatal("Did not panic")
+}
@@ -0,0 +1,327 @@
+package lock
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nvn1729/congo/deadlock"
+	"github.com/nvn1729/congo/internal/goid"
+)
+
+// A ReentrantRWLock is a reader/writer lock like sync.RWMutex, whose read and write locks are owned by the goroutines that locked them,
+// and may be locked again by their owner without deadlocking, like a ReentrantLock. Both locks can also be acquired without waiting,
+// with a timeout or with a context.
+//
+// The owner of the write lock may also lock the read lock, and downgrade to it by unlocking the write lock, or with Downgrade,
+// without letting another writer in between. The owner of a read lock may try to upgrade to the write lock with TryUpgrade,
+// which does not wait: two readers waiting for each other to upgrade would deadlock.
+// A goroutine owning only a read lock must not call Lock, which panics rather than deadlocking.
+//
+// Once a writer waits, new readers wait for it, so that a stream of readers cannot starve writers. Goroutines already owning
+// a read lock may still lock it again.
+//
+// The zero value is an unlocked lock, and a ReentrantRWLock must not be copied after first use.
+type ReentrantRWLock struct {
+	m          sync.Mutex
+	writer     int64         // the id of the goroutine owning the write lock, or 0
+	writeHolds int           // the number of times the writer locked the write lock
+	readers    map[int64]int // the number of times each goroutine owning a read lock locked it
+	waiting    int           // writers waiting for the write lock
+	changed    chan struct{} // closed when the lock is released, or a writer stops waiting
+	held       *acquisition  // the acquisition of the write lock, while profiling
+}
+
+// Lock locks the write lock, waiting until no other goroutine owns the read or write lock, unless the calling goroutine owns the write lock.
+// Lock panics if the calling goroutine owns a read lock but not the write lock.
+func (lock *ReentrantRWLock) Lock() {
+	lock.acquire(true, false, nil, nil)
+}
+
+// TryLock locks the write lock only if it is available without waiting, or the calling goroutine owns it, and reports whether it did.
+// TryLock panics if the calling goroutine owns a read lock but not the write lock.
+func (lock *ReentrantRWLock) TryLock() bool {
+	return lock.acquire(true, true, nil, nil)
+}
+
+// LockTimeout locks the write lock, waiting until a given timeout for it to be available, unless the calling goroutine owns it.
+// If the write lock is locked before the timeout, LockTimeout returns true. Otherwise it returns false.
+// LockTimeout panics if the calling goroutine owns a read lock but not the write lock.
+func (lock *ReentrantRWLock) LockTimeout(timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	return lock.acquire(true, false, timer.C, nil)
+}
+
+// LockContext locks the write lock, waiting until it is available or the context is done, unless the calling goroutine owns it.
+// If the context is done first, LockContext returns the context's error without locking the write lock.
+// If the context is already done, LockContext does not lock the write lock.
+// LockContext panics if the calling goroutine owns a read lock but not the write lock.
+func (lock *ReentrantRWLock) LockContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !lock.acquire(true, false, nil, ctx.Done()) {
+		return ctx.Err()
+	}
+	return nil
+}
+
+// Unlock unlocks the write lock once, releasing it if the calling goroutine unlocked it as many times as it locked it.
+// The read locks of the calling goroutine, if any, are kept.
+// Unlock panics if the calling goroutine does not own the write lock.
+func (lock *ReentrantRWLock) Unlock() {
+	id := goid.Current()
+	lock.m.Lock()
+	defer lock.m.Unlock()
+	if lock.writer != id {
+		panic("lock: unlock of write lock not owned by the goroutine")
+	}
+	deadlock.Released(lock, 1)
+	if lock.writeHolds--; lock.writeHolds == 0 {
+		lock.writer = 0
+		lock.held.release()
+		lock.held = nil
+		lock.broadcast()
+	}
+}
+
+// RLock locks a read lock, waiting until no other goroutine owns or waits for the write lock,
+// unless the calling goroutine owns a read lock or the write lock.
+func (lock *ReentrantRWLock) RLock() {
+	lock.acquire(false, false, nil, nil)
+}
+
+// TryRLock locks a read lock only if it is available without waiting, and reports whether it did.
+func (lock *ReentrantRWLock) TryRLock() bool {
+	return lock.acquire(false, true, nil, nil)
+}
+
+// RLockTimeout locks a read lock, waiting until a given timeout for it to be available.
+// If the read lock is locked before the timeout, RLockTimeout returns true. Otherwise it returns false.
+func (lock *ReentrantRWLock) RLockTimeout(timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	return lock.acquire(false, false, timer.C, nil)
+}
+
+// RLockContext locks a read lock, waiting until it is available or the context is done.
+// If the context is done first, RLockContext returns the context's error without locking a read lock.
+// If the context is already done, RLockContext does not lock a read lock.
+func (lock *ReentrantRWLock) RLockContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !lock.acquire(false, false, nil, ctx.Done()) {
+		return ctx.Err()
+	}
+	return nil
+}
+
+// RUnlock unlocks the read lock of the calling goroutine once, releasing it if the goroutine unlocked it as many times as it locked it.
+// RUnlock panics if the calling goroutine does not own a read lock.
+func (lock *ReentrantRWLock) RUnlock() {
+	id := goid.Current()
+	lock.m.Lock()
+	defer lock.m.Unlock()
+	holds := lock.readers[id]
+	if holds == 0 {
+		panic("lock: unlock of read lock not owned by the goroutine")
+	}
+	deadlock.Released(lock, 1)
+	if holds == 1 {
+		delete(lock.readers, id)
+		lock.broadcast()
+	} else {
+		lock.readers[id] = holds - 1
+	}
+}
+
+// RLocker returns a sync.Locker locking and unlocking the read lock.
+func (lock *ReentrantRWLock) RLocker() sync.Locker {
+	return (*rlocker)(lock)
+}
+
+// Downgrade turns the write lock of the calling goroutine into a read lock, atomically, so that no writer gets in between,
+// letting other readers in. Downgrade panics if the calling goroutine does not own the write lock, or locked it several times.
+func (lock *ReentrantRWLock) Downgrade() {
+	id := goid.Current()
+	lock.m.Lock()
+	defer lock.m.Unlock()
+	if lock.writer != id {
+		panic("lock: downgrade of write lock not owned by the goroutine")
+	}
+	if lock.writeHolds > 1 {
+		panic("lock: downgrade of write lock locked several times")
+	}
+	lock.writer = 0
+	lock.writeHolds = 0
+	lock.held.release()
+	lock.held = nil
+	lock.addReader(id)
+	lock.broadcast()
+}
+
+// TryUpgrade locks the write lock if the calling goroutine owns the only read lock and no goroutine owns the write lock,
+// without waiting, and reports whether it did. The goroutine keeps its read lock, to which it returns by unlocking the write lock.
+//
+// TryUpgrade never waits, as two readers each waiting for the other to release its read lock would deadlock.
+// If it fails, the goroutine should release its read lock, lock the write lock, and check again whatever it read,
+// which may have changed meanwhile. TryUpgrade panics if the calling goroutine does not own a read lock.
+func (lock *ReentrantRWLock) TryUpgrade() bool {
+	id := goid.Current()
+	lock.m.Lock()
+	defer lock.m.Unlock()
+	if lock.readers[id] == 0 {
+		panic("lock: upgrade of read lock not owned by the goroutine")
+	}
+	if lock.writer == id {
+		lock.writeHolds++
+		deadlock.Acquired(lock, 1)
+		return true
+	}
+	if lock.writer != 0 || len(lock.readers) > 1 {
+		return false
+	}
+	lock.writer = id
+	lock.writeHolds = 1
+	lock.held = startAcquisition().acquire(false)
+	deadlock.Acquired(lock, 1)
+	return true
+}
+
+// ReadHoldCount returns the number of times the calling goroutine locked the read lock without unlocking it.
+func (lock *ReentrantRWLock) ReadHoldCount() int {
+	id := goid.Current()
+	lock.m.Lock()
+	defer lock.m.Unlock()
+	return lock.readers[id]
+}
+
+// WriteHoldCount returns the number of times the calling goroutine locked the write lock without unlocking it,
+// which is 0 if it does not own it.
+func (lock *ReentrantRWLock) WriteHoldCount() int {
+	id := goid.Current()
+	lock.m.Lock()
+	defer lock.m.Unlock()
+	if lock.writer != id {
+		return 0
+	}
+	return lock.writeHolds
+}
+
+// acquire locks the write lock or a read lock, waiting until it is available, unless try is set, timeout fires or done is closed,
+// and reports whether it did.
+func (lock *ReentrantRWLock) acquire(write bool, try bool, timeout <-chan time.Time, done <-chan struct{}) bool {
+	id := goid.Current()
+	a := startAcquisition()
+	lock.m.Lock()
+	defer lock.m.Unlock()
+	if write {
+		if lock.writer == id {
+			lock.writeHolds++
+			deadlock.Acquired(lock, 1)
+			return true
+		}
+		if lock.readers[id] > 0 {
+			panic("lock: write lock by the owner of a read lock, which would deadlock")
+		}
+	} else if lock.writer == id || lock.readers[id] > 0 {
+		lock.addReader(id)
+		deadlock.Acquired(lock, 1)
+		return true
+	}
+
+	waiting, contended := false, false
+	var wait *deadlock.Wait
+	defer func() {
+		if waiting {
+			lock.waiting--
+			lock.broadcast()
+		}
+	}()
+	for {
+		if write && lock.writer == 0 && len(lock.readers) == 0 {
+			lock.writer = id
+			lock.writeHolds = 1
+			lock.held = a.acquire(contended)
+			lock.acquired(wait)
+			return true
+		}
+		if !write && lock.writer == 0 && lock.waiting == 0 {
+			lock.addReader(id)
+			a.acquire(contended)
+			lock.acquired(wait)
+			return true
+		}
+		if try {
+			return false
+		}
+		if write && !waiting {
+			waiting = true
+			lock.waiting++
+		}
+		if !contended {
+			contended = true
+			wait = deadlock.Waiting(lock)
+		}
+		if lock.changed == nil {
+			lock.changed = make(chan struct{})
+		}
+		changed := lock.changed
+		lock.m.Unlock()
+		select {
+		case <-changed:
+			lock.m.Lock()
+		case <-timeout:
+			lock.m.Lock()
+			wait.Abandoned()
+			a.abandon()
+			return false
+		case <-done:
+			lock.m.Lock()
+			wait.Abandoned()
+			a.abandon()
+			return false
+		}
+	}
+}
+
+// acquired records the acquisition of the lock by the calling goroutine for deadlock detection, ending its wait, if any.
+// This call must be guarded using the lock mutex.
+func (lock *ReentrantRWLock) acquired(wait *deadlock.Wait) {
+	if wait != nil {
+		wait.Acquired(1)
+	} else {
+		deadlock.Acquired(lock, 1)
+	}
+}
+
+// addReader locks the read lock for the goroutine with the given id.
+// This call must be guarded using the lock mutex.
+func (lock *ReentrantRWLock) addReader(id int64) {
+	if lock.readers == nil {
+		lock.readers = make(map[int64]int)
+	}
+	lock.readers[id]++
+}
+
+// broadcast wakes up the goroutines waiting for the lock, to check it again.
+// This call must be guarded using the lock mutex.
+func (lock *ReentrantRWLock) broadcast() {
+	if lock.changed != nil {
+		close(lock.changed)
+		lock.changed = nil
+	}
+}
+
+// An rlocker is a sync.Locker locking the read lock of a ReentrantRWLock.
+type rlocker ReentrantRWLock
+
+func (r *rlocker) Lock() {
+	(*ReentrantRWLock)(r).RLock()
+}
+
+func (r *rlocker) Unlock() {
+	(*ReentrantRWLock)(r).RUnlock()
+}
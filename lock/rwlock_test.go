@@ -0,0 +1,191 @@
+package lock
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReentrantRWLock(t *testing.T) {
+	var lock ReentrantRWLock
+	var _ sync.Locker = &lock
+	var _ sync.Locker = lock.RLocker()
+	counter := 0
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				lock.Lock()
+				lock.Lock()
+				counter++
+				lock.Unlock()
+				lock.Unlock()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				lock.RLock()
+				lock.RLock()
+				_ = counter
+				lock.RUnlock()
+				lock.RUnlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assertEqual(t, 1000, counter)
+}
+
+func TestReentrantRWLock_readers(t *testing.T) {
+	var lock ReentrantRWLock
+	lock.RLock()
+	assertEqual(t, true, lock.TryRLock())
+	assertEqual(t, 2, lock.ReadHoldCount())
+
+	// readers share the lock, and exclude writers
+	inGoroutine(func() {
+		assertEqual(t, true, lock.RLockTimeout(time.Millisecond))
+		assertEqual(t, 1, lock.ReadHoldCount())
+		lock.RUnlock()
+		assertEqual(t, false, lock.TryLock())
+		assertEqual(t, false, lock.LockTimeout(10*time.Millisecond))
+	})
+
+	// a waiting writer keeps new readers out, but not the readers already owning the lock
+	locked := make(chan struct{})
+	go func() {
+		lock.Lock()
+		close(locked)
+		lock.Unlock()
+	}()
+	for {
+		lock.m.Lock()
+		waiting := lock.waiting
+		lock.m.Unlock()
+		if waiting == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	inGoroutine(func() {
+		assertEqual(t, false, lock.TryRLock())
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assertEqual(t, context.DeadlineExceeded, lock.RLockContext(ctx))
+	})
+	lock.RLock()
+	lock.RUnlock()
+	lock.RUnlock()
+	lock.RUnlock()
+	<-locked
+	assertEqual(t, 0, lock.ReadHoldCount())
+}
+
+func TestReentrantRWLock_writer(t *testing.T) {
+	var lock ReentrantRWLock
+	assertEqual(t, true, lock.TryLock())
+	assertNil(t, lock.LockContext(context.Background()))
+	assertEqual(t, 2, lock.WriteHoldCount())
+	inGoroutine(func() {
+		assertEqual(t, 0, lock.WriteHoldCount())
+		assertEqual(t, false, lock.TryRLock())
+		assertEqual(t, false, lock.TryLock())
+	})
+
+	// the writer may lock the read lock, and keep it when unlocking the write lock
+	lock.RLock()
+	lock.Unlock()
+	lock.Unlock()
+	assertEqual(t, 1, lock.ReadHoldCount())
+	inGoroutine(func() {
+		assertEqual(t, true, lock.TryRLock())
+		lock.RUnlock()
+	})
+	lock.RUnlock()
+}
+
+func TestReentrantRWLock_downgrade(t *testing.T) {
+	var lock ReentrantRWLock
+	lock.Lock()
+	lock.Downgrade()
+	assertEqual(t, 0, lock.WriteHoldCount())
+	assertEqual(t, 1, lock.ReadHoldCount())
+	inGoroutine(func() {
+		assertEqual(t, true, lock.TryRLock())
+		lock.RUnlock()
+		assertEqual(t, false, lock.TryLock())
+	})
+	lock.RUnlock()
+
+	lock.Lock()
+	lock.Lock()
+	assertPanics(t, lock.Downgrade)
+	inGoroutine(func() {
+		assertPanics(t, lock.Downgrade)
+	})
+}
+
+func TestReentrantRWLock_tryUpgrade(t *testing.T) {
+	var lock ReentrantRWLock
+	lock.RLock()
+	assertEqual(t, true, lock.TryUpgrade())
+	assertEqual(t, 1, lock.WriteHoldCount())
+	assertEqual(t, true, lock.TryUpgrade())
+	lock.Unlock()
+	lock.Unlock()
+	assertEqual(t, 1, lock.ReadHoldCount())
+
+	// upgrading fails while other readers own the lock
+	release := make(chan struct{})
+	released := make(chan struct{})
+	locked := make(chan struct{})
+	go func() {
+		lock.RLock()
+		close(locked)
+		<-release
+		lock.RUnlock()
+		close(released)
+	}()
+	<-locked
+	assertEqual(t, false, lock.TryUpgrade())
+	close(release)
+	<-released
+	assertEqual(t, true, lock.TryUpgrade())
+	lock.Unlock()
+	lock.RUnlock()
+	assertPanics(t, func() { lock.TryUpgrade() })
+}
+
+func TestReentrantRWLock_misuse(t *testing.T) {
+	var lock ReentrantRWLock
+	assertPanics(t, lock.Unlock)
+	assertPanics(t, lock.RUnlock)
+
+	// a reader locking the write lock would deadlock
+	lock.RLock()
+	assertPanics(t, lock.Lock)
+	assertPanics(t, func() { lock.TryLock() })
+	lock.RUnlock()
+}
+
+// inGoroutine runs fn in another goroutine, and waits until it returns.
+func inGoroutine(fn func()) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	<-done
+}
+
+func assertPanics(t *testing.T, fn func()) {
+	defer func() {
+		assertNotNil(t, recover())
+	}()
+	fn()
+	t.Fatal("Did not panic")
+}
@@ -0,0 +1,125 @@
+package lock
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/nvn1729/congo/deadlock"
+)
+
+// A StampedLock is a reader/writer lock supporting optimistic reads, in the style of Java's StampedLock:
+// a reader takes a stamp with TryOptimisticRead, reads without locking, and checks with Validate that no writer
+// locked the lock meanwhile. Optimistic readers neither wait for each other nor write to shared memory,
+// which makes them much cheaper than read locks on read-mostly data. If validation fails, the reader reads again
+// under the read lock, as Read does.
+//
+// Optimistic readers race with writers by design: the fields they read must be accessed atomically, by readers and writers,
+// for the reads to be well-defined and for the race detector not to report them. What was read is only usable once validated.
+//
+// The zero value is an unlocked lock, and a StampedLock must not be copied after first use.
+type StampedLock struct {
+	rw   sync.RWMutex
+	seq  uint64       // set atomically, odd while the write lock is held, incremented when it is locked and unlocked
+	held *acquisition // the acquisition of the write lock, while profiling
+}
+
+// Lock locks the write lock, waiting until no goroutine holds the read or write lock.
+func (lock *StampedLock) Lock() {
+	a := startAcquisition()
+	contended := !lock.rw.TryLock()
+	if contended {
+		wait := deadlock.Waiting(lock)
+		lock.rw.Lock()
+		wait.Acquired(1)
+	} else {
+		deadlock.Acquired(lock, 1)
+	}
+	lock.held = a.acquire(contended)
+	atomic.AddUint64(&lock.seq, 1)
+}
+
+// TryLock locks the write lock only if it is available without waiting, and reports whether it did.
+func (lock *StampedLock) TryLock() bool {
+	a := startAcquisition()
+	if !lock.rw.TryLock() {
+		return false
+	}
+	deadlock.Acquired(lock, 1)
+	lock.held = a.acquire(false)
+	atomic.AddUint64(&lock.seq, 1)
+	return true
+}
+
+// Unlock unlocks the write lock, invalidating the stamps taken before. Unlock panics if the write lock is not locked.
+func (lock *StampedLock) Unlock() {
+	if atomic.LoadUint64(&lock.seq)&1 == 0 {
+		panic("lock: unlock of unlocked stamped lock")
+	}
+	held := lock.held
+	lock.held = nil
+	held.release()
+	deadlock.Released(lock, 1)
+	atomic.AddUint64(&lock.seq, 1)
+	lock.rw.Unlock()
+}
+
+// RLock locks a read lock, waiting until no goroutine holds the write lock.
+func (lock *StampedLock) RLock() {
+	a := startAcquisition()
+	contended := !lock.rw.TryRLock()
+	if contended {
+		wait := deadlock.Waiting(lock)
+		lock.rw.RLock()
+		wait.Acquired(1)
+	} else {
+		deadlock.Acquired(lock, 1)
+	}
+	a.acquire(contended)
+}
+
+// TryRLock locks a read lock only if it is available without waiting, and reports whether it did.
+func (lock *StampedLock) TryRLock() bool {
+	a := startAcquisition()
+	if !lock.rw.TryRLock() {
+		return false
+	}
+	deadlock.Acquired(lock, 1)
+	a.acquire(false)
+	return true
+}
+
+// RUnlock unlocks a read lock.
+func (lock *StampedLock) RUnlock() {
+	deadlock.Released(lock, 1)
+	lock.rw.RUnlock()
+}
+
+// TryOptimisticRead returns a stamp for an optimistic read, to be passed to Validate once read,
+// or 0, which never validates, if the write lock is held.
+func (lock *StampedLock) TryOptimisticRead() uint64 {
+	seq := atomic.LoadUint64(&lock.seq)
+	if seq&1 == 1 {
+		return 0
+	}
+	return seq + 1
+}
+
+// Validate reports whether the write lock was not locked since the stamp was returned by TryOptimisticRead,
+// in which case what was read since is consistent.
+func (lock *StampedLock) Validate(stamp uint64) bool {
+	return stamp != 0 && atomic.LoadUint64(&lock.seq) == stamp-1
+}
+
+// Read calls read optimistically, and again under the read lock if the read was not consistent.
+// read must only read the data guarded by the lock, atomically, and may be called twice.
+func (lock *StampedLock) Read(read func()) {
+	if stamp := lock.TryOptimisticRead(); stamp != 0 {
+		read()
+		if lock.Validate(stamp) {
+			return
+		}
+	}
+	lock.RLock()
+	defer lock.RUnlock()
+	read()
+}
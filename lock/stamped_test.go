@@ -0,0 +1,109 @@
+package lock
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func ExampleStampedLock() {
+	var lock StampedLock
+	var x, y int64
+	move := func(dx, dy int64) {
+		lock.Lock()
+		defer lock.Unlock()
+		atomic.AddInt64(&x, dx)
+		atomic.AddInt64(&y, dy)
+	}
+	move(3, 4)
+
+	stamp := lock.TryOptimisticRead()
+	cx, cy := atomic.LoadInt64(&x), atomic.LoadInt64(&y)
+	if !lock.Validate(stamp) {
+		lock.RLock()
+		cx, cy = atomic.LoadInt64(&x), atomic.LoadInt64(&y)
+		lock.RUnlock()
+	}
+	fmt.Println(cx, cy)
+	// Output:
+	// 3 4
+}
+
+func TestStampedLock(t *testing.T) {
+	var lock StampedLock
+	stamp := lock.TryOptimisticRead()
+	assertEqual(t, true, lock.Validate(stamp))
+	assertEqual(t, false, lock.Validate(0))
+
+	// writers invalidate the stamps, and optimistic reads fail while they hold the lock
+	lock.Lock()
+	assertEqual(t, false, lock.Validate(stamp))
+	assertEqual(t, uint64(0), lock.TryOptimisticRead())
+	assertEqual(t, false, lock.TryLock())
+	assertEqual(t, false, lock.TryRLock())
+	lock.Unlock()
+	assertEqual(t, false, lock.Validate(stamp))
+	stamp = lock.TryOptimisticRead()
+	assertEqual(t, true, lock.Validate(stamp))
+
+	// readers do not invalidate the stamps
+	lock.RLock()
+	assertEqual(t, true, lock.TryRLock())
+	assertEqual(t, false, lock.TryLock())
+	assertEqual(t, true, lock.Validate(lock.TryOptimisticRead()))
+	lock.RUnlock()
+	lock.RUnlock()
+	assertEqual(t, true, lock.TryLock())
+	lock.Unlock()
+	assertEqual(t, false, lock.Validate(stamp))
+}
+
+func TestStampedLock_unlockUnlocked(t *testing.T) {
+	var lock StampedLock
+	lock.Lock()
+	lock.Unlock()
+	stamp := lock.TryOptimisticRead()
+	defer func() {
+		assertNotNil(t, recover())
+		// the failed unlock invalidates no stamp, and leaves the lock usable
+		assertEqual(t, true, lock.Validate(stamp))
+		lock.Lock()
+		lock.Unlock()
+	}()
+	lock.Unlock()
+	t.Fatal("Unlock did not panic")
+}
+
+func TestStampedLock_read(t *testing.T) {
+	var lock StampedLock
+	var a, b int64
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			lock.Lock()
+			atomic.AddInt64(&a, 1)
+			atomic.AddInt64(&b, 1)
+			lock.Unlock()
+		}
+	}()
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				var ra, rb int64
+				lock.Read(func() {
+					ra, rb = atomic.LoadInt64(&a), atomic.LoadInt64(&b)
+				})
+				if ra != rb {
+					t.Error("Inconsistent read:", ra, rb)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,117 @@
+package lock
+
+import (
+	"hash/maphash"
+	"sort"
+	"sync"
+)
+
+// A Striped is a fixed set of mutexes, the stripes, guarding a large keyed data set: each key is guarded by the stripe its hash maps to.
+// It bounds the memory of the locks however many keys there are, unlike a lock per key, while keys mapped to different stripes
+// are locked concurrently, unlike with a single lock. Keys may share a stripe, so a goroutine must not lock a key while holding
+// the lock of another key, except with ForKeys, which locks the stripes in a consistent order.
+type Striped struct {
+	seed    maphash.Seed
+	stripes []paddedMutex
+}
+
+// A StripedRW is a Striped set of reader/writer mutexes, for read-mostly keyed data sets.
+type StripedRW struct {
+	seed    maphash.Seed
+	stripes []paddedRWMutex
+}
+
+// paddedMutex is a mutex padded to a cache line, so that goroutines locking adjacent stripes do not contend on the same cache line.
+type paddedMutex struct {
+	sync.Mutex
+	_ [64 - 8]byte
+}
+
+// paddedRWMutex is a reader/writer mutex padded to a cache line.
+type paddedRWMutex struct {
+	sync.RWMutex
+	_ [64 - 24]byte
+}
+
+// NewStriped creates a Striped set of stripes mutexes. More stripes lower the contention between unrelated keys,
+// e.g. a few times the number of goroutines locking keys concurrently. NewStriped panics if stripes is less than 1.
+func NewStriped(stripes int) *Striped {
+	if stripes < 1 {
+		panic("lock: stripes must be at least 1")
+	}
+	return &Striped{seed: maphash.MakeSeed(), stripes: make([]paddedMutex, stripes)}
+}
+
+// NewStripedRW creates a StripedRW set of stripes reader/writer mutexes, like NewStriped.
+// NewStripedRW panics if stripes is less than 1.
+func NewStripedRW(stripes int) *StripedRW {
+	if stripes < 1 {
+		panic("lock: stripes must be at least 1")
+	}
+	return &StripedRW{seed: maphash.MakeSeed(), stripes: make([]paddedRWMutex, stripes)}
+}
+
+// Stripes returns the number of stripes.
+func (striped *Striped) Stripes() int {
+	return len(striped.stripes)
+}
+
+// ForKey returns the mutex guarding the key.
+func (striped *Striped) ForKey(key string) sync.Locker {
+	return &striped.stripes[stripeOf(striped.seed, key, len(striped.stripes))].Mutex
+}
+
+// ForKeys returns the mutexes guarding the keys, once each, in the order in which they must be locked,
+// so that goroutines locking several keys, e.g. to move an item between two keys, cannot deadlock.
+func (striped *Striped) ForKeys(keys ...string) []sync.Locker {
+	indexes := stripesOf(striped.seed, keys, len(striped.stripes))
+	lockers := make([]sync.Locker, len(indexes))
+	for i, index := range indexes {
+		lockers[i] = &striped.stripes[index].Mutex
+	}
+	return lockers
+}
+
+// Stripes returns the number of stripes.
+func (striped *StripedRW) Stripes() int {
+	return len(striped.stripes)
+}
+
+// ForKey returns the reader/writer mutex guarding the key.
+func (striped *StripedRW) ForKey(key string) *sync.RWMutex {
+	return &striped.stripes[stripeOf(striped.seed, key, len(striped.stripes))].RWMutex
+}
+
+// ForKeys returns the reader/writer mutexes guarding the keys, once each, in the order in which they must be locked, like Striped.ForKeys.
+func (striped *StripedRW) ForKeys(keys ...string) []*sync.RWMutex {
+	indexes := stripesOf(striped.seed, keys, len(striped.stripes))
+	mutexes := make([]*sync.RWMutex, len(indexes))
+	for i, index := range indexes {
+		mutexes[i] = &striped.stripes[index].RWMutex
+	}
+	return mutexes
+}
+
+// stripeOf returns the index of the stripe of a key, among n stripes.
+func stripeOf(seed maphash.Seed, key string, n int) int {
+	var h maphash.Hash
+	h.SetSeed(seed)
+	h.WriteString(key)
+	return int(h.Sum64() % uint64(n))
+}
+
+// stripesOf returns the indexes of the stripes of the keys, among n stripes, sorted and deduplicated.
+func stripesOf(seed maphash.Seed, keys []string, n int) []int {
+	indexes := make([]int, 0, len(keys))
+	for _, key := range keys {
+		indexes = append(indexes, stripeOf(seed, key, n))
+	}
+	sort.Ints(indexes)
+	unique := indexes[:0]
+	for i, index := range indexes {
+		if i == 0 || index != indexes[i-1] {
+			unique = append(unique, index)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,80 @@
+package lock
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestStriped(t *testing.T) {
+	striped := NewStriped(8)
+	assertEqual(t, 8, striped.Stripes())
+	assertEqual(t, striped.ForKey("a"), striped.ForKey("a"))
+
+	counters := make(map[string]int)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				key := strconv.Itoa((i + j) % 10)
+				// the stripe of "all" also guards the map itself
+				lockers := striped.ForKeys(key, "all")
+				for _, locker := range lockers {
+					locker.Lock()
+				}
+				counters[key]++
+				counters["all"]++
+				for i := len(lockers) - 1; i >= 0; i-- {
+					lockers[i].Unlock()
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	assertEqual(t, 2000, counters["all"])
+	for i := 0; i < 10; i++ {
+		assertEqual(t, 200, counters[strconv.Itoa(i)])
+	}
+}
+
+func TestStriped_forKeys(t *testing.T) {
+	striped := NewStriped(4)
+	keys := make([]string, 100)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+	// keys sharing a stripe are locked once, in the same order whatever the order of the keys
+	lockers := striped.ForKeys(keys...)
+	assertEqual(t, 4, len(lockers))
+	reversed := striped.ForKeys(keys[99], keys[0])
+	ordered := striped.ForKeys(keys[0], keys[99])
+	assertEqual(t, len(ordered), len(reversed))
+	for i := range ordered {
+		assertEqual(t, ordered[i], reversed[i])
+	}
+	assertEqual(t, 1, len(NewStriped(1).ForKeys(keys...)))
+	assertEqual(t, 0, len(striped.ForKeys()))
+}
+
+func TestStripedRW(t *testing.T) {
+	striped := NewStripedRW(16)
+	assertEqual(t, 16, striped.Stripes())
+	mutex := striped.ForKey("a")
+	assertEqual(t, mutex, striped.ForKey("a"))
+	mutex.RLock()
+	assertEqual(t, true, mutex.TryRLock())
+	assertEqual(t, false, mutex.TryLock())
+	mutex.RUnlock()
+	mutex.RUnlock()
+
+	mutexes := striped.ForKeys("a", "a")
+	assertEqual(t, 1, len(mutexes))
+	assertEqual(t, mutex, mutexes[0])
+}
+
+func TestNewStriped_invalid(t *testing.T) {
+	assertPanics(t, func() { NewStriped(0) })
+	assertPanics(t, func() { NewStripedRW(0) })
+}
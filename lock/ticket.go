@@ -0,0 +1,180 @@
+package lock
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nvn1729/congo/deadlock"
+)
+
+// A TicketLock is a mutual exclusion lock granted in strict arrival order: each goroutine locking it takes a ticket,
+// and the lock is handed over to the tickets in turn, so that no goroutine starves under heavy contention.
+// A Mutex, like sync.Mutex, rather lets a goroutine arriving while the lock is released take it ahead of waiting goroutines,
+// which is faster, but unfair. Goroutines giving up waiting, with LockTimeout or LockContext, leave their turn.
+//
+// The zero value is an unlocked lock without hooks, and a TicketLock must not be copied after first use.
+// Like a Mutex, a locked TicketLock is not associated with a particular goroutine.
+type TicketLock struct {
+	m       sync.Mutex
+	next    uint64                   // the next ticket to take
+	serving uint64                   // the ticket of the goroutine owning the lock, while locked
+	locked  bool                     // whether the lock is owned
+	waiters map[uint64]chan struct{} // the channels of waiting tickets, closed when the lock is handed over
+	hooks   TicketHooks
+	held    *acquisition // the acquisition holding the lock, while profiling
+}
+
+// TicketHooks are callbacks invoked as goroutines wait for a TicketLock, e.g. to feed metrics. Nil callbacks are skipped.
+// Hooks are invoked by the goroutine locking the lock, outside of the lock's internal mutex, so they may call QueueLen.
+type TicketHooks struct {
+	// OnWait is invoked when a goroutine starts waiting for the lock, with the number of waiting goroutines, including it.
+	OnWait func(queueLen int)
+
+	// OnLock is invoked when a goroutine locks the lock, with the time it waited for it.
+	OnLock func(wait time.Duration)
+}
+
+// NewTicketLock creates an unlocked TicketLock invoking the given hooks.
+func NewTicketLock(hooks TicketHooks) *TicketLock {
+	return &TicketLock{hooks: hooks}
+}
+
+// Lock locks the lock, waiting for the turn of the calling goroutine.
+func (lock *TicketLock) Lock() {
+	lock.lock(nil, nil)
+}
+
+// TryLock locks the lock only if it is available and no goroutine is waiting for it, and reports whether it did.
+func (lock *TicketLock) TryLock() bool {
+	a := startAcquisition()
+	lock.m.Lock()
+	defer lock.m.Unlock()
+	if lock.locked {
+		return false
+	}
+	lock.locked = true
+	lock.serving = lock.next
+	lock.next++
+	deadlock.Acquired(lock, 1)
+	lock.held = a.acquire(false)
+	return true
+}
+
+// LockTimeout locks the lock, waiting until a given timeout for the turn of the calling goroutine.
+// If the lock is locked before the timeout, LockTimeout returns true. Otherwise it returns false.
+func (lock *TicketLock) LockTimeout(timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	return lock.lock(timer.C, nil)
+}
+
+// LockContext locks the lock, waiting for the turn of the calling goroutine or until the context is done.
+// If the context is done first, LockContext returns the context's error without locking the lock.
+// If the context is already done, LockContext does not lock the lock.
+func (lock *TicketLock) LockContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !lock.lock(nil, ctx.Done()) {
+		return ctx.Err()
+	}
+	return nil
+}
+
+// Unlock unlocks the lock, handing it over to the next waiting goroutine, if any. It may be called by a goroutine other than
+// the one that locked the lock. Unlock panics if the lock is not locked.
+func (lock *TicketLock) Unlock() {
+	lock.m.Lock()
+	defer lock.m.Unlock()
+	if !lock.locked {
+		panic("lock: unlock of unlocked ticket lock")
+	}
+	lock.held.release()
+	lock.held = nil
+	deadlock.Released(lock, 1)
+	for {
+		lock.serving++
+		if lock.serving == lock.next {
+			lock.locked = false
+			return
+		}
+		if ch, ok := lock.waiters[lock.serving]; ok {
+			delete(lock.waiters, lock.serving)
+			close(ch)
+			return
+		}
+		// the goroutine holding the ticket gave up waiting
+	}
+}
+
+// QueueLen returns the number of goroutines waiting for the lock.
+func (lock *TicketLock) QueueLen() int {
+	lock.m.Lock()
+	defer lock.m.Unlock()
+	return len(lock.waiters)
+}
+
+// lock takes a ticket, and waits for its turn, until timeout fires or done is closed, and reports whether it locked the lock.
+func (lock *TicketLock) lock(timeout <-chan time.Time, done <-chan struct{}) bool {
+	a := startAcquisition()
+	lock.m.Lock()
+	ticket := lock.next
+	lock.next++
+	if !lock.locked {
+		lock.locked = true
+		lock.serving = ticket
+		deadlock.Acquired(lock, 1)
+		lock.held = a.acquire(false)
+		lock.m.Unlock()
+		if lock.hooks.OnLock != nil {
+			lock.hooks.OnLock(0)
+		}
+		return true
+	}
+	if lock.waiters == nil {
+		lock.waiters = make(map[uint64]chan struct{})
+	}
+	ch := make(chan struct{})
+	lock.waiters[ticket] = ch
+	queueLen := len(lock.waiters)
+	wait := deadlock.Waiting(lock)
+	lock.m.Unlock()
+
+	start := time.Now()
+	if lock.hooks.OnWait != nil {
+		lock.hooks.OnWait(queueLen)
+	}
+	select {
+	case <-ch:
+	case <-timeout:
+		if lock.leave(ticket) {
+			wait.Abandoned()
+			a.abandon()
+			return false
+		}
+	case <-done:
+		if lock.leave(ticket) {
+			wait.Abandoned()
+			a.abandon()
+			return false
+		}
+	}
+	wait.Acquired(1)
+	lock.held = a.acquire(true)
+	if lock.hooks.OnLock != nil {
+		lock.hooks.OnLock(time.Since(start))
+	}
+	return true
+}
+
+// leave gives up the turn of a waiting ticket, and reports whether it did, which it does not if the lock was handed over to it meanwhile.
+func (lock *TicketLock) leave(ticket uint64) bool {
+	lock.m.Lock()
+	defer lock.m.Unlock()
+	if _, ok := lock.waiters[ticket]; !ok {
+		return false
+	}
+	delete(lock.waiters, ticket)
+	return true
+}
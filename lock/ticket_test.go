@@ -0,0 +1,105 @@
+package lock
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTicketLock(t *testing.T) {
+	var lock TicketLock
+	var _ sync.Locker = &lock
+	counter := 0
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				lock.Lock()
+				counter++
+				lock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assertEqual(t, 5000, counter)
+	assertEqual(t, 0, lock.QueueLen())
+}
+
+func TestTicketLock_fifo(t *testing.T) {
+	var m sync.Mutex
+	var queueLens []int
+	lock := NewTicketLock(TicketHooks{OnWait: func(queueLen int) {
+		m.Lock()
+		defer m.Unlock()
+		queueLens = append(queueLens, queueLen)
+	}})
+	lock.Lock()
+
+	// goroutines are granted the lock in the order they started waiting
+	order := make(chan int, 5)
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			lock.Lock()
+			order <- i
+			lock.Unlock()
+		}(i)
+		waitQueueLen(lock, i+1)
+	}
+	assertEqual(t, false, lock.TryLock())
+	lock.Unlock()
+	wg.Wait()
+	for i := 0; i < 5; i++ {
+		assertEqual(t, i, <-order)
+	}
+	m.Lock()
+	defer m.Unlock()
+	for i, queueLen := range queueLens {
+		assertEqual(t, i+1, queueLen)
+	}
+}
+
+func TestTicketLock_leave(t *testing.T) {
+	var waited time.Duration
+	lock := NewTicketLock(TicketHooks{OnLock: func(wait time.Duration) {
+		waited = wait
+	}})
+	assertEqual(t, true, lock.TryLock())
+	assertEqual(t, false, lock.LockTimeout(10*time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assertEqual(t, context.Canceled, lock.LockContext(ctx))
+
+	// the tickets that gave up are skipped
+	locked := make(chan struct{})
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		assertNil(t, lock.LockContext(ctx))
+		close(locked)
+	}()
+	waitQueueLen(lock, 1)
+	time.Sleep(10 * time.Millisecond)
+	lock.Unlock()
+	<-locked
+	assertEqual(t, true, waited >= 10*time.Millisecond)
+	lock.Unlock()
+	assertEqual(t, true, lock.TryLock())
+}
+
+func TestTicketLock_unlockUnlocked(t *testing.T) {
+	var lock TicketLock
+	assertPanics(t, lock.Unlock)
+}
+
+// waitQueueLen waits until the given number of goroutines wait for the lock.
+func waitQueueLen(lock *TicketLock, n int) {
+	for lock.QueueLen() < n {
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,135 @@
+package congo
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// A ManualResetEvent is a reusable gate: Set opens it, releasing all the goroutines waiting for it and letting future waiters through,
+// until Reset closes it again, making future waiters wait for the next Set. Unlike a CountDownLatch, which completes once,
+// it suits conditions that come and go, such as a connection being up or a service being unpaused.
+//
+// The zero value is an unset event, and a ManualResetEvent must not be copied after first use.
+type ManualResetEvent struct {
+	m     sync.Mutex
+	set   bool
+	ch    chan struct{} // closed while the event is set
+	clock Clock
+}
+
+// An EventOption configures a ManualResetEvent at creation time.
+type EventOption func(*ManualResetEvent)
+
+// WithEventClock sets the Clock used by the event for time-based waits.
+// By default the event uses RealClock.
+func WithEventClock(clock Clock) EventOption {
+	return func(event *ManualResetEvent) {
+		event.clock = clock
+	}
+}
+
+// NewManualResetEvent creates a ManualResetEvent, set if set is true.
+// The event may be further configured by passing EventOption values.
+func NewManualResetEvent(set bool, opts ...EventOption) *ManualResetEvent {
+	event := &ManualResetEvent{}
+	for _, opt := range opts {
+		opt(event)
+	}
+	if set {
+		event.Set()
+	}
+	return event
+}
+
+// Set sets the event, releasing the goroutines waiting for it, and letting the goroutines that wait for it later through until Reset.
+// Set does nothing if the event is already set.
+func (event *ManualResetEvent) Set() {
+	event.m.Lock()
+	defer event.m.Unlock()
+	if event.set {
+		return
+	}
+	event.set = true
+	close(event.getCh())
+}
+
+// Reset resets the event, so that the goroutines that wait for it later wait until Set. Reset does nothing if the event is not set.
+func (event *ManualResetEvent) Reset() {
+	event.m.Lock()
+	defer event.m.Unlock()
+	if !event.set {
+		return
+	}
+	event.set = false
+	event.ch = make(chan struct{})
+}
+
+// IsSet reports whether the event is set.
+func (event *ManualResetEvent) IsSet() bool {
+	event.m.Lock()
+	defer event.m.Unlock()
+	return event.set
+}
+
+// Done returns a channel that is closed when the event is set, to wait for it in a select statement alongside other channels.
+// A channel returned while the event is set is already closed, and stays so after Reset.
+func (event *ManualResetEvent) Done() <-chan struct{} {
+	event.m.Lock()
+	defer event.m.Unlock()
+	return event.getCh()
+}
+
+// Wait waits indefinitely until the event is set. Wait returns immediately if the event is set.
+func (event *ManualResetEvent) Wait() {
+	<-event.Done()
+}
+
+// WaitTimeout waits until a given timeout for the event to be set.
+// If the event is set before the timeout, WaitTimeout returns true. Otherwise it returns false.
+// WaitTimeout returns immediately if the event is set.
+func (event *ManualResetEvent) WaitTimeout(timeout time.Duration) bool {
+	done := event.Done()
+	select {
+	case <-done:
+		return true
+	default:
+	}
+	timer := event.getClock().NewTimer(timeout)
+	defer timer.Stop()
+	return await(done, timer.C())
+}
+
+// WaitContext waits until the event is set or the context is done.
+// If the context is done first, WaitContext returns the context's error. WaitContext returns nil immediately if the event is set.
+func (event *ManualResetEvent) WaitContext(ctx context.Context) error {
+	done := event.Done()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		select {
+		case <-done:
+			return nil
+		default:
+			return ctx.Err()
+		}
+	}
+}
+
+// getCh returns the channel closed when the event is set, creating it on first use.
+// This call must be guarded using the event mutex.
+func (event *ManualResetEvent) getCh() chan struct{} {
+	if event.ch == nil {
+		event.ch = make(chan struct{})
+	}
+	return event.ch
+}
+
+// getClock returns the event's Clock, falling back to RealClock for events not created with WithEventClock.
+func (event *ManualResetEvent) getClock() Clock {
+	if event.clock == nil {
+		return RealClock()
+	}
+	return event.clock
+}
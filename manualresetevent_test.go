@@ -0,0 +1,76 @@
+package congo
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nvn1729/congo/internal/clocktest"
+)
+
+func ExampleManualResetEvent() {
+	connected := NewManualResetEvent(false)
+	go func() {
+		// connect, then let the senders through
+		connected.Set()
+	}()
+
+	connected.Wait()
+	fmt.Println("Connected:", connected.IsSet())
+	// Output:
+	// Connected: true
+}
+
+func TestManualResetEvent(t *testing.T) {
+	var zero ManualResetEvent
+	assertEqual(t, false, zero.IsSet())
+	assertEqual(t, false, zero.WaitTimeout(0))
+
+	clock := clocktest.New(time.Unix(0, 0))
+	event := NewManualResetEvent(false, WithEventClock(clock))
+	assertEqual(t, false, event.IsSet())
+	assertEqual(t, false, waitTimeout(clock, event, time.Second))
+
+	// Set releases all the waiters
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			event.Wait()
+		}()
+	}
+	done := event.Done()
+	event.Set()
+	event.Set()
+	wg.Wait()
+	<-done
+	assertEqual(t, true, event.IsSet())
+	assertEqual(t, true, event.WaitTimeout(0))
+	assertNil(t, event.WaitContext(context.Background()))
+
+	// Reset makes future waiters wait for the next Set
+	event.Reset()
+	event.Reset()
+	assertEqual(t, false, event.IsSet())
+	assertEqual(t, false, waitTimeout(clock, event, time.Second))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assertEqual(t, context.DeadlineExceeded, event.WaitContext(ctx))
+	released := make(chan bool)
+	go func() {
+		released <- event.WaitTimeout(time.Minute)
+	}()
+	clock.BlockUntilTimers(1)
+	event.Set()
+	assertEqual(t, true, <-released)
+	assertEqual(t, 0, clock.Timers())
+
+	// a canceled context does not fail a wait for a set event
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	assertNil(t, event.WaitContext(ctx))
+	assertEqual(t, true, NewManualResetEvent(true).IsSet())
+}
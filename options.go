@@ -0,0 +1,48 @@
+package congo
+
+// An Option configures a CountDownLatch at creation time.
+type Option func(*CountDownLatch)
+
+// Hooks are callbacks invoked as the count down of a latch progresses. Nil callbacks are skipped.
+//
+// Hooks are invoked while the latch's internal lock is held, so they are called in the order in which
+// count downs take place. They must be fast and must not call methods on the latch.
+type Hooks struct {
+	// OnCountDown is invoked after each successful count down with the weight of the count down
+	// and the count remaining after it.
+	OnCountDown func(weight uint, remaining uint)
+
+	// OnComplete is invoked once, when the count down completes.
+	// It is not invoked for a latch created with a count of 0.
+	OnComplete func()
+}
+
+// WithClock sets the Clock used by the latch for time-based waits.
+// By default the latch uses RealClock.
+func WithClock(clock Clock) Option {
+	return func(latch *CountDownLatch) {
+		latch.clock = clock
+	}
+}
+
+// WithName sets a name for the latch, which is returned by Name and may be used to identify the latch in diagnostics.
+func WithName(name string) Option {
+	return func(latch *CountDownLatch) {
+		latch.name = name
+	}
+}
+
+// WithHooks sets callbacks to be invoked as the count down of the latch progresses.
+func WithHooks(hooks Hooks) Option {
+	return func(latch *CountDownLatch) {
+		latch.hooks = hooks
+	}
+}
+
+// WithStrictWeights makes WeightedCountDown return ErrCountDownOverflow, rather than clamping the count to 0,
+// when the weight exceeds the remaining count. This surfaces accounting bugs where more work is counted down than the latch expects.
+func WithStrictWeights() Option {
+	return func(latch *CountDownLatch) {
+		latch.strictWeights = true
+	}
+}
@@ -0,0 +1,94 @@
+package congo
+
+import (
+	"fmt"
+	"testing"
+)
+
+func ExampleNew() {
+	latch := New(2,
+		WithName("batch"),
+		WithHooks(Hooks{
+			OnCountDown: func(weight uint, remaining uint) {
+				fmt.Println("Remaining:", remaining)
+			},
+			OnComplete: func() {
+				fmt.Println("Complete")
+			},
+		}),
+	)
+	latch.CountDown()
+	latch.CountDown()
+	fmt.Println("Latch:", latch.Name())
+	// Output:
+	// Remaining: 1
+	// Remaining: 0
+	// Complete
+	// Latch: batch
+}
+
+func TestNew_defaults(t *testing.T) {
+	latch := New(1)
+	assertEqual(t, "", latch.Name())
+	assertEqual(t, uint(1), latch.Count())
+	assertNil(t, latch.CountDown())
+	latch.Wait()
+}
+
+func TestNew_hooks(t *testing.T) {
+	var weights, remaining []uint
+	completions := 0
+	latch := New(10, WithHooks(Hooks{
+		OnCountDown: func(w uint, r uint) {
+			weights = append(weights, w)
+			remaining = append(remaining, r)
+		},
+		OnComplete: func() {
+			completions++
+		},
+	}))
+
+	assertNil(t, latch.CountDown())
+	assertNil(t, latch.WeightedCountDown(4))
+	assertNil(t, latch.Complete())
+
+	// count downs after completion do not invoke hooks
+	assertNotNil(t, latch.CountDown())
+	assertNotNil(t, latch.Complete())
+
+	assertEqual(t, 3, len(weights))
+	assertEqual(t, uint(1), weights[0])
+	assertEqual(t, uint(4), weights[1])
+	assertEqual(t, uint(5), weights[2])
+	assertEqual(t, uint(9), remaining[0])
+	assertEqual(t, uint(5), remaining[1])
+	assertEqual(t, uint(0), remaining[2])
+	assertEqual(t, 1, completions)
+
+	// hooks are only called on count downs, not for a latch created complete
+	latch = New(0, WithHooks(Hooks{OnComplete: func() { completions++ }}))
+	latch.Wait()
+	assertEqual(t, 1, completions)
+}
+
+func TestNew_strictWeights(t *testing.T) {
+	latch := New(5, WithStrictWeights())
+
+	assertEqual(t, ErrCountDownOverflow, latch.WeightedCountDown(6))
+	assertEqual(t, uint(5), latch.Count())
+
+	assertNil(t, latch.WeightedCountDown(3))
+	assertEqual(t, ErrCountDownOverflow, latch.WeightedCountDown(3))
+	assertEqual(t, uint(2), latch.Count())
+
+	// Complete always counts down exactly the remaining count
+	assertNil(t, latch.Complete())
+	assertEqual(t, uint(0), latch.Count())
+	assertEqual(t, ErrCountDownLatchCompleted, latch.WeightedCountDown(1))
+	latch.Wait()
+
+	// without the option, the count is clamped
+	latch = New(5)
+	assertNil(t, latch.WeightedCountDown(6))
+	assertEqual(t, uint(0), latch.Count())
+}
@@ -0,0 +1,98 @@
+package congo
+
+import (
+	"sync"
+	"time"
+)
+
+// An OutcomeLatch is a CountDownLatch that separately tallies successful and failed count downs.
+//
+// It covers the common pattern of waiting for a number of tasks to finish and then checking how many of them failed.
+// Goroutines invoke CountDownOK or CountDownErr when their task finishes, and the main goroutine invokes Wait
+// or WaitTimeout to retrieve the Outcome once all tasks are done.
+type OutcomeLatch struct {
+	m       sync.Mutex
+	latch   *CountDownLatch
+	outcome Outcome
+}
+
+// An Outcome is the tally of an OutcomeLatch.
+type Outcome struct {
+	// Succeeded is the number of count downs made with CountDownOK.
+	Succeeded uint
+
+	// Failed is the number of count downs made with CountDownErr.
+	Failed uint
+
+	// Errors are the errors passed to CountDownErr, in the order in which they were reported.
+	Errors []error
+}
+
+// Err returns the first error reported to the latch, or nil if all count downs succeeded.
+func (outcome Outcome) Err() error {
+	if len(outcome.Errors) == 0 {
+		return nil
+	}
+	return outcome.Errors[0]
+}
+
+// NewOutcomeLatch creates an OutcomeLatch with the provided count and latch options.
+// If the count is 0, the latch will be set to immediately signal count down completion with an empty Outcome.
+func NewOutcomeLatch(count uint, opts ...Option) *OutcomeLatch {
+	return &OutcomeLatch{
+		latch: NewCountDownLatch(count, opts...),
+	}
+}
+
+// Count returns the remaining count.
+func (latch *OutcomeLatch) Count() uint {
+	return latch.latch.Count()
+}
+
+// CountDownOK records a success and reduces the count on the OutcomeLatch by 1.
+// An error, ErrCountDownLatchCompleted, is returned if count down has already been completed.
+func (latch *OutcomeLatch) CountDownOK() error {
+	return latch.CountDownErr(nil)
+}
+
+// CountDownErr records a failure with the given error and reduces the count on the OutcomeLatch by 1.
+// If err is nil, CountDownErr records a success, which allows the result of a task to be passed directly.
+// An error, ErrCountDownLatchCompleted, is returned if count down has already been completed.
+func (latch *OutcomeLatch) CountDownErr(err error) error {
+	latch.m.Lock()
+	defer latch.m.Unlock()
+	if latch.latch.Count() == 0 {
+		return ErrCountDownLatchCompleted
+	}
+	if err == nil {
+		latch.outcome.Succeeded++
+	} else {
+		latch.outcome.Failed++
+		latch.outcome.Errors = append(latch.outcome.Errors, err)
+	}
+	return latch.latch.CountDown()
+}
+
+// Wait waits indefinitely until the count down is completed and returns the Outcome.
+// Wait returns immediately if the count down has already been completed.
+func (latch *OutcomeLatch) Wait() Outcome {
+	latch.latch.Wait()
+	return latch.Outcome()
+}
+
+// WaitTimeout waits until a given timeout for the count down to complete.
+// If the count down is completed before the timeout, WaitTimeout returns the Outcome and true.
+// Otherwise it returns the Outcome so far and false.
+func (latch *OutcomeLatch) WaitTimeout(timeout time.Duration) (Outcome, bool) {
+	completed := latch.latch.WaitTimeout(timeout)
+	return latch.Outcome(), completed
+}
+
+// Outcome returns the tally of the count downs made so far.
+func (latch *OutcomeLatch) Outcome() Outcome {
+	latch.m.Lock()
+	defer latch.m.Unlock()
+	outcome := latch.outcome
+	outcome.Errors = append([]error(nil), latch.outcome.Errors...)
+	return outcome
+}
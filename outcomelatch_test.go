@@ -0,0 +1,65 @@
+package congo
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func ExampleOutcomeLatch() {
+	latch := NewOutcomeLatch(4)
+	for i := 0; i < 4; i++ {
+		go func(n int) {
+			var err error
+			if n == 2 {
+				err = errors.New("task 2 failed")
+			}
+			latch.CountDownErr(err) // a nil error counts as a success
+		}(i)
+	}
+
+	outcome := latch.Wait()
+	fmt.Println("Succeeded:", outcome.Succeeded, "Failed:", outcome.Failed)
+	fmt.Println("Error:", outcome.Err())
+	// Output:
+	// Succeeded: 3 Failed: 1
+	// Error: task 2 failed
+}
+
+func TestOutcomeLatch_zero(t *testing.T) {
+	latch := NewOutcomeLatch(0)
+	assertEqual(t, ErrCountDownLatchCompleted, latch.CountDownOK())
+
+	outcome := latch.Wait()
+	assertEqual(t, uint(0), outcome.Succeeded)
+	assertEqual(t, uint(0), outcome.Failed)
+	assertNil(t, outcome.Err())
+}
+
+func TestOutcomeLatch_tally(t *testing.T) {
+	latch := NewOutcomeLatch(3)
+	err1 := errors.New("first")
+	err2 := errors.New("second")
+
+	assertNil(t, latch.CountDownErr(err1))
+	assertNil(t, latch.CountDownOK())
+
+	outcome, ok := latch.WaitTimeout(100 * time.Millisecond)
+	assertEqual(t, false, ok)
+	assertEqual(t, uint(1), outcome.Succeeded)
+	assertEqual(t, uint(1), outcome.Failed)
+	assertEqual(t, uint(1), latch.Count())
+
+	assertNil(t, latch.CountDownErr(err2))
+	assertEqual(t, ErrCountDownLatchCompleted, latch.CountDownOK())
+
+	outcome, ok = latch.WaitTimeout(time.Second)
+	assertEqual(t, true, ok)
+	assertEqual(t, uint(1), outcome.Succeeded)
+	assertEqual(t, uint(2), outcome.Failed)
+	assertEqual(t, 2, len(outcome.Errors))
+	assertEqual(t, err1, outcome.Errors[0])
+	assertEqual(t, err2, outcome.Errors[1])
+	assertEqual(t, err1, outcome.Err())
+}
@@ -0,0 +1,9 @@
+package phaser
+
+import "errors"
+
+// These are errors related to Phaser.
+var (
+	// ErrTimeout is returned by AwaitAdvanceTimeout when the timeout elapses before the phaser advances
+	ErrTimeout = errors.New("Phaser wait timed out")
+)
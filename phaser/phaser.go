@@ -0,0 +1,351 @@
+// Package phaser provides a Phaser, a reusable barrier whose number of parties may change over time.
+package phaser
+
+import (
+	"context"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// A Phaser is a reusable synchronization barrier, like a cyclicbarrier.CyclicBarrier, but whose parties may register
+// and deregister at any time, e.g. as workers join and leave a pool processing a phased computation.
+//
+// Each phase of the phaser is numbered, starting from 0. Once every registered party has arrived at the current phase,
+// the phaser advances to the next phase and releases the goroutines waiting for it to do so.
+// Arrival and waiting are separate: a party may Arrive without waiting for the others, and any goroutine,
+// registered or not, may AwaitAdvance from a given phase. This mirrors java.util.concurrent.Phaser.
+//
+// Phasers may be tiered to reduce contention when there are very many parties, by partitioning the parties among
+// child phasers created WithParent. A child phaser with registered parties is registered as a single party at its parent,
+// and arrives at it once all its own parties have arrived, so that most arrivals only contend on the child.
+// All the phasers of a tree share the phase of the root phaser, and advance together.
+//
+// A phaser may be terminated by its OnAdvance hook, set WithOnAdvance. Once terminated, a phaser and all its children stop advancing,
+// the goroutines waiting for it to advance are released, and all methods returning a phase return a negative value
+// without further effect. Unlike java.util.concurrent.Phaser, a phaser without hook never terminates,
+// even when no parties remain registered.
+type Phaser struct {
+	m          sync.Mutex
+	parent     *Phaser
+	root       *Phaser
+	onAdvance  func(phase, registered int) bool
+	terminated int32 // set atomically on the root phaser
+	phase      int
+	parties    int
+	arrived    int
+	advanceCh  <-chan struct{} // closed when the current phase advances, fetched lazily from the parent for child phasers
+	closeCh    chan struct{}   // advanceCh of a root phaser
+}
+
+// An Option configures a Phaser at creation time.
+type Option func(*Phaser)
+
+// WithParent makes the phaser a child of the given parent phaser, see Phaser.
+func WithParent(parent *Phaser) Option {
+	return func(phaser *Phaser) {
+		phaser.parent = parent
+	}
+}
+
+// WithOnAdvance sets a hook run each time the phaser is about to advance from a phase,
+// with the number of the phase and the number of parties registered at the phaser.
+// If the hook returns true, the phaser is terminated rather than advanced.
+// This puts controller logic such as "stop after 100 iterations or when no parties remain" in the phaser:
+//
+//	phaser.WithOnAdvance(func(phase, registered int) bool {
+//		return phase >= 99 || registered == 0
+//	})
+//
+// The hook is run while the phaser's internal lock is held, so it must not call methods on the phaser.
+// Child phasers advance with their root, so only the hook of the root phaser is run.
+func WithOnAdvance(onAdvance func(phase, registered int) bool) Option {
+	return func(phaser *Phaser) {
+		phaser.onAdvance = onAdvance
+	}
+}
+
+// New creates a Phaser with the given number of initially registered parties.
+// New panics if parties is negative.
+func New(parties int, opts ...Option) *Phaser {
+	if parties < 0 {
+		panic("phaser: negative parties")
+	}
+	phaser := &Phaser{parties: parties}
+	for _, opt := range opts {
+		opt(phaser)
+	}
+	switch {
+	case phaser.parent == nil:
+		phaser.root = phaser
+		phaser.closeCh = make(chan struct{})
+		phaser.advanceCh = phaser.closeCh
+	case parties > 0:
+		phaser.root = phaser.parent.root
+		phaser.phase = phaser.parent.Register()
+	default:
+		phaser.root = phaser.parent.root
+		phaser.phase = phaser.parent.Phase()
+	}
+	return phaser
+}
+
+// Parent returns the parent of the phaser, or nil if it has none.
+func (phaser *Phaser) Parent() *Phaser {
+	return phaser.parent
+}
+
+// IsTerminated reports whether the phaser, or the root of its tree, has been terminated.
+func (phaser *Phaser) IsTerminated() bool {
+	return atomic.LoadInt32(&phaser.root.terminated) != 0
+}
+
+// Register adds a new unarrived party to the phaser, and returns the phase it is registered at.
+func (phaser *Phaser) Register() int {
+	return phaser.BulkRegister(1)
+}
+
+// BulkRegister adds the given number of unarrived parties to the phaser, and returns the phase they are registered at.
+// BulkRegister panics if parties is negative.
+func (phaser *Phaser) BulkRegister(parties int) int {
+	if parties < 0 {
+		panic("phaser: negative parties")
+	}
+	phaser.m.Lock()
+	defer phaser.m.Unlock()
+	for {
+		if phaser.IsTerminated() {
+			return terminatedPhase(phaser.phase)
+		}
+		phaser.reconcile()
+		if phaser.parent == nil || phaser.parties == 0 || phaser.arrived < phaser.parties {
+			break
+		}
+		// the phaser already arrived at its parent, so new parties have to wait for the next phase
+		advanceCh := phaser.advanceCh
+		phaser.m.Unlock()
+		<-advanceCh
+		phaser.m.Lock()
+	}
+	if phaser.parent != nil && phaser.parties == 0 && parties > 0 {
+		phaser.phase = phaser.parent.Register()
+		phaser.advanceCh = nil
+	}
+	phaser.parties += parties
+	return phaser.phase
+}
+
+// Arrive arrives at the current phase without waiting for the other parties, and returns the phase arrived at.
+// If the calling party is the last to arrive, the phaser advances to the next phase.
+// Arrive panics if all registered parties have already arrived at the current phase.
+func (phaser *Phaser) Arrive() int {
+	phase, _ := phaser.arrive(false)
+	return phase
+}
+
+// ArriveAndDeregister arrives at the current phase and deregisters the calling party, without waiting for the other parties,
+// and returns the phase arrived at. If the calling party was the last unarrived one, the phaser advances to the next phase.
+// ArriveAndDeregister panics if all registered parties have already arrived at the current phase.
+func (phaser *Phaser) ArriveAndDeregister() int {
+	phase, _ := phaser.arrive(true)
+	return phase
+}
+
+// ArriveAndAwaitAdvance arrives at the current phase and waits for the other parties to arrive,
+// like a cyclicbarrier.CyclicBarrier, then returns the phase that the phaser advanced to.
+// ArriveAndAwaitAdvance panics if all registered parties have already arrived at the current phase.
+func (phaser *Phaser) ArriveAndAwaitAdvance() int {
+	return phaser.AwaitAdvance(phaser.Arrive())
+}
+
+// AwaitAdvance waits for the phaser to advance from the given phase, and returns the phase it advanced to.
+// If the current phase of the phaser is not the given phase, AwaitAdvance returns the current phase immediately.
+func (phaser *Phaser) AwaitAdvance(phase int) int {
+	phase, _ = phaser.awaitAdvance(context.Background(), phase, nil)
+	return phase
+}
+
+// AwaitAdvanceTimeout is like AwaitAdvance, but gives up waiting once the timeout elapses.
+// It then returns the given phase and ErrTimeout. Unlike with a cyclicbarrier.CyclicBarrier,
+// giving up does not affect the phaser or the other parties.
+func (phaser *Phaser) AwaitAdvanceTimeout(phase int, timeout time.Duration) (int, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	return phaser.awaitAdvance(context.Background(), phase, timer.C)
+}
+
+// AwaitAdvanceContext is like AwaitAdvance, but gives up waiting when the context is done.
+// It then returns the given phase and the context's error. Unlike with a cyclicbarrier.CyclicBarrier,
+// giving up does not affect the phaser or the other parties.
+func (phaser *Phaser) AwaitAdvanceContext(ctx context.Context, phase int) (int, error) {
+	return phaser.awaitAdvance(ctx, phase, nil)
+}
+
+// awaitAdvance waits for the phaser to advance from the given phase, the context to be done or timeoutCh to fire.
+// A nil timeoutCh never fires.
+func (phaser *Phaser) awaitAdvance(ctx context.Context, phase int, timeoutCh <-chan time.Time) (int, error) {
+	current, advanceCh := phaser.awaitCh(phase)
+	if advanceCh == nil {
+		return current, nil
+	}
+	select {
+	case <-advanceCh:
+	case <-ctx.Done():
+		return phase, ctx.Err()
+	case <-timeoutCh:
+		return phase, ErrTimeout
+	}
+	if phaser.IsTerminated() {
+		return terminatedPhase(phase), nil
+	}
+	return phase + 1, nil
+}
+
+// Phase returns the current phase of the phaser.
+func (phaser *Phaser) Phase() int {
+	phaser.m.Lock()
+	defer phaser.m.Unlock()
+	if phaser.IsTerminated() {
+		return terminatedPhase(phaser.phase)
+	}
+	phaser.reconcile()
+	return phaser.phase
+}
+
+// RegisteredParties returns the number of parties registered at the phaser.
+func (phaser *Phaser) RegisteredParties() int {
+	phaser.m.Lock()
+	defer phaser.m.Unlock()
+	return phaser.parties
+}
+
+// ArrivedParties returns the number of registered parties that have arrived at the current phase.
+func (phaser *Phaser) ArrivedParties() int {
+	phaser.m.Lock()
+	defer phaser.m.Unlock()
+	phaser.reconcile()
+	return phaser.arrived
+}
+
+// UnarrivedParties returns the number of registered parties that have not yet arrived at the current phase.
+func (phaser *Phaser) UnarrivedParties() int {
+	phaser.m.Lock()
+	defer phaser.m.Unlock()
+	phaser.reconcile()
+	return phaser.parties - phaser.arrived
+}
+
+// arrive arrives at the current phase, deregistering the calling party if requested.
+// It returns the phase arrived at, and a channel closed when that phase advances.
+func (phaser *Phaser) arrive(deregister bool) (int, <-chan struct{}) {
+	phaser.m.Lock()
+	defer phaser.m.Unlock()
+	if phaser.IsTerminated() {
+		return terminatedPhase(phaser.phase), nil
+	}
+	phaser.reconcile()
+	if phaser.arrived == phaser.parties {
+		panic("phaser: arrival of unregistered party")
+	}
+	phase := phaser.phase
+	if deregister {
+		phaser.parties--
+	} else {
+		phaser.arrived++
+	}
+	switch {
+	case phaser.arrived < phaser.parties:
+		return phase, phaser.currentAdvanceCh()
+	case phaser.parent == nil:
+		advanceCh := phaser.advanceCh
+		phaser.advance()
+		return phase, advanceCh
+	default:
+		// the last party arrives at the parent on behalf of all the parties of the phaser,
+		// deregistering it from the parent if no parties remain
+		_, phaser.advanceCh = phaser.parent.arrive(phaser.parties == 0)
+		return phase, phaser.advanceCh
+	}
+}
+
+// awaitCh returns a channel closed when the phaser advances from the given phase,
+// or the current phase of the phaser and a nil channel if it is not at the given phase.
+func (phaser *Phaser) awaitCh(phase int) (int, <-chan struct{}) {
+	phaser.m.Lock()
+	defer phaser.m.Unlock()
+	if phaser.IsTerminated() {
+		return terminatedPhase(phaser.phase), nil
+	}
+	phaser.reconcile()
+	if phaser.phase != phase {
+		return phaser.phase, nil
+	}
+	if advanceCh := phaser.currentAdvanceCh(); advanceCh != nil {
+		return phase, advanceCh
+	}
+	// the parent of a phaser without parties advanced or terminated in the meantime
+	if phaser.IsTerminated() {
+		return terminatedPhase(phaser.phase), nil
+	}
+	phaser.reconcile()
+	return phaser.phase, nil
+}
+
+// currentAdvanceCh returns a channel closed when the current phase advances, fetching it from the parent for child phasers.
+// It returns nil if the parent already advanced, which may only happen if the phaser has no parties.
+// This call must be guarded using the phaser mutex.
+func (phaser *Phaser) currentAdvanceCh() <-chan struct{} {
+	if phaser.advanceCh == nil {
+		_, phaser.advanceCh = phaser.parent.awaitCh(phaser.phase)
+	}
+	return phaser.advanceCh
+}
+
+// reconcile catches the phase of a child phaser up with the phase of its parent.
+// A terminated tree is left as is, as it does not advance anymore.
+// This call must be guarded using the phaser mutex.
+func (phaser *Phaser) reconcile() {
+	switch {
+	case phaser.parent == nil, phaser.arrived < phaser.parties:
+		// the parent cannot advance before the parties of the phaser arrive
+	case phaser.parties == 0:
+		// the phaser is not registered at its parent, which may have advanced any number of phases
+		if phase := phaser.parent.Phase(); phase >= 0 && phase != phaser.phase {
+			phaser.phase = phase
+			phaser.advanceCh = nil
+		}
+	default:
+		// all parties arrived, and the phaser arrived at its parent
+		select {
+		case <-phaser.advanceCh:
+			if !phaser.IsTerminated() {
+				phaser.phase++
+				phaser.arrived = 0
+				phaser.advanceCh = nil
+			}
+		default:
+		}
+	}
+}
+
+// advance advances a root phaser to the next phase, or terminates it if the OnAdvance hook says so,
+// releasing the goroutines waiting for it.
+// This call must be guarded using the phaser mutex.
+func (phaser *Phaser) advance() {
+	if phaser.onAdvance != nil && phaser.onAdvance(phaser.phase, phaser.parties) {
+		atomic.StoreInt32(&phaser.terminated, 1)
+		close(phaser.closeCh)
+		return
+	}
+	phaser.phase++
+	phaser.arrived = 0
+	close(phaser.closeCh)
+	phaser.closeCh = make(chan struct{})
+	phaser.advanceCh = phaser.closeCh
+}
+
+// terminatedPhase returns the negative value reported in place of the given phase once a phaser is terminated.
+func terminatedPhase(phase int) int {
+	return phase + math.MinInt
+}
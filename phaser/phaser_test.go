@@ -0,0 +1,398 @@
+package phaser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func ExamplePhaser() {
+	// the controller is registered to keep the phaser from advancing while tasks are started
+	phaser := New(1)
+	var wg sync.WaitGroup
+	for task := 0; task < 3; task++ {
+		phaser.Register()
+		wg.Add(1)
+		go func(task int) {
+			defer wg.Done()
+			// tasks leave after a number of phases that differs between them
+			for phase := 0; phase <= task; phase++ {
+				// do this phase's work
+				// ...
+				phaser.ArriveAndAwaitAdvance()
+			}
+			phaser.ArriveAndDeregister()
+		}(task)
+	}
+	phaser.ArriveAndDeregister()
+	wg.Wait()
+	fmt.Println("Phases:", phaser.Phase(), "Parties:", phaser.RegisteredParties())
+	// Output:
+	// Phases: 4 Parties: 0
+}
+
+func TestPhaser_arrive(t *testing.T) {
+	phaser := New(2)
+	assertEqual(t, 0, phaser.Phase())
+	assertEqual(t, 2, phaser.UnarrivedParties())
+
+	assertEqual(t, 0, phaser.Arrive())
+	assertEqual(t, 1, phaser.ArrivedParties())
+	assertEqual(t, 1, phaser.UnarrivedParties())
+	assertEqual(t, 0, phaser.Phase())
+
+	assertEqual(t, 0, phaser.Arrive())
+	assertEqual(t, 1, phaser.Phase())
+	assertEqual(t, 0, phaser.ArrivedParties())
+	assertEqual(t, 2, phaser.RegisteredParties())
+
+	// waiting from a past phase returns immediately
+	assertEqual(t, 1, phaser.AwaitAdvance(0))
+}
+
+func TestPhaser_awaitAdvance(t *testing.T) {
+	phaser := New(2)
+	released := make(chan int)
+	go func() {
+		released <- phaser.ArriveAndAwaitAdvance()
+	}()
+	go func() {
+		// an unregistered goroutine may wait too
+		released <- phaser.AwaitAdvance(0)
+	}()
+
+	select {
+	case <-released:
+		t.Fatal("AwaitAdvance returned before all parties arrived")
+	case <-time.After(100 * time.Millisecond):
+	}
+	assertEqual(t, 1, phaser.ArriveAndAwaitAdvance())
+	assertEqual(t, 1, <-released)
+	assertEqual(t, 1, <-released)
+}
+
+func TestPhaser_awaitAdvanceTimeout(t *testing.T) {
+	phaser := New(2)
+	assertEqual(t, 0, phaser.Arrive())
+	phase, err := phaser.AwaitAdvanceTimeout(0, 50*time.Millisecond)
+	assertEqual(t, ErrTimeout, err)
+	assertEqual(t, 0, phase)
+
+	// giving up does not affect the phaser
+	assertEqual(t, 1, phaser.UnarrivedParties())
+	go phaser.Arrive()
+	phase, err = phaser.AwaitAdvanceTimeout(0, time.Second)
+	assertNil(t, err)
+	assertEqual(t, 1, phase)
+
+	// a past phase returns immediately
+	phase, err = phaser.AwaitAdvanceTimeout(0, 0)
+	assertNil(t, err)
+	assertEqual(t, 1, phase)
+}
+
+func TestPhaser_awaitAdvanceContext(t *testing.T) {
+	phaser := New(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	released := make(chan error)
+	go func() {
+		_, err := phaser.AwaitAdvanceContext(ctx, 0)
+		released <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	assertEqual(t, true, errors.Is(<-released, context.Canceled))
+	assertEqual(t, 0, phaser.Phase())
+
+	go func() {
+		_, err := phaser.AwaitAdvanceContext(context.Background(), 0)
+		released <- err
+	}()
+	phaser.Arrive()
+	assertNil(t, <-released)
+
+	// a past phase returns immediately, even with a done context
+	phase, err := phaser.AwaitAdvanceContext(ctx, 0)
+	assertNil(t, err)
+	assertEqual(t, 1, phase)
+}
+
+func TestPhaser_register(t *testing.T) {
+	phaser := New(1)
+	assertEqual(t, 0, phaser.Arrive())
+	assertEqual(t, 1, phaser.Phase())
+
+	// a party registered in the middle of a phase has to arrive at it
+	assertEqual(t, 1, phaser.Register())
+	assertEqual(t, 1, phaser.Arrive())
+	assertEqual(t, 1, phaser.Phase())
+	assertEqual(t, 1, phaser.Arrive())
+	assertEqual(t, 2, phaser.Phase())
+
+	assertEqual(t, 2, phaser.BulkRegister(3))
+	assertEqual(t, 5, phaser.RegisteredParties())
+}
+
+func TestPhaser_deregister(t *testing.T) {
+	phaser := New(3)
+	assertEqual(t, 0, phaser.Arrive())
+	assertEqual(t, 0, phaser.ArriveAndDeregister())
+	assertEqual(t, 2, phaser.RegisteredParties())
+	assertEqual(t, 0, phaser.Phase())
+
+	// deregistering the last unarrived party advances the phaser
+	assertEqual(t, 0, phaser.ArriveAndDeregister())
+	assertEqual(t, 1, phaser.Phase())
+	assertEqual(t, 1, phaser.RegisteredParties())
+	assertEqual(t, 1, phaser.UnarrivedParties())
+}
+
+func TestPhaser_concurrent(t *testing.T) {
+	const parties = 8
+	const phases = 100
+	phaser := New(parties)
+	var wg sync.WaitGroup
+	for p := 0; p < parties; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for phase := 1; phase <= phases; phase++ {
+				assertEqual(t, phase, phaser.ArriveAndAwaitAdvance())
+			}
+		}()
+	}
+	wg.Wait()
+	assertEqual(t, phases, phaser.Phase())
+}
+
+func TestPhaser_tiered(t *testing.T) {
+	root := New(0)
+	children := []*Phaser{New(2, WithParent(root)), New(3, WithParent(root))}
+	assertEqual(t, root, children[0].Parent())
+	// each child with parties is a party of the root
+	assertEqual(t, 2, root.RegisteredParties())
+
+	for _, party := range []*Phaser{children[0], children[0], children[1], children[1]} {
+		assertEqual(t, 0, party.Arrive())
+	}
+	assertEqual(t, 0, children[0].Phase())
+	assertEqual(t, 2, children[0].ArrivedParties())
+	assertEqual(t, 1, root.ArrivedParties())
+	assertEqual(t, 0, root.Phase())
+
+	released := make(chan int)
+	go func() {
+		released <- children[0].AwaitAdvance(0)
+	}()
+	select {
+	case <-released:
+		t.Fatal("AwaitAdvance returned before all parties arrived")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// the last party of the last child advances the whole tree
+	assertEqual(t, 0, children[1].Arrive())
+	assertEqual(t, 1, <-released)
+	assertEqual(t, 1, root.Phase())
+	assertEqual(t, 1, children[0].Phase())
+	assertEqual(t, 1, children[1].Phase())
+	assertEqual(t, 3, children[1].UnarrivedParties())
+
+	// a child without parties deregisters from its parent, and registers again with new parties
+	children[0].ArriveAndDeregister()
+	children[0].ArriveAndDeregister()
+	assertEqual(t, 1, root.RegisteredParties())
+	for i := 0; i < 3; i++ {
+		children[1].Arrive()
+	}
+	assertEqual(t, 2, root.Phase())
+	assertEqual(t, 2, children[0].Phase())
+	assertEqual(t, 2, children[0].Register())
+	assertEqual(t, 2, root.RegisteredParties())
+	assertEqual(t, 2, children[0].AwaitAdvance(1))
+}
+
+func TestPhaser_tieredConcurrent(t *testing.T) {
+	const phases = 50
+	root := New(0)
+	var wg sync.WaitGroup
+	for c := 0; c < 4; c++ {
+		child := New(0, WithParent(root))
+		for g := 0; g < 2; g++ {
+			grandchild := New(0, WithParent(child))
+			for p := 0; p < 3; p++ {
+				grandchild.Register()
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for phase := 1; phase <= phases; phase++ {
+						assertEqual(t, phase, grandchild.ArriveAndAwaitAdvance())
+					}
+					grandchild.ArriveAndDeregister()
+				}()
+			}
+		}
+	}
+	wg.Wait()
+	assertEqual(t, phases+1, root.Phase())
+	assertEqual(t, 0, root.RegisteredParties())
+}
+
+func TestPhaser_onAdvance(t *testing.T) {
+	var advanced []int
+	phaser := New(2, WithOnAdvance(func(phase, registered int) bool {
+		advanced = append(advanced, phase)
+		return phase >= 2 || registered == 0
+	}))
+
+	released := make(chan int)
+	go func() {
+		phase := 0
+		for phase >= 0 {
+			phase = phaser.ArriveAndAwaitAdvance()
+		}
+		released <- phase
+	}()
+	for i := 0; i < 3; i++ {
+		assertEqual(t, i, phaser.Arrive())
+		phaser.AwaitAdvance(i)
+	}
+
+	assertEqual(t, true, <-released < 0)
+	assertEqual(t, true, phaser.IsTerminated())
+	assertEqual(t, 3, len(advanced))
+	assertEqual(t, 2, advanced[2])
+
+	// a terminated phaser returns negative phases without further effect
+	assertEqual(t, true, phaser.Phase() < 0)
+	assertEqual(t, true, phaser.Arrive() < 0)
+	assertEqual(t, true, phaser.Register() < 0)
+	assertEqual(t, true, phaser.AwaitAdvance(2) < 0)
+	assertEqual(t, 2, phaser.RegisteredParties())
+	assertEqual(t, 3, len(advanced))
+
+	// no parties remaining
+	phaser = New(1, WithOnAdvance(func(phase, registered int) bool {
+		return registered == 0
+	}))
+	assertEqual(t, 0, phaser.Arrive())
+	assertEqual(t, false, phaser.IsTerminated())
+	assertEqual(t, 1, phaser.ArriveAndDeregister())
+	assertEqual(t, true, phaser.IsTerminated())
+
+	// without hook, a phaser never terminates
+	phaser = New(1)
+	phaser.ArriveAndDeregister()
+	assertEqual(t, false, phaser.IsTerminated())
+	assertEqual(t, 1, phaser.Phase())
+}
+
+func TestPhaser_tieredOnAdvance(t *testing.T) {
+	root := New(0, WithOnAdvance(func(phase, registered int) bool {
+		return phase >= 4
+	}))
+	child := New(2, WithParent(root))
+	idle := New(0, WithParent(root))
+
+	released := make(chan int)
+	for i := 0; i < 2; i++ {
+		go func() {
+			phase := 0
+			for phase >= 0 {
+				phase = child.ArriveAndAwaitAdvance()
+			}
+			released <- phase
+		}()
+	}
+	assertEqual(t, true, <-released < 0)
+	assertEqual(t, true, <-released < 0)
+	assertEqual(t, true, root.IsTerminated())
+	assertEqual(t, true, child.IsTerminated())
+	assertEqual(t, true, idle.IsTerminated())
+	assertEqual(t, true, child.Phase() < 0)
+	assertEqual(t, true, idle.Register() < 0)
+	assertEqual(t, 1, root.RegisteredParties())
+}
+
+func TestPhaser_invalid(t *testing.T) {
+	func() {
+		defer func() {
+			assertNotNil(t, recover())
+		}()
+		New(-1)
+		t.Fatal("New did not panic")
+	}()
+	func() {
+		defer func() {
+			assertNotNil(t, recover())
+		}()
+		New(0).Arrive()
+		t.Fatal("Arrive did not panic")
+	}()
+
+	defer func() {
+		assertNotNil(t, recover())
+	}()
+	phaser := New(1)
+	phaser.ArriveAndDeregister()
+	phaser.ArriveAndDeregister()
+	t.Fatal("ArriveAndDeregister did not panic")
+}
+
+func BenchmarkPhaser_flat(b *testing.B) {
+	phaser := New(0)
+	benchmarkPhaser(b, 64, func(int) *Phaser {
+		return phaser
+	})
+}
+
+func BenchmarkPhaser_tiered(b *testing.B) {
+	root := New(0)
+	children := make([]*Phaser, 8)
+	for i := range children {
+		children[i] = New(0, WithParent(root))
+	}
+	benchmarkPhaser(b, 64, func(party int) *Phaser {
+		return children[party%len(children)]
+	})
+}
+
+// benchmarkPhaser runs b.N phases with the given number of parties, each registered at the phaser returned by phaserOf.
+func benchmarkPhaser(b *testing.B, parties int, phaserOf func(party int) *Phaser) {
+	for party := 0; party < parties; party++ {
+		phaserOf(party).Register()
+	}
+	var wg sync.WaitGroup
+	b.ResetTimer()
+	for party := 0; party < parties; party++ {
+		wg.Add(1)
+		go func(phaser *Phaser) {
+			defer wg.Done()
+			for i := 0; i < b.N; i++ {
+				phaser.ArriveAndAwaitAdvance()
+			}
+		}(phaserOf(party))
+	}
+	wg.Wait()
+}
+
+func assertEqual(t *testing.T, expected interface{}, actual interface{}) {
+	if expected != actual {
+		t.Fatal("Not equal:", "expected:", expected, ", actual:", actual)
+	}
+}
+
+func assertNil(t *testing.T, actual interface{}) {
+	if actual != nil {
+		t.Fatal("Value not nil, actual:", actual)
+	}
+}
+
+func assertNotNil(t *testing.T, actual interface{}) {
+	if actual == nil {
+		t.Fatal("Value is nil")
+	}
+}
@@ -0,0 +1,100 @@
+package congo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetCountDownLatch(t *testing.T) {
+	latch := GetCountDownLatch(2)
+	assertEqual(t, uint(2), latch.Count())
+	assertNil(t, latch.CountDown())
+	assertNil(t, latch.CountDown())
+	latch.Wait()
+	PutCountDownLatch(latch)
+
+	// a reused latch starts afresh
+	latch = GetCountDownLatch(1)
+	assertEqual(t, uint(1), latch.Count())
+	assertEqual(t, uint64(0), latch.Epoch())
+	assertNil(t, latch.Err())
+	assertEqual(t, false, latch.WaitTimeout(10*time.Millisecond))
+	assertNil(t, latch.Cancel(nil))
+	PutCountDownLatch(latch)
+
+	latch = GetCountDownLatch(0)
+	assertEqual(t, uint(0), latch.Count())
+	assertNil(t, latch.Err())
+	latch.Wait()
+}
+
+func TestPutCountDownLatch_incomplete(t *testing.T) {
+	latch := GetCountDownLatch(1)
+	PutCountDownLatch(latch)
+
+	// the incomplete latch is not reset by a later Get
+	other := GetCountDownLatch(5)
+	assertEqual(t, uint(1), latch.Count())
+	assertEqual(t, uint(5), other.Count())
+}
+
+func TestPutCountDownLatch_awaitAdvance(t *testing.T) {
+	latch := GetCountDownLatch(1)
+	assertNil(t, latch.CountDown())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		latch.AwaitAdvance(1)
+	}()
+	for latch.Waiters() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	PutCountDownLatch(latch)
+
+	// the latch is not reused while a goroutine waits for its next round
+	other := GetCountDownLatch(5)
+	assertEqual(t, true, other != latch)
+	latch.Reset(0)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("AwaitAdvance waiter not released")
+	}
+	assertEqual(t, uint(5), other.Count())
+}
+
+func TestPutCountDownLatch_leakDetection(t *testing.T) {
+	latch := New(1, WithLeakDetection(func(WaiterLeak) {}))
+	assertNil(t, latch.CountDown())
+	PutCountDownLatch(latch)
+
+	// a latch with leak detection keeps its finalizer and is not reused
+	other := GetCountDownLatch(5)
+	assertEqual(t, true, other != latch)
+	assertEqual(t, uint(0), latch.Count())
+}
+
+func benchmarkFanOut(b *testing.B, get func(uint) *CountDownLatch, put func(*CountDownLatch)) {
+	const workers = 8
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			latch := get(workers)
+			for i := 0; i < workers; i++ {
+				latch.CountDown()
+			}
+			latch.Wait()
+			put(latch)
+		}
+	})
+}
+
+func BenchmarkNewCountDownLatch(b *testing.B) {
+	benchmarkFanOut(b, func(count uint) *CountDownLatch {
+		return NewCountDownLatch(count)
+	}, func(*CountDownLatch) {})
+}
+
+func BenchmarkGetCountDownLatch(b *testing.B) {
+	benchmarkFanOut(b, GetCountDownLatch, PutCountDownLatch)
+}
@@ -0,0 +1,467 @@
+// Package queue provides generic blocking queues, handing off items between producer and consumer goroutines.
+package queue
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// A BlockingQueue is a bounded first-in-first-out queue, for producer and consumer goroutines.
+//
+// Producers Put items, waiting while the queue is full, which applies backpressure when consumers fall behind.
+// Consumers Take items, waiting while the queue is empty. Unlike a buffered channel, a BlockingQueue
+// can also be offered an item or polled with a timeout, and producers may Transfer an item,
+// waiting until a consumer has received it rather than only until it is queued.
+//
+// Like a channel, a BlockingQueue may be closed by its producers to signal the end of the stream:
+// see Close.
+type BlockingQueue[T any] struct {
+	m        sync.Mutex
+	entries  []entry[T] // ring buffer of the queued items
+	head     int
+	count    int
+	closed   bool
+	notEmpty signal
+	notFull  signal
+	stats    Stats
+	hooks    Hooks
+	events   []event   // items added and removed since the mutex was locked, for the hooks
+	overflow *overflow // spills items to disk, with WithOverflow
+}
+
+// An entry is an item of a BlockingQueue.
+type entry[T any] struct {
+	item      T
+	at        time.Time     // when the item was added
+	delivered chan struct{} // closed when the item is taken, for a transferred item
+}
+
+// An Option configures a BlockingQueue at creation time.
+type Option func(*options)
+
+type options struct {
+	hooks    Hooks
+	overflow *overflow
+}
+
+// NewBlocking creates a BlockingQueue holding at most capacity items.
+// The queue may be further configured by passing options such as WithHooks.
+// NewBlocking panics if capacity is less than 1, or if the options are invalid.
+func NewBlocking[T any](capacity int, opts ...Option) *BlockingQueue[T] {
+	if capacity < 1 {
+		panic("queue: capacity must be at least 1")
+	}
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.overflow != nil {
+		checkOverflow[T](o.overflow, capacity)
+	}
+	return &BlockingQueue[T]{
+		entries:  make([]entry[T], capacity),
+		stats:    Stats{Cap: capacity, Created: time.Now()},
+		hooks:    o.hooks,
+		overflow: o.overflow,
+	}
+}
+
+// Put adds an item at the tail of the queue, waiting while the queue is full.
+// Put returns ErrClosed if the queue is or becomes closed before the item is added.
+func (queue *BlockingQueue[T]) Put(item T) error {
+	return queue.put(context.Background(), nil, entry[T]{item: item})
+}
+
+// Offer adds an item at the tail of the queue only if it is not full or closed, and reports whether it did.
+func (queue *BlockingQueue[T]) Offer(item T) bool {
+	queue.m.Lock()
+	defer queue.unlock()
+	e := entry[T]{item: item}
+	if queue.closed || queue.full(e) {
+		return false
+	}
+	return queue.push(e) == nil
+}
+
+// OfferTimeout adds an item at the tail of the queue, waiting until a given timeout while the queue is full.
+// It reports whether the item was added before the timeout, and returns false if the queue is closed.
+func (queue *BlockingQueue[T]) OfferTimeout(item T, timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	return queue.put(context.Background(), timer.C, entry[T]{item: item}) == nil
+}
+
+// PutContext is like Put, but gives up waiting when the context is done, and returns the context's error.
+// The item is then not added. If the context is already done, PutContext does not add the item.
+func (queue *BlockingQueue[T]) PutContext(ctx context.Context, item T) error {
+	return queue.put(ctx, nil, entry[T]{item: item})
+}
+
+// PutAll adds the given items at the tail of the queue, in order, waiting while the queue is full.
+// The items are added in as few batches as the free capacity of the queue allows, rather than locking the queue for each of them,
+// so consumers may take the first items before the last ones are added.
+// PutAll returns ErrClosed if the queue is or becomes closed before all the items are added.
+func (queue *BlockingQueue[T]) PutAll(items []T) error {
+	_, err := queue.putAll(context.Background(), nil, items)
+	return err
+}
+
+// PutAllContext is like PutAll, but gives up waiting when the context is done, and returns the context's error
+// with the number of items that were added, which are the first ones.
+func (queue *BlockingQueue[T]) PutAllContext(ctx context.Context, items []T) (int, error) {
+	return queue.putAll(ctx, nil, items)
+}
+
+// Transfer adds an item at the tail of the queue, waiting while the queue is full,
+// then waits until a consumer has taken it. Unlike Put, it lets a producer know that the item was delivered.
+// Transfer returns ErrClosed if the queue is or becomes closed before the item is added.
+// Once added, the item is still delivered after the queue is closed.
+func (queue *BlockingQueue[T]) Transfer(item T) error {
+	return queue.transfer(context.Background(), nil, item)
+}
+
+// TryTransfer is like Transfer, but gives up waiting once the timeout elapses, and reports whether the item was taken
+// by a consumer before the timeout. If it was not, the item is removed from the queue.
+func (queue *BlockingQueue[T]) TryTransfer(item T, timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	return queue.transfer(context.Background(), timer.C, item) == nil
+}
+
+// TransferContext is like Transfer, but gives up waiting when the context is done, and returns the context's error.
+// If the item was not taken by then, it is removed from the queue, so it is never both delivered and reported as failed.
+func (queue *BlockingQueue[T]) TransferContext(ctx context.Context, item T) error {
+	return queue.transfer(ctx, nil, item)
+}
+
+// Take removes and returns the item at the head of the queue, waiting while the queue is empty.
+// Once the queue is closed, Take keeps returning the remaining items, then returns ErrClosed.
+func (queue *BlockingQueue[T]) Take() (T, error) {
+	return queue.take(context.Background(), nil)
+}
+
+// Poll removes and returns the item at the head of the queue only if it is not empty.
+// The boolean result reports whether an item was removed.
+func (queue *BlockingQueue[T]) Poll() (T, bool) {
+	queue.m.Lock()
+	defer queue.unlock()
+	if queue.count == 0 {
+		var zero T
+		return zero, false
+	}
+	return queue.pop(), true
+}
+
+// TakeContext is like Take, but gives up waiting when the context is done, and returns the context's error.
+// If the context is already done, TakeContext does not remove any item.
+func (queue *BlockingQueue[T]) TakeContext(ctx context.Context) (T, error) {
+	return queue.take(ctx, nil)
+}
+
+// PollTimeout removes and returns the item at the head of the queue, waiting until a given timeout while the queue is empty.
+// The boolean result reports whether an item was removed before the timeout. PollTimeout does not wait if the queue is closed.
+func (queue *BlockingQueue[T]) PollTimeout(timeout time.Duration) (T, bool) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	item, err := queue.take(context.Background(), timer.C)
+	return item, err == nil
+}
+
+// DrainTo removes and returns up to max items from the head of the queue, in order, without waiting.
+// If max is 0 or less, DrainTo removes all the items in the queue. It returns nil if the queue is empty.
+func (queue *BlockingQueue[T]) DrainTo(max int) []T {
+	queue.m.Lock()
+	defer queue.unlock()
+	return queue.drain(max)
+}
+
+// DrainTimeout is like DrainTo, but waits until a given timeout for the queue not to be empty.
+// It returns nil if no item was removed before the timeout, or the queue is closed and empty.
+func (queue *BlockingQueue[T]) DrainTimeout(max int, timeout time.Duration) []T {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	items, _ := queue.drainWait(context.Background(), timer.C, max)
+	return items
+}
+
+// DrainContext is like DrainTimeout, but waits until the context is done, and then returns the context's error.
+// It returns ErrClosed if the queue is closed and empty.
+func (queue *BlockingQueue[T]) DrainContext(ctx context.Context, max int) ([]T, error) {
+	return queue.drainWait(ctx, nil, max)
+}
+
+// Close closes the queue, to signal that no more items will be added, like closing a channel.
+// Subsequent and waiting Puts fail with ErrClosed, while consumers keep taking the remaining items,
+// then are told that the queue is closed. Closing a closed queue has no effect.
+func (queue *BlockingQueue[T]) Close() {
+	queue.m.Lock()
+	defer queue.unlock()
+	queue.closed = true
+	queue.notEmpty.broadcast()
+	queue.notFull.broadcast()
+	if queue.overflow != nil && queue.overflow.done() {
+		queue.overflow.discard()
+	}
+}
+
+// IsClosed reports whether the queue is closed.
+func (queue *BlockingQueue[T]) IsClosed() bool {
+	queue.m.Lock()
+	defer queue.unlock()
+	return queue.closed
+}
+
+// Len returns the number of items in the queue, including those spilled to disk.
+func (queue *BlockingQueue[T]) Len() int {
+	queue.m.Lock()
+	defer queue.unlock()
+	return queue.len()
+}
+
+// Cap returns the maximum number of items in the queue, or in memory with overflow.
+func (queue *BlockingQueue[T]) Cap() int {
+	return len(queue.entries)
+}
+
+// put adds an entry, waiting while the queue is full until the context is done or timeoutCh fires.
+func (queue *BlockingQueue[T]) put(ctx context.Context, timeoutCh <-chan time.Time, e entry[T]) error {
+	queue.m.Lock()
+	if err := ctx.Err(); err != nil {
+		queue.unlock()
+		return err
+	}
+	for !queue.closed && queue.full(e) {
+		ch := queue.notFull.waitCh()
+		queue.unlock()
+		if err := wait(ctx, timeoutCh, ch); err != nil {
+			return err
+		}
+		queue.m.Lock()
+	}
+	if queue.closed {
+		queue.unlock()
+		return ErrClosed
+	}
+	err := queue.push(e)
+	queue.unlock()
+	return err
+}
+
+// putAll adds items in batches, waiting while the queue is full until the context is done or timeoutCh fires.
+// It returns the number of items added.
+func (queue *BlockingQueue[T]) putAll(ctx context.Context, timeoutCh <-chan time.Time, items []T) (int, error) {
+	added := 0
+	queue.m.Lock()
+	if err := ctx.Err(); err != nil {
+		queue.unlock()
+		return 0, err
+	}
+	for added < len(items) {
+		for !queue.closed && queue.full(entry[T]{}) {
+			ch := queue.notFull.waitCh()
+			queue.unlock()
+			if err := wait(ctx, timeoutCh, ch); err != nil {
+				return added, err
+			}
+			queue.m.Lock()
+		}
+		if queue.closed {
+			queue.unlock()
+			return added, ErrClosed
+		}
+		for ; added < len(items) && !queue.full(entry[T]{}); added++ {
+			if err := queue.push(entry[T]{item: items[added]}); err != nil {
+				queue.unlock()
+				return added, err
+			}
+		}
+	}
+	queue.unlock()
+	return added, nil
+}
+
+// transfer adds an item and waits for it to be taken, until the context is done or timeoutCh fires.
+// If the item is not taken by then, it is removed from the queue.
+func (queue *BlockingQueue[T]) transfer(ctx context.Context, timeoutCh <-chan time.Time, item T) error {
+	e := entry[T]{item: item, delivered: make(chan struct{})}
+	if err := queue.put(ctx, timeoutCh, e); err != nil {
+		return err
+	}
+	err := wait(ctx, timeoutCh, e.delivered)
+	if err == nil {
+		return nil
+	}
+
+	queue.m.Lock()
+	defer queue.unlock()
+	select {
+	case <-e.delivered:
+		// the item was taken while giving up
+		return nil
+	default:
+	}
+	queue.remove(e.delivered)
+	return err
+}
+
+// take removes an item, waiting while the queue is empty until the context is done or timeoutCh fires.
+func (queue *BlockingQueue[T]) take(ctx context.Context, timeoutCh <-chan time.Time) (T, error) {
+	var zero T
+	queue.m.Lock()
+	if err := ctx.Err(); err != nil {
+		queue.unlock()
+		return zero, err
+	}
+	for queue.count == 0 {
+		if err := queue.overflowErr(); err != nil {
+			queue.unlock()
+			return zero, err
+		}
+		if queue.closed {
+			queue.unlock()
+			return zero, ErrClosed
+		}
+		ch := queue.notEmpty.waitCh()
+		queue.unlock()
+		if err := wait(ctx, timeoutCh, ch); err != nil {
+			return zero, err
+		}
+		queue.m.Lock()
+	}
+	item := queue.pop()
+	queue.unlock()
+	return item, nil
+}
+
+// drainWait removes up to max items, waiting while the queue is empty until the context is done or timeoutCh fires.
+func (queue *BlockingQueue[T]) drainWait(ctx context.Context, timeoutCh <-chan time.Time, max int) ([]T, error) {
+	queue.m.Lock()
+	if err := ctx.Err(); err != nil {
+		queue.unlock()
+		return nil, err
+	}
+	for queue.count == 0 {
+		if err := queue.overflowErr(); err != nil {
+			queue.unlock()
+			return nil, err
+		}
+		if queue.closed {
+			queue.unlock()
+			return nil, ErrClosed
+		}
+		ch := queue.notEmpty.waitCh()
+		queue.unlock()
+		if err := wait(ctx, timeoutCh, ch); err != nil {
+			return nil, err
+		}
+		queue.m.Lock()
+	}
+	items := queue.drain(max)
+	queue.unlock()
+	return items, nil
+}
+
+// drain removes up to max items from the head of the queue, or all of them if max is 0 or less.
+// This call must be guarded using the queue mutex.
+func (queue *BlockingQueue[T]) drain(max int) []T {
+	n := queue.len()
+	if max > 0 && max < n {
+		n = max
+	}
+	if n == 0 {
+		return nil
+	}
+	items := make([]T, 0, n)
+	// spilled items are read back as the queue is drained, unless that fails
+	for len(items) < n && queue.count > 0 {
+		items = append(items, queue.pop())
+	}
+	return items
+}
+
+// len returns the number of items in the queue, including those spilled to disk.
+// This call must be guarded using the queue mutex.
+func (queue *BlockingQueue[T]) len() int {
+	if queue.overflow != nil {
+		return queue.count + queue.overflow.n
+	}
+	return queue.count
+}
+
+// full reports whether adding the entry must wait for room in the queue.
+// With overflow, items that are not transferred never wait, as they are spilled to disk instead.
+// This call must be guarded using the queue mutex.
+func (queue *BlockingQueue[T]) full(e entry[T]) bool {
+	if queue.overflow != nil && e.delivered == nil {
+		return false
+	}
+	return queue.count == len(queue.entries)
+}
+
+// push adds an entry at the tail of the queue, which must not be full, spilling it to disk with overflow.
+// This call must be guarded using the queue mutex.
+func (queue *BlockingQueue[T]) push(e entry[T]) error {
+	e.at = time.Now()
+	if queue.spilling(e) {
+		if err := queue.spill(e); err != nil {
+			return err
+		}
+	} else {
+		queue.store(e)
+	}
+	queue.stats.Enqueued++
+	if queue.hooks.OnPut != nil {
+		queue.events = append(queue.events, event{put: true, depth: queue.len()})
+	}
+	return nil
+}
+
+// store stores an entry at the tail of the ring buffer, which must not be full.
+// This call must be guarded using the queue mutex.
+func (queue *BlockingQueue[T]) store(e entry[T]) {
+	queue.entries[(queue.head+queue.count)%len(queue.entries)] = e
+	queue.count++
+	queue.notEmpty.broadcast()
+}
+
+// pop removes the item at the head of the queue, which must not be empty, and notifies its producer if it was transferred.
+// This call must be guarded using the queue mutex.
+func (queue *BlockingQueue[T]) pop() T {
+	e := queue.entries[queue.head]
+	queue.entries[queue.head] = entry[T]{}
+	queue.head = (queue.head + 1) % len(queue.entries)
+	queue.count--
+	queue.notFull.broadcast()
+	if e.delivered != nil {
+		close(e.delivered)
+	}
+	wait := time.Since(e.at)
+	queue.stats.Dequeued++
+	queue.stats.TimeInQueue += wait
+	if wait > queue.stats.MaxTimeInQueue {
+		queue.stats.MaxTimeInQueue = wait
+	}
+	queue.refill()
+	if queue.hooks.OnTake != nil {
+		queue.events = append(queue.events, event{wait: wait, depth: queue.len()})
+	}
+	return e.item
+}
+
+// remove removes the transferred entry with the given delivered channel, which must be in the queue.
+// This call must be guarded using the queue mutex.
+func (queue *BlockingQueue[T]) remove(delivered chan struct{}) {
+	n := len(queue.entries)
+	i := 0
+	for queue.entries[(queue.head+i)%n].delivered != delivered {
+		i++
+	}
+	for ; i < queue.count-1; i++ {
+		queue.entries[(queue.head+i)%n] = queue.entries[(queue.head+i+1)%n]
+	}
+	queue.entries[(queue.head+queue.count-1)%n] = entry[T]{}
+	queue.count--
+	queue.notFull.broadcast()
+	queue.refill()
+}
@@ -0,0 +1,356 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func ExampleBlockingQueue() {
+	jobs := NewBlocking[int](2)
+	go func() {
+		for i := 0; i < 5; i++ {
+			// waits while 2 jobs are pending
+			jobs.Put(i)
+		}
+		jobs.Close()
+	}()
+	for {
+		job, err := jobs.Take()
+		if err == ErrClosed {
+			break
+		}
+		fmt.Println("Processed job", job)
+	}
+	// Output:
+	// Processed job 0
+	// Processed job 1
+	// Processed job 2
+	// Processed job 3
+	// Processed job 4
+}
+
+func TestBlockingQueue_putTake(t *testing.T) {
+	queue := NewBlocking[int](2)
+	assertEqual(t, 2, queue.Cap())
+	queue.Put(1)
+	queue.Put(2)
+	assertEqual(t, 2, queue.Len())
+
+	put := make(chan struct{})
+	go func() {
+		queue.Put(3)
+		close(put)
+	}()
+	select {
+	case <-put:
+		t.Fatal("Put returned while the queue was full")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assertEqual(t, 1, must(queue.Take()))
+	<-put
+	assertEqual(t, 2, must(queue.Take()))
+	assertEqual(t, 3, must(queue.Take()))
+	assertEqual(t, 0, queue.Len())
+
+	taken := make(chan int)
+	go func() {
+		taken <- must(queue.Take())
+	}()
+	select {
+	case <-taken:
+		t.Fatal("Take returned while the queue was empty")
+	case <-time.After(50 * time.Millisecond):
+	}
+	queue.Put(4)
+	assertEqual(t, 4, <-taken)
+}
+
+func TestBlockingQueue_offerPoll(t *testing.T) {
+	queue := NewBlocking[string](1)
+	item, ok := queue.Poll()
+	assertEqual(t, "", item)
+	assertEqual(t, false, ok)
+	assertEqual(t, true, queue.Offer("a"))
+	assertEqual(t, false, queue.Offer("b"))
+	item, ok = queue.Poll()
+	assertEqual(t, "a", item)
+	assertEqual(t, true, ok)
+}
+
+func TestBlockingQueue_offerPollTimeout(t *testing.T) {
+	queue := NewBlocking[string](1)
+	item, ok := queue.PollTimeout(50 * time.Millisecond)
+	assertEqual(t, "", item)
+	assertEqual(t, false, ok)
+
+	assertEqual(t, true, queue.OfferTimeout("a", time.Second))
+	assertEqual(t, false, queue.OfferTimeout("b", 50*time.Millisecond))
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		queue.Take()
+	}()
+	assertEqual(t, true, queue.OfferTimeout("c", time.Second))
+
+	item, ok = queue.PollTimeout(time.Second)
+	assertEqual(t, "c", item)
+	assertEqual(t, true, ok)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		queue.Put("d")
+	}()
+	item, ok = queue.PollTimeout(time.Second)
+	assertEqual(t, "d", item)
+	assertEqual(t, true, ok)
+}
+
+func TestBlockingQueue_transfer(t *testing.T) {
+	queue := NewBlocking[int](2)
+	queue.Put(1)
+	transferred := make(chan struct{})
+	go func() {
+		queue.Transfer(2)
+		close(transferred)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	assertEqual(t, 2, queue.Len())
+
+	// Transfer waits for the item to be taken, not only queued
+	assertEqual(t, 1, must(queue.Take()))
+	select {
+	case <-transferred:
+		t.Fatal("Transfer returned before the item was taken")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assertEqual(t, 2, must(queue.Take()))
+	<-transferred
+}
+
+func TestBlockingQueue_tryTransfer(t *testing.T) {
+	queue := NewBlocking[int](3)
+	queue.Put(1)
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		queue.Put(3)
+	}()
+	assertEqual(t, false, queue.TryTransfer(2, 50*time.Millisecond))
+
+	// the item that was not taken is removed from the queue
+	assertEqual(t, 2, queue.Len())
+	assertEqual(t, 1, must(queue.Take()))
+	assertEqual(t, 3, must(queue.Take()))
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		queue.Take()
+	}()
+	assertEqual(t, true, queue.TryTransfer(4, time.Second))
+	assertEqual(t, 0, queue.Len())
+
+	// the timeout includes waiting for the queue not to be full
+	queue = NewBlocking[int](1)
+	queue.Put(1)
+	assertEqual(t, false, queue.TryTransfer(2, 50*time.Millisecond))
+	assertEqual(t, 1, queue.Len())
+}
+
+func TestBlockingQueue_putAll(t *testing.T) {
+	queue := NewBlocking[int](2)
+	put := make(chan struct{})
+	go func() {
+		queue.PutAll([]int{1, 2, 3, 4, 5})
+		close(put)
+	}()
+	for i := 1; i <= 5; i++ {
+		assertEqual(t, i, must(queue.Take()))
+	}
+	<-put
+	queue.PutAll(nil)
+	assertEqual(t, 0, queue.Len())
+}
+
+func TestBlockingQueue_drainTo(t *testing.T) {
+	queue := NewBlocking[int](5)
+	assertEqual(t, 0, len(queue.DrainTo(0)))
+	queue.PutAll([]int{1, 2, 3, 4, 5})
+	assertEqual(t, "[1 2]", fmt.Sprint(queue.DrainTo(2)))
+	assertEqual(t, "[3 4 5]", fmt.Sprint(queue.DrainTo(0)))
+
+	// draining frees capacity for waiting producers
+	queue.PutAll([]int{1, 2, 3, 4, 5})
+	put := make(chan struct{})
+	go func() {
+		queue.Put(6)
+		close(put)
+	}()
+	assertEqual(t, "[1 2 3 4 5]", fmt.Sprint(queue.DrainTo(10)))
+	<-put
+	assertEqual(t, 1, queue.Len())
+}
+
+func TestBlockingQueue_drainTimeout(t *testing.T) {
+	queue := NewBlocking[int](5)
+	assertEqual(t, 0, len(queue.DrainTimeout(3, 50*time.Millisecond)))
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		queue.PutAll([]int{1, 2, 3, 4})
+	}()
+	items := queue.DrainTimeout(3, time.Second)
+	assertEqual(t, true, len(items) >= 1 && len(items) <= 3)
+	assertEqual(t, 1, items[0])
+}
+
+func TestBlockingQueue_context(t *testing.T) {
+	queue := NewBlocking[int](1)
+	ctx, cancel := context.WithCancel(context.Background())
+	got := make(chan error)
+	go func() {
+		_, err := queue.TakeContext(ctx)
+		got <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	assertEqual(t, context.Canceled, <-got)
+
+	// a done context neither adds nor removes items
+	assertEqual(t, context.Canceled, queue.PutContext(ctx, 1))
+	assertEqual(t, 0, queue.Len())
+	assertEqual(t, nil, queue.PutContext(context.Background(), 1))
+	_, err := queue.TakeContext(ctx)
+	assertEqual(t, context.Canceled, err)
+	_, err = queue.DrainContext(ctx, 0)
+	assertEqual(t, context.Canceled, err)
+	assertEqual(t, 1, queue.Len())
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assertEqual(t, context.DeadlineExceeded, queue.PutContext(ctx, 2))
+	n, err := queue.PutAllContext(ctx, []int{2, 3})
+	assertEqual(t, 0, n)
+	assertEqual(t, context.DeadlineExceeded, err)
+	item, err := queue.TakeContext(context.Background())
+	assertEqual(t, 1, item)
+	assertEqual(t, nil, err)
+	items, err := queue.DrainContext(ctx, 0)
+	assertEqual(t, 0, len(items))
+	assertEqual(t, context.DeadlineExceeded, err)
+}
+
+func TestBlockingQueue_transferContext(t *testing.T) {
+	queue := NewBlocking[int](2)
+	ctx, cancel := context.WithCancel(context.Background())
+	got := make(chan error)
+	go func() {
+		got <- queue.TransferContext(ctx, 1)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	assertEqual(t, context.Canceled, <-got)
+	// the canceled item is not delivered
+	assertEqual(t, 0, queue.Len())
+
+	go func() {
+		got <- queue.TransferContext(context.Background(), 2)
+	}()
+	assertEqual(t, 2, must(queue.Take()))
+	assertEqual(t, nil, <-got)
+}
+
+func TestBlockingQueue_close(t *testing.T) {
+	queue := NewBlocking[int](2)
+	queue.PutAll([]int{1, 2})
+	put := make(chan error)
+	go func() {
+		put <- queue.Put(3)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	queue.Close()
+	queue.Close()
+	assertEqual(t, true, queue.IsClosed())
+
+	// producers are refused
+	assertEqual(t, ErrClosed, <-put)
+	assertEqual(t, ErrClosed, queue.Put(4))
+	assertEqual(t, ErrClosed, queue.PutAll([]int{4}))
+	assertEqual(t, ErrClosed, queue.Transfer(4))
+	assertEqual(t, false, queue.Offer(4))
+
+	// consumers take the remaining items, then are told the queue is closed
+	assertEqual(t, 1, must(queue.Take()))
+	assertEqual(t, "[2]", fmt.Sprint(queue.DrainTo(0)))
+	_, err := queue.Take()
+	assertEqual(t, ErrClosed, err)
+	_, ok := queue.PollTimeout(time.Hour)
+	assertEqual(t, false, ok)
+	assertEqual(t, 0, len(queue.DrainTimeout(0, time.Hour)))
+
+	// waiting consumers are released
+	queue = NewBlocking[int](2)
+	taken := make(chan error)
+	go func() {
+		_, err := queue.Take()
+		taken <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	queue.Close()
+	assertEqual(t, ErrClosed, <-taken)
+}
+
+func TestBlockingQueue_concurrent(t *testing.T) {
+	queue := NewBlocking[int](4)
+	const producers, items = 10, 100
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < items; i++ {
+				queue.Put(p*items + i)
+			}
+		}(p)
+	}
+
+	// every item is taken once, and the items of a producer in order
+	seen := make(map[int]bool)
+	last := make(map[int]int)
+	for i := 0; i < producers*items; i++ {
+		item := must(queue.Take())
+		assertEqual(t, false, seen[item])
+		seen[item] = true
+		if previous, ok := last[item/items]; ok {
+			assertEqual(t, true, previous < item)
+		}
+		last[item/items] = item
+	}
+	wg.Wait()
+	assertEqual(t, 0, queue.Len())
+}
+
+func TestNewBlocking_invalid(t *testing.T) {
+	defer func() {
+		assertNotNil(t, recover())
+	}()
+	NewBlocking[int](0)
+	t.Fatal("Did not panic")
+}
+
+func assertEqual(t *testing.T, expected interface{}, actual interface{}) {
+	if expected != actual {
+		t.Fatal("Not equal:", "expected:", expected, ", actual:", actual)
+	}
+}
+
+func assertNotNil(t *testing.T, actual interface{}) {
+	if actual == nil {
+		t.Fatal("Value is nil")
+	}
+}
+
+func must[T any](item T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return item
+}
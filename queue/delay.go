@@ -0,0 +1,222 @@
+package queue
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// A DelayQueue is an unbounded queue of items that may only be taken once their delay has expired,
+// e.g. the operations of a retry scheduler, or entries of a cache to expire.
+//
+// Items are taken in the order their delays expire. The queue keeps a single timer, for the item expiring first,
+// which wakes the waiting consumers when it fires. As the queue is unbounded, Put never waits.
+// Like a BlockingQueue, it may be closed to signal the end of the stream.
+type DelayQueue[T any] struct {
+	m        sync.Mutex
+	items    itemHeap[delayed[T]]
+	closed   bool
+	seq      uint64 // orders items expiring at the same time in the order they were put
+	timer    *time.Timer
+	timerAt  time.Time // when the timer fires, or the zero time if it is stopped
+	notEmpty signal    // broadcast when an item expires
+}
+
+// A delayed is an item of a DelayQueue, with the time at which it expires.
+type delayed[T any] struct {
+	item T
+	at   time.Time
+	seq  uint64
+}
+
+// NewDelay creates a DelayQueue.
+func NewDelay[T any]() *DelayQueue[T] {
+	queue := &DelayQueue[T]{}
+	queue.items.less = func(a, b delayed[T]) bool {
+		if a.at.Equal(b.at) {
+			return a.seq < b.seq
+		}
+		return a.at.Before(b.at)
+	}
+	return queue
+}
+
+// Put adds an item to the queue, which may be taken once the given delay has expired.
+// An item with no delay, or a negative one, may be taken immediately. Put returns ErrClosed if the queue is closed.
+func (queue *DelayQueue[T]) Put(item T, delay time.Duration) error {
+	return queue.PutAt(item, time.Now().Add(delay))
+}
+
+// PutAt adds an item to the queue, which may be taken from the given time on. PutAt returns ErrClosed if the queue is closed.
+func (queue *DelayQueue[T]) PutAt(item T, at time.Time) error {
+	queue.m.Lock()
+	defer queue.m.Unlock()
+	if queue.closed {
+		return ErrClosed
+	}
+	queue.seq++
+	heap.Push(&queue.items, delayed[T]{item: item, at: at, seq: queue.seq})
+	queue.schedule()
+	return nil
+}
+
+// Take removes and returns the item whose delay expired first, waiting until an item has expired.
+// Once the queue is closed, Take keeps returning the remaining items as they expire, then returns ErrClosed.
+func (queue *DelayQueue[T]) Take() (T, error) {
+	return queue.take(context.Background(), nil)
+}
+
+// Poll removes and returns the item whose delay expired first only if an item has expired.
+// The boolean result reports whether an item was removed.
+func (queue *DelayQueue[T]) Poll() (T, bool) {
+	queue.m.Lock()
+	defer queue.m.Unlock()
+	if !queue.expired() {
+		var zero T
+		return zero, false
+	}
+	return queue.pop(), true
+}
+
+// PollTimeout removes and returns the item whose delay expired first, waiting until a given timeout for an item to expire.
+// The boolean result reports whether an item was removed before the timeout.
+func (queue *DelayQueue[T]) PollTimeout(timeout time.Duration) (T, bool) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	item, err := queue.take(context.Background(), timer.C)
+	return item, err == nil
+}
+
+// TakeContext is like Take, but gives up waiting when the context is done, and returns the context's error.
+// If the context is already done, TakeContext does not remove any item.
+func (queue *DelayQueue[T]) TakeContext(ctx context.Context) (T, error) {
+	return queue.take(ctx, nil)
+}
+
+// Close closes the queue, to signal that no more items will be added. Subsequent Puts fail with ErrClosed,
+// while consumers keep taking the remaining items, then are told that the queue is closed. Closing a closed queue has no effect.
+func (queue *DelayQueue[T]) Close() {
+	queue.m.Lock()
+	defer queue.m.Unlock()
+	queue.closed = true
+	queue.notEmpty.broadcast()
+}
+
+// IsClosed reports whether the queue is closed.
+func (queue *DelayQueue[T]) IsClosed() bool {
+	queue.m.Lock()
+	defer queue.m.Unlock()
+	return queue.closed
+}
+
+// Len returns the number of items in the queue, whether their delay has expired or not.
+func (queue *DelayQueue[T]) Len() int {
+	queue.m.Lock()
+	defer queue.m.Unlock()
+	return queue.items.Len()
+}
+
+// Clear removes and returns all the items in the queue, whether their delay has expired or not, in the order they expire.
+// It returns nil if the queue is empty.
+func (queue *DelayQueue[T]) Clear() []T {
+	queue.m.Lock()
+	defer queue.m.Unlock()
+	if queue.items.Len() == 0 {
+		return nil
+	}
+	items := make([]T, 0, queue.items.Len())
+	for queue.items.Len() > 0 {
+		items = append(items, heap.Pop(&queue.items).(delayed[T]).item)
+	}
+	// consumers waiting for the remaining items of a closed queue are told that it is closed
+	queue.notEmpty.broadcast()
+	return items
+}
+
+// Remove removes an item of the queue for which match returns true, whether its delay has expired or not,
+// e.g. a canceled operation, so that the queue does not hold it until it expires. It reports whether an item was removed.
+func (queue *DelayQueue[T]) Remove(match func(item T) bool) bool {
+	queue.m.Lock()
+	defer queue.m.Unlock()
+	for i, delayed := range queue.items.items {
+		if match(delayed.item) {
+			heap.Remove(&queue.items, i)
+			// consumers waiting for the remaining items of a closed queue are told if it is now empty
+			queue.notEmpty.broadcast()
+			return true
+		}
+	}
+	return false
+}
+
+// take removes an item, waiting for an item to expire until the context is done or timeoutCh fires.
+func (queue *DelayQueue[T]) take(ctx context.Context, timeoutCh <-chan time.Time) (T, error) {
+	var zero T
+	queue.m.Lock()
+	if err := ctx.Err(); err != nil {
+		queue.m.Unlock()
+		return zero, err
+	}
+	for !queue.expired() {
+		if queue.closed && queue.items.Len() == 0 {
+			queue.m.Unlock()
+			return zero, ErrClosed
+		}
+		ch := queue.notEmpty.waitCh()
+		queue.m.Unlock()
+		if err := wait(ctx, timeoutCh, ch); err != nil {
+			return zero, err
+		}
+		queue.m.Lock()
+	}
+	item := queue.pop()
+	queue.m.Unlock()
+	return item, nil
+}
+
+// expired reports whether the item at the head of the queue has expired.
+// This call must be guarded using the queue mutex.
+func (queue *DelayQueue[T]) expired() bool {
+	return queue.items.Len() > 0 && !time.Now().Before(queue.items.items[0].at)
+}
+
+// pop removes the item at the head of the queue, which must have expired.
+// This call must be guarded using the queue mutex.
+func (queue *DelayQueue[T]) pop() T {
+	item := heap.Pop(&queue.items).(delayed[T]).item
+	queue.schedule()
+	return item
+}
+
+// schedule sets the timer to fire when the item at the head of the queue expires, or wakes the waiting consumers
+// if it has already expired.
+// This call must be guarded using the queue mutex.
+func (queue *DelayQueue[T]) schedule() {
+	if queue.items.Len() == 0 {
+		return
+	}
+	at := queue.items.items[0].at
+	if !time.Now().Before(at) {
+		queue.notEmpty.broadcast()
+		return
+	}
+	if !queue.timerAt.IsZero() && !at.Before(queue.timerAt) {
+		// the timer fires first anyway
+		return
+	}
+	queue.timerAt = at
+	if queue.timer == nil {
+		queue.timer = time.AfterFunc(time.Until(at), queue.fire)
+		return
+	}
+	queue.timer.Reset(time.Until(at))
+}
+
+// fire is called by the timer, and wakes the waiting consumers once the item at the head of the queue expires.
+func (queue *DelayQueue[T]) fire() {
+	queue.m.Lock()
+	defer queue.m.Unlock()
+	queue.timerAt = time.Time{}
+	queue.schedule()
+}
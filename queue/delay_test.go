@@ -0,0 +1,182 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func ExampleDelayQueue() {
+	retries := NewDelay[string]()
+	retries.Put("third", 30*time.Millisecond)
+	retries.Put("first", 10*time.Millisecond)
+	retries.Put("second", 20*time.Millisecond)
+	for i := 0; i < 3; i++ {
+		request, _ := retries.Take()
+		fmt.Println("Retrying", request)
+	}
+	// Output:
+	// Retrying first
+	// Retrying second
+	// Retrying third
+}
+
+func TestDelayQueue_take(t *testing.T) {
+	queue := NewDelay[int]()
+	start := time.Now()
+	queue.Put(1, 100*time.Millisecond)
+	_, ok := queue.Poll()
+	assertEqual(t, false, ok)
+	assertEqual(t, 1, queue.Len())
+	assertEqual(t, 1, must(queue.Take()))
+	assertEqual(t, true, time.Since(start) >= 100*time.Millisecond)
+
+	// an item with no delay is takeable immediately
+	queue.Put(2, 0)
+	queue.Put(3, -time.Second)
+	item, ok := queue.Poll()
+	assertEqual(t, 3, item)
+	assertEqual(t, true, ok)
+	item, ok = queue.Poll()
+	assertEqual(t, 2, item)
+	assertEqual(t, true, ok)
+	assertEqual(t, 0, queue.Len())
+}
+
+func TestDelayQueue_earlierItem(t *testing.T) {
+	// an item put while a consumer waits for a later one is taken first
+	queue := NewDelay[int]()
+	queue.Put(1, time.Hour)
+	taken := make(chan int)
+	go func() {
+		taken <- must(queue.Take())
+	}()
+	time.Sleep(50 * time.Millisecond)
+	queue.Put(2, 50*time.Millisecond)
+	assertEqual(t, 2, <-taken)
+
+	// items expiring at the same time are taken in the order they were put
+	at := time.Now().Add(50 * time.Millisecond)
+	for i := 3; i < 6; i++ {
+		queue.PutAt(i, at)
+	}
+	for i := 3; i < 6; i++ {
+		assertEqual(t, i, must(queue.Take()))
+	}
+}
+
+func TestDelayQueue_pollTimeout(t *testing.T) {
+	queue := NewDelay[int]()
+	queue.Put(1, time.Second)
+	_, ok := queue.PollTimeout(50 * time.Millisecond)
+	assertEqual(t, false, ok)
+	queue.Put(2, 50*time.Millisecond)
+	item, ok := queue.PollTimeout(time.Second)
+	assertEqual(t, 2, item)
+	assertEqual(t, true, ok)
+}
+
+func TestDelayQueue_takeContext(t *testing.T) {
+	queue := NewDelay[int]()
+	queue.Put(1, time.Hour)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := queue.TakeContext(ctx)
+	assertEqual(t, context.DeadlineExceeded, err)
+
+	// a done context does not take an item
+	queue.Put(2, 0)
+	_, err = queue.TakeContext(ctx)
+	assertEqual(t, context.DeadlineExceeded, err)
+	item, err := queue.TakeContext(context.Background())
+	assertEqual(t, 2, item)
+	assertEqual(t, nil, err)
+}
+
+func TestDelayQueue_concurrent(t *testing.T) {
+	queue := NewDelay[int]()
+	const items = 100
+	var wg sync.WaitGroup
+	for i := 0; i < items; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			queue.Put(i, time.Duration(i%10)*time.Millisecond)
+		}(i)
+	}
+	seen := make(map[int]bool)
+	for i := 0; i < items; i++ {
+		item := must(queue.Take())
+		assertEqual(t, false, seen[item])
+		seen[item] = true
+	}
+	wg.Wait()
+	assertEqual(t, 0, queue.Len())
+}
+
+func TestDelayQueue_close(t *testing.T) {
+	queue := NewDelay[int]()
+	queue.Put(1, 50*time.Millisecond)
+	taken := make(chan error)
+	go func() {
+		_, err := queue.Take()
+		taken <- err
+	}()
+	queue.Close()
+	assertEqual(t, true, queue.IsClosed())
+	assertEqual(t, ErrClosed, queue.Put(2, 0))
+
+	// the remaining item is still taken once it expires
+	assertEqual(t, nil, <-taken)
+	_, err := queue.Take()
+	assertEqual(t, ErrClosed, err)
+}
+
+func TestDelayQueue_clear(t *testing.T) {
+	queue := NewDelay[int]()
+	assertEqual(t, 0, len(queue.Clear()))
+	queue.Put(2, time.Hour)
+	queue.Put(1, 0)
+	queue.Put(3, 2*time.Hour)
+	items := queue.Clear()
+	assertEqual(t, 3, len(items))
+	for i, item := range items {
+		assertEqual(t, i+1, item)
+	}
+	assertEqual(t, 0, queue.Len())
+
+	// clearing a closed queue releases its consumers
+	queue.Put(1, time.Hour)
+	queue.Close()
+	taken := make(chan error)
+	go func() {
+		_, err := queue.Take()
+		taken <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	queue.Clear()
+	assertEqual(t, ErrClosed, <-taken)
+}
+
+func TestDelayQueue_remove(t *testing.T) {
+	queue := NewDelay[int]()
+	queue.Put(1, time.Hour)
+	queue.Put(2, time.Hour)
+	queue.Put(3, 0)
+	assertEqual(t, true, queue.Remove(func(item int) bool { return item == 1 }))
+	assertEqual(t, false, queue.Remove(func(item int) bool { return item == 1 }))
+	assertEqual(t, 2, queue.Len())
+	assertEqual(t, 3, must(queue.Take()))
+
+	// consumers of a closed queue are told that it is closed once its last item is removed
+	queue.Close()
+	errCh := make(chan error)
+	go func() {
+		_, err := queue.Take()
+		errCh <- err
+	}()
+	assertEqual(t, true, queue.Remove(func(item int) bool { return item == 2 }))
+	assertEqual(t, ErrClosed, <-errCh)
+}
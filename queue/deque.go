@@ -0,0 +1,129 @@
+package queue
+
+import "sync/atomic"
+
+// A Deque is a work-stealing double-ended queue, the building block of work-stealing schedulers.
+//
+// Each worker of a scheduler owns a Deque, to which it pushes the tasks it spawns, and from which it pops them back
+// in last-in-first-out order, which keeps the data they use hot in its cache. Idle workers steal tasks from the other end
+// of the deques of busy workers, in first-in-first-out order, so they take the oldest, and usually largest, tasks.
+//
+// A Deque is lock-free, following "Dynamic Circular Work-Stealing Deque" by Chase and Lev: the owner only contends
+// with thieves for the last item. Push and Pop must only be called by the owner goroutine, while Steal may be called
+// by any number of goroutines concurrently.
+type Deque[T any] struct {
+	top    int64        // index of the next item to steal, incremented by thieves and by the owner popping the last item
+	bottom int64        // index of the next item to push, only written by the owner
+	ring   atomic.Value // *ring[T], replaced by a larger one when full
+}
+
+// A ring is the circular array of the items of a Deque. The slots hold *T, so that thieves may read them atomically.
+type ring[T any] struct {
+	slots []atomic.Value
+}
+
+// get returns the item of the slot i. The boolean result is false if the slot was never stored, which a thief holding
+// a stale top may read from a ring grown after the other thieves moved top past it.
+func (ring *ring[T]) get(i int64) (T, bool) {
+	item, ok := ring.slots[i%int64(len(ring.slots))].Load().(*T)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return *item, true
+}
+
+func (ring *ring[T]) put(i int64, item T) {
+	ring.slots[i%int64(len(ring.slots))].Store(&item)
+}
+
+// grow returns a ring of twice the size, holding the items from top to bottom.
+func (ring *ring[T]) grow(top, bottom int64) *ring[T] {
+	larger := newRing[T](2 * len(ring.slots))
+	for i := top; i < bottom; i++ {
+		item, _ := ring.get(i)
+		larger.put(i, item)
+	}
+	return larger
+}
+
+func newRing[T any](size int) *ring[T] {
+	return &ring[T]{slots: make([]atomic.Value, size)}
+}
+
+// NewDeque creates an empty Deque.
+func NewDeque[T any]() *Deque[T] {
+	deque := &Deque[T]{}
+	deque.ring.Store(newRing[T](32))
+	return deque
+}
+
+// Push adds an item at the bottom of the deque. It must only be called by the owner of the deque.
+func (deque *Deque[T]) Push(item T) {
+	bottom := atomic.LoadInt64(&deque.bottom)
+	top := atomic.LoadInt64(&deque.top)
+	r := deque.ring.Load().(*ring[T])
+	if bottom-top >= int64(len(r.slots)) {
+		r = r.grow(top, bottom)
+		deque.ring.Store(r)
+	}
+	r.put(bottom, item)
+	atomic.StoreInt64(&deque.bottom, bottom+1)
+}
+
+// Pop removes and returns the item at the bottom of the deque, the last one pushed, if the deque is not empty.
+// The boolean result reports whether an item was removed. It must only be called by the owner of the deque.
+func (deque *Deque[T]) Pop() (T, bool) {
+	var zero T
+	bottom := atomic.LoadInt64(&deque.bottom) - 1
+	r := deque.ring.Load().(*ring[T])
+	// reserve the bottom item before looking at top, so that thieves cannot take it without the owner noticing
+	atomic.StoreInt64(&deque.bottom, bottom)
+	top := atomic.LoadInt64(&deque.top)
+	if top > bottom {
+		atomic.StoreInt64(&deque.bottom, bottom+1)
+		return zero, false
+	}
+	item, _ := r.get(bottom)
+	if top < bottom {
+		return item, true
+	}
+	// this is the last item, which a thief may be stealing too
+	won := atomic.CompareAndSwapInt64(&deque.top, top, top+1)
+	atomic.StoreInt64(&deque.bottom, bottom+1)
+	if !won {
+		return zero, false
+	}
+	return item, true
+}
+
+// Steal removes and returns the item at the top of the deque, the oldest one, if the deque is not empty.
+// The boolean result reports whether an item was removed. Steal may be called by any goroutine.
+func (deque *Deque[T]) Steal() (T, bool) {
+	for {
+		top := atomic.LoadInt64(&deque.top)
+		bottom := atomic.LoadInt64(&deque.bottom)
+		if top >= bottom {
+			var zero T
+			return zero, false
+		}
+		item, ok := deque.ring.Load().(*ring[T]).get(top)
+		if !ok {
+			// top is stale: other thieves took the item, and the owner grew the ring from the new top
+			continue
+		}
+		if atomic.CompareAndSwapInt64(&deque.top, top, top+1) {
+			return item, true
+		}
+		// another thief, or the owner, took the item first
+	}
+}
+
+// Len returns the number of items in the deque. It is only a snapshot, as thieves may steal items concurrently.
+func (deque *Deque[T]) Len() int {
+	n := atomic.LoadInt64(&deque.bottom) - atomic.LoadInt64(&deque.top)
+	if n < 0 {
+		return 0
+	}
+	return int(n)
+}
@@ -0,0 +1,128 @@
+package queue
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDeque_owner(t *testing.T) {
+	deque := NewDeque[int]()
+	_, ok := deque.Pop()
+	assertEqual(t, false, ok)
+
+	// the deque grows past its initial size
+	for i := 0; i < 100; i++ {
+		deque.Push(i)
+	}
+	assertEqual(t, 100, deque.Len())
+	for i := 99; i >= 0; i-- {
+		item, ok := deque.Pop()
+		assertEqual(t, i, item)
+		assertEqual(t, true, ok)
+	}
+	_, ok = deque.Pop()
+	assertEqual(t, false, ok)
+	assertEqual(t, 0, deque.Len())
+}
+
+func TestDeque_steal(t *testing.T) {
+	deque := NewDeque[int]()
+	_, ok := deque.Steal()
+	assertEqual(t, false, ok)
+	for i := 0; i < 3; i++ {
+		deque.Push(i)
+	}
+	item, _ := deque.Steal()
+	assertEqual(t, 0, item)
+	item, _ = deque.Pop()
+	assertEqual(t, 2, item)
+	item, _ = deque.Steal()
+	assertEqual(t, 1, item)
+	_, ok = deque.Steal()
+	assertEqual(t, false, ok)
+	_, ok = deque.Pop()
+	assertEqual(t, false, ok)
+}
+
+func TestDeque_concurrent(t *testing.T) {
+	const items, thieves = 10000, 4
+	deque := NewDeque[int]()
+	var taken [items]int32
+	var done int32
+	var wg sync.WaitGroup
+	for i := 0; i < thieves; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for atomic.LoadInt32(&done) == 0 || deque.Len() > 0 {
+				if item, ok := deque.Steal(); ok {
+					atomic.AddInt32(&taken[item], 1)
+				}
+			}
+		}()
+	}
+
+	// the owner pushes items, and pops some of them back
+	for i := 0; i < items; i++ {
+		deque.Push(i)
+		if i%3 == 0 {
+			if item, ok := deque.Pop(); ok {
+				atomic.AddInt32(&taken[item], 1)
+			}
+		}
+	}
+	atomic.StoreInt32(&done, 1)
+	wg.Wait()
+
+	// every item is taken exactly once
+	for i := range taken {
+		assertEqual(t, int32(1), taken[i])
+	}
+}
+
+func TestDeque_stealWhileGrowing(t *testing.T) {
+	const rounds, items, thieves = 200, 1000, 16
+	for round := 0; round < rounds; round++ {
+		deque := NewDeque[int]()
+		var taken [items]int32
+		var done int32
+		var wg sync.WaitGroup
+		start := make(chan struct{})
+		for i := 0; i < thieves; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				for atomic.LoadInt32(&done) == 0 || deque.Len() > 0 {
+					if item, ok := deque.Steal(); ok {
+						atomic.AddInt32(&taken[item], 1)
+					}
+				}
+			}()
+		}
+
+		// the owner fills the initial ring, then only pushes, so that the ring grows while thieves steal
+		for i := 0; i < items; i++ {
+			if i == 32 {
+				close(start)
+			}
+			deque.Push(i)
+		}
+		atomic.StoreInt32(&done, 1)
+		wg.Wait()
+
+		for i := range taken {
+			assertEqual(t, int32(1), taken[i])
+		}
+	}
+}
+
+func BenchmarkDeque_pushPop(b *testing.B) {
+	deque := NewDeque[int]()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		deque.Push(i)
+		deque.Pop()
+	}
+}
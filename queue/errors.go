@@ -0,0 +1,9 @@
+package queue
+
+import "errors"
+
+// These are errors related to the blocking queues.
+var (
+	// ErrClosed is returned when adding an item to a queue that is closed, or taking an item from a queue that is closed and empty
+	ErrClosed = errors.New("Queue is closed")
+)
@@ -0,0 +1,164 @@
+package queue
+
+import (
+	"encoding"
+	"encoding/binary"
+	"os"
+	"time"
+)
+
+// WithOverflow makes a BlockingQueue spill items to a temporary file in dir, rather than make producers wait,
+// for ingestion pipelines that must absorb bursts. If dir is empty, the default directory for temporary files is used.
+//
+// Once high items are in memory, the items added to the queue are spilled to disk, and Put, Offer and PutAll never wait.
+// Spilled items are read back into memory, up to high items, once consumers have taken all but low of the items in memory,
+// so items are still taken in the order they were added. Transferred items are never spilled: Transfer waits for room
+// in memory like without overflow, and its item may be taken before spilled ones.
+//
+// The items must implement encoding.BinaryMarshaler, and their pointers encoding.BinaryUnmarshaler.
+// The temporary file is removed once the queue is closed and drained. If reading spilled items back fails,
+// consumers get the error once the items in memory are taken.
+//
+// NewBlocking panics if the items do not implement the interfaces, or the watermarks are not 0 <= low < high <= capacity.
+func WithOverflow(dir string, high, low int) Option {
+	return func(o *options) {
+		o.overflow = &overflow{dir: dir, high: high, low: low}
+	}
+}
+
+// checkOverflow panics if items of type T cannot be spilled with the overflow, in a queue of the given capacity.
+func checkOverflow[T any](overflow *overflow, capacity int) {
+	if overflow.low < 0 || overflow.low >= overflow.high || overflow.high > capacity {
+		panic("queue: overflow watermarks must satisfy 0 <= low < high <= capacity")
+	}
+	var item T
+	_, marshaler := interface{}(item).(encoding.BinaryMarshaler)
+	_, unmarshaler := interface{}(&item).(encoding.BinaryUnmarshaler)
+	if !marshaler || !unmarshaler {
+		panic("queue: overflow requires items implementing encoding.BinaryMarshaler and encoding.BinaryUnmarshaler")
+	}
+}
+
+// An overflow is the temporary file to which a BlockingQueue spills items, as records of the time an item was added,
+// the length of its encoding, and its encoding. Its methods must be guarded using the mutex of the queue.
+type overflow struct {
+	dir       string
+	high, low int
+	file      *os.File // created when the first item is spilled
+	readOff   int64
+	writeOff  int64
+	n         int   // number of spilled items
+	err       error // error reading spilled items back, after which no more are read
+}
+
+// recordHeaderSize is the size of the time and length preceding each encoded item.
+const recordHeaderSize = 12
+
+// write appends an encoded item to the file.
+func (overflow *overflow) write(at time.Time, data []byte) error {
+	if overflow.file == nil {
+		file, err := os.CreateTemp(overflow.dir, "congo-queue-*")
+		if err != nil {
+			return err
+		}
+		overflow.file = file
+	}
+	record := make([]byte, recordHeaderSize+len(data))
+	binary.BigEndian.PutUint64(record, uint64(at.UnixNano()))
+	binary.BigEndian.PutUint32(record[8:], uint32(len(data)))
+	copy(record[recordHeaderSize:], data)
+	if _, err := overflow.file.WriteAt(record, overflow.writeOff); err != nil {
+		return err
+	}
+	overflow.writeOff += int64(len(record))
+	overflow.n++
+	return nil
+}
+
+// read reads the oldest encoded item from the file. Once all items are read, the file is truncated to reclaim its space,
+// which may fail harmlessly, as the next items are written over the stale ones.
+func (overflow *overflow) read() (time.Time, []byte, error) {
+	var header [recordHeaderSize]byte
+	if _, err := overflow.file.ReadAt(header[:], overflow.readOff); err != nil {
+		return time.Time{}, nil, err
+	}
+	at := time.Unix(0, int64(binary.BigEndian.Uint64(header[:8])))
+	data := make([]byte, binary.BigEndian.Uint32(header[8:]))
+	if _, err := overflow.file.ReadAt(data, overflow.readOff+recordHeaderSize); err != nil {
+		return time.Time{}, nil, err
+	}
+	overflow.readOff += int64(recordHeaderSize + len(data))
+	overflow.n--
+	if overflow.n == 0 {
+		overflow.readOff, overflow.writeOff = 0, 0
+		overflow.file.Truncate(0)
+	}
+	return at, data, nil
+}
+
+// done reports whether no more spilled items are going to be read back, so that the file may be removed once the queue is closed.
+func (overflow *overflow) done() bool {
+	return overflow.n == 0 || overflow.err != nil
+}
+
+// discard closes and removes the file, if any.
+func (overflow *overflow) discard() {
+	if overflow.file != nil {
+		overflow.file.Close()
+		os.Remove(overflow.file.Name())
+		overflow.file = nil
+	}
+}
+
+// spilling reports whether the entry must be spilled to disk rather than stored in memory: once there are high items in memory,
+// or other items are spilled already, which must be taken first.
+// This call must be guarded using the queue mutex.
+func (queue *BlockingQueue[T]) spilling(e entry[T]) bool {
+	overflow := queue.overflow
+	return overflow != nil && e.delivered == nil && (overflow.n > 0 || queue.count >= overflow.high)
+}
+
+// spill writes an entry to the overflow file.
+// This call must be guarded using the queue mutex.
+func (queue *BlockingQueue[T]) spill(e entry[T]) error {
+	data, err := interface{}(e.item).(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return queue.overflow.write(e.at, data)
+}
+
+// refill reads spilled items back into memory, up to the high watermark, once there are at most low items in memory.
+// This call must be guarded using the queue mutex.
+func (queue *BlockingQueue[T]) refill() {
+	overflow := queue.overflow
+	if overflow == nil || overflow.n == 0 || overflow.err != nil || queue.count > overflow.low {
+		return
+	}
+	for overflow.n > 0 && queue.count < overflow.high {
+		at, data, err := overflow.read()
+		var item T
+		if err == nil {
+			err = interface{}(&item).(encoding.BinaryUnmarshaler).UnmarshalBinary(data)
+		}
+		if err != nil {
+			overflow.err = err
+			// release the consumers waiting for items that cannot be read
+			queue.notEmpty.broadcast()
+			break
+		}
+		queue.store(entry[T]{item: item, at: at})
+	}
+	if overflow.done() && queue.closed {
+		overflow.discard()
+	}
+}
+
+// overflowErr returns the error that prevented spilled items from being read back, if any.
+// This call must be guarded using the queue mutex.
+func (queue *BlockingQueue[T]) overflowErr() error {
+	if queue.overflow == nil {
+		return nil
+	}
+	return queue.overflow.err
+}
@@ -0,0 +1,133 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type record int
+
+func (r record) MarshalBinary() ([]byte, error) {
+	return []byte(strconv.Itoa(int(r))), nil
+}
+
+func (r *record) UnmarshalBinary(data []byte) error {
+	n, err := strconv.Atoi(string(data))
+	*r = record(n)
+	return err
+}
+
+func TestBlockingQueue_overflow(t *testing.T) {
+	dir := t.TempDir()
+	queue := NewBlocking[record](4, WithOverflow(dir, 2, 1))
+
+	// producers do not wait once the queue is full
+	for i := 0; i < 10; i++ {
+		assertEqual(t, nil, queue.Put(record(i)))
+	}
+	assertEqual(t, true, queue.Offer(10))
+	added, err := queue.PutAllContext(context.Background(), []record{11, 12})
+	assertEqual(t, 2, added)
+	assertEqual(t, nil, err)
+	assertEqual(t, 13, queue.Len())
+	stats := queue.Stats()
+	assertEqual(t, 13, stats.Len)
+	assertEqual(t, 11, stats.Spilled)
+	assertEqual(t, 1, countFiles(t, dir))
+
+	// items are taken in order
+	for i := 0; i < 5; i++ {
+		assertEqual(t, record(i), must(queue.Take()))
+	}
+	items := queue.DrainTo(0)
+	assertEqual(t, 8, len(items))
+	for i, item := range items {
+		assertEqual(t, record(i+5), item)
+	}
+	assertEqual(t, 0, queue.Stats().Spilled)
+
+	// the file is reused, then removed once the queue is closed and drained
+	queue.PutAll([]record{1, 2, 3})
+	assertEqual(t, 1, queue.Stats().Spilled)
+	queue.Close()
+	assertEqual(t, 1, countFiles(t, dir))
+	assertEqual(t, 3, len(queue.DrainTo(0)))
+	assertEqual(t, 0, countFiles(t, dir))
+}
+
+func TestBlockingQueue_overflowTransfer(t *testing.T) {
+	queue := NewBlocking[record](2, WithOverflow(t.TempDir(), 1, 0))
+	queue.PutAll([]record{1, 2})
+
+	// transferred items are not spilled, and overtake spilled ones
+	transferred := make(chan error)
+	go func() {
+		transferred <- queue.Transfer(3)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	stats := queue.Stats()
+	assertEqual(t, 3, stats.Len)
+	assertEqual(t, 1, stats.Spilled)
+	assertEqual(t, false, queue.TryTransfer(4, 50*time.Millisecond))
+	assertEqual(t, record(1), must(queue.Take()))
+	assertEqual(t, record(3), must(queue.Take()))
+	assertEqual(t, nil, <-transferred)
+	assertEqual(t, record(2), must(queue.Take()))
+}
+
+func TestBlockingQueue_overflowError(t *testing.T) {
+	dir := t.TempDir()
+	queue := NewBlocking[record](2, WithOverflow(dir, 1, 0))
+	queue.PutAll([]record{1, 2, 3})
+	queue.m.Lock()
+	queue.overflow.file.WriteAt([]byte("x"), recordHeaderSize)
+	queue.unlock()
+
+	// the items in memory are taken before the error
+	assertEqual(t, record(1), must(queue.Take()))
+	_, err := queue.Take()
+	assertEqual(t, true, errors.Is(err, strconv.ErrSyntax))
+	_, err = queue.DrainContext(context.Background(), 0)
+	assertEqual(t, true, errors.Is(err, strconv.ErrSyntax))
+
+	// the items that cannot be read back do not keep the file once the queue is closed
+	assertEqual(t, 1, countFiles(t, dir))
+	queue.Close()
+	assertEqual(t, 0, countFiles(t, dir))
+
+	// spilling fails in a missing directory
+	queue = NewBlocking[record](1, WithOverflow(t.TempDir()+"/missing", 1, 0))
+	assertEqual(t, nil, queue.Put(1))
+	assertNotNil(t, queue.Put(2))
+	assertEqual(t, false, queue.Offer(2))
+	assertEqual(t, 1, queue.Len())
+}
+
+func TestWithOverflow_invalid(t *testing.T) {
+	for _, f := range []func(){
+		func() { NewBlocking[int](4, WithOverflow("", 2, 1)) },
+		func() { NewBlocking[record](4, WithOverflow("", 5, 1)) },
+		func() { NewBlocking[record](4, WithOverflow("", 2, 2)) },
+		func() { NewBlocking[record](4, WithOverflow("", 2, -1)) },
+	} {
+		func() {
+			defer func() {
+				assertNotNil(t, recover())
+			}()
+			f()
+			t.Fatal("Did not panic")
+		}()
+	}
+}
+
+func countFiles(t *testing.T, dir string) int {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(entries)
+}
@@ -0,0 +1,213 @@
+package queue
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// A PriorityBlockingQueue is an unbounded queue, whose consumers always take the item with the highest priority.
+//
+// The priority of items is defined by a less function: the item at the head of the queue is the least one.
+// Items of equal priority are taken in no particular order. As the queue is unbounded, Put never waits.
+// Like a BlockingQueue, it may be closed to signal the end of the stream.
+type PriorityBlockingQueue[T any] struct {
+	m        sync.Mutex
+	items    itemHeap[T]
+	closed   bool
+	notEmpty signal
+}
+
+// An itemHeap is a heap of items, implementing heap.Interface.
+type itemHeap[T any] struct {
+	items []T
+	less  func(a, b T) bool
+}
+
+func (h *itemHeap[T]) Len() int           { return len(h.items) }
+func (h *itemHeap[T]) Less(i, j int) bool { return h.less(h.items[i], h.items[j]) }
+func (h *itemHeap[T]) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *itemHeap[T]) Push(x interface{}) { h.items = append(h.items, x.(T)) }
+
+func (h *itemHeap[T]) Pop() interface{} {
+	var zero T
+	n := len(h.items) - 1
+	item := h.items[n]
+	h.items[n] = zero
+	h.items = h.items[:n]
+	return item
+}
+
+// NewPriority creates a PriorityBlockingQueue, whose items are ordered by the given less function,
+// which reports whether item a has a higher priority than item b.
+func NewPriority[T any](less func(a, b T) bool) *PriorityBlockingQueue[T] {
+	return &PriorityBlockingQueue[T]{items: itemHeap[T]{less: less}}
+}
+
+// Put adds an item to the queue. Put returns ErrClosed if the queue is closed.
+func (queue *PriorityBlockingQueue[T]) Put(item T) error {
+	queue.m.Lock()
+	defer queue.m.Unlock()
+	if queue.closed {
+		return ErrClosed
+	}
+	heap.Push(&queue.items, item)
+	queue.notEmpty.broadcast()
+	return nil
+}
+
+// PutAll adds the given items to the queue at once. PutAll returns ErrClosed if the queue is closed.
+func (queue *PriorityBlockingQueue[T]) PutAll(items []T) error {
+	queue.m.Lock()
+	defer queue.m.Unlock()
+	if queue.closed {
+		return ErrClosed
+	}
+	for _, item := range items {
+		heap.Push(&queue.items, item)
+	}
+	queue.notEmpty.broadcast()
+	return nil
+}
+
+// Take removes and returns the item with the highest priority, waiting while the queue is empty.
+// Once the queue is closed, Take keeps returning the remaining items, then returns ErrClosed.
+func (queue *PriorityBlockingQueue[T]) Take() (T, error) {
+	return queue.take(context.Background(), nil)
+}
+
+// Poll removes and returns the item with the highest priority only if the queue is not empty.
+// The boolean result reports whether an item was removed.
+func (queue *PriorityBlockingQueue[T]) Poll() (T, bool) {
+	queue.m.Lock()
+	defer queue.m.Unlock()
+	if queue.items.Len() == 0 {
+		var zero T
+		return zero, false
+	}
+	return heap.Pop(&queue.items).(T), true
+}
+
+// PollTimeout removes and returns the item with the highest priority, waiting until a given timeout while the queue is empty.
+// The boolean result reports whether an item was removed before the timeout. PollTimeout does not wait if the queue is closed.
+func (queue *PriorityBlockingQueue[T]) PollTimeout(timeout time.Duration) (T, bool) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	item, err := queue.take(context.Background(), timer.C)
+	return item, err == nil
+}
+
+// TakeContext is like Take, but gives up waiting when the context is done, and returns the context's error.
+// If the context is already done, TakeContext does not remove any item.
+func (queue *PriorityBlockingQueue[T]) TakeContext(ctx context.Context) (T, error) {
+	return queue.take(ctx, nil)
+}
+
+// DrainTo removes and returns up to max items with the highest priority, in priority order, without waiting.
+// If max is 0 or less, DrainTo removes all the items in the queue. It returns nil if the queue is empty.
+func (queue *PriorityBlockingQueue[T]) DrainTo(max int) []T {
+	queue.m.Lock()
+	defer queue.m.Unlock()
+	return queue.drain(max)
+}
+
+// DrainTimeout is like DrainTo, but waits until a given timeout for the queue not to be empty.
+// It returns nil if no item was removed before the timeout, or the queue is closed and empty.
+func (queue *PriorityBlockingQueue[T]) DrainTimeout(max int, timeout time.Duration) []T {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	queue.m.Lock()
+	for queue.items.Len() == 0 {
+		if queue.closed {
+			queue.m.Unlock()
+			return nil
+		}
+		ch := queue.notEmpty.waitCh()
+		queue.m.Unlock()
+		if err := wait(context.Background(), timer.C, ch); err != nil {
+			return nil
+		}
+		queue.m.Lock()
+	}
+	items := queue.drain(max)
+	queue.m.Unlock()
+	return items
+}
+
+// Peek returns the item with the highest priority without removing it, if the queue is not empty.
+// The boolean result reports whether there was an item.
+func (queue *PriorityBlockingQueue[T]) Peek() (T, bool) {
+	queue.m.Lock()
+	defer queue.m.Unlock()
+	if queue.items.Len() == 0 {
+		var zero T
+		return zero, false
+	}
+	return queue.items.items[0], true
+}
+
+// Close closes the queue, to signal that no more items will be added. Subsequent Puts fail with ErrClosed,
+// while consumers keep taking the remaining items, then are told that the queue is closed. Closing a closed queue has no effect.
+func (queue *PriorityBlockingQueue[T]) Close() {
+	queue.m.Lock()
+	defer queue.m.Unlock()
+	queue.closed = true
+	queue.notEmpty.broadcast()
+}
+
+// IsClosed reports whether the queue is closed.
+func (queue *PriorityBlockingQueue[T]) IsClosed() bool {
+	queue.m.Lock()
+	defer queue.m.Unlock()
+	return queue.closed
+}
+
+// Len returns the number of items in the queue.
+func (queue *PriorityBlockingQueue[T]) Len() int {
+	queue.m.Lock()
+	defer queue.m.Unlock()
+	return queue.items.Len()
+}
+
+// take removes an item, waiting while the queue is empty until the context is done or timeoutCh fires.
+func (queue *PriorityBlockingQueue[T]) take(ctx context.Context, timeoutCh <-chan time.Time) (T, error) {
+	var zero T
+	queue.m.Lock()
+	if err := ctx.Err(); err != nil {
+		queue.m.Unlock()
+		return zero, err
+	}
+	for queue.items.Len() == 0 {
+		if queue.closed {
+			queue.m.Unlock()
+			return zero, ErrClosed
+		}
+		ch := queue.notEmpty.waitCh()
+		queue.m.Unlock()
+		if err := wait(ctx, timeoutCh, ch); err != nil {
+			return zero, err
+		}
+		queue.m.Lock()
+	}
+	item := heap.Pop(&queue.items).(T)
+	queue.m.Unlock()
+	return item, nil
+}
+
+// drain removes up to max items with the highest priority, or all of them if max is 0 or less.
+// This call must be guarded using the queue mutex.
+func (queue *PriorityBlockingQueue[T]) drain(max int) []T {
+	n := queue.items.Len()
+	if max > 0 && max < n {
+		n = max
+	}
+	if n == 0 {
+		return nil
+	}
+	items := make([]T, n)
+	for i := range items {
+		items[i] = heap.Pop(&queue.items).(T)
+	}
+	return items
+}
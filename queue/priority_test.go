@@ -0,0 +1,135 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func ExamplePriorityBlockingQueue() {
+	type task struct {
+		name     string
+		priority int
+	}
+	tasks := NewPriority(func(a, b task) bool {
+		return a.priority > b.priority
+	})
+	tasks.Put(task{"backup", 1})
+	tasks.Put(task{"page oncall", 10})
+	tasks.Put(task{"reindex", 5})
+	for tasks.Len() > 0 {
+		task, _ := tasks.Take()
+		fmt.Println(task.name)
+	}
+	// Output:
+	// page oncall
+	// reindex
+	// backup
+}
+
+func TestPriorityBlockingQueue_order(t *testing.T) {
+	queue := NewPriority(func(a, b int) bool { return a < b })
+	for _, item := range []int{5, 3, 8, 1, 9, 2, 7} {
+		queue.Put(item)
+	}
+	item, ok := queue.Peek()
+	assertEqual(t, 1, item)
+	assertEqual(t, true, ok)
+	assertEqual(t, 7, queue.Len())
+	for _, expected := range []int{1, 2, 3, 5, 7, 8, 9} {
+		assertEqual(t, expected, must(queue.Take()))
+	}
+	_, ok = queue.Peek()
+	assertEqual(t, false, ok)
+	_, ok = queue.Poll()
+	assertEqual(t, false, ok)
+}
+
+func TestPriorityBlockingQueue_drain(t *testing.T) {
+	queue := NewPriority(func(a, b int) bool { return a < b })
+	assertEqual(t, 0, len(queue.DrainTo(0)))
+	queue.PutAll([]int{5, 3, 8, 1, 9})
+	assertEqual(t, "[1 3]", fmt.Sprint(queue.DrainTo(2)))
+	assertEqual(t, "[5 8 9]", fmt.Sprint(queue.DrainTo(0)))
+
+	assertEqual(t, 0, len(queue.DrainTimeout(2, 50*time.Millisecond)))
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		queue.PutAll([]int{2, 1, 3})
+	}()
+	assertEqual(t, "[1 2]", fmt.Sprint(queue.DrainTimeout(2, time.Second)))
+}
+
+func TestPriorityBlockingQueue_take(t *testing.T) {
+	queue := NewPriority(func(a, b int) bool { return a < b })
+	taken := make(chan int)
+	go func() {
+		taken <- must(queue.Take())
+	}()
+	select {
+	case <-taken:
+		t.Fatal("Take returned while the queue was empty")
+	case <-time.After(50 * time.Millisecond):
+	}
+	queue.Put(1)
+	assertEqual(t, 1, <-taken)
+}
+
+func TestPriorityBlockingQueue_pollTimeout(t *testing.T) {
+	queue := NewPriority(func(a, b int) bool { return a < b })
+	_, ok := queue.PollTimeout(50 * time.Millisecond)
+	assertEqual(t, false, ok)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		queue.Put(1)
+	}()
+	item, ok := queue.PollTimeout(time.Second)
+	assertEqual(t, 1, item)
+	assertEqual(t, true, ok)
+}
+
+func TestPriorityBlockingQueue_takeContext(t *testing.T) {
+	queue := NewPriority(func(a, b int) bool { return a < b })
+	ctx, cancel := context.WithCancel(context.Background())
+	got := make(chan error)
+	go func() {
+		_, err := queue.TakeContext(ctx)
+		got <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	assertEqual(t, context.Canceled, <-got)
+
+	// a done context does not take an item
+	queue.Put(1)
+	_, err := queue.TakeContext(ctx)
+	assertEqual(t, context.Canceled, err)
+	item, err := queue.TakeContext(context.Background())
+	assertEqual(t, 1, item)
+	assertEqual(t, nil, err)
+}
+
+func TestPriorityBlockingQueue_close(t *testing.T) {
+	queue := NewPriority(func(a, b int) bool { return a < b })
+	queue.PutAll([]int{2, 1})
+	taken := make(chan error)
+	queue.Close()
+	assertEqual(t, true, queue.IsClosed())
+	assertEqual(t, ErrClosed, queue.Put(3))
+	assertEqual(t, ErrClosed, queue.PutAll([]int{3}))
+	assertEqual(t, 1, must(queue.Take()))
+	assertEqual(t, 2, must(queue.Take()))
+	_, err := queue.Take()
+	assertEqual(t, ErrClosed, err)
+	assertEqual(t, 0, len(queue.DrainTimeout(0, time.Hour)))
+
+	queue = NewPriority(func(a, b int) bool { return a < b })
+	go func() {
+		_, err := queue.Take()
+		taken <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	queue.Close()
+	assertEqual(t, ErrClosed, <-taken)
+}
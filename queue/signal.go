@@ -0,0 +1,45 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// errTimeout is returned by wait when timeoutCh fires.
+var errTimeout = errors.New("Queue wait timed out")
+
+// A signal wakes the goroutines waiting for a change in the state of a queue, e.g. for it to be no longer empty.
+// Its methods must be guarded using the mutex of the queue.
+type signal struct {
+	ch chan struct{} // closed on broadcast, or nil if no goroutine is waiting
+}
+
+// waitCh returns a channel that is closed at the next broadcast.
+func (signal *signal) waitCh() <-chan struct{} {
+	if signal.ch == nil {
+		signal.ch = make(chan struct{})
+	}
+	return signal.ch
+}
+
+// broadcast wakes all the goroutines waiting on the signal.
+func (signal *signal) broadcast() {
+	if signal.ch != nil {
+		close(signal.ch)
+		signal.ch = nil
+	}
+}
+
+// wait waits for ch to be closed, the context to be done or timeoutCh to fire. A nil timeoutCh never fires.
+// It returns nil if ch was closed, and the reason for giving up otherwise.
+func wait(ctx context.Context, timeoutCh <-chan time.Time, ch <-chan struct{}) error {
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timeoutCh:
+		return errTimeout
+	}
+}
@@ -0,0 +1,91 @@
+package queue
+
+import "time"
+
+// Stats are a point-in-time view of the state and throughput of a BlockingQueue, as returned by Stats,
+// to observe the saturation of producer and consumer stages.
+//
+// The counters are cumulative since the queue was created: rates are derived from the difference between two snapshots,
+// e.g. (b.Dequeued - a.Dequeued) / b.Time.Sub(a.Time).Seconds() items taken per second.
+type Stats struct {
+	// Len is the number of items in the queue, including those spilled to disk.
+	Len int
+
+	// Cap is the maximum number of items in the queue, or in memory with overflow.
+	Cap int
+
+	// Spilled is the number of items of Len spilled to disk, with WithOverflow.
+	Spilled int
+
+	// Enqueued is the number of items added to the queue.
+	Enqueued uint64
+
+	// Dequeued is the number of items taken from the queue. Transferred items withdrawn on a timeout or a done context are not counted.
+	Dequeued uint64
+
+	// TimeInQueue is the total time spent in the queue by the items taken from it,
+	// so that TimeInQueue / Dequeued is their mean time in the queue.
+	TimeInQueue time.Duration
+
+	// MaxTimeInQueue is the longest time spent in the queue by an item taken from it.
+	MaxTimeInQueue time.Duration
+
+	// Created is the time at which the queue was created.
+	Created time.Time
+
+	// Time is the time at which the snapshot was taken.
+	Time time.Time
+}
+
+// Hooks are callbacks invoked as items go through a BlockingQueue, e.g. to feed metrics. Nil callbacks are skipped.
+//
+// Hooks are invoked by the goroutine adding or removing the items once the queue's internal lock is released,
+// so they may call methods such as Stats and Len.
+type Hooks struct {
+	// OnPut is invoked after an item is added, with the number of items in the queue once it was added.
+	OnPut func(depth int)
+
+	// OnTake is invoked after an item is removed, with the time it spent in the queue and the number of items left in the queue.
+	OnTake func(timeInQueue time.Duration, depth int)
+}
+
+// An event is an item added to or removed from a queue, for which hooks are invoked.
+type event struct {
+	put   bool
+	wait  time.Duration
+	depth int
+}
+
+// WithHooks sets callbacks to be invoked as items are added to and removed from the queue.
+func WithHooks(hooks Hooks) Option {
+	return func(o *options) {
+		o.hooks = hooks
+	}
+}
+
+// Stats returns the state and throughput of the queue, captured under a single lock acquisition.
+func (queue *BlockingQueue[T]) Stats() Stats {
+	queue.m.Lock()
+	defer queue.m.Unlock()
+	stats := queue.stats
+	stats.Len = queue.len()
+	if queue.overflow != nil {
+		stats.Spilled = queue.overflow.n
+	}
+	stats.Time = time.Now()
+	return stats
+}
+
+// unlock releases the queue mutex, then invokes the hooks for the items added and removed while it was locked.
+func (queue *BlockingQueue[T]) unlock() {
+	events := queue.events
+	queue.events = nil
+	queue.m.Unlock()
+	for _, e := range events {
+		if e.put {
+			queue.hooks.OnPut(e.depth)
+		} else {
+			queue.hooks.OnTake(e.wait, e.depth)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package queue
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBlockingQueue_stats(t *testing.T) {
+	queue := NewBlocking[int](4)
+	stats := queue.Stats()
+	assertEqual(t, 0, stats.Len)
+	assertEqual(t, 4, stats.Cap)
+	assertEqual(t, false, stats.Created.IsZero())
+
+	queue.PutAll([]int{1, 2, 3})
+	time.Sleep(50 * time.Millisecond)
+	queue.Take()
+	queue.DrainTo(1)
+	stats = queue.Stats()
+	assertEqual(t, 1, stats.Len)
+	assertEqual(t, uint64(3), stats.Enqueued)
+	assertEqual(t, uint64(2), stats.Dequeued)
+	assertEqual(t, true, stats.MaxTimeInQueue >= 50*time.Millisecond)
+	assertEqual(t, true, stats.TimeInQueue >= 2*stats.MaxTimeInQueue-time.Millisecond)
+	assertEqual(t, true, !stats.Time.Before(stats.Created))
+}
+
+func TestBlockingQueue_hooks(t *testing.T) {
+	var m sync.Mutex
+	var puts, takes []int
+	var queue *BlockingQueue[int]
+	queue = NewBlocking[int](4, WithHooks(Hooks{
+		OnPut: func(depth int) {
+			m.Lock()
+			defer m.Unlock()
+			puts = append(puts, depth)
+		},
+		OnTake: func(timeInQueue time.Duration, depth int) {
+			// hooks may use the queue
+			queue.Stats()
+			m.Lock()
+			defer m.Unlock()
+			takes = append(takes, depth)
+		},
+	}))
+	queue.Put(1)
+	queue.PutAll([]int{2, 3})
+	queue.Offer(4)
+	queue.Take()
+	queue.DrainTo(0)
+
+	m.Lock()
+	defer m.Unlock()
+	assertEqual(t, 4, len(puts))
+	assertEqual(t, 4, len(takes))
+	for i := 0; i < 4; i++ {
+		assertEqual(t, i+1, puts[i])
+		assertEqual(t, 3-i, takes[i])
+	}
+}
@@ -0,0 +1,84 @@
+package congo
+
+import "time"
+
+// WaitQuorum waits until at least k count downs of the current round have taken place, even though the latch keeps tracking the rest.
+// It suits replication style workloads, where a write is acknowledged once a quorum of replicas has applied it.
+// Count downs are measured by weight, so a WeightedCountDown of 2 contributes 2 towards the quorum.
+//
+// WaitQuorum returns immediately if the quorum has already been reached. It also returns once the count down is completed or canceled,
+// including when k exceeds the count that the current round started with.
+func (latch *CountDownLatch) WaitQuorum(k uint) {
+	latch.block(latch.quorumCh(k), nil)
+}
+
+// WaitQuorumTimeout waits until a given timeout for at least k count downs of the current round to take place.
+// If the quorum is reached, or the count down is completed or canceled, before the timeout, WaitQuorumTimeout returns true.
+// Otherwise it returns false.
+func (latch *CountDownLatch) WaitQuorumTimeout(k uint, timeout time.Duration) bool {
+	quorumCh := latch.quorumCh(k)
+	select {
+	case <-quorumCh:
+		return true
+	default:
+	}
+
+	timer := latch.getClock().NewTimer(timeout)
+	defer timer.Stop()
+	return latch.block(quorumCh, timer.C())
+}
+
+// quorumCh returns a channel that is closed once k count downs of the current round have taken place.
+func (latch *CountDownLatch) quorumCh(k uint) <-chan struct{} {
+	latch.m.Lock()
+	defer latch.m.Unlock()
+	select {
+	case <-latch.countDownCompleteCh:
+		return latch.countDownCompleteCh
+	default:
+	}
+	if k > latch.initialCount {
+		return latch.countDownCompleteCh
+	}
+
+	release := latch.initialCount - k
+	if latch.remainingCount <= release {
+		return closedCh
+	}
+	if latch.quorums == nil {
+		latch.quorums = make(map[uint]chan struct{})
+	}
+	ch, ok := latch.quorums[release]
+	if !ok {
+		ch = make(chan struct{})
+		latch.quorums[release] = ch
+	}
+	return ch
+}
+
+// releaseQuorums releases the goroutines in WaitQuorum whose quorum has been reached.
+// This call must be guarded using the latch mutex.
+func (latch *CountDownLatch) releaseQuorums() {
+	for release, ch := range latch.quorums {
+		if latch.remainingCount <= release {
+			close(ch)
+			delete(latch.quorums, release)
+		}
+	}
+}
+
+// releaseAllQuorums releases all goroutines in WaitQuorum once the count down is completed or canceled.
+// This call must be guarded using the latch mutex.
+func (latch *CountDownLatch) releaseAllQuorums() {
+	for _, ch := range latch.quorums {
+		close(ch)
+	}
+	latch.quorums = nil
+}
+
+// closedCh is a closed channel, used to signal conditions that already hold.
+var closedCh = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
@@ -0,0 +1,89 @@
+package congo
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func ExampleCountDownLatch_WaitQuorum() {
+	replicas := 5
+	acks := NewCountDownLatch(uint(replicas))
+	for i := 0; i < replicas; i++ {
+		go func() {
+			// apply the write
+			// ...
+			acks.CountDown()
+		}()
+	}
+
+	acks.WaitQuorum(3) // a majority of replicas acknowledged the write
+	fmt.Println("Write committed")
+	// Output:
+	// Write committed
+}
+
+func TestCountDownLatch_quorum(t *testing.T) {
+	latch := NewCountDownLatch(5)
+
+	// a quorum of 0 is always reached
+	latch.WaitQuorum(0)
+
+	released := NewCountDownLatch(2)
+	go func() {
+		latch.WaitQuorum(3)
+		released.CountDown()
+	}()
+	go func() {
+		latch.WaitQuorum(4)
+		released.CountDown()
+	}()
+	for latch.Waiters() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	assertNil(t, latch.WeightedCountDown(2))
+	assertEqual(t, false, latch.WaitQuorumTimeout(3, 100*time.Millisecond))
+	assertEqual(t, uint(2), released.Count())
+
+	assertNil(t, latch.CountDown())
+	assertEqual(t, true, latch.WaitQuorumTimeout(3, time.Second))
+	for released.Count() > 1 {
+		time.Sleep(time.Millisecond)
+	}
+	assertEqual(t, uint(1), released.Count())
+
+	// the latch keeps tracking the remaining count downs
+	assertEqual(t, uint(2), latch.Count())
+	assertNil(t, latch.CountDown())
+	assertEqual(t, true, released.WaitTimeout(time.Second))
+	assertEqual(t, false, latch.WaitTimeout(100*time.Millisecond))
+}
+
+func TestCountDownLatch_quorumDone(t *testing.T) {
+	// a quorum larger than the count is released on completion
+	latch := NewCountDownLatch(2)
+	assertEqual(t, false, latch.WaitQuorumTimeout(3, 100*time.Millisecond))
+	assertNil(t, latch.Complete())
+	assertEqual(t, true, latch.WaitQuorumTimeout(3, time.Second))
+
+	// cancellation releases quorum waiters
+	latch = NewCountDownLatch(4)
+	released := NewCountDownLatch(1)
+	go func() {
+		latch.WaitQuorum(2)
+		released.CountDown()
+	}()
+	for latch.Waiters() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	assertNil(t, latch.Cancel(nil))
+	assertEqual(t, true, released.WaitTimeout(time.Second))
+
+	// quorums apply to the current round after a reset
+	_, err := latch.Reset(3)
+	assertNil(t, err)
+	assertEqual(t, false, latch.WaitQuorumTimeout(1, 100*time.Millisecond))
+	assertNil(t, latch.CountDown())
+	latch.WaitQuorum(1)
+}
@@ -0,0 +1,154 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/nvn1729/congo"
+)
+
+// DefaultBackoff is the ratio by which an AdaptiveLimiter multiplies its limit on overload, unless set with WithBackoff.
+const DefaultBackoff = 0.9
+
+// latencySmoothing is the weight of each latency sample in the average latency of an AdaptiveLimiter.
+const latencySmoothing = 0.05
+
+// WithBackoff sets the ratio, between 0 and 1, by which an AdaptiveLimiter multiplies its limit when a call signals overload.
+// It defaults to DefaultBackoff. It does not apply to other limiters.
+func WithBackoff(ratio float64) Option {
+	return func(o *options) {
+		o.backoff = ratio
+	}
+}
+
+// WithLatencyTolerance makes an AdaptiveLimiter treat a call whose latency exceeds tolerance times the average latency
+// of the calls as a signal of overload, like a failed call, e.g. 2 for calls taking twice as long as usual.
+// By default, only failed calls signal overload. It does not apply to other limiters.
+func WithLatencyTolerance(tolerance float64) Option {
+	return func(o *options) {
+		o.tolerance = tolerance
+	}
+}
+
+// An AdaptiveLimiter limits the number of concurrent calls to a downstream, adjusting the limit to the feedback of the calls
+// with the additive increase, multiplicative decrease (AIMD) algorithm of TCP congestion control, rather than a hand-tuned static limit.
+//
+// Each call acquires a Flight, and reports its outcome when done. The limit grows by one for every limit's worth of successful calls
+// made while the limit was in use, and is multiplied by the backoff ratio when a call fails, signaling overload such as
+// a timeout or a rejection by the downstream. With WithLatencyTolerance, a call that is slower than usual also signals overload,
+// which backs off before the downstream fails. The limit stays within the given minimum and maximum.
+type AdaptiveLimiter struct {
+	m         sync.Mutex
+	limit     float64
+	min, max  float64
+	inFlight  int
+	latency   float64 // the average latency of the calls, in seconds
+	backoff   float64
+	tolerance float64
+	clock     congo.Clock
+}
+
+// A Flight is a call admitted by an AdaptiveLimiter, which must report its outcome once done, with Success, Failure or Ignore.
+type Flight struct {
+	limiter  *AdaptiveLimiter
+	start    time.Time
+	inFlight int  // the calls in flight when the call started, including itself
+	done     bool // guarded by the limiter mutex
+}
+
+// NewAdaptive creates an AdaptiveLimiter, with an initial limit, adjusted between min and max.
+// The limiter may be further configured by passing options such as WithLatencyTolerance.
+// NewAdaptive panics unless 1 <= min <= initial <= max, or if the backoff ratio is not between 0 and 1.
+func NewAdaptive(initial, min, max int, opts ...Option) *AdaptiveLimiter {
+	if min < 1 || initial < min || max < initial {
+		panic("ratelimit: limits must satisfy 1 <= min <= initial <= max")
+	}
+	o := newOptions(opts)
+	if !(o.backoff > 0 && o.backoff < 1) {
+		panic("ratelimit: backoff must be between 0 and 1")
+	}
+	return &AdaptiveLimiter{
+		limit:     float64(initial),
+		min:       float64(min),
+		max:       float64(max),
+		backoff:   o.backoff,
+		tolerance: o.tolerance,
+		clock:     o.clock,
+	}
+}
+
+// TryAcquire admits a call if fewer calls than the limit are in flight, and reports whether it did.
+// The outcome of an admitted call must be reported to the returned Flight.
+func (limiter *AdaptiveLimiter) TryAcquire() (*Flight, bool) {
+	limiter.m.Lock()
+	defer limiter.m.Unlock()
+	if limiter.inFlight >= int(limiter.limit) {
+		return nil, false
+	}
+	limiter.inFlight++
+	return &Flight{limiter: limiter, start: limiter.clock.Now(), inFlight: limiter.inFlight}, true
+}
+
+// Limit returns the current limit of concurrent calls.
+func (limiter *AdaptiveLimiter) Limit() int {
+	limiter.m.Lock()
+	defer limiter.m.Unlock()
+	return int(limiter.limit)
+}
+
+// InFlight returns the number of calls in flight.
+func (limiter *AdaptiveLimiter) InFlight() int {
+	limiter.m.Lock()
+	defer limiter.m.Unlock()
+	return limiter.inFlight
+}
+
+// Success reports that the call succeeded, which may raise the limit, unless it was slower than tolerated.
+// Reporting the outcome of a call again has no effect.
+func (flight *Flight) Success() {
+	flight.limiter.release(flight, true, false)
+}
+
+// Failure reports that the call failed in a way signaling overload, such as a timeout, which lowers the limit.
+// Reporting the outcome of a call again has no effect.
+func (flight *Flight) Failure() {
+	flight.limiter.release(flight, true, true)
+}
+
+// Ignore reports that the call is done, but its outcome says nothing about the load of the downstream,
+// such as a call canceled by its client, which leaves the limit unchanged. Reporting the outcome of a call again has no effect.
+func (flight *Flight) Ignore() {
+	flight.limiter.release(flight, false, false)
+}
+
+// release ends a flight, and adjusts the limit to its outcome, if it is a sample of the load of the downstream.
+func (limiter *AdaptiveLimiter) release(flight *Flight, sample bool, failed bool) {
+	limiter.m.Lock()
+	defer limiter.m.Unlock()
+	if flight.done {
+		return
+	}
+	flight.done = true
+	limiter.inFlight--
+	if !sample {
+		return
+	}
+	overloaded := failed
+	if !failed && limiter.tolerance > 0 {
+		latency := limiter.clock.Now().Sub(flight.start).Seconds()
+		if limiter.latency == 0 {
+			limiter.latency = latency
+		} else {
+			overloaded = latency > limiter.tolerance*limiter.latency
+			limiter.latency += (latency - limiter.latency) * latencySmoothing
+		}
+	}
+	switch {
+	case overloaded:
+		limiter.limit = math.Max(limiter.min, limiter.limit*limiter.backoff)
+	case float64(flight.inFlight)*2 >= limiter.limit:
+		// the limit only grows while it is in use, rather than while calls are too few to test it
+		limiter.limit = math.Min(limiter.max, limiter.limit+1/limiter.limit)
+	}
+}
@@ -0,0 +1,112 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nvn1729/congo/internal/clocktest"
+)
+
+func TestAdaptiveLimiter(t *testing.T) {
+	limiter := NewAdaptive(2, 1, 3)
+	assertEqual(t, 2, limiter.Limit())
+	first, ok := limiter.TryAcquire()
+	assertEqual(t, true, ok)
+	second, ok := limiter.TryAcquire()
+	assertEqual(t, true, ok)
+	_, ok = limiter.TryAcquire()
+	assertEqual(t, false, ok)
+	assertEqual(t, 2, limiter.InFlight())
+
+	// the limit grows by about one per limit's worth of successes
+	first.Success()
+	second.Success()
+	assertEqual(t, 0, limiter.InFlight())
+	assertEqual(t, 2, limiter.Limit())
+	first, _ = limiter.TryAcquire()
+	second, _ = limiter.TryAcquire()
+	first.Success()
+	second.Success()
+	assertEqual(t, 3, limiter.Limit())
+
+	// up to the maximum
+	for i := 0; i < 10; i++ {
+		first, _ = limiter.TryAcquire()
+		second, _ = limiter.TryAcquire()
+		first.Success()
+		second.Success()
+	}
+	assertEqual(t, 3, limiter.Limit())
+
+	// failures back off, down to the minimum, and outcomes are reported once
+	flight, _ := limiter.TryAcquire()
+	flight.Failure()
+	flight.Failure()
+	assertEqual(t, 2, limiter.Limit())
+	for i := 0; i < 10; i++ {
+		flight, _ := limiter.TryAcquire()
+		flight.Failure()
+	}
+	assertEqual(t, 1, limiter.Limit())
+	assertEqual(t, 0, limiter.InFlight())
+
+	flight, _ = limiter.TryAcquire()
+	flight.Ignore()
+	flight.Success()
+	assertEqual(t, 1, limiter.Limit())
+	assertEqual(t, 0, limiter.InFlight())
+}
+
+func TestAdaptiveLimiter_unused(t *testing.T) {
+	limiter := NewAdaptive(10, 1, 100)
+	for i := 0; i < 100; i++ {
+		flight, _ := limiter.TryAcquire()
+		flight.Success()
+	}
+	// a limit of 10 is not tested by one call at a time
+	assertEqual(t, 10, limiter.Limit())
+}
+
+func TestAdaptiveLimiter_latency(t *testing.T) {
+	clock := clocktest.New(time.Now())
+	limiter := NewAdaptive(4, 1, 10, WithLatencyTolerance(2), WithBackoff(0.5), WithClock(clock))
+	call := func(latency time.Duration) {
+		flights := make([]*Flight, 0, limiter.Limit())
+		for {
+			flight, ok := limiter.TryAcquire()
+			if !ok {
+				break
+			}
+			flights = append(flights, flight)
+		}
+		clock.Advance(latency)
+		for _, flight := range flights {
+			flight.Success()
+		}
+	}
+	call(100 * time.Millisecond)
+	assertEqual(t, 4, limiter.Limit())
+	call(150 * time.Millisecond)
+	assertEqual(t, 5, limiter.Limit())
+
+	// slow calls back off before the downstream fails
+	call(time.Second)
+	assertEqual(t, 1, limiter.Limit())
+}
+
+func TestNewAdaptive_invalid(t *testing.T) {
+	for _, f := range []func(){
+		func() { NewAdaptive(1, 0, 1) },
+		func() { NewAdaptive(1, 2, 3) },
+		func() { NewAdaptive(3, 1, 2) },
+		func() { NewAdaptive(1, 1, 2, WithBackoff(1)) },
+	} {
+		func() {
+			defer func() {
+				assertNotNil(t, recover())
+			}()
+			f()
+			t.Fatal("Did not panic")
+		}()
+	}
+}
@@ -0,0 +1,12 @@
+package ratelimit
+
+import "errors"
+
+// These are errors related to rate limiters.
+var (
+	// ErrExceedsBurst is returned by WaitN when more events are requested at once than the burst of the limiter allows, which never happens
+	ErrExceedsBurst = errors.New("Events exceed the burst of the limiter")
+
+	// ErrExceedsDeadline is returned by Wait and WaitN when the events would be allowed after the deadline of the context
+	ErrExceedsDeadline = errors.New("Wait would exceed the context deadline")
+)
@@ -0,0 +1,43 @@
+package ratelimit
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Middleware returns HTTP middleware rate limiting the requests per key, as returned by the key function, e.g. RemoteIP.
+// The requests allowed by the limiter of their key are passed to the next handler. The others are rejected
+// with 429 Too Many Requests, and a Retry-After header if the limiter of their key is a Delayer.
+func Middleware(limiter *KeyedLimiter, key func(*http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			k := key(r)
+			if limiter.Allow(k) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if delay, ok := limiter.Delay(k); ok {
+				w.Header().Set("Retry-After", RetryAfter(delay))
+			}
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		})
+	}
+}
+
+// RemoteIP returns the IP address of the client of a request, without its port, as a key for Middleware.
+// It does not trust headers such as X-Forwarded-For, which are set by proxies, but may be forged by clients.
+func RemoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// RetryAfter formats a delay as the value of a Retry-After header, in seconds, rounded up so that clients do not retry too early.
+func RetryAfter(delay time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(delay.Seconds())), 10)
+}
@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nvn1729/congo/internal/clocktest"
+)
+
+func TestMiddleware(t *testing.T) {
+	clock := clocktest.New(time.Now())
+	limiter := NewKeyed(func(key string) Limiter {
+		return NewTokenBucket(Per(1, 10*time.Second), 1, WithClock(clock))
+	}, time.Minute, WithClock(clock))
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	handler := Middleware(limiter, RemoteIP)(next)
+	serve := func(remoteAddr string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	assertEqual(t, http.StatusNoContent, serve("10.0.0.1:1234").Code)
+	assertEqual(t, http.StatusNoContent, serve("10.0.0.2:1234").Code)
+
+	// the port is not part of the key
+	clock.Advance(2500 * time.Millisecond)
+	w := serve("10.0.0.1:5678")
+	assertEqual(t, http.StatusTooManyRequests, w.Code)
+	assertEqual(t, "8", w.Header().Get("Retry-After"))
+	clock.Advance(7500 * time.Millisecond)
+	assertEqual(t, http.StatusNoContent, serve("10.0.0.1:1234").Code)
+
+	// limiters that are not Delayers give no Retry-After
+	limiter = NewKeyed(func(key string) Limiter {
+		return denyAll{}
+	}, time.Minute)
+	handler = Middleware(limiter, RemoteIP)(next)
+	w = serve("10.0.0.1:1234")
+	assertEqual(t, http.StatusTooManyRequests, w.Code)
+	assertEqual(t, "", w.Header().Get("Retry-After"))
+}
+
+// denyAll is a Limiter allowing no event.
+type denyAll struct{}
+
+func (denyAll) Allow() bool {
+	return false
+}
+
+func TestRemoteIP(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "[::1]:80"
+	assertEqual(t, "::1", RemoteIP(r))
+	r.RemoteAddr = "pipe"
+	assertEqual(t, "pipe", RemoteIP(r))
+}
+
+func TestRetryAfter(t *testing.T) {
+	assertEqual(t, "0", RetryAfter(0))
+	assertEqual(t, "1", RetryAfter(time.Millisecond))
+	assertEqual(t, "2", RetryAfter(2*time.Second))
+}
@@ -0,0 +1,169 @@
+package ratelimit
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nvn1729/congo"
+)
+
+// A Limiter limits the rate of events, such as a TokenBucket or a SlidingWindow.
+type Limiter interface {
+	// Allow reports whether an event is allowed now, counting it if it is.
+	Allow() bool
+}
+
+// A Delayer is a Limiter that tells when it allows the next event, such as a TokenBucket or a SlidingWindow,
+// e.g. to tell rejected clients when to retry.
+type Delayer interface {
+	Limiter
+
+	// Delay returns the time until an event is allowed, which is 0 if it is allowed now.
+	Delay() time.Duration
+}
+
+// A KeyedLimiter maintains an independent Limiter per key, such as a tenant or a client IP,
+// enforcing limits such as "at most N requests per second per tenant" with a single object.
+//
+// The limiter of a key is created when an event of the key first takes place, and evicted once the key has been idle,
+// with no event, for an idle timeout, so that keys do not accumulate over time. A limiter that is evicted and created again
+// starts afresh: the idle timeout should be long enough for the limiters to be back to their initial state,
+// e.g. for a TokenBucket to be refilled.
+type KeyedLimiter struct {
+	m           sync.Mutex
+	newLimiter  func(key string) Limiter
+	idleTimeout time.Duration
+	entries     map[string]*keyedEntry
+	lastSweep   time.Time
+	clock       congo.Clock
+}
+
+// A keyedEntry is the limiter of a key, and its counters.
+type keyedEntry struct {
+	limiter  Limiter
+	lastUsed time.Time // guarded by the keyed limiter mutex
+	allowed  uint64    // set atomically
+	rejected uint64    // set atomically
+}
+
+// KeyStats are the counters of a key of a KeyedLimiter, since its limiter was created.
+type KeyStats struct {
+	Key string
+
+	// Allowed and Rejected are the numbers of events of the key that were allowed and rejected.
+	Allowed, Rejected uint64
+
+	// LastUsed is when the last event of the key took place.
+	LastUsed time.Time
+}
+
+// NewKeyed creates a KeyedLimiter, whose limiters are created by newLimiter, given their key, e.g. to give tenants different limits,
+// and evicted once idle for idleTimeout. NewKeyed panics if idleTimeout is not positive.
+func NewKeyed(newLimiter func(key string) Limiter, idleTimeout time.Duration, opts ...Option) *KeyedLimiter {
+	if idleTimeout <= 0 {
+		panic("ratelimit: idle timeout must be positive")
+	}
+	o := newOptions(opts)
+	return &KeyedLimiter{
+		newLimiter:  newLimiter,
+		idleTimeout: idleTimeout,
+		entries:     make(map[string]*keyedEntry),
+		lastSweep:   o.clock.Now(),
+		clock:       o.clock,
+	}
+}
+
+// Allow reports whether an event of the key is allowed now by its limiter, counting it if it is.
+func (keyed *KeyedLimiter) Allow(key string) bool {
+	entry := keyed.entry(key)
+	if entry.limiter.Allow() {
+		atomic.AddUint64(&entry.allowed, 1)
+		return true
+	}
+	atomic.AddUint64(&entry.rejected, 1)
+	return false
+}
+
+// Limiter returns the limiter of the key, creating it if needed, e.g. to wait for an event with TokenBucket.Wait.
+// Using the limiter counts as an event of the key for its eviction, but not in its KeyStats.
+func (keyed *KeyedLimiter) Limiter(key string) Limiter {
+	return keyed.entry(key).limiter
+}
+
+// Delay returns the time until an event of the key is allowed by its limiter, and true, if the limiter is a Delayer.
+// Otherwise, Delay returns 0 and false.
+func (keyed *KeyedLimiter) Delay(key string) (time.Duration, bool) {
+	if delayer, ok := keyed.entry(key).limiter.(Delayer); ok {
+		return delayer.Delay(), true
+	}
+	return 0, false
+}
+
+// Len returns the number of keys whose limiters were not evicted.
+func (keyed *KeyedLimiter) Len() int {
+	keyed.m.Lock()
+	defer keyed.m.Unlock()
+	keyed.sweep(keyed.clock.Now())
+	return len(keyed.entries)
+}
+
+// Stats returns the counters of the n keys with the most events, allowed or rejected, most first,
+// e.g. to find the tenants being throttled. If n is 0 or less, Stats returns the counters of all the keys.
+func (keyed *KeyedLimiter) Stats(n int) []KeyStats {
+	keyed.m.Lock()
+	keyed.sweep(keyed.clock.Now())
+	stats := make([]KeyStats, 0, len(keyed.entries))
+	for key, entry := range keyed.entries {
+		stats = append(stats, KeyStats{
+			Key:      key,
+			Allowed:  atomic.LoadUint64(&entry.allowed),
+			Rejected: atomic.LoadUint64(&entry.rejected),
+			LastUsed: entry.lastUsed,
+		})
+	}
+	keyed.m.Unlock()
+
+	sort.Slice(stats, func(i, j int) bool {
+		a, b := stats[i].Allowed+stats[i].Rejected, stats[j].Allowed+stats[j].Rejected
+		if a == b {
+			return stats[i].Key < stats[j].Key
+		}
+		return a > b
+	})
+	if n > 0 && n < len(stats) {
+		stats = stats[:n]
+	}
+	return stats
+}
+
+// entry returns the entry of the key, creating it if needed, and marks it as used.
+func (keyed *KeyedLimiter) entry(key string) *keyedEntry {
+	keyed.m.Lock()
+	defer keyed.m.Unlock()
+	now := keyed.clock.Now()
+	keyed.sweep(now)
+	entry, ok := keyed.entries[key]
+	if !ok {
+		entry = &keyedEntry{limiter: keyed.newLimiter(key)}
+		keyed.entries[key] = entry
+	}
+	entry.lastUsed = now
+	return entry
+}
+
+// sweep evicts the limiters of the keys idle for the idle timeout. The keys are swept at most once per idle timeout,
+// so that keys are evicted in amortized constant time, up to twice the idle timeout after their last event.
+// This call must be guarded using the keyed limiter mutex.
+func (keyed *KeyedLimiter) sweep(now time.Time) {
+	if now.Sub(keyed.lastSweep) < keyed.idleTimeout {
+		return
+	}
+	keyed.lastSweep = now
+	for key, entry := range keyed.entries {
+		if now.Sub(entry.lastUsed) >= keyed.idleTimeout {
+			delete(keyed.entries, key)
+		}
+	}
+}
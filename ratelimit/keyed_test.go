@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nvn1729/congo/internal/clocktest"
+)
+
+func ExampleKeyedLimiter() {
+	// 2 requests per second per tenant
+	tenants := NewKeyed(func(tenant string) Limiter {
+		return NewTokenBucket(2, 2)
+	}, time.Minute)
+	for _, tenant := range []string{"acme", "acme", "acme", "globex"} {
+		fmt.Println(tenant, tenants.Allow(tenant))
+	}
+	// Output:
+	// acme true
+	// acme true
+	// acme false
+	// globex true
+}
+
+func TestKeyedLimiter(t *testing.T) {
+	clock := clocktest.New(time.Now())
+	created := 0
+	keyed := NewKeyed(func(key string) Limiter {
+		created++
+		if key == "vip" {
+			return NewSlidingWindow(3, time.Hour, WithClock(clock))
+		}
+		return NewSlidingWindow(1, time.Hour, WithClock(clock))
+	}, time.Minute, WithClock(clock))
+
+	assertEqual(t, true, keyed.Allow("a"))
+	assertEqual(t, false, keyed.Allow("a"))
+	assertEqual(t, true, keyed.Allow("b"))
+	for i := 0; i < 3; i++ {
+		assertEqual(t, true, keyed.Allow("vip"))
+	}
+	assertEqual(t, false, keyed.Allow("vip"))
+	assertEqual(t, 3, created)
+	assertEqual(t, 3, keyed.Len())
+	assertEqual(t, 3, keyed.Limiter("vip").(*SlidingWindow).Limit())
+
+	// the hottest keys come first
+	stats := keyed.Stats(2)
+	assertEqual(t, 2, len(stats))
+	assertEqual(t, KeyStats{Key: "vip", Allowed: 3, Rejected: 1, LastUsed: clock.Now()}, stats[0])
+	assertEqual(t, KeyStats{Key: "a", Allowed: 1, Rejected: 1, LastUsed: clock.Now()}, stats[1])
+	assertEqual(t, 3, len(keyed.Stats(0)))
+
+	// idle keys are evicted, and their limiters start afresh
+	clock.Advance(30 * time.Second)
+	assertEqual(t, false, keyed.Allow("a"))
+	clock.Advance(30 * time.Second)
+	assertEqual(t, 1, keyed.Len())
+	clock.Advance(time.Minute)
+	assertEqual(t, true, keyed.Allow("b"))
+	assertEqual(t, 1, keyed.Len())
+	assertEqual(t, 4, created)
+}
+
+func TestKeyedLimiter_concurrent(t *testing.T) {
+	keyed := NewKeyed(func(key string) Limiter {
+		return NewTokenBucket(Per(1, time.Hour), 10)
+	}, time.Minute)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				keyed.Allow(fmt.Sprint(i % 2))
+			}
+		}(i)
+	}
+	wg.Wait()
+	for _, stats := range keyed.Stats(0) {
+		assertEqual(t, uint64(10), stats.Allowed)
+		assertEqual(t, uint64(70), stats.Rejected)
+	}
+}
+
+func TestNewKeyed_invalid(t *testing.T) {
+	defer func() {
+		assertNotNil(t, recover())
+	}()
+	NewKeyed(func(key string) Limiter { return nil }, 0)
+	t.Fatal("Did not panic")
+}
@@ -0,0 +1,100 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nvn1729/congo"
+)
+
+// A LeakyBucket paces events at a constant interval, with the leaky bucket algorithm used as a meter:
+// unlike a TokenBucket, it allows no bursts, even after a quiet period, which suits pacing writes to a rate-sensitive downstream.
+//
+// Each event is given the next free slot, one interval after the slot of the previous event, or now if that slot has passed.
+// Events waiting with Wait are thus allowed in the order they called it.
+type LeakyBucket struct {
+	m        sync.Mutex
+	interval time.Duration
+	next     time.Time // the next free slot
+	clock    congo.Clock
+}
+
+// NewLeakyBucket creates a LeakyBucket allowing events at the given rate, in events per second, i.e. at an interval of 1/rate seconds.
+// The rate of n events per period may be given as Per(n, period). NewLeakyBucket panics if the rate is not positive.
+func NewLeakyBucket(rate float64, opts ...Option) *LeakyBucket {
+	if !(rate > 0) {
+		panic("ratelimit: rate must be positive")
+	}
+	o := newOptions(opts)
+	return &LeakyBucket{
+		interval: durationOf(1, rate),
+		clock:    o.clock,
+	}
+}
+
+// Interval returns the interval between events.
+func (bucket *LeakyBucket) Interval() time.Duration {
+	return bucket.interval
+}
+
+// Allow reports whether an event is allowed now, taking the current slot if it is.
+func (bucket *LeakyBucket) Allow() bool {
+	bucket.m.Lock()
+	defer bucket.m.Unlock()
+	now := bucket.clock.Now()
+	if now.Before(bucket.next) {
+		return false
+	}
+	bucket.next = now.Add(bucket.interval)
+	return true
+}
+
+// Delay returns the time until the next free slot, which is 0 if an event is allowed now.
+func (bucket *LeakyBucket) Delay() time.Duration {
+	bucket.m.Lock()
+	defer bucket.m.Unlock()
+	if delay := bucket.next.Sub(bucket.clock.Now()); delay > 0 {
+		return delay
+	}
+	return 0
+}
+
+// Wait waits for the next free slot, and returns the pause applied, e.g. so that callers can log their pacing delay.
+// If the context is done first, Wait gives the slot back if no other event took the next one, and returns the context's error.
+// It returns ErrExceedsDeadline, without waiting, if the slot is after the deadline of the context.
+func (bucket *LeakyBucket) Wait(ctx context.Context) (time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	bucket.m.Lock()
+	now := bucket.clock.Now()
+	slot := bucket.next
+	if slot.Before(now) {
+		slot = now
+	}
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(slot) {
+		bucket.m.Unlock()
+		return 0, ErrExceedsDeadline
+	}
+	bucket.next = slot.Add(bucket.interval)
+	bucket.m.Unlock()
+
+	pause := slot.Sub(now)
+	if pause == 0 {
+		return 0, nil
+	}
+	timer := bucket.clock.NewTimer(pause)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return pause, nil
+	case <-ctx.Done():
+		bucket.m.Lock()
+		if bucket.next.Equal(slot.Add(bucket.interval)) {
+			bucket.next = slot
+		}
+		bucket.m.Unlock()
+		return 0, ctx.Err()
+	}
+}
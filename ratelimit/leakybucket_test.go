@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nvn1729/congo/internal/clocktest"
+)
+
+func TestLeakyBucket_allow(t *testing.T) {
+	clock := clocktest.New(time.Now())
+	bucket := NewLeakyBucket(Per(10, time.Second), WithClock(clock))
+	assertEqual(t, 100*time.Millisecond, bucket.Interval())
+	assertEqual(t, true, bucket.Allow())
+	assertEqual(t, false, bucket.Allow())
+	clock.Advance(99 * time.Millisecond)
+	assertEqual(t, false, bucket.Allow())
+	assertEqual(t, time.Millisecond, bucket.Delay())
+	clock.Advance(time.Millisecond)
+	assertEqual(t, time.Duration(0), bucket.Delay())
+	assertEqual(t, true, bucket.Allow())
+
+	// no burst is allowed after a quiet period
+	clock.Advance(time.Hour)
+	assertEqual(t, true, bucket.Allow())
+	assertEqual(t, false, bucket.Allow())
+}
+
+func TestLeakyBucket_wait(t *testing.T) {
+	clock := clocktest.New(time.Now())
+	bucket := NewLeakyBucket(10, WithClock(clock))
+	pause, err := bucket.Wait(context.Background())
+	assertEqual(t, time.Duration(0), pause)
+	assertNil(t, err)
+
+	// events are paced at the interval, in order
+	type result struct {
+		pause time.Duration
+		err   error
+	}
+	waited := make(chan result, 2)
+	for i := 1; i <= 2; i++ {
+		go func() {
+			pause, err := bucket.Wait(context.Background())
+			waited <- result{pause, err}
+		}()
+		clock.BlockUntilTimers(i)
+	}
+	clock.Advance(100 * time.Millisecond)
+	assertEqual(t, result{100 * time.Millisecond, nil}, <-waited)
+	clock.Advance(100 * time.Millisecond)
+	assertEqual(t, result{200 * time.Millisecond, nil}, <-waited)
+
+	// a canceled wait gives its slot back
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		pause, err := bucket.Wait(ctx)
+		waited <- result{pause, err}
+	}()
+	clock.BlockUntilTimers(1)
+	cancel()
+	assertEqual(t, result{0, context.Canceled}, <-waited)
+	assertEqual(t, false, bucket.Allow())
+	clock.Advance(100 * time.Millisecond)
+	assertEqual(t, true, bucket.Allow())
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = bucket.Wait(ctx)
+	assertEqual(t, ErrExceedsDeadline, err)
+	_, err = bucket.Wait(ctx)
+	assertEqual(t, ErrExceedsDeadline, err)
+}
+
+func TestNewLeakyBucket_invalid(t *testing.T) {
+	defer func() {
+		assertNotNil(t, recover())
+	}()
+	NewLeakyBucket(0)
+	t.Fatal("Did not panic")
+}
@@ -0,0 +1,60 @@
+// Package ratelimit provides rate limiters, bounding the rate of events such as requests or writes,
+// for admission control next to the concurrency limits of the semaphore package.
+package ratelimit
+
+import (
+	"math"
+	"time"
+
+	"github.com/nvn1729/congo"
+)
+
+// InfDuration is the delay of a Reservation that is not OK, which never allows its events.
+const InfDuration = time.Duration(math.MaxInt64)
+
+// Per returns the rate of n events per period, in events per second, e.g. Per(100, time.Minute) for 100 requests per minute.
+// Per panics if the period is not positive.
+func Per(n int, period time.Duration) float64 {
+	if period <= 0 {
+		panic("ratelimit: period must be positive")
+	}
+	return float64(n) / period.Seconds()
+}
+
+// An Option configures a limiter at creation time.
+type Option func(*options)
+
+type options struct {
+	clock     congo.Clock
+	backoff   float64
+	tolerance float64
+}
+
+// WithClock sets the Clock used by the limiter to measure time, and to wait.
+// By default the limiter uses congo.RealClock.
+func WithClock(clock congo.Clock) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// newOptions applies the given options to the defaults.
+func newOptions(opts []Option) options {
+	o := options{clock: congo.RealClock(), backoff: DefaultBackoff}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// durationOf returns the time it takes to accumulate the given number of tokens at a rate, in tokens per second.
+func durationOf(tokens float64, rate float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	seconds := tokens / rate
+	if seconds >= InfDuration.Seconds() {
+		return InfDuration
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
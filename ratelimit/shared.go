@@ -0,0 +1,145 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/nvn1729/congo"
+)
+
+// A SharedTokenBucket is a token bucket per key whose state is held by a Store, so that processes sharing the store
+// enforce the limit of each key together, e.g. at most N requests per second per tenant across a cluster.
+// Unlike a TokenBucket, it cannot reserve tokens ahead of time: events finding the bucket empty are rejected.
+type SharedTokenBucket struct {
+	store  Store
+	prefix string
+	rate   float64
+	burst  int
+	clock  congo.Clock
+}
+
+// NewSharedTokenBucket creates a SharedTokenBucket whose buckets are held by the store under the given prefix followed by their key,
+// refilled at the given rate, in tokens per second, and holding up to burst tokens.
+// NewSharedTokenBucket panics if the rate is not positive, or burst is less than 1.
+func NewSharedTokenBucket(store Store, prefix string, rate float64, burst int, opts ...Option) *SharedTokenBucket {
+	if !(rate > 0) {
+		panic("ratelimit: rate must be positive")
+	}
+	if burst < 1 {
+		panic("ratelimit: burst must be at least 1")
+	}
+	o := newOptions(opts)
+	return &SharedTokenBucket{
+		store:  store,
+		prefix: prefix,
+		rate:   rate,
+		burst:  burst,
+		clock:  o.clock,
+	}
+}
+
+// Rate returns the rate at which the buckets are refilled, in tokens per second.
+func (bucket *SharedTokenBucket) Rate() float64 {
+	return bucket.rate
+}
+
+// Burst returns the number of tokens that the buckets hold when full.
+func (bucket *SharedTokenBucket) Burst() int {
+	return bucket.burst
+}
+
+// Allow reports whether an event of the key is allowed now, taking a token from its bucket if it is.
+// It returns the error of the store if the store fails.
+func (bucket *SharedTokenBucket) Allow(ctx context.Context, key string) (bool, error) {
+	return bucket.AllowN(ctx, key, 1)
+}
+
+// AllowN reports whether n events of the key are allowed now, taking n tokens from its bucket if they are.
+// It returns the error of the store if the store fails. AllowN panics if n is negative.
+func (bucket *SharedTokenBucket) AllowN(ctx context.Context, key string, n int) (bool, error) {
+	if n < 0 {
+		panic("ratelimit: negative events")
+	}
+	if n > bucket.burst {
+		return false, nil
+	}
+	return bucket.store.TakeTokens(ctx, bucket.prefix+key, n, bucket.rate, bucket.burst, bucket.clock.Now())
+}
+
+// A SharedSlidingWindow is a sliding window per key whose counters are held by a Store, so that processes sharing the store
+// enforce the limit of each key together, e.g. at most N requests per minute per tenant across a cluster.
+// It estimates the events over the rolling window as a SlidingWindow does.
+//
+// The windows start at multiples of the window duration since the Unix epoch, so that the processes agree on them
+// as long as their clocks are synchronized.
+type SharedSlidingWindow struct {
+	store  Store
+	prefix string
+	limit  int
+	window time.Duration
+	clock  congo.Clock
+}
+
+// NewSharedSlidingWindow creates a SharedSlidingWindow whose counters are held by the store under the given prefix followed by their key,
+// allowing up to limit events of each key over a rolling window of the given duration.
+// NewSharedSlidingWindow panics if limit is less than 1, or the window is not positive.
+func NewSharedSlidingWindow(store Store, prefix string, limit int, window time.Duration, opts ...Option) *SharedSlidingWindow {
+	if limit < 1 {
+		panic("ratelimit: limit must be at least 1")
+	}
+	if window <= 0 {
+		panic("ratelimit: window must be positive")
+	}
+	o := newOptions(opts)
+	return &SharedSlidingWindow{
+		store:  store,
+		prefix: prefix,
+		limit:  limit,
+		window: window,
+		clock:  o.clock,
+	}
+}
+
+// Limit returns the number of events of each key allowed over the window.
+func (limiter *SharedSlidingWindow) Limit() int {
+	return limiter.limit
+}
+
+// Window returns the duration of the window.
+func (limiter *SharedSlidingWindow) Window() time.Duration {
+	return limiter.window
+}
+
+// Allow reports whether an event of the key is allowed now, counting it if it is.
+// It returns the error of the store if the store fails.
+func (limiter *SharedSlidingWindow) Allow(ctx context.Context, key string) (bool, error) {
+	return limiter.AllowN(ctx, key, 1)
+}
+
+// AllowN reports whether n events of the key are allowed now, counting them if they are.
+// It returns the error of the store if the store fails. AllowN panics if n is negative.
+func (limiter *SharedSlidingWindow) AllowN(ctx context.Context, key string, n int) (bool, error) {
+	if n < 0 {
+		panic("ratelimit: negative events")
+	}
+	// each window has a counter of the events still allowed in it, which lives until the end of the next window
+	at := limiter.clock.Now()
+	now := at.UnixNano()
+	index := now / int64(limiter.window)
+	limit, ttl := int64(limiter.limit), 2*limiter.window
+	remaining, _, err := limiter.store.Decrement(ctx, limiter.counterKey(key, index-1), 0, 0, limit, ttl, at)
+	if err != nil {
+		return false, err
+	}
+	overlap := 1 - float64(now-index*int64(limiter.window))/float64(limiter.window)
+	previous := int64(math.Ceil(float64(limit-remaining) * overlap))
+	_, ok, err := limiter.store.Decrement(ctx, limiter.counterKey(key, index), int64(n), previous, limit, ttl, at)
+	return ok, err
+}
+
+// counterKey returns the key of the counter of the given window of a key in the store.
+func (limiter *SharedSlidingWindow) counterKey(key string, index int64) string {
+	return limiter.prefix + key + ":" + strconv.FormatInt(index, 10)
+}
@@ -0,0 +1,143 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nvn1729/congo/internal/clocktest"
+)
+
+func TestSharedTokenBucket(t *testing.T) {
+	ctx := context.Background()
+	clock := clocktest.New(time.Now())
+	store := NewMemoryStore()
+
+	// two buckets sharing the store enforce the limit of each key together
+	first := NewSharedTokenBucket(store, "api:", 2, 3, WithClock(clock))
+	second := NewSharedTokenBucket(store, "api:", 2, 3, WithClock(clock))
+	assertEqual(t, 2.0, first.Rate())
+	assertEqual(t, 3, first.Burst())
+	assertAllowed(t, true)(first.AllowN(ctx, "a", 2))
+	assertAllowed(t, true)(second.Allow(ctx, "a"))
+	assertAllowed(t, false)(first.Allow(ctx, "a"))
+	assertAllowed(t, true)(second.Allow(ctx, "b"))
+	assertAllowed(t, false)(second.AllowN(ctx, "b", 4))
+
+	clock.Advance(500 * time.Millisecond)
+	assertAllowed(t, true)(second.Allow(ctx, "a"))
+	assertAllowed(t, false)(first.Allow(ctx, "a"))
+	assertAllowed(t, true)(first.AllowN(ctx, "a", 0))
+
+	// full buckets are dropped
+	clock.Advance(time.Hour)
+	assertAllowed(t, true)(first.AllowN(ctx, "a", 3))
+	assertEqual(t, 2, store.Len())
+	for i := 0; i < 64; i++ {
+		first.Allow(ctx, string(rune('A'+i)))
+	}
+	clock.Advance(time.Hour)
+	first.Allow(ctx, "b")
+	assertEqual(t, 1, store.Len())
+}
+
+func TestSharedSlidingWindow(t *testing.T) {
+	ctx := context.Background()
+	clock := clocktest.New(time.Now().Truncate(time.Minute))
+	store := NewMemoryStore()
+	first := NewSharedSlidingWindow(store, "api:", 10, time.Minute, WithClock(clock))
+	second := NewSharedSlidingWindow(store, "api:", 10, time.Minute, WithClock(clock))
+	assertEqual(t, 10, first.Limit())
+	assertEqual(t, time.Minute, first.Window())
+	assertAllowed(t, true)(first.AllowN(ctx, "a", 8))
+	clock.Advance(30 * time.Second)
+	assertAllowed(t, true)(second.AllowN(ctx, "a", 2))
+	assertAllowed(t, false)(first.Allow(ctx, "a"))
+	assertAllowed(t, true)(first.Allow(ctx, "b"))
+
+	// 15s into the current window, the previous one counts for 7.5 events
+	clock.Advance(45 * time.Second)
+	assertAllowed(t, true)(second.AllowN(ctx, "a", 2))
+	assertAllowed(t, false)(first.Allow(ctx, "a"))
+	clock.Advance(15 * time.Second)
+	assertAllowed(t, true)(first.AllowN(ctx, "a", 3))
+	assertAllowed(t, false)(second.Allow(ctx, "a"))
+
+	// windows without events are forgotten
+	clock.Advance(2 * time.Minute)
+	assertAllowed(t, false)(first.AllowN(ctx, "a", 11))
+	assertAllowed(t, true)(second.AllowN(ctx, "a", 10))
+}
+
+func TestMemoryStore_decrement(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	start := time.Unix(0, 0)
+
+	// counters expire according to the times passed by the limiters
+	value, ok, err := store.Decrement(ctx, "a", 3, 0, 10, time.Minute, start)
+	assertEqual(t, int64(7), value)
+	assertEqual(t, true, ok)
+	assertNil(t, err)
+	value, ok, _ = store.Decrement(ctx, "a", 8, 0, 10, time.Minute, start.Add(59*time.Second))
+	assertEqual(t, int64(7), value)
+	assertEqual(t, false, ok)
+	value, ok, _ = store.Decrement(ctx, "a", 8, 0, 10, time.Minute, start.Add(time.Minute))
+	assertEqual(t, int64(2), value)
+	assertEqual(t, true, ok)
+}
+
+func TestShared_storeError(t *testing.T) {
+	ctx := context.Background()
+	bucket := NewSharedTokenBucket(failingStore{}, "", 1, 1)
+	allowed, err := bucket.Allow(ctx, "a")
+	assertEqual(t, false, allowed)
+	assertEqual(t, errStore, err)
+	window := NewSharedSlidingWindow(failingStore{}, "", 1, time.Second)
+	allowed, err = window.Allow(ctx, "a")
+	assertEqual(t, false, allowed)
+	assertEqual(t, errStore, err)
+}
+
+func TestNewShared_invalid(t *testing.T) {
+	store := NewMemoryStore()
+	for _, f := range []func(){
+		func() { NewSharedTokenBucket(store, "", 0, 1) },
+		func() { NewSharedTokenBucket(store, "", 1, 0) },
+		func() { NewSharedTokenBucket(store, "", 1, 1).AllowN(context.Background(), "a", -1) },
+		func() { NewSharedSlidingWindow(store, "", 0, time.Second) },
+		func() { NewSharedSlidingWindow(store, "", 1, 0) },
+		func() { NewSharedSlidingWindow(store, "", 1, time.Second).AllowN(context.Background(), "a", -1) },
+	} {
+		func() {
+			defer func() {
+				assertNotNil(t, recover())
+			}()
+			f()
+			t.Fatal("Did not panic")
+		}()
+	}
+}
+
+var errStore = errors.New("store down")
+
+// A failingStore is a Store that always fails.
+type failingStore struct{}
+
+func (failingStore) TakeTokens(ctx context.Context, key string, n int, rate float64, burst int, now time.Time) (bool, error) {
+	return false, errStore
+}
+
+func (failingStore) Decrement(ctx context.Context, key string, n, floor, initial int64, ttl time.Duration, now time.Time) (int64, bool, error) {
+	return 0, false, errStore
+}
+
+// assertAllowed returns a function asserting the result of a shared limiter.
+func assertAllowed(t *testing.T, expected bool) func(bool, error) {
+	return func(allowed bool, err error) {
+		t.Helper()
+		assertNil(t, err)
+		assertEqual(t, expected, allowed)
+	}
+}
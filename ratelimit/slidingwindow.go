@@ -0,0 +1,123 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/nvn1729/congo"
+)
+
+// A SlidingWindow limits the number of events over a rolling window, e.g. 100 requests per minute,
+// with the sliding window counter algorithm.
+//
+// A fixed window counter allows up to twice the limit around the boundary between two windows. A sliding window
+// rather weighs the count of the previous window by the part of it that overlaps the rolling window ending now,
+// assuming its events were evenly spread, and adds the count of the current window. This estimate is accurate enough
+// for most limits, with two counters per limiter, however many events it allows.
+type SlidingWindow struct {
+	m        sync.Mutex
+	limit    int
+	window   time.Duration
+	start    time.Time // when the current window started
+	current  int       // events allowed in the current window
+	previous int       // events allowed in the previous window
+	clock    congo.Clock
+}
+
+// NewSlidingWindow creates a SlidingWindow allowing up to limit events over a rolling window of the given duration.
+// NewSlidingWindow panics if limit is less than 1, or the window is not positive.
+func NewSlidingWindow(limit int, window time.Duration, opts ...Option) *SlidingWindow {
+	if limit < 1 {
+		panic("ratelimit: limit must be at least 1")
+	}
+	if window <= 0 {
+		panic("ratelimit: window must be positive")
+	}
+	o := newOptions(opts)
+	return &SlidingWindow{
+		limit:  limit,
+		window: window,
+		start:  o.clock.Now(),
+		clock:  o.clock,
+	}
+}
+
+// Limit returns the number of events allowed over the window.
+func (limiter *SlidingWindow) Limit() int {
+	return limiter.limit
+}
+
+// Window returns the duration of the window.
+func (limiter *SlidingWindow) Window() time.Duration {
+	return limiter.window
+}
+
+// Allow reports whether an event is allowed now, counting it if it is.
+func (limiter *SlidingWindow) Allow() bool {
+	return limiter.AllowN(1)
+}
+
+// AllowN reports whether n events are allowed now, counting them if they are. AllowN panics if n is negative.
+func (limiter *SlidingWindow) AllowN(n int) bool {
+	if n < 0 {
+		panic("ratelimit: negative events")
+	}
+	limiter.m.Lock()
+	defer limiter.m.Unlock()
+	if limiter.count(limiter.clock.Now())+float64(n) > float64(limiter.limit) {
+		return false
+	}
+	limiter.current += n
+	return true
+}
+
+// Count returns the estimated number of events allowed over the rolling window ending now.
+func (limiter *SlidingWindow) Count() float64 {
+	limiter.m.Lock()
+	defer limiter.m.Unlock()
+	return limiter.count(limiter.clock.Now())
+}
+
+// Delay returns the time until an event is allowed, which is 0 if it is allowed now, assuming no other event is allowed meanwhile.
+func (limiter *SlidingWindow) Delay() time.Duration {
+	limiter.m.Lock()
+	defer limiter.m.Unlock()
+	now := limiter.clock.Now()
+	if limiter.count(now)+1 <= float64(limiter.limit) {
+		return 0
+	}
+	// the count falls as the previous window slides out of the rolling window, within the current window if possible
+	start, previous, current := limiter.start, limiter.previous, limiter.current
+	if current+1 > limiter.limit {
+		start, previous, current = start.Add(limiter.window), current, 0
+	}
+	excess := previous + current + 1 - limiter.limit
+	at := start.Add(time.Duration(math.Ceil(float64(limiter.window) * float64(excess) / float64(previous))))
+	if delay := at.Sub(now); delay > 0 {
+		return delay
+	}
+	return 0
+}
+
+// count moves the current window to the one including now, and returns the estimated number of events allowed
+// over the rolling window ending now.
+// This call must be guarded using the limiter mutex.
+func (limiter *SlidingWindow) count(now time.Time) float64 {
+	if elapsed := now.Sub(limiter.start); elapsed >= limiter.window {
+		windows := elapsed / limiter.window
+		if windows == 1 {
+			limiter.previous = limiter.current
+		} else {
+			limiter.previous = 0
+		}
+		limiter.current = 0
+		limiter.start = limiter.start.Add(windows * limiter.window)
+	}
+	overlap := 1 - float64(now.Sub(limiter.start))/float64(limiter.window)
+	if overlap > 1 {
+		// the clock went backwards
+		overlap = 1
+	}
+	return float64(limiter.previous)*overlap + float64(limiter.current)
+}
@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nvn1729/congo/internal/clocktest"
+)
+
+func TestSlidingWindow(t *testing.T) {
+	clock := clocktest.New(time.Now())
+	limiter := NewSlidingWindow(10, time.Minute, WithClock(clock))
+	assertEqual(t, 10, limiter.Limit())
+	assertEqual(t, time.Minute, limiter.Window())
+	assertEqual(t, true, limiter.AllowN(8))
+	clock.Advance(30 * time.Second)
+	assertEqual(t, true, limiter.AllowN(2))
+	assertEqual(t, false, limiter.Allow())
+	assertEqual(t, 10.0, limiter.Count())
+	// in the next window, the previous one must overlap the rolling window for at most 54s
+	assertEqual(t, 36*time.Second, limiter.Delay())
+
+	// unlike a fixed window, the limit holds around the boundary between windows:
+	// 15s into the current window, the previous one counts for the 45s of it overlapping the rolling window
+	clock.Advance(45 * time.Second)
+	assertEqual(t, 7.5, limiter.Count())
+	assertEqual(t, time.Duration(0), limiter.Delay())
+	assertEqual(t, true, limiter.AllowN(2))
+	assertEqual(t, false, limiter.Allow())
+	assertEqual(t, 3*time.Second, limiter.Delay())
+	clock.Advance(15 * time.Second)
+	assertEqual(t, 7.0, limiter.Count())
+	assertEqual(t, true, limiter.AllowN(3))
+	assertEqual(t, false, limiter.Allow())
+
+	// windows without events are forgotten
+	clock.Advance(2 * time.Minute)
+	assertEqual(t, 0.0, limiter.Count())
+	assertEqual(t, false, limiter.AllowN(11))
+	assertEqual(t, true, limiter.AllowN(10))
+	assertEqual(t, true, limiter.AllowN(0))
+}
+
+func TestNewSlidingWindow_invalid(t *testing.T) {
+	for _, f := range []func(){
+		func() { NewSlidingWindow(0, time.Second) },
+		func() { NewSlidingWindow(1, 0) },
+		func() { NewSlidingWindow(1, time.Second).AllowN(-1) },
+	} {
+		func() {
+			defer func() {
+				assertNotNil(t, recover())
+			}()
+			f()
+			t.Fatal("Did not panic")
+		}()
+	}
+}
@@ -0,0 +1,122 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// A Store holds the state of limiters shared by several processes, such as the nodes of a cluster, to enforce cluster-wide limits
+// with a SharedTokenBucket or a SharedSlidingWindow. A Store may be backed by Redis or another shared store, with each operation
+// running atomically, e.g. as a Lua script. MemoryStore is an in-process Store.
+//
+// The limiters pass the current time of their clock to the store, which may use its own clock instead,
+// so that the clocks of the processes need not be synchronized.
+type Store interface {
+	// TakeTokens takes n tokens from the token bucket of the key at the given time, if the bucket holds at least n tokens,
+	// and reports whether it did. The bucket holds up to burst tokens, is refilled at rate tokens per second, and starts full.
+	// The store may drop a bucket once it is full again.
+	TakeTokens(ctx context.Context, key string, n int, rate float64, burst int, now time.Time) (bool, error)
+
+	// Decrement decrements the counter of the key by n at the given time, if the counter is at least n+floor, and returns the counter
+	// and whether it was decremented. A counter that does not exist starts at initial, and expires ttl after it started.
+	Decrement(ctx context.Context, key string, n, floor, initial int64, ttl time.Duration, now time.Time) (int64, bool, error)
+}
+
+// A MemoryStore is a Store holding the state of limiters in memory, shared by the limiters of a single process.
+type MemoryStore struct {
+	m         sync.Mutex
+	buckets   map[string]*storedBucket
+	counters  map[string]*storedCounter
+	lastSweep int // the number of buckets and counters after the last sweep
+}
+
+// A storedBucket is the state of a token bucket of a MemoryStore.
+type storedBucket struct {
+	tokens  float64
+	last    time.Time // when tokens was last updated
+	expires time.Time // when the bucket is full again
+}
+
+// A storedCounter is a counter of a MemoryStore.
+type storedCounter struct {
+	value   int64
+	expires time.Time
+}
+
+// NewMemoryStore creates an empty MemoryStore, whose buckets and counters expire according to the times passed by the limiters.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:  make(map[string]*storedBucket),
+		counters: make(map[string]*storedCounter),
+	}
+}
+
+// TakeTokens implements Store. It never fails.
+func (store *MemoryStore) TakeTokens(ctx context.Context, key string, n int, rate float64, burst int, now time.Time) (bool, error) {
+	store.m.Lock()
+	defer store.m.Unlock()
+	store.sweep(now)
+	bucket, ok := store.buckets[key]
+	if !ok || !now.Before(bucket.expires) {
+		bucket = &storedBucket{tokens: float64(burst), last: now}
+		store.buckets[key] = bucket
+	} else if elapsed := now.Sub(bucket.last); elapsed > 0 {
+		bucket.tokens += elapsed.Seconds() * rate
+		if bucket.tokens > float64(burst) {
+			bucket.tokens = float64(burst)
+		}
+		bucket.last = now
+	}
+	taken := bucket.tokens >= float64(n)
+	if taken {
+		bucket.tokens -= float64(n)
+	}
+	bucket.expires = bucket.last.Add(durationOf(float64(burst)-bucket.tokens, rate))
+	return taken, nil
+}
+
+// Decrement implements Store. It never fails.
+func (store *MemoryStore) Decrement(ctx context.Context, key string, n, floor, initial int64, ttl time.Duration, now time.Time) (int64, bool, error) {
+	store.m.Lock()
+	defer store.m.Unlock()
+	store.sweep(now)
+	counter, ok := store.counters[key]
+	if !ok || !now.Before(counter.expires) {
+		counter = &storedCounter{value: initial, expires: now.Add(ttl)}
+		store.counters[key] = counter
+	}
+	if counter.value-n < floor {
+		return counter.value, false, nil
+	}
+	counter.value -= n
+	return counter.value, true, nil
+}
+
+// Len returns the number of buckets and counters held by the store, including the expired ones not dropped yet.
+func (store *MemoryStore) Len() int {
+	store.m.Lock()
+	defer store.m.Unlock()
+	return len(store.buckets) + len(store.counters)
+}
+
+// sweep drops the buckets and counters expired at the given time once their number doubled since the last sweep,
+// so that they are dropped in amortized constant time.
+// This call must be guarded using the store mutex.
+func (store *MemoryStore) sweep(now time.Time) {
+	size := len(store.buckets) + len(store.counters)
+	if size < 2*store.lastSweep || size < 64 {
+		return
+	}
+	for key, bucket := range store.buckets {
+		if !now.Before(bucket.expires) {
+			delete(store.buckets, key)
+		}
+	}
+	for key, counter := range store.counters {
+		if !now.Before(counter.expires) {
+			delete(store.counters, key)
+		}
+	}
+	store.lastSweep = len(store.buckets) + len(store.counters)
+}
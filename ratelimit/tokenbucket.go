@@ -0,0 +1,227 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nvn1729/congo"
+)
+
+// A TokenBucket limits the rate of events with the token bucket algorithm: the bucket holds up to burst tokens,
+// is refilled at a constant rate, and each event takes a token. Events are thus allowed at the rate of the bucket on average,
+// with bursts of up to burst events after a quiet period.
+//
+// Events that find the bucket empty may be rejected with Allow, waited for with Wait, or reserved with Reserve,
+// which tells when they will be allowed. Reserved tokens are taken ahead of time, so that events are allowed in the order
+// they were reserved or waited for. The bucket starts full.
+type TokenBucket struct {
+	m      sync.Mutex
+	rate   float64 // tokens added per second
+	burst  int
+	tokens float64   // tokens available at last, negative if tokens were reserved ahead of time
+	last   time.Time // when tokens was last updated
+	lastAt time.Time // when the events of the latest reservation are allowed
+	clock  congo.Clock
+}
+
+// A Reservation holds the tokens reserved by TokenBucket.Reserve for events, which are allowed once its Delay has elapsed.
+type Reservation struct {
+	bucket   *TokenBucket
+	ok       bool
+	n        int
+	at       time.Time // when the events are allowed
+	canceled bool      // guarded by the bucket mutex
+}
+
+// NewTokenBucket creates a TokenBucket refilled at the given rate, in tokens per second, and holding up to burst tokens.
+// The rate of n events per period may be given as Per(n, period).
+// NewTokenBucket panics if the rate is not positive, or burst is less than 1.
+func NewTokenBucket(rate float64, burst int, opts ...Option) *TokenBucket {
+	if !(rate > 0) {
+		panic("ratelimit: rate must be positive")
+	}
+	if burst < 1 {
+		panic("ratelimit: burst must be at least 1")
+	}
+	o := newOptions(opts)
+	now := o.clock.Now()
+	return &TokenBucket{
+		rate:   rate,
+		burst:  burst,
+		tokens: float64(burst),
+		last:   now,
+		lastAt: now,
+		clock:  o.clock,
+	}
+}
+
+// Rate returns the rate at which the bucket is refilled, in tokens per second.
+func (bucket *TokenBucket) Rate() float64 {
+	return bucket.rate
+}
+
+// Burst returns the number of tokens that the bucket holds when full.
+func (bucket *TokenBucket) Burst() int {
+	return bucket.burst
+}
+
+// Tokens returns the number of tokens available now, which is negative if tokens were reserved ahead of time.
+func (bucket *TokenBucket) Tokens() float64 {
+	bucket.m.Lock()
+	defer bucket.m.Unlock()
+	return bucket.advance(bucket.clock.Now())
+}
+
+// Delay returns the time until a token is available for an event, which is 0 if one is available now.
+func (bucket *TokenBucket) Delay() time.Duration {
+	bucket.m.Lock()
+	defer bucket.m.Unlock()
+	return durationOf(1-bucket.advance(bucket.clock.Now()), bucket.rate)
+}
+
+// Allow reports whether an event is allowed now, taking a token if it is.
+func (bucket *TokenBucket) Allow() bool {
+	return bucket.AllowN(1)
+}
+
+// AllowN reports whether n events are allowed now, taking n tokens if they are. AllowN panics if n is negative.
+func (bucket *TokenBucket) AllowN(n int) bool {
+	return bucket.reserve(n, 0).ok
+}
+
+// Wait waits until an event is allowed, or the context is done.
+// It returns the context's error if the context is done first, or ErrExceedsDeadline, without waiting,
+// if the event would be allowed after the deadline of the context.
+func (bucket *TokenBucket) Wait(ctx context.Context) error {
+	return bucket.WaitN(ctx, 1)
+}
+
+// WaitN is like Wait, for n events at once. It returns ErrExceedsBurst if n exceeds the burst of the bucket.
+// WaitN panics if n is negative.
+func (bucket *TokenBucket) WaitN(ctx context.Context, n int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if n > bucket.burst {
+		return ErrExceedsBurst
+	}
+	maxWait := InfDuration
+	if deadline, ok := ctx.Deadline(); ok {
+		maxWait = deadline.Sub(bucket.clock.Now())
+	}
+	reservation := bucket.reserve(n, maxWait)
+	if !reservation.ok {
+		return ErrExceedsDeadline
+	}
+	delay := reservation.Delay()
+	if delay == 0 {
+		return nil
+	}
+	timer := bucket.clock.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return nil
+	case <-ctx.Done():
+		reservation.Cancel()
+		return ctx.Err()
+	}
+}
+
+// Reserve reserves a token for an event, which is allowed once the delay of the returned Reservation has elapsed.
+// The reservation may be canceled if the event does not take place.
+func (bucket *TokenBucket) Reserve() *Reservation {
+	return bucket.ReserveN(1)
+}
+
+// ReserveN is like Reserve, for n events at once. The reservation is not OK if n exceeds the burst of the bucket.
+// ReserveN panics if n is negative.
+func (bucket *TokenBucket) ReserveN(n int) *Reservation {
+	return bucket.reserve(n, InfDuration)
+}
+
+// reserve takes n tokens, unless they would be available after maxWait.
+func (bucket *TokenBucket) reserve(n int, maxWait time.Duration) *Reservation {
+	if n < 0 {
+		panic("ratelimit: negative events")
+	}
+	bucket.m.Lock()
+	defer bucket.m.Unlock()
+	if n > bucket.burst {
+		return &Reservation{bucket: bucket, n: n}
+	}
+	now := bucket.clock.Now()
+	tokens := bucket.advance(now) - float64(n)
+	wait := durationOf(-tokens, bucket.rate)
+	if wait > maxWait {
+		return &Reservation{bucket: bucket, n: n}
+	}
+	bucket.tokens = tokens
+	at := now.Add(wait)
+	if at.After(bucket.lastAt) {
+		bucket.lastAt = at
+	}
+	return &Reservation{bucket: bucket, ok: true, n: n, at: at}
+}
+
+// advance adds the tokens accumulated since the bucket was last updated, up to its burst, and returns the tokens available.
+// This call must be guarded using the bucket mutex.
+func (bucket *TokenBucket) advance(now time.Time) float64 {
+	if elapsed := now.Sub(bucket.last); elapsed > 0 {
+		bucket.tokens += elapsed.Seconds() * bucket.rate
+		if bucket.tokens > float64(bucket.burst) {
+			bucket.tokens = float64(bucket.burst)
+		}
+		bucket.last = now
+	}
+	return bucket.tokens
+}
+
+// OK reports whether the tokens were reserved. A reservation is not OK if more events were reserved than the burst of the bucket allows.
+func (reservation *Reservation) OK() bool {
+	return reservation.ok
+}
+
+// Delay returns the time to wait until the events of the reservation are allowed, which is 0 if they are allowed now,
+// or InfDuration if the reservation is not OK.
+func (reservation *Reservation) Delay() time.Duration {
+	if !reservation.ok {
+		return InfDuration
+	}
+	if delay := reservation.at.Sub(reservation.bucket.clock.Now()); delay > 0 {
+		return delay
+	}
+	return 0
+}
+
+// Cancel gives back the tokens of a reservation whose events will not take place, as far as possible:
+// the tokens that were reserved after it stay reserved. Canceling a reservation whose events are already allowed has no effect.
+func (reservation *Reservation) Cancel() {
+	if !reservation.ok || reservation.n == 0 {
+		return
+	}
+	bucket := reservation.bucket
+	bucket.m.Lock()
+	defer bucket.m.Unlock()
+	now := bucket.clock.Now()
+	if reservation.canceled || !now.Before(reservation.at) {
+		return
+	}
+	reservation.canceled = true
+	restore := float64(reservation.n) - bucket.lastAt.Sub(reservation.at).Seconds()*bucket.rate
+	if restore <= 0 {
+		return
+	}
+	bucket.advance(now)
+	bucket.tokens += restore
+	if bucket.tokens > float64(bucket.burst) {
+		bucket.tokens = float64(bucket.burst)
+	}
+	if reservation.at.Equal(bucket.lastAt) {
+		previous := reservation.at.Add(-durationOf(float64(reservation.n), bucket.rate))
+		if !previous.Before(now) {
+			bucket.lastAt = previous
+		}
+	}
+}
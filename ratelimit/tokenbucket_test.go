@@ -0,0 +1,178 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nvn1729/congo/internal/clocktest"
+)
+
+func ExampleTokenBucket() {
+	// 10 events per second, in bursts of up to 3
+	bucket := NewTokenBucket(10, 3)
+	allowed := 0
+	for i := 0; i < 5; i++ {
+		if bucket.Allow() {
+			allowed++
+		}
+	}
+	fmt.Println("Allowed", allowed, "events")
+	// Output:
+	// Allowed 3 events
+}
+
+func TestTokenBucket_allow(t *testing.T) {
+	clock := clocktest.New(time.Now())
+	bucket := NewTokenBucket(Per(2, time.Second), 4, WithClock(clock))
+	assertEqual(t, 2.0, bucket.Rate())
+	assertEqual(t, 4, bucket.Burst())
+	assertEqual(t, true, bucket.AllowN(3))
+	assertEqual(t, true, bucket.Allow())
+	assertEqual(t, false, bucket.Allow())
+	assertEqual(t, 0.0, bucket.Tokens())
+	assertEqual(t, 500*time.Millisecond, bucket.Delay())
+
+	// the bucket is refilled at its rate, up to its burst
+	clock.Advance(500 * time.Millisecond)
+	assertEqual(t, true, bucket.Allow())
+	assertEqual(t, false, bucket.Allow())
+	clock.Advance(time.Hour)
+	assertEqual(t, 4.0, bucket.Tokens())
+	assertEqual(t, false, bucket.AllowN(5))
+	assertEqual(t, true, bucket.AllowN(0))
+	assertEqual(t, 4.0, bucket.Tokens())
+}
+
+func TestTokenBucket_reserve(t *testing.T) {
+	clock := clocktest.New(time.Now())
+	bucket := NewTokenBucket(10, 2, WithClock(clock))
+	first := bucket.ReserveN(2)
+	assertEqual(t, true, first.OK())
+	assertEqual(t, time.Duration(0), first.Delay())
+
+	// reservations are served in order, ahead of time
+	second := bucket.Reserve()
+	third := bucket.Reserve()
+	assertEqual(t, 100*time.Millisecond, second.Delay())
+	assertEqual(t, 200*time.Millisecond, third.Delay())
+	assertEqual(t, -2.0, bucket.Tokens())
+	assertEqual(t, false, bucket.Allow())
+
+	// canceling the last reservation gives its token back
+	third.Cancel()
+	third.Cancel()
+	assertEqual(t, -1.0, bucket.Tokens())
+	// canceling a reservation followed by another gives back no token, as the tokens are taken in order
+	fourth := bucket.Reserve()
+	assertEqual(t, 200*time.Millisecond, fourth.Delay())
+	second.Cancel()
+	assertEqual(t, -2.0, bucket.Tokens())
+
+	// canceling a reservation whose events are allowed has no effect
+	clock.Advance(time.Second)
+	fourth.Cancel()
+	assertEqual(t, 2.0, bucket.Tokens())
+
+	tooMany := bucket.ReserveN(3)
+	assertEqual(t, false, tooMany.OK())
+	assertEqual(t, InfDuration, tooMany.Delay())
+	tooMany.Cancel()
+	assertEqual(t, 2.0, bucket.Tokens())
+}
+
+func TestTokenBucket_wait(t *testing.T) {
+	clock := clocktest.New(time.Now())
+	bucket := NewTokenBucket(1, 1, WithClock(clock))
+	assertNil(t, bucket.Wait(context.Background()))
+
+	waited := make(chan error)
+	go func() {
+		waited <- bucket.Wait(context.Background())
+	}()
+	clock.BlockUntilTimers(1)
+	clock.Advance(999 * time.Millisecond)
+	select {
+	case <-waited:
+		t.Fatal("Wait returned before a token was available")
+	case <-time.After(20 * time.Millisecond):
+	}
+	clock.Advance(time.Millisecond)
+	assertNil(t, <-waited)
+
+	// waiting is given up when the context is done, giving back the token
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		waited <- bucket.Wait(ctx)
+	}()
+	clock.BlockUntilTimers(1)
+	cancel()
+	assertEqual(t, context.Canceled, <-waited)
+	assertEqual(t, 0.0, bucket.Tokens())
+	assertEqual(t, context.Canceled, bucket.Wait(ctx))
+
+	assertEqual(t, ErrExceedsBurst, bucket.WaitN(context.Background(), 2))
+	ctx, cancel = context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	assertEqual(t, ErrExceedsDeadline, bucket.Wait(ctx))
+	assertEqual(t, 0.0, bucket.Tokens())
+}
+
+func TestTokenBucket_concurrent(t *testing.T) {
+	bucket := NewTokenBucket(Per(1, time.Hour), 100)
+	var wg sync.WaitGroup
+	var m sync.Mutex
+	allowed := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if bucket.Allow() {
+					m.Lock()
+					allowed++
+					m.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	assertEqual(t, 100, allowed)
+}
+
+func TestNewTokenBucket_invalid(t *testing.T) {
+	for _, f := range []func(){
+		func() { NewTokenBucket(0, 1) },
+		func() { NewTokenBucket(1, 0) },
+		func() { NewTokenBucket(1, 1).AllowN(-1) },
+		func() { Per(1, 0) },
+	} {
+		func() {
+			defer func() {
+				assertNotNil(t, recover())
+			}()
+			f()
+			t.Fatal("Did not panic")
+		}()
+	}
+}
+
+func assertEqual(t *testing.T, expected interface{}, actual interface{}) {
+	if expected != actual {
+		t.Fatal("Not equal:", "expected:", expected, ", actual:", actual)
+	}
+}
+
+func assertNil(t *testing.T, actual interface{}) {
+	if actual != nil {
+		t.Fatal("Value not nil, actual:", actual)
+	}
+}
+
+func assertNotNil(t *testing.T, actual interface{}) {
+	if actual == nil {
+		t.Fatal("Value is nil")
+	}
+}
@@ -0,0 +1,79 @@
+package congo
+
+import (
+	"sync"
+	"time"
+)
+
+// A ResultLatch is a CountDownLatch where each count down contributes a result.
+//
+// It replaces the common pattern of pairing a latch with a mutex-protected results slice.
+// Goroutines invoke CountDown with their result, and the main goroutine invokes Wait or WaitTimeout
+// to retrieve all collected results once the count down is complete.
+type ResultLatch[T any] struct {
+	m       sync.Mutex
+	latch   *CountDownLatch
+	results []T
+}
+
+// NewResultLatch creates a ResultLatch with the provided count and latch options.
+// If the count is 0, the latch will be set to immediately signal count down completion with no results.
+func NewResultLatch[T any](count uint, opts ...Option) *ResultLatch[T] {
+	// the results grow as they arrive, so that a huge count does not allocate its results up front
+	capacity := count
+	if capacity > maxResultCapacity {
+		capacity = maxResultCapacity
+	}
+	return &ResultLatch[T]{
+		latch:   NewCountDownLatch(count, opts...),
+		results: make([]T, 0, capacity),
+	}
+}
+
+// maxResultCapacity is the largest number of results for which NewResultLatch allocates room up front.
+const maxResultCapacity = 1024
+
+// Count returns the remaining count.
+func (latch *ResultLatch[T]) Count() uint {
+	return latch.latch.Count()
+}
+
+// CountDown records the given result and reduces the count on the ResultLatch by 1.
+// If the count hits 0, CountDown signals latch count down completion, waking up any goroutines waiting with Wait or WaitTimeout.
+// An error, ErrCountDownLatchCompleted, is returned if count down has already been completed. In that case the result is discarded.
+func (latch *ResultLatch[T]) CountDown(result T) error {
+	latch.m.Lock()
+	defer latch.m.Unlock()
+	if latch.latch.Count() == 0 {
+		return ErrCountDownLatchCompleted
+	}
+	latch.results = append(latch.results, result)
+	return latch.latch.CountDown()
+}
+
+// Wait waits indefinitely until the count down is completed and returns the collected results
+// in the order in which CountDown was called.
+// Wait returns immediately if the count down has already been completed.
+func (latch *ResultLatch[T]) Wait() []T {
+	latch.latch.Wait()
+	return latch.collect()
+}
+
+// WaitTimeout waits until a given timeout for the count down to complete.
+// If the count down is completed before the timeout, WaitTimeout returns the collected results and true.
+// Otherwise it returns nil and false.
+func (latch *ResultLatch[T]) WaitTimeout(timeout time.Duration) ([]T, bool) {
+	if !latch.latch.WaitTimeout(timeout) {
+		return nil, false
+	}
+	return latch.collect(), true
+}
+
+// collect returns a copy of the results so that callers may freely modify them.
+func (latch *ResultLatch[T]) collect() []T {
+	latch.m.Lock()
+	defer latch.m.Unlock()
+	results := make([]T, len(latch.results))
+	copy(results, latch.results)
+	return results
+}
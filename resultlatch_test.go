@@ -0,0 +1,93 @@
+package congo
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func ExampleResultLatch() {
+	latch := NewResultLatch[int](3)
+	for i := 1; i <= 3; i++ {
+		go func(n int) {
+			// do work
+			// ...
+			latch.CountDown(n * n) // count down with the result of the work
+		}(i)
+	}
+
+	sum := 0
+	for _, result := range latch.Wait() {
+		sum += result
+	}
+	fmt.Println("Sum of squares:", sum)
+	// Output:
+	// Sum of squares: 14
+}
+
+func TestResultLatch_zero(t *testing.T) {
+	latch := NewResultLatch[string](0)
+
+	assertEqual(t, uint(0), latch.Count())
+	assertNotNil(t, latch.CountDown("late"))
+
+	// Wait should not block and should return no results
+	assertEqual(t, 0, len(latch.Wait()))
+
+	results, ok := latch.WaitTimeout(time.Second)
+	assertEqual(t, true, ok)
+	assertEqual(t, 0, len(results))
+}
+
+func TestResultLatch_hugeCount(t *testing.T) {
+	const count = ^uint(0) >> 1
+	latch := NewResultLatch[int](count)
+	assertEqual(t, count, latch.Count())
+	assertNil(t, latch.CountDown(1))
+	assertEqual(t, count-1, latch.Count())
+}
+
+func TestResultLatch_order(t *testing.T) {
+	latch := NewResultLatch[string](2)
+
+	results, ok := latch.WaitTimeout(100 * time.Millisecond)
+	assertEqual(t, false, ok)
+	assertEqual(t, 0, len(results))
+
+	assertNil(t, latch.CountDown("first"))
+	assertEqual(t, uint(1), latch.Count())
+	assertNil(t, latch.CountDown("second"))
+	assertEqual(t, uint(0), latch.Count())
+
+	// result of a count down after completion is discarded
+	assertNotNil(t, latch.CountDown("third"))
+
+	results = latch.Wait()
+	assertEqual(t, 2, len(results))
+	assertEqual(t, "first", results[0])
+	assertEqual(t, "second", results[1])
+
+	// modifying returned results does not affect later waiters
+	results[0] = "changed"
+	assertEqual(t, "first", latch.Wait()[0])
+}
+
+func TestResultLatch_manyasync(t *testing.T) {
+	count := 1000
+	latch := NewResultLatch[int](uint(count))
+	for i := 1; i <= count; i++ {
+		go func(n int) {
+			assertNil(t, latch.CountDown(n))
+		}(i)
+	}
+
+	results, ok := latch.WaitTimeout(5 * time.Second)
+	assertEqual(t, true, ok)
+	assertEqual(t, count, len(results))
+
+	sum := 0
+	for _, result := range results {
+		sum += result
+	}
+	assertEqual(t, count*(count+1)/2, sum)
+}
@@ -0,0 +1,47 @@
+package semaphore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nvn1729/congo/deadlock"
+)
+
+func TestSemaphore_deadlock(t *testing.T) {
+	reports := make(chan deadlock.Deadlock, 1)
+	deadlock.Enable(10*time.Millisecond, func(d deadlock.Deadlock) {
+		reports <- d
+	})
+	defer deadlock.Disable()
+
+	// two goroutines acquire the permits of two semaphores in opposite orders
+	first, second := New(2), New(1)
+	acquired, start := make(chan struct{}), make(chan struct{})
+	done := make(chan struct{}, 2)
+	go func() {
+		first.Acquire(2)
+		acquired <- struct{}{}
+		<-start
+		second.Acquire(1)
+		done <- struct{}{}
+	}()
+	go func() {
+		second.Acquire(1)
+		acquired <- struct{}{}
+		<-start
+		first.Acquire(1)
+		done <- struct{}{}
+	}()
+	<-acquired
+	<-acquired
+	close(start)
+	d := <-reports
+	assertEqual(t, 2, len(d.Goroutines))
+	assertEqual(t, int64(3), d.Goroutines[0].Holding[0].Units+d.Goroutines[1].Holding[0].Units)
+
+	// releasing a permit from another goroutine breaks the deadlock
+	first.Release(1)
+	<-done
+	second.Release(1)
+	<-done
+}
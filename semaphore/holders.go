@@ -0,0 +1,177 @@
+package semaphore
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/nvn1729/congo/internal/goid"
+)
+
+// A Holder describes permits of a semaphore held by a call site, as tracked WithHolderTracking.
+type Holder struct {
+	// Permits is the number of permits held.
+	Permits int64
+
+	// Label is the label attached with ContextWithLabel to the context the permits were acquired with.
+	Label string
+
+	// Acquired is the time the permits were acquired.
+	Acquired time.Time
+
+	// Stack is the stack trace of the goroutine that acquired the permits, at the time it asked for them.
+	Stack string
+}
+
+// String formats the holder as a warning including its stack trace.
+func (holder Holder) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "semaphore: %d permit(s) held for %v", holder.Permits, time.Since(holder.Acquired).Round(time.Millisecond))
+	if holder.Label != "" {
+		fmt.Fprintf(&b, " by %q", holder.Label)
+	}
+	b.WriteString(", acquired at\n\n")
+	b.WriteString(holder.Stack)
+	return b.String()
+}
+
+// WithHolderTracking enables diagnostics for the "semaphore drained and never recovers" class of bugs,
+// by tracking the call sites holding permits, which are returned by DumpHolders.
+// If threshold is positive, a watchdog reports permits held for longer than threshold, once.
+// If report is nil, they are written to the standard logger.
+//
+// Permits are not owned by goroutines, so a release is attributed to the permits acquired by the releasing goroutine first,
+// and then to the oldest permits held. Holder tracking records the stack of every acquisition,
+// so it is intended for debugging rather than production.
+func WithHolderTracking(threshold time.Duration, report func(Holder)) Option {
+	if report == nil {
+		report = func(holder Holder) {
+			log.Print(holder.String())
+		}
+	}
+	return func(semaphore *Semaphore) {
+		semaphore.tracking = &holderTracking{
+			threshold: threshold,
+			report:    report,
+		}
+	}
+}
+
+// DumpHolders returns the permits currently held, in the order they were acquired.
+// It returns nil unless holder tracking is enabled WithHolderTracking.
+func (semaphore *Semaphore) DumpHolders() []Holder {
+	if semaphore.tracking == nil {
+		return nil
+	}
+	semaphore.m.Lock()
+	defer semaphore.m.Unlock()
+	holders := make([]Holder, len(semaphore.tracking.held))
+	for i, held := range semaphore.tracking.held {
+		holders[i] = held.holder
+	}
+	return holders
+}
+
+// holderTracking is the state of holder tracking. It is guarded by the semaphore mutex.
+type holderTracking struct {
+	threshold time.Duration
+	report    func(Holder)
+	held      []*heldPermits
+}
+
+// heldPermits are permits held, or being acquired, by a call site.
+type heldPermits struct {
+	holder    Holder
+	goroutine int64
+	timer     *time.Timer
+}
+
+// newHeldPermits records the call site of an acquisition of n permits, before they are acquired.
+// It returns nil unless holder tracking is enabled.
+func (semaphore *Semaphore) newHeldPermits(ctx context.Context, n int64) *heldPermits {
+	if semaphore.tracking == nil {
+		return nil
+	}
+	buf := make([]byte, 4096)
+	buf = buf[:runtime.Stack(buf, false)]
+	return &heldPermits{
+		holder: Holder{
+			Permits: n,
+			Label:   labelFrom(ctx),
+			Stack:   string(buf),
+		},
+		goroutine: goid.Parse(buf),
+	}
+}
+
+// hold tracks permits once acquired, starting the watchdog for them. It does nothing if held is nil.
+// This call must be guarded using the semaphore mutex.
+func (semaphore *Semaphore) hold(held *heldPermits) {
+	if held == nil {
+		return
+	}
+	held.holder.Acquired = time.Now()
+	semaphore.tracking.held = append(semaphore.tracking.held, held)
+	if semaphore.tracking.threshold > 0 {
+		held.timer = time.AfterFunc(semaphore.tracking.threshold, func() {
+			semaphore.overdue(held)
+		})
+	}
+}
+
+// unhold stops tracking n released permits, preferring those acquired by the releasing goroutine.
+// This call must be guarded using the semaphore mutex.
+func (semaphore *Semaphore) unhold(n int64, goroutine int64) {
+	tracking := semaphore.tracking
+	for i := len(tracking.held) - 1; i >= 0 && n > 0; i-- {
+		if tracking.held[i].goroutine == goroutine {
+			n = semaphore.unholdAt(i, n)
+		}
+	}
+	for len(tracking.held) > 0 && n > 0 {
+		n = semaphore.unholdAt(0, n)
+	}
+}
+
+// unholdAt stops tracking up to n permits of the holder at index i, and returns the number of permits left to untrack.
+// This call must be guarded using the semaphore mutex.
+func (semaphore *Semaphore) unholdAt(i int, n int64) int64 {
+	held := semaphore.tracking.held[i]
+	if held.holder.Permits > n {
+		held.holder.Permits -= n
+		return 0
+	}
+	if held.timer != nil {
+		held.timer.Stop()
+	}
+	semaphore.tracking.held = append(semaphore.tracking.held[:i], semaphore.tracking.held[i+1:]...)
+	return n - held.holder.Permits
+}
+
+// overdue reports permits held beyond the watchdog threshold, if they are still held.
+func (semaphore *Semaphore) overdue(held *heldPermits) {
+	semaphore.m.Lock()
+	stillHeld := false
+	for _, h := range semaphore.tracking.held {
+		if h == held {
+			stillHeld = true
+			break
+		}
+	}
+	holder := held.holder
+	semaphore.m.Unlock()
+	if stillHeld {
+		semaphore.tracking.report(holder)
+	}
+}
+
+// currentGoroutineID returns the id of the calling goroutine if holder tracking is enabled, and 0 otherwise.
+func (semaphore *Semaphore) currentGoroutineID() int64 {
+	if semaphore.tracking == nil {
+		return 0
+	}
+	return goid.Current()
+}
@@ -0,0 +1,91 @@
+package semaphore
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSemaphore_dumpHolders(t *testing.T) {
+	assertEqual(t, 0, len(New(1).DumpHolders()))
+
+	semaphore := New(4, WithHolderTracking(0, nil))
+	semaphore.Acquire(1)
+	assertEqual(t, true, semaphore.TryAcquire(2))
+	holders := semaphore.DumpHolders()
+	assertEqual(t, 2, len(holders))
+	assertEqual(t, int64(1), holders[0].Permits)
+	assertEqual(t, int64(2), holders[1].Permits)
+	assertEqual(t, true, strings.Contains(holders[0].Stack, "TestSemaphore_dumpHolders"))
+	assertEqual(t, false, holders[0].Acquired.After(holders[1].Acquired))
+
+	// permits released by another goroutine are attributed to the oldest holders
+	released := make(chan struct{})
+	go func() {
+		semaphore.Release(2)
+		close(released)
+	}()
+	<-released
+	holders = semaphore.DumpHolders()
+	assertEqual(t, 1, len(holders))
+	assertEqual(t, int64(1), holders[0].Permits)
+
+	// permits released by the acquiring goroutine are attributed to its own acquisitions
+	ctx := ContextWithLabel(context.Background(), "tenant-a")
+	acquired := make(chan struct{})
+	release := make(chan struct{})
+	go func() {
+		assertNil(t, semaphore.AcquireContext(ctx, 3))
+		close(acquired)
+		<-release
+		semaphore.Release(3)
+		close(released)
+	}()
+	<-acquired
+	holders = semaphore.DumpHolders()
+	assertEqual(t, 2, len(holders))
+	assertEqual(t, "tenant-a", holders[1].Label)
+	released = make(chan struct{})
+	close(release)
+	<-released
+	holders = semaphore.DumpHolders()
+	assertEqual(t, 1, len(holders))
+	assertEqual(t, "", holders[0].Label)
+
+	// waiters are tracked once granted their permits
+	acquired = make(chan struct{})
+	go func() {
+		semaphore.Acquire(4)
+		close(acquired)
+	}()
+	for semaphore.Waiting() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	semaphore.Release(1)
+	<-acquired
+	holders = semaphore.DumpHolders()
+	assertEqual(t, 1, len(holders))
+	assertEqual(t, int64(4), holders[0].Permits)
+}
+
+func TestSemaphore_holderWatchdog(t *testing.T) {
+	overdue := make(chan Holder, 2)
+	semaphore := New(2, WithHolderTracking(50*time.Millisecond, func(holder Holder) {
+		overdue <- holder
+	}))
+
+	// permits released in time are not reported
+	semaphore.Acquire(1)
+	semaphore.Release(1)
+
+	semaphore.Acquire(2)
+	holder := <-overdue
+	assertEqual(t, int64(2), holder.Permits)
+	assertEqual(t, true, strings.Contains(holder.String(), "semaphore: 2 permit(s) held for"))
+	assertEqual(t, true, strings.Contains(holder.String(), "TestSemaphore_holderWatchdog"))
+	semaphore.Release(2)
+
+	time.Sleep(100 * time.Millisecond)
+	assertEqual(t, 0, len(overdue))
+}
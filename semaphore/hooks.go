@@ -0,0 +1,69 @@
+package semaphore
+
+import (
+	"context"
+	"time"
+)
+
+// Hooks are callbacks invoked as permits of a semaphore are acquired, to observe the saturation of the semaphore
+// and the queueing delay of the goroutines waiting on it. Nil callbacks are skipped.
+//
+// Hooks are invoked by the acquiring goroutine once the semaphore's internal lock is released, so they may call
+// methods such as InUse and Waiting. The label of an acquisition is the one attached to its context with ContextWithLabel,
+// or the empty string.
+type Hooks struct {
+	// OnAcquire is invoked after n permits are acquired, with the time spent waiting for them, which is 0 if they were available.
+	OnAcquire func(label string, n int64, wait time.Duration)
+
+	// OnGiveUp is invoked after an acquisition of n permits is abandoned on a timeout or a done context,
+	// with the time spent waiting for them.
+	OnGiveUp func(label string, n int64, wait time.Duration)
+}
+
+// WithHooks sets callbacks to be invoked as permits of the semaphore are acquired.
+func WithHooks(hooks Hooks) Option {
+	return func(semaphore *Semaphore) {
+		semaphore.hooks = hooks
+	}
+}
+
+// labelKey is the context key of acquisition labels.
+type labelKey struct{}
+
+// ContextWithLabel returns a copy of the context carrying a label, e.g. the route or tenant of a request,
+// which is passed to the Hooks of the acquisitions made with AcquireContext and the context.
+func ContextWithLabel(ctx context.Context, label string) context.Context {
+	return context.WithValue(ctx, labelKey{}, label)
+}
+
+// InUse returns the number of permits currently held.
+func (semaphore *Semaphore) InUse() int64 {
+	semaphore.m.Lock()
+	defer semaphore.m.Unlock()
+	return semaphore.cur
+}
+
+// Waiting returns the number of goroutines currently waiting to acquire permits.
+func (semaphore *Semaphore) Waiting() int {
+	semaphore.m.Lock()
+	defer semaphore.m.Unlock()
+	return semaphore.waiters.Len()
+}
+
+func (semaphore *Semaphore) onAcquire(ctx context.Context, n int64, wait time.Duration) {
+	if semaphore.hooks.OnAcquire != nil {
+		semaphore.hooks.OnAcquire(labelFrom(ctx), n, wait)
+	}
+}
+
+func (semaphore *Semaphore) onGiveUp(ctx context.Context, n int64, wait time.Duration) {
+	if semaphore.hooks.OnGiveUp != nil {
+		semaphore.hooks.OnGiveUp(labelFrom(ctx), n, wait)
+	}
+}
+
+// labelFrom returns the label attached to the context with ContextWithLabel, or the empty string.
+func labelFrom(ctx context.Context) string {
+	label, _ := ctx.Value(labelKey{}).(string)
+	return label
+}
@@ -0,0 +1,67 @@
+package semaphore
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSemaphore_hooks(t *testing.T) {
+	type event struct {
+		hook  string
+		label string
+		n     int64
+		wait  time.Duration
+	}
+	var m sync.Mutex
+	var events []event
+	record := func(hook string) func(string, int64, time.Duration) {
+		return func(label string, n int64, wait time.Duration) {
+			m.Lock()
+			defer m.Unlock()
+			events = append(events, event{hook, label, n, wait})
+		}
+	}
+	var semaphore *Semaphore
+	semaphore = New(2, WithHooks(Hooks{
+		OnAcquire: func(label string, n int64, wait time.Duration) {
+			// hooks may observe the semaphore
+			semaphore.InUse()
+			record("acquire")(label, n, wait)
+		},
+		OnGiveUp: record("giveUp"),
+	}))
+
+	semaphore.Acquire(1)
+	assertEqual(t, true, semaphore.TryAcquire(1))
+	assertEqual(t, false, semaphore.TryAcquire(1))
+	assertEqual(t, int64(2), semaphore.InUse())
+	assertEqual(t, 0, semaphore.Waiting())
+
+	ctx := ContextWithLabel(context.Background(), "tenant-a")
+	released := make(chan error)
+	go func() {
+		released <- semaphore.AcquireContext(ctx, 1)
+	}()
+	for semaphore.Waiting() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	semaphore.Release(1)
+	assertNil(t, <-released)
+
+	assertEqual(t, false, semaphore.AcquireTimeout(2, 10*time.Millisecond))
+	assertEqual(t, 0, semaphore.Waiting())
+
+	m.Lock()
+	defer m.Unlock()
+	assertEqual(t, 4, len(events))
+	assertEqual(t, event{"acquire", "", 1, 0}, events[0])
+	assertEqual(t, event{"acquire", "", 1, 0}, events[1])
+	assertEqual(t, "tenant-a", events[2].label)
+	assertEqual(t, true, events[2].wait >= 20*time.Millisecond)
+	assertEqual(t, "giveUp", events[3].hook)
+	assertEqual(t, int64(2), events[3].n)
+	assertEqual(t, true, events[3].wait >= 10*time.Millisecond)
+}
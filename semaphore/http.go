@@ -0,0 +1,96 @@
+package semaphore
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// A MiddlewareOption configures the HTTP middleware returned by Middleware.
+type MiddlewareOption func(*middleware)
+
+// WithWeight sets a function deriving from each request the number of permits it holds while being served,
+// e.g. more for uploads than for reads. By default every request holds one permit.
+func WithWeight(weight func(*http.Request) int64) MiddlewareOption {
+	return func(mw *middleware) {
+		mw.weight = weight
+	}
+}
+
+// WithQueueTimeout lets requests wait up to the given timeout for permits, rather than being rejected
+// as soon as no permits are available. Requests are also rejected if their context is done while they wait,
+// e.g. when their deadline passes.
+func WithQueueTimeout(timeout time.Duration) MiddlewareOption {
+	return func(mw *middleware) {
+		mw.queueTimeout = timeout
+	}
+}
+
+// WithRejectStatus sets the status code of the responses to rejected requests, e.g. http.StatusTooManyRequests.
+// It defaults to http.StatusServiceUnavailable.
+func WithRejectStatus(code int) MiddlewareOption {
+	return func(mw *middleware) {
+		mw.rejectStatus = code
+	}
+}
+
+// WithRetryAfter sets the delay advertised to rejected clients in the Retry-After header, rounded up to whole seconds.
+// It defaults to one second.
+func WithRetryAfter(retryAfter time.Duration) MiddlewareOption {
+	return func(mw *middleware) {
+		mw.retryAfter = retryAfter
+	}
+}
+
+// Middleware returns net/http middleware limiting the concurrency of the requests served by a handler with the semaphore.
+// Each request holds permits of the semaphore while it is served. A request for which the permits cannot be acquired
+// is rejected with a 503 Service Unavailable response and a Retry-After header, without calling the handler.
+//
+// The permits are acquired with the context of the request, so labels attached to it with ContextWithLabel
+// are passed to the Hooks of the semaphore.
+func Middleware(semaphore *Semaphore, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	mw := &middleware{
+		semaphore:    semaphore,
+		rejectStatus: http.StatusServiceUnavailable,
+		retryAfter:   time.Second,
+	}
+	for _, opt := range opts {
+		opt(mw)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n := int64(1)
+			if mw.weight != nil {
+				n = mw.weight(r)
+			}
+			if !mw.acquire(r, n) {
+				seconds := int64((mw.retryAfter + time.Second - 1) / time.Second)
+				w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+				http.Error(w, http.StatusText(mw.rejectStatus), mw.rejectStatus)
+				return
+			}
+			defer semaphore.Release(n)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// middleware is the configuration of the HTTP middleware returned by Middleware.
+type middleware struct {
+	semaphore    *Semaphore
+	weight       func(*http.Request) int64
+	queueTimeout time.Duration
+	rejectStatus int
+	retryAfter   time.Duration
+}
+
+// acquire acquires n permits for serving the request, and reports whether it did.
+func (mw *middleware) acquire(r *http.Request, n int64) bool {
+	if mw.queueTimeout <= 0 {
+		return mw.semaphore.tryAcquire(r.Context(), n)
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), mw.queueTimeout)
+	defer cancel()
+	return mw.semaphore.AcquireContext(ctx, n) == nil
+}
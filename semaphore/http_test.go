@@ -0,0 +1,84 @@
+package semaphore
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestMiddleware(t *testing.T) {
+	semaphore := New(2)
+	entered := make(chan struct{})
+	block := make(chan struct{})
+	handler := Middleware(semaphore, WithWeight(func(r *http.Request) int64 {
+		n, _ := strconv.ParseInt(r.URL.Query().Get("weight"), 10, 64)
+		return n
+	}))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-block
+	}))
+
+	served := make(chan int)
+	serve := func(weight int) {
+		go func() {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest("GET", "/?weight="+strconv.Itoa(weight), nil))
+			served <- rec.Code
+		}()
+	}
+	serve(1)
+	<-entered
+	assertEqual(t, int64(1), semaphore.InUse())
+
+	// a request that does not fit is rejected without calling the handler
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/?weight=2", nil))
+	assertEqual(t, http.StatusServiceUnavailable, rec.Code)
+	assertEqual(t, "1", rec.Header().Get("Retry-After"))
+
+	serve(1)
+	<-entered
+	close(block)
+	assertEqual(t, http.StatusOK, <-served)
+	assertEqual(t, http.StatusOK, <-served)
+	assertEqual(t, int64(0), semaphore.InUse())
+}
+
+func TestMiddleware_queueTimeout(t *testing.T) {
+	semaphore := New(1)
+	handler := Middleware(semaphore,
+		WithQueueTimeout(50*time.Millisecond),
+		WithRejectStatus(http.StatusTooManyRequests),
+		WithRetryAfter(1500*time.Millisecond),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	semaphore.Acquire(1)
+	rec := httptest.NewRecorder()
+	start := time.Now()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	assertEqual(t, true, time.Since(start) >= 50*time.Millisecond)
+	assertEqual(t, http.StatusTooManyRequests, rec.Code)
+	assertEqual(t, "2", rec.Header().Get("Retry-After"))
+
+	// the deadline of the request applies too
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	rec = httptest.NewRecorder()
+	start = time.Now()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil).WithContext(ctx))
+	assertEqual(t, true, time.Since(start) < 50*time.Millisecond)
+	assertEqual(t, http.StatusTooManyRequests, rec.Code)
+
+	// queued requests are served once permits are released
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		semaphore.Release(1)
+	}()
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	assertEqual(t, http.StatusOK, rec.Code)
+	assertEqual(t, int64(0), semaphore.InUse())
+}
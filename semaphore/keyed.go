@@ -0,0 +1,131 @@
+package semaphore
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// A KeyedSemaphore maintains an independent number of permits per key, enforcing limits such as
+// "at most N concurrent operations per tenant" with a single object.
+//
+// The semaphore of a key is created when permits are first acquired for it, and evicted as soon as the key is idle,
+// with no permits held and no goroutine waiting for them, so that keys do not accumulate over time.
+type KeyedSemaphore struct {
+	m       sync.Mutex
+	permits int64
+	opts    []Option
+	entries map[string]*keyedEntry
+}
+
+// A keyedEntry is the semaphore of a key, and the number of acquisitions of it in progress.
+type keyedEntry struct {
+	semaphore *Semaphore
+	acquiring int
+}
+
+// NewKeyed creates a KeyedSemaphore with the given number of permits per key.
+// The options apply to the semaphore of every key. NewKeyed panics if permits is negative.
+func NewKeyed(permits int64, opts ...Option) *KeyedSemaphore {
+	if permits < 0 {
+		panic("semaphore: negative permits")
+	}
+	return &KeyedSemaphore{
+		permits: permits,
+		opts:    opts,
+		entries: make(map[string]*keyedEntry),
+	}
+}
+
+// Acquire acquires n permits for the key, waiting until enough of them are available, like Semaphore.Acquire.
+func (keyed *KeyedSemaphore) Acquire(key string, n int64) {
+	keyed.acquire(key, func(semaphore *Semaphore) bool {
+		semaphore.Acquire(n)
+		return true
+	})
+}
+
+// TryAcquire acquires n permits for the key only if they are available without waiting, like Semaphore.TryAcquire.
+func (keyed *KeyedSemaphore) TryAcquire(key string, n int64) bool {
+	return keyed.acquire(key, func(semaphore *Semaphore) bool {
+		return semaphore.TryAcquire(n)
+	})
+}
+
+// AcquireTimeout acquires n permits for the key, waiting until a given timeout for enough of them to be available,
+// like Semaphore.AcquireTimeout.
+func (keyed *KeyedSemaphore) AcquireTimeout(key string, n int64, timeout time.Duration) bool {
+	return keyed.acquire(key, func(semaphore *Semaphore) bool {
+		return semaphore.AcquireTimeout(n, timeout)
+	})
+}
+
+// AcquireContext acquires n permits for the key, waiting until enough of them are available or the context is done,
+// like Semaphore.AcquireContext.
+func (keyed *KeyedSemaphore) AcquireContext(ctx context.Context, key string, n int64) error {
+	var err error
+	keyed.acquire(key, func(semaphore *Semaphore) bool {
+		err = semaphore.AcquireContext(ctx, n)
+		return err == nil
+	})
+	return err
+}
+
+// Release releases n permits for the key, like Semaphore.Release.
+// Release panics if more permits are released for the key than are held.
+func (keyed *KeyedSemaphore) Release(key string, n int64) {
+	keyed.m.Lock()
+	defer keyed.m.Unlock()
+	entry, ok := keyed.entries[key]
+	if !ok {
+		panic("semaphore: released more permits than held")
+	}
+	entry.semaphore.Release(n)
+	keyed.evictIdle(key, entry)
+}
+
+// Len returns the number of keys for which permits are held or waited for.
+func (keyed *KeyedSemaphore) Len() int {
+	keyed.m.Lock()
+	defer keyed.m.Unlock()
+	return len(keyed.entries)
+}
+
+// acquire acquires permits from the semaphore of the key with the given function, which reports whether it did.
+func (keyed *KeyedSemaphore) acquire(key string, acquire func(*Semaphore) bool) bool {
+	keyed.m.Lock()
+	entry, ok := keyed.entries[key]
+	if !ok {
+		entry = &keyedEntry{semaphore: New(keyed.permits, keyed.opts...)}
+		keyed.entries[key] = entry
+	}
+	entry.acquiring++
+	keyed.m.Unlock()
+
+	acquired := false
+	defer func() {
+		// also runs if acquire panics
+		keyed.m.Lock()
+		defer keyed.m.Unlock()
+		entry.acquiring--
+		if !acquired {
+			keyed.evictIdle(key, entry)
+		}
+	}()
+	acquired = acquire(entry.semaphore)
+	return acquired
+}
+
+// evictIdle evicts the semaphore of the key if no permits are held or being acquired.
+// This call must be guarded using the keyed semaphore mutex.
+func (keyed *KeyedSemaphore) evictIdle(key string, entry *keyedEntry) {
+	if entry.acquiring > 0 {
+		return
+	}
+	entry.semaphore.m.Lock()
+	idle := entry.semaphore.cur == 0
+	entry.semaphore.m.Unlock()
+	if idle {
+		delete(keyed.entries, key)
+	}
+}
@@ -0,0 +1,94 @@
+package semaphore
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestKeyedSemaphore_perKey(t *testing.T) {
+	keyed := NewKeyed(2)
+	keyed.Acquire("a", 2)
+	assertEqual(t, false, keyed.TryAcquire("a", 1))
+	// other keys have their own permits
+	assertEqual(t, true, keyed.TryAcquire("b", 2))
+	assertEqual(t, 2, keyed.Len())
+
+	acquired := make(chan struct{})
+	go func() {
+		keyed.Acquire("a", 1)
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("Acquire returned before permits of the key were released")
+	case <-time.After(50 * time.Millisecond):
+	}
+	keyed.Release("b", 2)
+	assertEqual(t, 1, keyed.Len())
+	keyed.Release("a", 1)
+	<-acquired
+
+	assertEqual(t, false, keyed.AcquireTimeout("a", 1, 50*time.Millisecond))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assertEqual(t, context.DeadlineExceeded, keyed.AcquireContext(ctx, "a", 1))
+	assertNil(t, keyed.AcquireContext(context.Background(), "c", 1))
+}
+
+func TestKeyedSemaphore_evictIdle(t *testing.T) {
+	keyed := NewKeyed(1, WithFairness())
+	keyed.Acquire("a", 1)
+	keyed.Release("a", 1)
+	assertEqual(t, 0, keyed.Len())
+
+	// failed acquisitions do not leave keys behind
+	assertEqual(t, true, keyed.TryAcquire("a", 1))
+	assertEqual(t, false, keyed.TryAcquire("b", 2))
+	assertEqual(t, false, keyed.AcquireTimeout("a", 1, 10*time.Millisecond))
+	assertEqual(t, 1, keyed.Len())
+
+	// a key with waiters is not evicted when its permits are released
+	acquired := make(chan struct{})
+	go func() {
+		keyed.Acquire("a", 1)
+		close(acquired)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	keyed.Release("a", 1)
+	<-acquired
+	assertEqual(t, 1, keyed.Len())
+	keyed.Release("a", 1)
+	assertEqual(t, 0, keyed.Len())
+
+	defer func() {
+		assertNotNil(t, recover())
+	}()
+	keyed.Release("a", 1)
+	t.Fatal("Release did not panic")
+}
+
+func TestKeyedSemaphore_concurrent(t *testing.T) {
+	const permits = 2
+	keyed := NewKeyed(permits)
+	keys := []string{"a", "b", "c"}
+	holding := make([]int32, len(keys))
+	var wg sync.WaitGroup
+	for i := 0; i < 60; i++ {
+		wg.Add(1)
+		go func(k int) {
+			defer wg.Done()
+			keyed.Acquire(keys[k], 1)
+			if atomic.AddInt32(&holding[k], 1) > permits {
+				t.Errorf("More than %d permits held for key %s", permits, keys[k])
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&holding[k], -1)
+			keyed.Release(keys[k], 1)
+		}(i % len(keys))
+	}
+	wg.Wait()
+	assertEqual(t, 0, keyed.Len())
+}
@@ -0,0 +1,243 @@
+// Package semaphore provides a weighted counting Semaphore, limiting concurrent access to a shared resource.
+package semaphore
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nvn1729/congo/deadlock"
+)
+
+// A Semaphore maintains a number of permits, which goroutines acquire before accessing a shared resource and release afterwards,
+// so that no more goroutines access the resource concurrently than there are permits.
+//
+// Acquisitions are weighted: a goroutine may acquire several permits at once, e.g. in proportion to the memory its task uses.
+// A goroutine acquiring more permits than are available waits until enough permits are released.
+// By default, released permits go to any waiting goroutine they suffice for, and may be acquired by a goroutine
+// that did not wait at all. This maximizes throughput, but lets small acquisitions starve large ones: see WithFairness.
+type Semaphore struct {
+	m        sync.Mutex
+	size     int64
+	cur      int64
+	fair     bool
+	hooks    Hooks
+	tracking *holderTracking
+	waiters  list.List
+}
+
+// A waiter is a goroutine waiting to acquire permits.
+type waiter struct {
+	n     int64
+	held  *heldPermits
+	wait  *deadlock.Wait // the wait recorded for deadlock detection, if enabled
+	ready chan struct{}  // closed when the permits are acquired
+}
+
+// An Option configures a Semaphore at creation time.
+type Option func(*Semaphore)
+
+// WithFairness makes the semaphore grant permits in the order the goroutines asked for them.
+// A goroutine acquiring permits waits as long as any goroutine that asked before it is waiting, even if enough permits are available,
+// so that a stream of small acquisitions cannot starve a large one. TryAcquire fails as long as goroutines are waiting.
+func WithFairness() Option {
+	return func(semaphore *Semaphore) {
+		semaphore.fair = true
+	}
+}
+
+// New creates a Semaphore with the given number of permits.
+// New panics if permits is negative.
+func New(permits int64, opts ...Option) *Semaphore {
+	if permits < 0 {
+		panic("semaphore: negative permits")
+	}
+	semaphore := &Semaphore{size: permits}
+	for _, opt := range opts {
+		opt(semaphore)
+	}
+	return semaphore
+}
+
+// Acquire acquires n permits, waiting until enough of them are available.
+// If n exceeds the limit of the semaphore, Acquire waits until the limit is raised with SetLimit or AddPermits.
+// Acquire panics if n is negative.
+func (semaphore *Semaphore) Acquire(n int64) {
+	semaphore.acquire(context.Background(), n, nil)
+}
+
+// TryAcquire acquires n permits only if they are available without waiting, and reports whether it did.
+// TryAcquire panics if n is negative.
+func (semaphore *Semaphore) TryAcquire(n int64) bool {
+	return semaphore.tryAcquire(context.Background(), n)
+}
+
+// AcquireTimeout acquires n permits, waiting until a given timeout for enough of them to be available.
+// If the permits are acquired before the timeout, AcquireTimeout returns true.
+// Otherwise it returns false, without holding any of the permits.
+// AcquireTimeout panics if n is negative.
+func (semaphore *Semaphore) AcquireTimeout(n int64, timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	return semaphore.acquire(context.Background(), n, timer.C)
+}
+
+// AcquireContext acquires n permits, waiting until enough of them are available or the context is done.
+// If the context is done first, AcquireContext returns the context's error, without holding or waiting for any of the permits,
+// which suits a semaphore limiting the concurrency of requests. If the context is already done, AcquireContext does not acquire any permits.
+// AcquireContext panics if n is negative.
+func (semaphore *Semaphore) AcquireContext(ctx context.Context, n int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !semaphore.acquire(ctx, n, nil) {
+		return ctx.Err()
+	}
+	return nil
+}
+
+// Limit returns the total number of permits of the semaphore.
+func (semaphore *Semaphore) Limit() int64 {
+	semaphore.m.Lock()
+	defer semaphore.m.Unlock()
+	return semaphore.size
+}
+
+// SetLimit changes the total number of permits of the semaphore at runtime, e.g. in response to load shedding signals.
+// Raising the limit lets waiting goroutines acquire the new permits. Lowering it below the number of permits
+// currently held does not revoke any: no further permits are acquired until enough of them are released.
+// SetLimit panics if limit is negative.
+func (semaphore *Semaphore) SetLimit(limit int64) {
+	if limit < 0 {
+		panic("semaphore: negative permits")
+	}
+	semaphore.m.Lock()
+	defer semaphore.m.Unlock()
+	semaphore.size = limit
+	semaphore.notifyWaiters()
+}
+
+// AddPermits adds delta, which may be negative, to the total number of permits of the semaphore, like SetLimit.
+// AddPermits panics if the limit would become negative.
+func (semaphore *Semaphore) AddPermits(delta int64) {
+	semaphore.m.Lock()
+	defer semaphore.m.Unlock()
+	if semaphore.size+delta < 0 {
+		panic("semaphore: negative permits")
+	}
+	semaphore.size += delta
+	semaphore.notifyWaiters()
+}
+
+// Release releases n permits, allowing waiting goroutines to acquire them.
+// Release panics if n is negative or more permits are released than are held.
+func (semaphore *Semaphore) Release(n int64) {
+	goroutine := semaphore.currentGoroutineID()
+	semaphore.m.Lock()
+	defer semaphore.m.Unlock()
+	if n < 0 || n > semaphore.cur {
+		panic("semaphore: released more permits than held")
+	}
+	semaphore.cur -= n
+	deadlock.Released(semaphore, n)
+	if semaphore.tracking != nil {
+		semaphore.unhold(n, goroutine)
+	}
+	semaphore.notifyWaiters()
+}
+
+// tryAcquire acquires n permits only if they are available without waiting, with the label of the context.
+func (semaphore *Semaphore) tryAcquire(ctx context.Context, n int64) bool {
+	if n < 0 {
+		panic("semaphore: negative permits")
+	}
+	held := semaphore.newHeldPermits(ctx, n)
+	semaphore.m.Lock()
+	if !semaphore.available(n) {
+		semaphore.m.Unlock()
+		return false
+	}
+	semaphore.cur += n
+	semaphore.hold(held)
+	deadlock.Acquired(semaphore, n)
+	semaphore.m.Unlock()
+	semaphore.onAcquire(ctx, n, 0)
+	return true
+}
+
+// acquire acquires n permits, waiting until enough of them are available, the context is done or timeoutCh fires.
+// A nil timeoutCh never fires. acquire reports whether the permits were acquired.
+func (semaphore *Semaphore) acquire(ctx context.Context, n int64, timeoutCh <-chan time.Time) bool {
+	if n < 0 {
+		panic("semaphore: negative permits")
+	}
+	held := semaphore.newHeldPermits(ctx, n)
+	semaphore.m.Lock()
+	if semaphore.available(n) {
+		semaphore.cur += n
+		semaphore.hold(held)
+		deadlock.Acquired(semaphore, n)
+		semaphore.m.Unlock()
+		semaphore.onAcquire(ctx, n, 0)
+		return true
+	}
+	w := &waiter{n: n, held: held, wait: deadlock.Waiting(semaphore), ready: make(chan struct{})}
+	e := semaphore.waiters.PushBack(w)
+	semaphore.m.Unlock()
+
+	start := time.Now()
+	select {
+	case <-w.ready:
+		semaphore.onAcquire(ctx, n, time.Since(start))
+		return true
+	case <-ctx.Done():
+	case <-timeoutCh:
+	}
+
+	semaphore.m.Lock()
+	select {
+	case <-w.ready:
+		// the permits were acquired while giving up
+		semaphore.m.Unlock()
+		semaphore.onAcquire(ctx, n, time.Since(start))
+		return true
+	default:
+	}
+	semaphore.waiters.Remove(e)
+	w.wait.Abandoned()
+	// with fairness, the waiters queued behind may now acquire their permits
+	semaphore.notifyWaiters()
+	semaphore.m.Unlock()
+	semaphore.onGiveUp(ctx, n, time.Since(start))
+	return false
+}
+
+// available reports whether n permits may be acquired without waiting.
+// This call must be guarded using the semaphore mutex.
+func (semaphore *Semaphore) available(n int64) bool {
+	if semaphore.fair && semaphore.waiters.Len() > 0 {
+		return false
+	}
+	return semaphore.size-semaphore.cur >= n
+}
+
+// notifyWaiters grants permits to the waiters that fit in the available permits, in arrival order.
+// With fairness, it stops at the first waiter that does not fit.
+// This call must be guarded using the semaphore mutex.
+func (semaphore *Semaphore) notifyWaiters() {
+	for e := semaphore.waiters.Front(); e != nil; {
+		next := e.Next()
+		w := e.Value.(*waiter)
+		if semaphore.size-semaphore.cur >= w.n {
+			semaphore.cur += w.n
+			semaphore.hold(w.held)
+			w.wait.Acquired(w.n)
+			semaphore.waiters.Remove(e)
+			close(w.ready)
+		} else if semaphore.fair {
+			return
+		}
+		e = next
+	}
+}
@@ -0,0 +1,278 @@
+package semaphore
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func ExampleSemaphore() {
+	// at most 2 downloads at a time
+	downloads := New(2)
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			downloads.Acquire(1)
+			defer downloads.Release(1)
+			// download
+			// ...
+		}()
+	}
+	wg.Wait()
+	fmt.Println("Downloads complete")
+	// Output:
+	// Downloads complete
+}
+
+func TestSemaphore_acquire(t *testing.T) {
+	semaphore := New(3)
+	semaphore.Acquire(2)
+	semaphore.Acquire(1)
+	semaphore.Acquire(0)
+
+	acquired := make(chan struct{})
+	go func() {
+		semaphore.Acquire(2)
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("Acquire returned before permits were released")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// one permit is not enough
+	semaphore.Release(1)
+	select {
+	case <-acquired:
+		t.Fatal("Acquire returned before enough permits were released")
+	case <-time.After(50 * time.Millisecond):
+	}
+	semaphore.Release(2)
+	<-acquired
+	semaphore.Release(2)
+	semaphore.Acquire(3)
+}
+
+func TestSemaphore_tryAcquire(t *testing.T) {
+	semaphore := New(2)
+	assertEqual(t, true, semaphore.TryAcquire(2))
+	assertEqual(t, false, semaphore.TryAcquire(1))
+	assertEqual(t, true, semaphore.TryAcquire(0))
+	semaphore.Release(1)
+	assertEqual(t, false, semaphore.TryAcquire(2))
+	assertEqual(t, true, semaphore.TryAcquire(1))
+}
+
+func TestSemaphore_acquireTimeout(t *testing.T) {
+	semaphore := New(2)
+	assertEqual(t, true, semaphore.AcquireTimeout(1, time.Second))
+	assertEqual(t, false, semaphore.AcquireTimeout(2, 50*time.Millisecond))
+
+	// a timed out acquisition does not hold or wait for permits
+	assertEqual(t, true, semaphore.TryAcquire(1))
+	semaphore.Release(2)
+	assertEqual(t, true, semaphore.TryAcquire(2))
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		semaphore.Release(1)
+	}()
+	assertEqual(t, true, semaphore.AcquireTimeout(1, time.Second))
+}
+
+func TestSemaphore_acquireContext(t *testing.T) {
+	semaphore := New(2)
+	assertNil(t, semaphore.AcquireContext(context.Background(), 2))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	released := make(chan error)
+	go func() {
+		released <- semaphore.AcquireContext(ctx, 2)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	assertEqual(t, context.Canceled, <-released)
+
+	// the abandoned acquisition does not hold or wait for permits
+	semaphore.Release(2)
+	assertEqual(t, true, semaphore.TryAcquire(2))
+	semaphore.Release(2)
+
+	// a done context does not acquire available permits
+	assertEqual(t, context.Canceled, semaphore.AcquireContext(ctx, 1))
+	assertEqual(t, true, semaphore.TryAcquire(2))
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assertEqual(t, context.DeadlineExceeded, semaphore.AcquireContext(ctx, 1))
+}
+
+func TestSemaphore_fairness(t *testing.T) {
+	// by default, small acquisitions overtake a large one
+	semaphore := New(3)
+	semaphore.Acquire(2)
+	large := make(chan struct{})
+	go func() {
+		semaphore.Acquire(3)
+		close(large)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	assertEqual(t, true, semaphore.TryAcquire(1))
+	semaphore.Release(3)
+	<-large
+
+	semaphore = New(3, WithFairness())
+	semaphore.Acquire(2)
+	large = make(chan struct{})
+	go func() {
+		semaphore.Acquire(3)
+		close(large)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	assertEqual(t, false, semaphore.TryAcquire(1))
+	small := make(chan struct{})
+	go func() {
+		semaphore.Acquire(1)
+		close(small)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case <-small:
+		t.Fatal("Small acquisition overtook a large one")
+	default:
+	}
+
+	// the large acquisition goes first, then the small one
+	semaphore.Release(2)
+	<-large
+	semaphore.Release(3)
+	<-small
+
+	// abandoning the head of the queue lets the waiters behind it through
+	semaphore = New(2, WithFairness())
+	semaphore.Acquire(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	abandoned := make(chan error)
+	go func() {
+		abandoned <- semaphore.AcquireContext(ctx, 2)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	small = make(chan struct{})
+	go func() {
+		semaphore.Acquire(1)
+		close(small)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	assertEqual(t, context.Canceled, <-abandoned)
+	<-small
+}
+
+func TestSemaphore_setLimit(t *testing.T) {
+	semaphore := New(2)
+	semaphore.Acquire(2)
+
+	// raising the limit releases waiters
+	acquired := make(chan struct{})
+	go func() {
+		semaphore.Acquire(3)
+		close(acquired)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	semaphore.AddPermits(2)
+	assertEqual(t, int64(4), semaphore.Limit())
+	select {
+	case <-acquired:
+		t.Fatal("Acquire returned before enough permits were added")
+	case <-time.After(50 * time.Millisecond):
+	}
+	semaphore.SetLimit(5)
+	<-acquired
+
+	// lowering the limit is absorbed as permits are released
+	semaphore.SetLimit(2)
+	assertEqual(t, false, semaphore.TryAcquire(1))
+	semaphore.Release(2)
+	assertEqual(t, false, semaphore.TryAcquire(1))
+	semaphore.Release(2)
+	assertEqual(t, true, semaphore.TryAcquire(1))
+
+	// acquiring more than the limit waits for it to be raised
+	assertEqual(t, false, semaphore.AcquireTimeout(3, 50*time.Millisecond))
+}
+
+func TestSemaphore_concurrent(t *testing.T) {
+	testConcurrent(t)
+	testConcurrent(t, WithFairness())
+}
+
+func testConcurrent(t *testing.T, opts ...Option) {
+	const permits = 4
+	semaphore := New(permits, opts...)
+	var holding, maxHolding int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(n int64) {
+			defer wg.Done()
+			semaphore.Acquire(n)
+			h := atomic.AddInt32(&holding, int32(n))
+			for {
+				m := atomic.LoadInt32(&maxHolding)
+				if h <= m || atomic.CompareAndSwapInt32(&maxHolding, m, h) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&holding, -int32(n))
+			semaphore.Release(n)
+		}(int64(i%2 + 1))
+	}
+	wg.Wait()
+	assertEqual(t, true, maxHolding <= permits)
+	semaphore.Acquire(permits)
+}
+
+func TestSemaphore_invalid(t *testing.T) {
+	for _, f := range []func(){
+		func() { New(-1) },
+		func() { New(1).Acquire(-1) },
+		func() { New(1).Release(1) },
+		func() { New(1).TryAcquire(-1) },
+		func() { New(1).AcquireTimeout(-1, time.Second) },
+		func() { New(1).SetLimit(-1) },
+		func() { New(1).AddPermits(-2) },
+	} {
+		func() {
+			defer func() {
+				assertNotNil(t, recover())
+			}()
+			f()
+			t.Fatal("Did not panic")
+		}()
+	}
+}
+
+func assertEqual(t *testing.T, expected interface{}, actual interface{}) {
+	if expected != actual {
+		t.Fatal("Not equal:", "expected:", expected, ", actual:", actual)
+	}
+}
+
+func assertNil(t *testing.T, actual interface{}) {
+	if actual != nil {
+		t.Fatal("Value not nil, actual:", actual)
+	}
+}
+
+func assertNotNil(t *testing.T, actual interface{}) {
+	if actual == nil {
+		t.Fatal("Value is nil")
+	}
+}
@@ -0,0 +1,48 @@
+package congo
+
+import "time"
+
+// A LatchSnapshot is a consistent, point-in-time view of the state of a CountDownLatch, as returned by Snapshot.
+type LatchSnapshot struct {
+	// Name is the name given to the latch with WithName.
+	Name string
+
+	// Epoch is the epoch of the current round of count down.
+	Epoch uint64
+
+	// InitialCount is the count that the current round of count down started with.
+	InitialCount uint
+
+	// RemainingCount is the remaining count.
+	RemainingCount uint
+
+	// Waiters is the number of goroutines blocked waiting on the latch.
+	Waiters int
+
+	// Created is the time at which the latch was created.
+	Created time.Time
+
+	// Completed is the time at which the current round of count down completed or was canceled,
+	// or the zero time if it is still in progress.
+	Completed time.Time
+
+	// Err is the cancellation cause if the latch was canceled, or nil otherwise.
+	Err error
+}
+
+// Snapshot returns the state of the latch captured under a single lock acquisition,
+// so that monitoring code does not observe inconsistent values across separate calls to Count, Waiters and others.
+func (latch *CountDownLatch) Snapshot() LatchSnapshot {
+	latch.m.Lock()
+	defer latch.m.Unlock()
+	return LatchSnapshot{
+		Name:           latch.name,
+		Epoch:          latch.epoch,
+		InitialCount:   latch.initialCount,
+		RemainingCount: latch.remainingCount,
+		Waiters:        latch.Waiters(),
+		Created:        latch.created,
+		Completed:      latch.completed,
+		Err:            latch.cause,
+	}
+}
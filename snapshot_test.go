@@ -0,0 +1,57 @@
+package congo
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nvn1729/congo/internal/clocktest"
+)
+
+func TestCountDownLatch_snapshot(t *testing.T) {
+	clock := clocktest.New(time.Unix(0, 0))
+	created := clock.Now()
+	latch := New(3, WithName("batch"), WithClock(clock))
+
+	snapshot := latch.Snapshot()
+	assertEqual(t, "batch", snapshot.Name)
+	assertEqual(t, uint64(0), snapshot.Epoch)
+	assertEqual(t, uint(3), snapshot.InitialCount)
+	assertEqual(t, uint(3), snapshot.RemainingCount)
+	assertEqual(t, 0, snapshot.Waiters)
+	assertEqual(t, created, snapshot.Created)
+	assertEqual(t, true, snapshot.Completed.IsZero())
+	assertNil(t, snapshot.Err)
+
+	go latch.Wait()
+	for latch.Waiters() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	assertNil(t, latch.WeightedCountDown(2))
+	snapshot = latch.Snapshot()
+	assertEqual(t, uint(1), snapshot.RemainingCount)
+	assertEqual(t, 1, snapshot.Waiters)
+
+	clock.Advance(time.Minute)
+	assertNil(t, latch.CountDown())
+	snapshot = latch.Snapshot()
+	assertEqual(t, uint(0), snapshot.RemainingCount)
+	assertEqual(t, created.Add(time.Minute), snapshot.Completed)
+
+	// a new round resets the initial count and completion time, but not the creation time
+	_, err := latch.Reset(5)
+	assertNil(t, err)
+	snapshot = latch.Snapshot()
+	assertEqual(t, uint64(1), snapshot.Epoch)
+	assertEqual(t, uint(5), snapshot.InitialCount)
+	assertEqual(t, created, snapshot.Created)
+	assertEqual(t, true, snapshot.Completed.IsZero())
+
+	cause := errors.New("aborted")
+	clock.Advance(time.Minute)
+	assertNil(t, latch.Cancel(cause))
+	snapshot = latch.Snapshot()
+	assertEqual(t, cause, snapshot.Err)
+	assertEqual(t, uint(5), snapshot.RemainingCount)
+	assertEqual(t, created.Add(2*time.Minute), snapshot.Completed)
+}
@@ -0,0 +1,68 @@
+package congo
+
+import (
+	"sync"
+	"time"
+)
+
+// A WaitGroup is a drop-in replacement for sync.WaitGroup backed by a CountDownLatch.
+//
+// It implements the Add, Done and Wait methods of sync.WaitGroup, so existing code can switch to it without rewrites,
+// and adds WaitTimeout and Count for waiting with a timeout and tracking progress.
+// Like sync.WaitGroup, the zero value is ready to use and a WaitGroup must not be copied after first use.
+//
+// Each time the counter rises from zero, a new round of count down is started on the underlying latch.
+type WaitGroup struct {
+	once  sync.Once
+	latch *CountDownLatch
+}
+
+// getLatch returns the underlying latch, creating it on first use.
+func (wg *WaitGroup) getLatch() *CountDownLatch {
+	wg.once.Do(func() {
+		wg.latch = NewCountDownLatch(0)
+	})
+	return wg.latch
+}
+
+// Add adds delta, which may be negative, to the WaitGroup counter.
+// If the counter becomes zero, all goroutines blocked on Wait are released.
+// If the counter goes negative, Add panics.
+func (wg *WaitGroup) Add(delta int) {
+	latch := wg.getLatch()
+	latch.m.Lock()
+	defer latch.unlock()
+	switch {
+	case delta > 0 && latch.remainingCount == 0:
+		latch.startRound(uint(delta))
+	case delta > 0:
+		latch.remainingCount += uint(delta)
+	case delta < 0:
+		if uint(-delta) > latch.remainingCount {
+			panic("congo: negative WaitGroup counter")
+		}
+		latch.doCountDown(uint(-delta))
+	}
+}
+
+// Done decrements the WaitGroup counter by one.
+func (wg *WaitGroup) Done() {
+	wg.Add(-1)
+}
+
+// Wait blocks until the WaitGroup counter is zero.
+func (wg *WaitGroup) Wait() {
+	wg.getLatch().Wait()
+}
+
+// WaitTimeout waits until a given timeout for the WaitGroup counter to become zero.
+// If the counter becomes zero before the timeout, WaitTimeout returns true.
+// Otherwise it returns false.
+func (wg *WaitGroup) WaitTimeout(timeout time.Duration) bool {
+	return wg.getLatch().WaitTimeout(timeout)
+}
+
+// Count returns the current value of the WaitGroup counter.
+func (wg *WaitGroup) Count() int {
+	return int(wg.getLatch().Count())
+}
@@ -0,0 +1,61 @@
+package congo
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func ExampleWaitGroup() {
+	var wg WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// do work
+			// ...
+		}()
+	}
+
+	if wg.WaitTimeout(5 * time.Second) {
+		fmt.Println("All done")
+	}
+	// Output:
+	// All done
+}
+
+func TestWaitGroup_zero(t *testing.T) {
+	var wg WaitGroup
+	assertEqual(t, 0, wg.Count())
+	wg.Wait()
+	assertEqual(t, true, wg.WaitTimeout(time.Second))
+}
+
+func TestWaitGroup_rounds(t *testing.T) {
+	var wg WaitGroup
+	wg.Add(2)
+	wg.Add(1)
+	assertEqual(t, 3, wg.Count())
+	wg.Done()
+	assertEqual(t, false, wg.WaitTimeout(100*time.Millisecond))
+	wg.Add(-2)
+	assertEqual(t, 0, wg.Count())
+	wg.Wait()
+
+	// the counter can rise from zero again, starting a new round
+	wg.Add(1)
+	assertEqual(t, false, wg.WaitTimeout(100*time.Millisecond))
+	go wg.Done()
+	assertEqual(t, true, wg.WaitTimeout(time.Second))
+}
+
+func TestWaitGroup_negative(t *testing.T) {
+	var wg WaitGroup
+	wg.Add(1)
+	defer func() {
+		assertNotNil(t, recover())
+		assertEqual(t, 1, wg.Count())
+	}()
+	wg.Add(-2)
+	t.Fatal("Add did not panic")
+}